@@ -0,0 +1,383 @@
+// Command fanout materialises the timelines of users on write. It consumes the
+// connection, event and object state changes and keeps the timelines of every
+// affected user up-to-date, so feeds can be read without walking the social
+// graph.
+//
+// SQS hands every message to one consumer only, so fanout doesn't read the
+// state change queues consumed by sims but its own copies prefixed with
+// "fanout-", e.g. fanout-connection-state-change. The queues are provisioned
+// with the others in infrastructure/terraform and filled by intaker when it
+// runs with a timeline store configured.
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	awsSession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/garyburd/redigo/redis"
+	"github.com/go-kit/kit/log"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/platform/metrics"
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/timeline"
+)
+
+const (
+	component        = "fanout"
+	namespaceService = "service"
+	namespaceSource  = "source"
+	subsystemErr     = "err"
+	subsystemOp      = "op"
+	subsystemQueue   = "queue"
+)
+
+// Supported timeline stores.
+const (
+	storePostgres = "postgres"
+	storeRedis    = "redis"
+)
+
+// Set at build time.
+var revision = "0000000-dev"
+
+func main() {
+	var (
+		begin = time.Now()
+
+		awsID         = flag.String("aws.id", "", "Identifier for AWS requests")
+		awsRegion     = flag.String("aws.region", "us-east-1", "AWS region to operate in")
+		awsSecret     = flag.String("aws.secret", "", "Identification secret for AWS requests")
+		postgresURL   = flag.String("postgres.url", "", "Postgres URL to connect to")
+		redisAddr     = flag.String("redis.addr", ":6379", "Redis address to connect to")
+		store         = flag.String("store", storeRedis, "Store used for the timelines")
+		telemetryAddr = flag.String("telemetry.addr", ":9002", "Address to expose telemetry on")
+	)
+	flag.Parse()
+
+	logger := log.NewContext(
+		log.NewJSONLogger(os.Stdout),
+	).With(
+		"caller", log.Caller(3),
+		"component", component,
+		"revision", revision,
+	)
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+	}
+
+	logger = log.NewContext(logger).With("host", hostname)
+
+	// Setup instrumenation
+	go func(addr string, begin time.Time, logger log.Logger) {
+		http.Handle("/metrics", prometheus.Handler())
+
+		logger = log.NewContext(logger).With(
+			"listen", addr,
+			"sub", "telemetry",
+		)
+
+		_ = logger.Log(
+			"duration", time.Now().Sub(begin).Nanoseconds(),
+			"lifecycle", "start",
+		)
+
+		err := http.ListenAndServe(addr, nil)
+		if err != nil {
+			logger.Log(
+				"err", err,
+				"lifecycle", "abort",
+			)
+		}
+	}(*telemetryAddr, begin, logger)
+
+	serviceFieldKeys := []string{
+		metrics.FieldComponent,
+		metrics.FieldMethod,
+		metrics.FieldNamespace,
+		metrics.FieldService,
+		metrics.FieldStore,
+	}
+
+	serviceErrCount := kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+		Namespace: namespaceService,
+		Subsystem: subsystemErr,
+		Name:      "count",
+		Help:      "Number of failed service operations",
+	}, serviceFieldKeys)
+
+	serviceOpCount := kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+		Namespace: namespaceService,
+		Subsystem: subsystemOp,
+		Name:      "count",
+		Help:      "Number of service operations performed",
+	}, serviceFieldKeys)
+
+	serviceOpLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespaceService,
+			Subsystem: subsystemOp,
+			Name:      "latency_seconds",
+			Help:      "Distribution of service op duration in seconds",
+		},
+		serviceFieldKeys,
+	)
+	prometheus.MustRegister(serviceOpLatency)
+
+	sourceFieldKeys := []string{
+		metrics.FieldComponent,
+		metrics.FieldMethod,
+		metrics.FieldNamespace,
+		metrics.FieldSource,
+		metrics.FieldStore,
+	}
+
+	sourceErrCount := kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+		Namespace: namespaceSource,
+		Subsystem: subsystemErr,
+		Name:      "count",
+		Help:      "Number of failed source operations",
+	}, sourceFieldKeys)
+
+	sourceOpCount := kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+		Namespace: namespaceSource,
+		Subsystem: subsystemOp,
+		Name:      "count",
+		Help:      "Number of source operations performed",
+	}, sourceFieldKeys)
+
+	sourceOpLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespaceSource,
+			Subsystem: subsystemOp,
+			Name:      "latency_seconds",
+			Help:      "Distribution of source op duration in seconds",
+			Buckets:   metrics.BucketsQueue,
+		},
+		sourceFieldKeys,
+	)
+	prometheus.MustRegister(sourceOpLatency)
+
+	sourceQueueLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespaceSource,
+			Subsystem: subsystemQueue,
+			Name:      "latency_seconds",
+			Help:      "Distribution of message queue latency in seconds",
+			Buckets:   metrics.BucketsQueue,
+		},
+		sourceFieldKeys,
+	)
+	prometheus.MustRegister(sourceQueueLatency)
+
+	aSession := awsSession.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(*awsID, *awsSecret, ""),
+		Region:      aws.String(*awsRegion),
+	})
+
+	db, err := sqlx.Connect("postgres", *postgresURL)
+	if err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	var connections connection.Service
+	connections = connection.NewPostgresService(db)
+	connections = connection.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(connections)
+	connections = connection.LogServiceMiddleware(logger, "postgres")(connections)
+
+	var events event.Service
+	events = event.NewPostgresService(db)
+	events = event.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(events)
+	events = event.LogServiceMiddleware(logger, "postgres")(events)
+
+	var objects object.Service
+	objects = object.NewPostgresService(db)
+	objects = object.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(objects)
+	objects = object.LogServiceMiddleware(logger, "postgres")(objects)
+
+	var timelines timeline.Service
+
+	switch *store {
+	case storePostgres:
+		timelines = timeline.NewPostgresService(db)
+	case storeRedis:
+		timelines = timeline.NewRedisService(&redis.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 240 * time.Second,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", *redisAddr)
+			},
+			TestOnBorrow: func(c redis.Conn, t time.Time) error {
+				_, err := c.Do("PING")
+				return err
+			},
+		})
+	default:
+		logger.Log("err", "unsupported store "+*store, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	timelines = timeline.InstrumentServiceMiddleware(component, *store, serviceErrCount, serviceOpCount, serviceOpLatency)(timelines)
+	timelines = timeline.LogServiceMiddleware(logger, *store)(timelines)
+
+	conSource, err := connection.FanoutSQSSource(sqs.New(aSession))
+	if err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	conSource = connection.InstrumentSourceMiddleware(
+		component,
+		"sqs",
+		sourceErrCount,
+		sourceOpCount,
+		sourceOpLatency,
+		sourceQueueLatency,
+	)(conSource)
+	conSource = connection.LogSourceMiddleware("sqs", logger)(conSource)
+
+	eventSource, err := event.FanoutSQSSource(sqs.New(aSession))
+	if err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	eventSource = event.InstrumentSourceMiddleware(
+		component,
+		"sqs",
+		sourceErrCount,
+		sourceOpCount,
+		sourceOpLatency,
+		sourceQueueLatency,
+	)(eventSource)
+	eventSource = event.LogSourceMiddleware("sqs", logger)(eventSource)
+
+	objectSource, err := object.FanoutSQSSource(sqs.New(aSession))
+	if err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	objectSource = object.InstrumentSourceMiddleware(
+		component,
+		"sqs",
+		sourceErrCount,
+		sourceOpCount,
+		sourceOpLatency,
+		sourceQueueLatency,
+	)(objectSource)
+	objectSource = object.LogSourceMiddleware("sqs", logger)(objectSource)
+
+	timelineController := controller.NewTimelineController(
+		connections,
+		events,
+		objects,
+		timelines,
+	)
+
+	logger.Log(
+		"duration", time.Now().Sub(begin).Nanoseconds(),
+		"lifecycle", "start",
+		"sub", "worker",
+	)
+
+	errc := make(chan error, 3)
+
+	go func() {
+		errc <- consumeConnection(conSource, timelineController.ConnectionChange)
+	}()
+
+	go func() {
+		errc <- consumeEvent(eventSource, timelineController.EventChange)
+	}()
+
+	go func() {
+		errc <- consumeObject(objectSource, timelineController.ObjectChange)
+	}()
+
+	logger.Log("err", <-errc, "lifecycle", "abort")
+	os.Exit(1)
+}
+
+func consumeConnection(
+	source connection.Source,
+	fn func(*connection.StateChange) error,
+) error {
+	for {
+		c, err := source.Consume()
+		if err != nil {
+			if connection.IsEmptySource(err) {
+				continue
+			}
+			return err
+		}
+
+		if err := fn(c); err != nil {
+			return err
+		}
+
+		if err := source.Ack(c.AckID); err != nil {
+			return err
+		}
+	}
+}
+
+func consumeEvent(
+	source event.Source,
+	fn func(*event.StateChange) error,
+) error {
+	for {
+		c, err := source.Consume()
+		if err != nil {
+			if event.IsEmptySource(err) {
+				continue
+			}
+			return err
+		}
+
+		if err := fn(c); err != nil {
+			return err
+		}
+
+		if err := source.Ack(c.AckID); err != nil {
+			return err
+		}
+	}
+}
+
+func consumeObject(
+	source object.Source,
+	fn func(*object.StateChange) error,
+) error {
+	for {
+		c, err := source.Consume()
+		if err != nil {
+			if object.IsEmptySource(err) {
+				continue
+			}
+			return err
+		}
+
+		if err := fn(c); err != nil {
+			return err
+		}
+
+		if err := source.Ack(c.AckID); err != nil {
+			return err
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	_ "expvar"
 	"flag"
 	"fmt"
@@ -27,6 +28,7 @@ import (
 	klog "github.com/go-kit/kit/log"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/net/context"
 
 	"github.com/tapglue/multiverse/config"
@@ -35,17 +37,25 @@ import (
 	handler "github.com/tapglue/multiverse/handler/http"
 	"github.com/tapglue/multiverse/limiter/redis"
 	tgLogger "github.com/tapglue/multiverse/logger"
+	"github.com/tapglue/multiverse/platform/access"
 	"github.com/tapglue/multiverse/platform/cache"
+	"github.com/tapglue/multiverse/platform/mail"
 	"github.com/tapglue/multiverse/platform/metrics"
+	"github.com/tapglue/multiverse/platform/oidc"
+	"github.com/tapglue/multiverse/platform/password"
 	"github.com/tapglue/multiverse/server"
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/device"
 	"github.com/tapglue/multiverse/service/event"
 	"github.com/tapglue/multiverse/service/member"
+	"github.com/tapglue/multiverse/service/notification"
 	"github.com/tapglue/multiverse/service/object"
 	"github.com/tapglue/multiverse/service/org"
+	"github.com/tapglue/multiverse/service/preference"
 	"github.com/tapglue/multiverse/service/session"
+	"github.com/tapglue/multiverse/service/timeline"
+	"github.com/tapglue/multiverse/service/token"
 	"github.com/tapglue/multiverse/service/user"
 	v04_postgres_core "github.com/tapglue/multiverse/v04/core/postgres"
 	v04_postgres "github.com/tapglue/multiverse/v04/storage/postgres"
@@ -57,6 +67,7 @@ const (
 	EnvConfigVar     = "TAPGLUE_INTAKER_CONFIG_PATH"
 	apiVersionNext   = "0.4"
 	component        = "gateway-http"
+	leaseExpiry      = "connection.expiry"
 	namespaceCache   = "cache"
 	namespaceService = "service"
 	namespaceSource  = "source"
@@ -66,12 +77,31 @@ const (
 	subsystemQueue   = "queue"
 )
 
+// Supported access token algorithms.
+const (
+	accessEd25519 = "ed25519"
+	accessHMAC    = "hs256"
+)
+
+// Supported mail transports.
+const (
+	mailFile = "file"
+	mailSMTP = "smtp"
+)
+
 // Supported source types.
 const (
 	sourceNop = "nop"
 	sourceSQS = "sqs"
 )
 
+// Supported timeline stores.
+const (
+	timelineNone     = "none"
+	timelinePostgres = "postgres"
+	timelineRedis    = "redis"
+)
+
 var (
 	currentRevision = "0000000-dev"
 
@@ -96,11 +126,28 @@ func init() {
 
 func main() {
 	var (
+		accessAlgo = flag.String("access.algorithm", accessHMAC, "Algorithm used to sign access tokens")
+		accessKey  = flag.String("access.key", "", "Base64 encoded HMAC secret or Ed25519 seed to sign access tokens with, tokens are disabled without")
 		awsID      = flag.String("aws.id", "", "Identifier for AWS requests")
 		awsRegion  = flag.String("aws.region", "us-east-1", "AWS Region to operate in")
 		awsSecret  = flag.String("aws.secret", "", "Identification secret for AWS requests")
 		source     = flag.String("source", sourceNop, "Source type used for state change propagations")
+		timelines  = flag.String("timeline", timelineNone, "Store of the materialised timelines read by feeds")
+		expiry     = flag.Duration("connection.expiry", time.Minute, "Interval to reject pending connection requests past the expiry of their App")
 		forceNoSec = flag.Bool("force-no-sec", false, "Force no sec enables launching the backend in production without security checks")
+		mailAddr   = flag.String("mail.smtp.addr", "localhost:25", "SMTP relay used by the smtp mail transport")
+		mailDir    = flag.String("mail.dir", os.TempDir(), "Directory the file mail transport writes to")
+		mailFrom   = flag.String("mail.from", "noreply@tapglue.com", "Sender address of account mails")
+		mailSMTPPW = flag.String("mail.smtp.password", "", "Password for authentication against the SMTP relay")
+		mailSMTPUN = flag.String("mail.smtp.username", "", "Username for authentication against the SMTP relay")
+		mailType   = flag.String("mail", mailSMTP, "Transport used to deliver account mails, file is meant for development only")
+		jwksTTL    = flag.Duration("oidc.jwks.ttl", time.Hour, "Duration key sets of identity providers are cached for")
+		pwAlgo     = flag.String("password.algorithm", password.DefaultHasher.Algorithm, "Algorithm used to hash new passwords")
+		pwCost     = flag.Int("password.bcrypt.cost", 12, "Cost of bcrypt password hashes")
+		pwScryptN  = flag.Int("password.scrypt.n", password.DefaultHasher.N, "CPU/memory cost of scrypt password hashes")
+		pwScryptP  = flag.Int("password.scrypt.p", password.DefaultHasher.P, "Parallelization of scrypt password hashes")
+		pwScryptR  = flag.Int("password.scrypt.r", password.DefaultHasher.R, "Block size of scrypt password hashes")
+		seenFlush  = flag.Duration("session.flush", time.Minute, "Interval to persist the last seen times of sessions")
 	)
 	flag.Parse()
 
@@ -362,6 +409,47 @@ func main() {
 	)(objectSource)
 	objectSource = object.LogSourceMiddleware(*source, logger)(objectSource)
 
+	var (
+		conProducer    connection.Producer = conSource
+		eventProducer  event.Producer      = eventSource
+		objectProducer object.Producer     = objectSource
+	)
+
+	// The fan-out consumes its own copy of the state changes, as every message
+	// of a queue is only delivered to one consumer.
+	if *source == sourceSQS && *timelines != timelineNone {
+		conFanout, err := connection.FanoutSQSSource(sqsAPI)
+		if err != nil {
+			logger.Log("err", err, "lifecycle", "abort")
+			os.Exit(1)
+		}
+
+		eventFanout, err := event.FanoutSQSSource(sqsAPI)
+		if err != nil {
+			logger.Log("err", err, "lifecycle", "abort")
+			os.Exit(1)
+		}
+
+		objectFanout, err := object.FanoutSQSSource(sqsAPI)
+		if err != nil {
+			logger.Log("err", err, "lifecycle", "abort")
+			os.Exit(1)
+		}
+
+		conProducer = connection.MultiProducer(
+			conSource,
+			connection.LogSourceMiddleware("sqs_fanout", logger)(conFanout),
+		)
+		eventProducer = event.MultiProducer(
+			eventSource,
+			event.LogSourceMiddleware("sqs_fanout", logger)(eventFanout),
+		)
+		objectProducer = object.MultiProducer(
+			objectSource,
+			object.LogSourceMiddleware("sqs_fanout", logger)(objectFanout),
+		)
+	}
+
 	// Setup services
 	var apps app.Service
 	apps = app.NewPostgresService(pgClient.MainDatastore())
@@ -373,7 +461,7 @@ func main() {
 	connections = connection.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(connections)
 	connections = connection.LogServiceMiddleware(logger, "postgres")(connections)
 	// Combine connection service and source.
-	connections = connection.SourcingServiceMiddleware(conSource)(connections)
+	connections = connection.SourcingServiceMiddleware(conProducer)(connections)
 
 	var devices device.Service
 	devices = device.PostgresService(pgClient.MainDatastore())
@@ -385,7 +473,7 @@ func main() {
 	events = event.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(events)
 	events = event.LogServiceMiddleware(logger, "postgres")(events)
 	// Combine event service and source.
-	events = event.SourcingServiceMiddleware(eventSource)(events)
+	events = event.SourcingServiceMiddleware(eventProducer)(events)
 	// Add counts cache.
 	// events = event.CacheServiceMiddleware(eventCountsCache)(events)
 
@@ -394,12 +482,17 @@ func main() {
 	members = member.InstrumentStrangleMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(members)
 	members = member.LogStrangleMiddleware(logger, "postgres")(members)
 
+	var notifications notification.Service
+	notifications = notification.PostgresService(pgClient.MainDatastore())
+	notifications = notification.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(notifications)
+	notifications = notification.LogServiceMiddleware(logger, "postgres")(notifications)
+
 	var objects object.Service
 	objects = object.NewPostgresService(pgClient.MainDatastore())
 	objects = object.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(objects)
 	objects = object.LogServiceMiddleware(logger, "postgres")(objects)
 	// Combine object service and source.
-	objects = object.SourcingServiceMiddleware(objectSource)(objects)
+	objects = object.SourcingServiceMiddleware(objectProducer)(objects)
 	// Add counts cache.
 	// objects = object.CacheServiceMiddleware(objectCountsCache)(objects)
 
@@ -408,16 +501,137 @@ func main() {
 	orgs = org.InstrumentStrangleMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(orgs)
 	orgs = org.LogStrangleMiddleware(logger, "postgres")(orgs)
 
+	var preferences preference.Service
+	preferences = preference.PostgresService(pgClient.MainDatastore())
+	preferences = preference.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(preferences)
+	preferences = preference.LogServiceMiddleware(logger, "postgres")(preferences)
+
 	var sessions session.Service
 	sessions = session.NewPostgresService(pgClient.MainDatastore())
 	sessions = session.InstrumentMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(sessions)
 	sessions = session.LogMiddleware(logger, "postgres")(sessions)
 
+	sessionRevocations := session.NewRedisRevocations(redisClient)
+	sessions = session.RevokeServiceMiddleware(sessionRevocations, app.MaxAccessTTL)(sessions)
+
+	sessionTracker := session.NewRedisTracker(redisClient)
+
+	expiryLease := cache.RedisLeaseService(redisClient)
+
+	var tokens token.Service
+	tokens = token.NewPostgresService(pgClient.MainDatastore())
+	tokens = token.InstrumentMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(tokens)
+	tokens = token.LogMiddleware(logger, "postgres")(tokens)
+
 	var users user.Service
 	users = user.NewPostgresService(pgClient.MainDatastore())
 	users = user.InstrumentMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(users)
 	users = user.LogMiddleware(logger, "postgres")(users)
 
+	// Timelines are only read if the fan-out is running.
+	var timelineService timeline.Service
+
+	switch *timelines {
+	case timelineNone:
+		// Feeds are computed from the social graph.
+	case timelinePostgres:
+		timelineService = timeline.NewPostgresService(pgClient.MainDatastore())
+	case timelineRedis:
+		timelineService = timeline.NewRedisService(redisClient)
+	default:
+		logger.Log(
+			"err", fmt.Sprintf("unsupported timeline store %s", *timelines),
+			"lifecycle", "abort",
+		)
+		os.Exit(1)
+	}
+
+	if timelineService != nil {
+		timelineService = timeline.InstrumentServiceMiddleware(component, *timelines, serviceErrCount, serviceOpCount, serviceOpLatency)(timelineService)
+		timelineService = timeline.LogServiceMiddleware(logger, *timelines)(timelineService)
+	}
+
+	var mails mail.Sender
+
+	switch *mailType {
+	case mailFile:
+		mails = mail.FileSender(*mailDir, *mailFrom)
+	case mailSMTP:
+		mails, err = mail.SMTPSender(mail.SMTPConfig{
+			Addr:     *mailAddr,
+			From:     *mailFrom,
+			Password: *mailSMTPPW,
+			Username: *mailSMTPUN,
+		})
+		if err != nil {
+			logger.Log("err", err, "lifecycle", "abort")
+			os.Exit(1)
+		}
+	default:
+		logger.Log(
+			"err", fmt.Sprintf("unsupported mail transport %s", *mailType),
+			"lifecycle", "abort",
+		)
+		os.Exit(1)
+	}
+
+	hasher := password.Hasher{
+		Algorithm: *pwAlgo,
+		Cost:      *pwCost,
+		N:         *pwScryptN,
+		P:         *pwScryptP,
+		R:         *pwScryptR,
+	}
+
+	if err := hasher.Validate(); err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	verifier := oidc.NewVerifier(
+		oidc.HTTPFetch(&http.Client{Timeout: 5 * time.Second}),
+		*jwksTTL,
+	)
+
+	key, err := base64.StdEncoding.DecodeString(*accessKey)
+	if err != nil {
+		logger.Log("err", fmt.Sprintf("malformed access key: %s", err), "lifecycle", "abort")
+		os.Exit(1)
+	}
+
+	// Without a key access tokens stay disabled, a random key would only be
+	// valid for the lifetime of the process and not be accepted by other
+	// instances.
+	var signer access.Signer
+
+	switch {
+	case len(key) == 0:
+		logger.Log("lifecycle", "start", "sub", "access", "tokens", "disabled")
+	case *accessAlgo == accessEd25519:
+		if len(key) != ed25519.SeedSize {
+			logger.Log(
+				"err", fmt.Sprintf("ed25519 access key must be %d bytes", ed25519.SeedSize),
+				"lifecycle", "abort",
+			)
+			os.Exit(1)
+		}
+
+		signer, err = access.Ed25519Signer(ed25519.NewKeyFromSeed(key))
+	case *accessAlgo == accessHMAC:
+		signer, err = access.HMACSigner(key)
+	default:
+		logger.Log(
+			"err", fmt.Sprintf("unsupported access algorithm %s", *accessAlgo),
+			"lifecycle", "abort",
+		)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		logger.Log("err", err, "lifecycle", "abort")
+		os.Exit(1)
+	}
+
 	// Setup controllers
 	var (
 		analyticsController = controller.NewAnalyticsController(
@@ -427,18 +641,19 @@ func main() {
 			objects,
 			users,
 		)
-		commentController        = controller.NewCommentController(connections, objects, users)
+		commentController        = controller.NewCommentController(connections, events, objects, users)
 		connectionController     = controller.NewConnectionController(connections, users)
 		eventController          = controller.NewEventController(connections, events, objects, users)
-		feedController           = controller.NewFeedController(connections, events, objects, users)
+		feedController           = controller.NewFeedController(connections, events, objects, timelineService, users)
 		likeController           = controller.NewLikeController(connections, events, objects, users)
 		postController           = controller.NewPostController(connections, events, objects, users)
 		recommendationController = controller.NewRecommendationController(
 			connections,
 			events,
+			objects,
 			users,
 		)
-		userController = controller.NewUserController(connections, sessions, users)
+		userController = controller.NewUserController(connections, hasher, sessions, signer, users, verifier)
 	)
 
 	// Setup middlewares
@@ -470,7 +685,11 @@ func main() {
 		)
 		withUser = handler.Chain(
 			withApp,
-			handler.CtxUser(sessions, users),
+			handler.CtxUser(logger, sessionRevocations, sessions, signer, sessionTracker, users),
+		)
+		withUserLoaded = handler.Chain(
+			withUser,
+			handler.CtxUserLoad(users),
 		)
 	)
 
@@ -540,6 +759,13 @@ func main() {
 		),
 	)
 
+	next.Methods("PUT").Path(`/me/connections/pending/{type:[a-z]+}/{fromID:[0-9]+}`).Name("connectionAnswer").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.ConnectionAnswer(connectionController),
+		),
+	)
+
 	next.Methods("DELETE").Path(`/me/connections/{type:[a-z]+}/{toID:[0-9]+}`).Name("connectionDelete").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -575,6 +801,20 @@ func main() {
 		),
 	)
 
+	next.Methods("GET").Path(`/me/blocks`).Name("connectionBlocksMe").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.ConnectionByType(connectionController, connection.TypeBlock),
+		),
+	)
+
+	next.Methods("GET").Path(`/me/mutes`).Name("connectionMutesMe").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.ConnectionByType(connectionController, connection.TypeMute),
+		),
+	)
+
 	next.Methods("GET").Path(`/me/friends`).Name("connectionFriendsMe").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -589,6 +829,13 @@ func main() {
 		),
 	)
 
+	next.Methods("GET").Path(`/users/{userID:[0-9]+}/mutuals`).Name("connectionMutuals").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.ConnectionMutuals(connectionController),
+		),
+	)
+
 	next.Methods("GET").Path(`/users/{userID:[0-9]+}/follows`).Name("connectionFollowings").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -649,7 +896,7 @@ func main() {
 
 	next.Methods("GET").Path("/me/feed").Name("feedNews").HandlerFunc(
 		handler.Wrap(
-			withUser,
+			withUserLoaded,
 			handler.FeedNews(feedController),
 		),
 	)
@@ -677,7 +924,7 @@ func main() {
 
 	next.Methods("GET").Path(`/me/feed/unread/count`).Name("feedUnreadCount").HandlerFunc(
 		handler.Wrap(
-			withUser,
+			withUserLoaded,
 			handler.FeedUnreadCount(feedController),
 		),
 	)
@@ -710,6 +957,90 @@ func main() {
 		),
 	)
 
+	next.Methods("GET").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/connections`).Name("appConnectionsRetrieve").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppConnectionsRetrieve(controller.AppConnectionsRetrieve(apps)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/connections`).Name("appConnectionsUpdate").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppConnectionsUpdate(controller.AppConnectionsUpdate(apps)),
+		),
+	)
+
+	next.Methods("GET").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/push`).Name("appPushRetrieve").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppPushRetrieve(controller.AppPushRetrieve(apps)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/push`).Name("appPushUpdate").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppPushUpdate(controller.AppPushUpdate(apps)),
+		),
+	)
+
+	next.Methods("GET").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/providers`).Name("appProvidersRetrieve").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppProvidersRetrieve(controller.AppProvidersRetrieve(apps)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/providers`).Name("appProvidersUpdate").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppProvidersUpdate(controller.AppProvidersUpdate(apps)),
+		),
+	)
+
+	next.Methods("GET").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/sessions`).Name("appSessionsRetrieve").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppSessionsRetrieve(controller.AppSessionsRetrieve(apps)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/sessions`).Name("appSessionsUpdate").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppSessionsUpdate(controller.AppSessionsUpdate(apps)),
+		),
+	)
+
+	next.Methods("GET").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/templates`).Name("appTemplatesRetrieve").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppTemplatesRetrieve(controller.AppTemplatesRetrieve(apps)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/templates`).Name("appTemplatesUpdate").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppTemplatesUpdate(controller.AppTemplatesUpdate(apps)),
+		),
+	)
+
+	next.Methods("GET").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/tokens`).Name("appTokensRetrieve").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppTokensRetrieve(controller.AppTokensRetrieve(apps)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}/tokens`).Name("appTokensUpdate").HandlerFunc(
+		handler.Wrap(
+			withMember,
+			handler.AppTokensUpdate(controller.AppTokensUpdate(apps, signer)),
+		),
+	)
+
 	next.Methods("PUT").Path(`/organizations/{orgID:[a-zA-Z0-9\-]+}/applications/{appID:[a-zA-Z0-9\-]+}`).Name("appUpdate").HandlerFunc(
 		handler.Wrap(
 			withMember,
@@ -752,6 +1083,22 @@ func main() {
 		),
 	)
 
+	next.Methods("GET").Path("/posts/{postID:[0-9]+}/comments/{commentID:[0-9]+}/replies").Name("commentListReplies").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.CommentListReplies(commentController),
+		),
+	)
+
+	next.Methods("POST").Path("/posts/{postID:[0-9]+}/comments/{commentID:[0-9]+}/reports").Name("reportComment").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.ReportComment(
+				controller.ReportCreate(connections, objects),
+			),
+		),
+	)
+
 	next.Methods("POST").Path("/posts/{postID:[0-9]+}/likes").Name("likeCreate").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -815,6 +1162,15 @@ func main() {
 		),
 	)
 
+	next.Methods("POST").Path("/posts/{postID:[0-9]+}/reports").Name("reportPost").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.ReportPost(
+				controller.ReportCreate(connections, objects),
+			),
+		),
+	)
+
 	next.Methods("GET").Path("/posts").Name("postListAll").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -822,6 +1178,149 @@ func main() {
 		),
 	)
 
+	next.Methods("GET").Path("/posts/nearby").Name("postListNearby").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.PostListNearby(postController),
+		),
+	)
+
+	next.Methods("GET").Path("/posts/search").Name("postSearch").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.PostSearch(postController),
+		),
+	)
+
+	next.Methods("GET").Path("/tags/search").Name("tagSearch").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.TagSearch(
+				controller.TagSearch(objects),
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/tags/trending/day").Name("tagTrendingDay").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.TagTrending(
+				controller.TagTrending(events, objects),
+				event.ByDay,
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/tags/trending/week").Name("tagTrendingWeek").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.TagTrending(
+				controller.TagTrending(events, objects),
+				event.ByWeek,
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/tags/trending/month").Name("tagTrendingMonth").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.TagTrending(
+				controller.TagTrending(events, objects),
+				event.ByMonth,
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/tags/{tag}/posts").Name("postListTag").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.PostListTag(postController),
+		),
+	)
+
+	next.Methods("GET").Path("/moderation").Name("moderationList").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.ModerationList(
+				controller.ModerationList(objects, users),
+			),
+		),
+	)
+
+	next.Methods("PUT").Path(`/moderation/{objectID:[0-9]+}/confirm`).Name("moderationConfirm").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.ModerationReview(
+				controller.ModerationReview(objects),
+				object.StateConfirmed,
+			),
+		),
+	)
+
+	next.Methods("PUT").Path(`/moderation/{objectID:[0-9]+}/decline`).Name("moderationDecline").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.ModerationReview(
+				controller.ModerationReview(objects),
+				object.StateDeclined,
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/me/notifications").Name("notificationList").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.NotificationList(
+				controller.NotificationList(notifications, users),
+			),
+		),
+	)
+
+	next.Methods("PUT").Path("/me/notifications/read").Name("notificationReadAll").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.NotificationReadAll(
+				controller.NotificationReadAll(notifications),
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/me/notifications/unread/count").Name("notificationUnreadCount").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.NotificationUnreadCount(
+				controller.NotificationUnreadCount(notifications),
+			),
+		),
+	)
+
+	next.Methods("PUT").Path(`/me/notifications/{notificationID:[0-9]+}/read`).Name("notificationRead").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.NotificationRead(
+				controller.NotificationRead(notifications),
+			),
+		),
+	)
+
+	next.Methods("GET").Path("/me/notifications/settings").Name("preferenceRetrieve").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.PreferenceRetrieve(
+				controller.PreferenceRetrieve(preferences),
+			),
+		),
+	)
+
+	next.Methods("PUT").Path("/me/notifications/settings").Name("preferenceUpdate").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.PreferenceUpdate(
+				controller.PreferenceUpdate(preferences),
+			),
+		),
+	)
+
 	next.Methods("GET").Path("/me/posts").Name("postListMe").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -836,6 +1335,13 @@ func main() {
 		),
 	)
 
+	next.Methods("GET").Path("/recommendations/users").Name("recommendUsers").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.RecommendUsers(recommendationController),
+		),
+	)
+
 	next.Methods("GET").Path("/recommendations/users/active/day").Name("recommendUsersActiveDay").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -866,7 +1372,7 @@ func main() {
 
 	next.Methods("PUT").Path("/me").Name("userUpdate").HandlerFunc(
 		handler.Wrap(
-			withUser,
+			withUserLoaded,
 			handler.UserUpdate(userController),
 		),
 	)
@@ -885,9 +1391,53 @@ func main() {
 		),
 	)
 
-	next.Methods("DELETE").Path("/me").Name("userDelete").HandlerFunc(
+	next.Methods("GET").Path("/me/sessions").Name("sessionList").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.SessionList(controller.SessionList(devices, sessions)),
+		),
+	)
+
+	next.Methods("DELETE").Path("/me/sessions").Name("sessionRevokeOthers").HandlerFunc(
 		handler.Wrap(
 			withUser,
+			handler.SessionRevokeOthers(controller.SessionRevokeOthers(sessions)),
+		),
+	)
+
+	next.Methods("DELETE").Path(`/me/sessions/{sessionID:[a-f0-9]+}`).Name("sessionRevoke").HandlerFunc(
+		handler.Wrap(
+			withUser,
+			handler.SessionRevoke(controller.SessionRevoke(sessions)),
+		),
+	)
+
+	next.Methods("PUT").Path(`/me/social/{platform:[a-z0-9_\-]+}`).Name("userSocialLink").HandlerFunc(
+		handler.Wrap(
+			withUserLoaded,
+			handler.UserSocialLink(userController),
+		),
+	)
+
+	next.Methods("DELETE").Path(`/me/social/{platform:[a-z0-9_\-]+}`).Name("userSocialUnlink").HandlerFunc(
+		handler.Wrap(
+			withUserLoaded,
+			handler.UserSocialUnlink(userController),
+		),
+	)
+
+	next.Methods("POST").Path("/me/verification").Name("emailVerificationRequest").HandlerFunc(
+		handler.Wrap(
+			withUserLoaded,
+			handler.EmailVerificationRequest(
+				controller.EmailVerificationRequest(mails, tokens),
+			),
+		),
+	)
+
+	next.Methods("DELETE").Path("/me").Name("userDelete").HandlerFunc(
+		handler.Wrap(
+			withUserLoaded,
 			handler.UserDelete(userController),
 		),
 	)
@@ -906,6 +1456,47 @@ func main() {
 		),
 	)
 
+	next.Methods("POST").Path("/users/login/social").Name("userLoginSocial").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.UserLoginSocial(userController),
+		),
+	)
+
+	next.Methods("POST").Path("/users/token/refresh").Name("accessRefresh").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.AccessRefresh(controller.AccessRefresh(sessions, signer, users)),
+		),
+	)
+
+	next.Methods("PUT").Path("/users/password").Name("passwordReset").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.PasswordReset(
+				controller.PasswordReset(hasher, sessions, tokens, users),
+			),
+		),
+	)
+
+	next.Methods("POST").Path("/users/password/reset").Name("passwordResetRequest").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.PasswordResetRequest(
+				controller.PasswordResetRequest(mails, tokens, users),
+			),
+		),
+	)
+
+	next.Methods("PUT").Path("/users/verification").Name("emailVerify").HandlerFunc(
+		handler.Wrap(
+			withApp,
+			handler.EmailVerify(
+				controller.EmailVerify(tokens, users),
+			),
+		),
+	)
+
 	next.Methods("POST").Path("/users/search/emails").Name("userSearchEmails").HandlerFunc(
 		handler.Wrap(
 			withUser,
@@ -946,6 +1537,55 @@ func main() {
 		server.TLSConfig = configTLS()
 	}
 
+	go func() {
+		flush := controller.SessionSeenFlush(sessions, sessionTracker)
+
+		for range time.Tick(*seenFlush) {
+			if err := flush(); err != nil {
+				logger.Log("err", err, "lifecycle", "flush")
+			}
+		}
+	}()
+
+	go func() {
+		var (
+			enabled = true
+			expire  = controller.ConnectionExpire(connections)
+		)
+
+		for range time.Tick(*expiry) {
+			// Only one replica sweeps per interval, the others skip the tick.
+			ok, err := expiryLease.Acquire(leaseExpiry, *expiry)
+			if err != nil {
+				logger.Log("err", err, "lifecycle", "expire")
+				continue
+			}
+
+			if !ok {
+				continue
+			}
+
+			as, err := apps.Query(app.NamespaceDefault, app.QueryOptions{
+				Enabled: &enabled,
+			})
+			if err != nil {
+				logger.Log("err", err, "lifecycle", "expire")
+				continue
+			}
+
+			for _, a := range as {
+				_, err := expire(a)
+				if err != nil {
+					logger.Log(
+						"err", err,
+						"lifecycle", "expire",
+						"namespace", a.Namespace(),
+					)
+				}
+			}
+		}
+	}()
+
 	go func() {
 		http.Handle("/metrics", prometheus.Handler())
 
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/device"
+)
+
+// legacyPushes are the push configurations which were hard-coded before they
+// were stored per app. They apply to apps without a stored configuration, so
+// their notifications keep being delivered until the app is configured.
+var legacyPushes = map[string]*app.Push{
+	"app_1_610": {
+		ARNs: map[device.Platform]string{
+			device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/simsTest",
+			device.PlatformAndroid:    "arn:aws:sns:eu-central-1:775034650473:app/GCM/simsTestGCM",
+		},
+		Enabled: true,
+		Scheme:  "simsTest",
+	},
+	"app_1_1147": {
+		ARNs: map[device.Platform]string{
+			device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/tapglue-iOSExample",
+		},
+		Enabled: true,
+		Scheme:  "TapglueSample",
+	},
+	"app_515_922": {
+		ARNs: map[device.Platform]string{
+			device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/bikestorming-iOSSandbox",
+		},
+		Enabled: true,
+		Scheme:  "bkx",
+	},
+	"app_684_948": {
+		ARNs: map[device.Platform]string{
+			device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/uMake-iOSSandbox",
+		},
+		Enabled: true,
+		Scheme:  "uMake",
+	},
+	"app_684_987": {
+		ARNs: map[device.Platform]string{
+			device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/uMake-iOSSandbox",
+		},
+		Enabled: true,
+		Scheme:  "uMake",
+	},
+	"app_831_1203": {
+		ARNs: map[device.Platform]string{
+			device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/lifesum-iOSSandbox",
+		},
+		Enabled: true,
+		Scheme:  "shapeupclub",
+	},
+}
+
+// legacyNamespaces maps the ARNs of the legacy configurations to the app their
+// endpoint events belong to, as some ARNs are shared by several apps.
+var legacyNamespaces = map[string]string{
+	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/simsTest":                "app_1_610",
+	"arn:aws:sns:eu-central-1:775034650473:app/GCM/simsTestGCM":                      "app_1_610",
+	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/uMake-iOSSandbox":        "app_684_948",
+	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/bikestorming-iOSSandbox": "app_515_922",
+	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/tapglue-iOSExample":      "app_1_1147",
+	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/lifesum-iOSSandbox":      "app_831_1203",
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/push"
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/device"
+)
+
+// pushCache holds the push configurations of all enabled apps and refreshes
+// them once they are older than the ttl.
+type pushCache struct {
+	sync.Mutex
+
+	apps       app.Service
+	client     *http.Client
+	fetchedAt  time.Time
+	namespaces map[string]string
+	overrides  map[string]app.Templates
+	pushers    map[string]pusherEntry
+	pushes     map[string]*app.Push
+	sns        push.SNSAPI
+	ttl        time.Duration
+}
+
+// pusherEntry remembers the configuration a Pusher was constructed with, so it
+// is only replaced once the configuration changes. Direct backends reuse their
+// authentication tokens which must not be renewed too often.
+type pusherEntry struct {
+	config interface{}
+	pusher push.Pusher
+}
+
+func newPushCache(
+	apps app.Service,
+	client *http.Client,
+	sns push.SNSAPI,
+	ttl time.Duration,
+) *pushCache {
+	return &pushCache{
+		apps:       apps,
+		client:     client,
+		namespaces: map[string]string{},
+		overrides:  map[string]app.Templates{},
+		pushers:    map[string]pusherEntry{},
+		pushes:     map[string]*app.Push{},
+		sns:        sns,
+		ttl:        ttl,
+	}
+}
+
+// backend returns the backend configured for the namespace and device
+// platform.
+func (c *pushCache) backend(
+	ns string,
+	platform device.Platform,
+) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return "", err
+	}
+
+	p, ok := c.pushes[ns]
+	if !ok {
+		return "", ErrNamespaceNotFound
+	}
+
+	return p.Backend(platform), nil
+}
+
+// namespace returns the namespace of the app the platform ARN is configured
+// for.
+func (c *pushCache) namespace(arn string) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return "", err
+	}
+
+	ns, ok := c.namespaces[arn]
+	if !ok {
+		return "", ErrNamespaceNotFound
+	}
+
+	return ns, nil
+}
+
+// platformARN returns the platform ARN configured for the namespace and device
+// platform.
+func (c *pushCache) platformARN(
+	ns string,
+	platform device.Platform,
+) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return "", err
+	}
+
+	p, ok := c.pushes[ns]
+	if !ok {
+		return "", ErrNamespaceNotFound
+	}
+
+	arn, ok := p.ARN(platform)
+	if !ok {
+		return "", ErrPlatformNotFound
+	}
+
+	return arn, nil
+}
+
+// pusher returns the Pusher delivering to the device platform of the
+// namespace.
+func (c *pushCache) pusher(
+	ns string,
+	platform device.Platform,
+) (push.Pusher, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	p, ok := c.pushes[ns]
+	if !ok {
+		return nil, ErrNamespaceNotFound
+	}
+
+	var (
+		backend = p.Backend(platform)
+		key     = fmt.Sprintf("%s.%d", ns, platform)
+		config  interface{}
+	)
+
+	switch backend {
+	case app.BackendAPNS:
+		if p.APNS == nil {
+			return nil, ErrPlatformNotFound
+		}
+
+		config = *p.APNS
+	case app.BackendFCM:
+		if p.FCM == nil {
+			return nil, ErrPlatformNotFound
+		}
+
+		config = *p.FCM
+	default:
+		config = backend
+	}
+
+	if e, ok := c.pushers[key]; ok && e.config == config {
+		return e.pusher, nil
+	}
+
+	pusher, err := c.newPusher(platform, backend, p)
+	if err != nil {
+		return nil, err
+	}
+
+	c.pushers[key] = pusherEntry{
+		config: config,
+		pusher: pusher,
+	}
+
+	return pusher, nil
+}
+
+// scheme returns the scheme used for deep links of the namespace.
+func (c *pushCache) scheme(ns string, platform device.Platform) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return "", err
+	}
+
+	p, ok := c.pushes[ns]
+	if !ok {
+		return "", ErrNamespaceNotFound
+	}
+
+	if _, ok := p.ARN(platform); !ok && p.Backend(platform) == app.BackendSNS {
+		return "", ErrPlatformNotFound
+	}
+
+	return p.Scheme, nil
+}
+
+// templates returns the notification templates the app of the namespace
+// overrides.
+func (c *pushCache) templates(ns string) (app.Templates, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	return c.overrides[ns], nil
+}
+
+func (c *pushCache) newPusher(
+	platform device.Platform,
+	backend string,
+	p *app.Push,
+) (push.Pusher, error) {
+	switch backend {
+	case app.BackendAPNS:
+		host := push.APNSHostProduction
+
+		if platform == device.PlatformIOSSandbox {
+			host = push.APNSHostSandbox
+		}
+
+		return push.APNSPusher(c.client, push.APNSConfig{
+			Host:   host,
+			Key:    []byte(p.APNS.Key),
+			KeyID:  p.APNS.KeyID,
+			TeamID: p.APNS.TeamID,
+			Topic:  p.APNS.Topic,
+		})
+	case app.BackendFCM:
+		return push.FCMPusher(c.client, push.FCMConfig{
+			ClientEmail: p.FCM.ClientEmail,
+			PrivateKey:  []byte(p.FCM.PrivateKey),
+			ProjectID:   p.FCM.ProjectID,
+		})
+	}
+
+	switch platform {
+	case device.PlatformAndroid:
+		return push.SNSPusher(c.sns, push.SNSPlatformGCM), nil
+	case device.PlatformIOS:
+		return push.SNSPusher(c.sns, push.SNSPlatformAPNS), nil
+	case device.PlatformIOSSandbox:
+		return push.SNSPusher(c.sns, push.SNSPlatformAPNSSandbox), nil
+	}
+
+	return nil, ErrPlatformNotFound
+}
+
+// refresh fetches the push configurations if the cached ones expired, apps
+// without a stored one fall back to their legacy configuration. It expects the
+// caller to hold the lock.
+func (c *pushCache) refresh() error {
+	if time.Since(c.fetchedAt) < c.ttl {
+		return nil
+	}
+
+	as, err := c.apps.Query(app.NamespaceDefault, app.QueryOptions{
+		Enabled: &defaultEnabled,
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		namespaces = map[string]string{}
+		overrides  = map[string]app.Templates{}
+		pushes     = map[string]*app.Push{}
+	)
+
+	for _, a := range as {
+		var (
+			ns     = a.Namespace()
+			p      = a.Push
+			legacy = false
+		)
+
+		if p == nil {
+			p, legacy = legacyPushes[ns]
+		}
+
+		if p == nil || !p.Enabled {
+			continue
+		}
+
+		pushes[ns] = p
+		overrides[ns] = a.Templates
+
+		for _, arn := range p.ARNs {
+			if !legacy {
+				namespaces[arn] = ns
+				continue
+			}
+
+			// Stored configurations take precedence over legacy ones sharing
+			// the same ARN.
+			if _, ok := namespaces[arn]; !ok {
+				namespaces[arn] = legacyNamespaces[arn]
+			}
+		}
+	}
+
+	c.fetchedAt = time.Now()
+	c.namespaces = namespaces
+	c.overrides = overrides
+	c.pushes = pushes
+
+	return nil
+}
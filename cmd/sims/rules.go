@@ -2,24 +2,17 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/event"
 	"github.com/tapglue/multiverse/service/object"
-	"github.com/tapglue/multiverse/service/user"
+	"github.com/tapglue/multiverse/service/preference"
 )
 
 const (
-	fmtCommentPost     = "%s commented on a Post."
-	fmtCommentPostOwn  = "%s commented on your Post."
-	fmtFollow          = "%s started following you"
-	fmtFriendConfirmed = "%s accepted your friend request."
-	fmtFriendRequest   = "%s sent you a friend request."
-	fmtLikePost        = "%s liked a Post."
-	fmtLikePostOwn     = "%s liked your Post."
-	fmtPostCreated     = "%s created a new Post."
-
 	urnComment = "tapglue/posts/%d/comments/%d"
 	urnPost    = "tapglue/posts/%d"
 	urnUser    = "tapglue/users/%d"
@@ -29,7 +22,10 @@ type conRuleFunc func(*connection.StateChange) (*message, error)
 type eventRuleFunc func(*event.StateChange) ([]*message, error)
 type objectRuleFunc func(*object.StateChange) ([]*message, error)
 
-func conRuleFollower(fetchUser fetchUserFunc) conRuleFunc {
+func conRuleFollower(
+	fetchUser fetchUserFunc,
+	isEnabled isEnabledFunc,
+) conRuleFunc {
 	return func(change *connection.StateChange) (*message, error) {
 		if change.Old != nil ||
 			change.New.State != connection.StateConfirmed ||
@@ -47,15 +43,29 @@ func conRuleFollower(fetchUser fetchUserFunc) conRuleFunc {
 			return nil, fmt.Errorf("target fetch: %s", err)
 		}
 
+		ok, err := isEnabled(change.Namespace, target.ID, preference.TypeFollow)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
+		if !ok {
+			return nil, nil
+		}
+
 		return &message{
-			message:   fmtToMessage(fmtFollow, origin),
+			origin:    origin,
 			recipient: target.ID,
+			target:    target,
+			template:  app.TemplateFollow,
 			urn:       fmt.Sprintf(urnUser, origin.ID),
 		}, nil
 	}
 }
 
-func conRuleFriendConfirmed(fetchUser fetchUserFunc) conRuleFunc {
+func conRuleFriendConfirmed(
+	fetchUser fetchUserFunc,
+	isEnabled isEnabledFunc,
+) conRuleFunc {
 	return func(change *connection.StateChange) (*message, error) {
 		if change.Old == nil ||
 			change.Old.Type != connection.TypeFriend ||
@@ -74,15 +84,29 @@ func conRuleFriendConfirmed(fetchUser fetchUserFunc) conRuleFunc {
 			return nil, fmt.Errorf("target fetch: %s", err)
 		}
 
+		ok, err := isEnabled(change.Namespace, origin.ID, preference.TypeFriendConfirmed)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
+		if !ok {
+			return nil, nil
+		}
+
 		return &message{
-			message:   fmtToMessage(fmtFriendConfirmed, target),
+			origin:    target,
 			recipient: origin.ID,
+			target:    origin,
+			template:  app.TemplateFriendConfirmed,
 			urn:       fmt.Sprintf(urnUser, origin.ID),
 		}, nil
 	}
 }
 
-func conRuleFriendRequest(fetchUser fetchUserFunc) conRuleFunc {
+func conRuleFriendRequest(
+	fetchUser fetchUserFunc,
+	isEnabled isEnabledFunc,
+) conRuleFunc {
 	return func(change *connection.StateChange) (*message, error) {
 		if change.Old != nil ||
 			change.New.State != connection.StatePending ||
@@ -100,9 +124,20 @@ func conRuleFriendRequest(fetchUser fetchUserFunc) conRuleFunc {
 			return nil, fmt.Errorf("target fetch: %s", err)
 		}
 
+		ok, err := isEnabled(change.Namespace, target.ID, preference.TypeFriendRequest)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
+		if !ok {
+			return nil, nil
+		}
+
 		return &message{
-			message:   fmtToMessage(fmtFriendRequest, origin),
+			origin:    origin,
 			recipient: target.ID,
+			target:    target,
+			template:  app.TemplateFriendRequest,
 			urn:       fmt.Sprintf(urnUser, origin.ID),
 		}, nil
 	}
@@ -114,6 +149,7 @@ func eventRuleLikeCreated(
 	fetchObject fetchObjectFunc,
 	fetchUser fetchUserFunc,
 	fetchUsers fetchUsersFunc,
+	filterEnabled filterEnabledFunc,
 ) eventRuleFunc {
 	return func(change *event.StateChange) ([]*message, error) {
 		if change.Old != nil ||
@@ -156,18 +192,26 @@ func eventRuleLikeCreated(
 
 		rs = append(rs, owner)
 
+		rs, err = filterEnabled(change.Namespace, preference.TypeLike, rs)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
 		ms := []*message{}
 
 		for _, recipient := range rs {
-			f := fmtLikePost
+			t := app.TemplateLikePost
 
 			if post.OwnerID == recipient.ID {
-				f = fmtLikePostOwn
+				t = app.TemplateLikePostOwn
 			}
 
 			ms = append(ms, &message{
-				message:   fmtToMessage(f, origin),
+				origin:    origin,
+				post:      post,
 				recipient: recipient.ID,
+				target:    recipient,
+				template:  t,
 				urn:       fmt.Sprintf(urnPost, post.ID),
 			})
 		}
@@ -176,17 +220,85 @@ func eventRuleLikeCreated(
 	}
 }
 
+func eventRuleMentionCreated(
+	fetchObject fetchObjectFunc,
+	fetchUser fetchUserFunc,
+	isEnabled isEnabledFunc,
+) eventRuleFunc {
+	return func(change *event.StateChange) ([]*message, error) {
+		if change.Old != nil ||
+			change.New.Enabled == false ||
+			!isMention(change.New) {
+			return nil, nil
+		}
+
+		targetID, err := strconv.ParseUint(change.New.Target.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("target id: %s", err)
+		}
+
+		post, err := fetchObject(change.Namespace, change.New.ObjectID)
+		if err != nil {
+			return nil, fmt.Errorf("post fetch: %s", err)
+		}
+
+		origin, err := fetchUser(change.Namespace, change.New.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("origin fetch: %s", err)
+		}
+
+		target, err := fetchUser(change.Namespace, targetID)
+		if err != nil {
+			return nil, fmt.Errorf("target fetch: %s", err)
+		}
+
+		ok, err := isEnabled(change.Namespace, target.ID, preference.TypeMention)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
+		if !ok {
+			return nil, nil
+		}
+
+		urn := fmt.Sprintf(urnPost, post.ID)
+
+		if id, ok := change.New.Metadata[controller.MetadataCommentID]; ok {
+			commentID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("comment id: %s", err)
+			}
+
+			urn = fmt.Sprintf(urnComment, post.ID, commentID)
+		}
+
+		return []*message{
+			{
+				origin:    origin,
+				post:      post,
+				recipient: target.ID,
+				target:    target,
+				template:  app.TemplateMention,
+				urn:       urn,
+			},
+		}, nil
+	}
+}
+
 func objectRuleCommentCreated(
 	fetchFollowerIDs fetchFollowerIDsFunc,
 	fetchFriendIDs fetchFriendIDsFunc,
 	fetchObject fetchObjectFunc,
 	fetchUser fetchUserFunc,
 	fetchUsers fetchUsersFunc,
+	filterEnabled filterEnabledFunc,
 ) objectRuleFunc {
 	return func(change *object.StateChange) ([]*message, error) {
+		// Replies are announced by objectRuleReplyCreated.
 		if change.Old != nil ||
 			change.New.Deleted == true ||
-			!isComment(change.New) {
+			!isComment(change.New) ||
+			isReply(change.New) {
 			return nil, nil
 		}
 
@@ -224,18 +336,26 @@ func objectRuleCommentCreated(
 
 		rs = append(rs, owner)
 
+		rs, err = filterEnabled(change.Namespace, preference.TypeComment, rs)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
 		ms := []*message{}
 
 		for _, recipient := range rs {
-			f := fmtCommentPost
+			t := app.TemplateCommentPost
 
 			if post.OwnerID == recipient.ID {
-				f = fmtCommentPostOwn
+				t = app.TemplateCommentPostOwn
 			}
 
 			ms = append(ms, &message{
-				message:   fmtToMessage(f, origin),
+				origin:    origin,
+				post:      post,
 				recipient: recipient.ID,
+				target:    recipient,
+				template:  t,
 				urn:       fmt.Sprintf(urnComment, post.ID, change.New.ID),
 			})
 		}
@@ -249,6 +369,7 @@ func objectRulePostCreated(
 	fetchFriendIDs fetchFriendIDsFunc,
 	fetchUser fetchUserFunc,
 	fetchUsers fetchUsersFunc,
+	filterEnabled filterEnabledFunc,
 ) objectRuleFunc {
 	return func(change *object.StateChange) ([]*message, error) {
 		if change.Old != nil ||
@@ -277,12 +398,20 @@ func objectRulePostCreated(
 			return nil, err
 		}
 
+		rs, err = filterEnabled(change.Namespace, preference.TypePostCreated, rs)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
 		ms := []*message{}
 
 		for _, recipient := range rs {
 			ms = append(ms, &message{
-				message:   fmtToMessage(fmtPostCreated, origin),
+				origin:    origin,
+				post:      change.New,
 				recipient: recipient.ID,
+				target:    recipient,
+				template:  app.TemplatePostCreated,
 				urn:       fmt.Sprintf(urnPost, change.New.ID),
 			})
 		}
@@ -291,6 +420,64 @@ func objectRulePostCreated(
 	}
 }
 
+func objectRuleReplyCreated(
+	fetchObject fetchObjectFunc,
+	fetchUser fetchUserFunc,
+	isEnabled isEnabledFunc,
+) objectRuleFunc {
+	return func(change *object.StateChange) ([]*message, error) {
+		if change.Old != nil ||
+			change.New.Deleted == true ||
+			!isReply(change.New) {
+			return nil, nil
+		}
+
+		parent, err := fetchObject(change.Namespace, change.New.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("parent fetch: %s", err)
+		}
+
+		if parent.OwnerID == change.New.OwnerID {
+			return nil, nil
+		}
+
+		ok, err := isEnabled(change.Namespace, parent.OwnerID, preference.TypeReply)
+		if err != nil {
+			return nil, fmt.Errorf("preference fetch: %s", err)
+		}
+
+		if !ok {
+			return nil, nil
+		}
+
+		post, err := fetchObject(change.Namespace, change.New.ObjectID)
+		if err != nil {
+			return nil, fmt.Errorf("post fetch: %s", err)
+		}
+
+		origin, err := fetchUser(change.Namespace, change.New.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("origin fetch: %s", err)
+		}
+
+		target, err := fetchUser(change.Namespace, parent.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("target fetch: %s", err)
+		}
+
+		return []*message{
+			{
+				origin:    origin,
+				post:      post,
+				recipient: target.ID,
+				target:    target,
+				template:  app.TemplateCommentReply,
+				urn:       fmt.Sprintf(urnComment, post.ID, change.New.ID),
+			},
+		}, nil
+	}
+}
+
 func filterIDs(ids []uint64, fs ...uint64) []uint64 {
 	var (
 		is   = []uint64{}
@@ -328,6 +515,16 @@ func isLike(e *event.Event) bool {
 	return e.Owned
 }
 
+func isMention(e *event.Event) bool {
+	if e.Type != controller.TypeMention ||
+		e.Target == nil ||
+		e.Target.Type != event.TargetUser {
+		return false
+	}
+
+	return e.Owned
+}
+
 func isPost(o *object.Object) bool {
 	if o.Type != controller.TypePost {
 		return false
@@ -336,10 +533,10 @@ func isPost(o *object.Object) bool {
 	return o.Owned
 }
 
-func fmtToMessage(f string, u *user.User) string {
-	if u.Firstname != "" {
-		return fmt.Sprintf(f, u.Firstname)
+func isReply(o *object.Object) bool {
+	if !isComment(o) {
+		return false
 	}
 
-	return fmt.Sprintf(f, u.Username)
+	return o.ParentID != 0
 }
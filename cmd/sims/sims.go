@@ -20,10 +20,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/tapglue/multiverse/platform/metrics"
+	"github.com/tapglue/multiverse/platform/push"
+	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/device"
 	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/notification"
 	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/preference"
 	"github.com/tapglue/multiverse/service/user"
 )
 
@@ -38,14 +42,16 @@ const (
 	attributeEnabled = "Enabled"
 	attributeToken   = "Token"
 
-	fmtURN         = `%s://%s`
-	msgAndroid     = `{"GCM": "{\"notification\": {\"title\": \"%s\", \"data\": {\"urn\": \"%s\"}} }"}`
-	msgAPNSSandbox = `{"APNS_SANDBOX": "{\"aps\": {\"alert\": \"%s\"}, \"urn\":\"%s\"}" }`
+	fmtURN = `%s://%s`
+
+	pushAttempts = 3
+	pushBackoff  = 200 * time.Millisecond
+	pushCacheTTL = 5 * time.Minute
+	pushTimeout  = 10 * time.Second
 )
 
 // Control flow.
 var (
-	ErrDeliveryFailure   = errors.New("delivery failed")
 	ErrEndpointDisabled  = errors.New("endppint disabled")
 	ErrEndpointNotFound  = errors.New("endpoint not found")
 	ErrNamespaceNotFound = errors.New("namespace not found")
@@ -59,63 +65,6 @@ var (
 	revision = "0000000-dev"
 )
 
-// appSchemes maps namespaces to device specific schemes for construction of
-// deep links.
-var appSchemes = map[string]map[device.Platform]string{
-	"app_1_610": map[device.Platform]string{
-		device.PlatformIOSSandbox: "simsTest",
-		device.PlatformAndroid:    "simsTest",
-	},
-	"app_1_1147": map[device.Platform]string{
-		device.PlatformIOSSandbox: "TapglueSample",
-	},
-	"app_684_948": map[device.Platform]string{
-		device.PlatformIOSSandbox: "uMake",
-	},
-	"app_684_987": map[device.Platform]string{
-		device.PlatformIOSSandbox: "uMake",
-	},
-	"app_515_922": map[device.Platform]string{
-		device.PlatformIOSSandbox: "bkx",
-	},
-	"app_831_1203": map[device.Platform]string{
-		device.PlatformIOSSandbox: "shapeupclub",
-	},
-}
-
-// arns maps Tapglue app namespaces to SNS ARNs.
-var arns = map[string]map[device.Platform]string{
-	"app_1_610": map[device.Platform]string{
-		device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/simsTest",
-		device.PlatformAndroid:    "arn:aws:sns:eu-central-1:775034650473:app/GCM/simsTestGCM",
-	},
-	"app_1_1147": map[device.Platform]string{
-		device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/tapglue-iOSExample",
-	},
-	"app_515_922": map[device.Platform]string{
-		device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/bikestorming-iOSSandbox",
-	},
-	"app_684_948": map[device.Platform]string{
-		device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/uMake-iOSSandbox",
-	},
-	"app_684_987": map[device.Platform]string{
-		device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/uMake-iOSSandbox",
-	},
-	"app_831_1203": map[device.Platform]string{
-		device.PlatformIOSSandbox: "arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/lifesum-iOSSandbox",
-	},
-}
-
-// namespaces maps SNS ARNs to Tapglue app namespaces.
-var namespaces = map[string]string{
-	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/simsTest":                "app_1_610",
-	"arn:aws:sns:eu-central-1:775034650473:app/GCM/simsTestGCM":                      "app_1_610",
-	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/uMake-iOSSandbox":        "app_684_948",
-	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/bikestorming-iOSSandbox": "app_515_922",
-	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/tapglue-iOSExample":      "app_1_1147",
-	"arn:aws:sns:eu-central-1:775034650473:app/APNS_SANDBOX/lifesum-iOSSandbox":      "app_831_1203",
-}
-
 type ackFunc func() error
 type channelFunc func(string, *message) error
 type createEndpointFunc func(platformARN, token string) (string, error)
@@ -126,14 +75,18 @@ type fetchObjectFunc func(namespace string, id uint64) (*object.Object, error)
 type fetchUserFunc func(namespace string, id uint64) (*user.User, error)
 type fetchUsersFunc func(namespace string, ids ...uint64) (user.List, error)
 type findDevicesFunc func(namespace string, userID uint64, platforms ...device.Platform) (device.List, error)
+type filterEnabledFunc func(namespace string, t preference.Type, us user.List) (user.List, error)
+type getBackendFunc func(namespace string, platform device.Platform) (string, error)
 type getEndpointFunc func(arn string) (string, error)
 type getNamespaceFunc func(arn string) (string, error)
 type getPlatformARNFunc func(namespace string, platform device.Platform) (string, error)
 type getPlatformNameFunc func(namespace string, platform device.Platform) (string, error)
+type getPusherFunc func(namespace string, platform device.Platform) (push.Pusher, error)
 type getUserDevicesFunc func(namespace string, userID uint64) (device.List, error)
+type invalidateDeviceFunc func(namespace string, d *device.Device) error
+type isEnabledFunc func(namespace string, userID uint64, t preference.Type) (bool, error)
+type putNotificationFunc func(namespace string, n *notification.Notification) (*notification.Notification, error)
 type prepareDeviceEndpointFunc func(namespace string, d *device.Device) (*device.Device, error)
-type pushAndroidFunc func(arn, platformName string, message *message) error
-type pushAPNSSandboxFunc func(arn, platformName string, message *message) error
 type updateTokenFunc func(arn, token string) error
 
 type batch struct {
@@ -143,8 +96,11 @@ type batch struct {
 }
 
 type message struct {
-	message   string
+	origin    *user.User
+	post      *object.Object
 	recipient uint64
+	target    *user.User
+	template  string
 	urn       string
 }
 
@@ -288,6 +244,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	var apps app.Service
+	apps = app.NewPostgresService(db)
+	apps = app.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(apps)
+	apps = app.LogServiceMiddleware(logger, "postgres")(apps)
+
 	var connections connection.Service
 	connections = connection.NewPostgresService(db)
 	connections = connection.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(connections)
@@ -298,11 +259,21 @@ func main() {
 	devices = device.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(devices)
 	devices = device.LogServiceMiddleware(logger, "postgres")(devices)
 
+	var notifications notification.Service
+	notifications = notification.PostgresService(db)
+	notifications = notification.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(notifications)
+	notifications = notification.LogServiceMiddleware(logger, "postgres")(notifications)
+
 	var objects object.Service
 	objects = object.NewPostgresService(db)
 	objects = object.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(objects)
 	objects = object.LogServiceMiddleware(logger, "postgres")(objects)
 
+	var preferences preference.Service
+	preferences = preference.PostgresService(db)
+	preferences = preference.InstrumentServiceMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(preferences)
+	preferences = preference.LogServiceMiddleware(logger, "postgres")(preferences)
+
 	var users user.Service
 	users = user.NewPostgresService(db)
 	users = user.InstrumentMiddleware(component, "postgres", serviceErrCount, serviceOpCount, serviceOpLatency)(users)
@@ -366,13 +337,17 @@ func main() {
 	var fetchUser fetchUserFunc
 	var fetchUsers fetchUsersFunc
 	var getUserDevices getUserDevicesFunc
+	var getBackend getBackendFunc
 	var getEndpoint getEndpointFunc
 	var getNamespace getNamespaceFunc
 	var getPlatformARN getPlatformARNFunc
 	var getPlatformName getPlatformNameFunc
+	var getPusher getPusherFunc
+	var filterEnabled filterEnabledFunc
+	var invalidateDevice invalidateDeviceFunc
+	var isEnabled isEnabledFunc
 	var prepareDeviceEndpoint prepareDeviceEndpointFunc
-	var pushAndroid pushAndroidFunc
-	var pushAPNSSandbox pushAPNSSandboxFunc
+	var fetchTemplates fetchTemplatesFunc
 	var updateToken updateTokenFunc
 
 	createEndpoint = func(pARN, token string) (string, error) {
@@ -535,7 +510,20 @@ func main() {
 		es := device.List{}
 
 		for _, d := range ds {
-			_, err := prepareDeviceEndpoint(ns, d)
+			backend, err := getBackend(ns, d.Platform)
+			if isNamespaceNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if backend != app.BackendSNS {
+				es = append(es, d)
+				continue
+			}
+
+			_, err = prepareDeviceEndpoint(ns, d)
 			if isEndpointDisabled(err) || isNamespaceNotFound(err) || isPlatformNotFound(err) {
 				continue
 			}
@@ -570,41 +558,74 @@ func main() {
 		return *r.Attributes[attributeToken], nil
 	}
 
-	getNamespace = func(arn string) (string, error) {
-		ns, ok := namespaces[arn]
-		if !ok {
-			return "", ErrNamespaceNotFound
-		}
+	pushes := newPushCache(
+		apps,
+		&http.Client{Timeout: pushTimeout},
+		snsService,
+		pushCacheTTL,
+	)
 
-		return ns, nil
+	getBackend = pushes.backend
+	getNamespace = pushes.namespace
+	getPlatformARN = pushes.platformARN
+	getPlatformName = pushes.scheme
+	getPusher = pushes.pusher
+	fetchTemplates = pushes.templates
+
+	invalidateDevice = func(ns string, d *device.Device) error {
+		d.Disabled = true
+
+		_, err := devices.Put(ns, d)
+
+		return err
 	}
 
-	getPlatformARN = func(ns string, platform device.Platform) (string, error) {
-		cs, ok := arns[ns]
-		if !ok {
-			return "", ErrNamespaceNotFound
+	filterEnabled = func(ns string, t preference.Type, us user.List) (user.List, error) {
+		if len(us) == 0 {
+			return us, nil
+		}
+
+		ids := []uint64{}
+
+		for _, u := range us {
+			ids = append(ids, u.ID)
 		}
 
-		arn, ok := cs[platform]
-		if !ok {
-			return "", ErrPlatformNotFound
+		ps, err := preferences.Query(ns, preference.QueryOptions{
+			Types: []preference.Type{
+				t,
+			},
+			UserIDs: ids,
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		return arn, nil
-	}
+		rs := user.List{}
 
-	getPlatformName = func(ns string, platform device.Platform) (string, error) {
-		cs, ok := appSchemes[ns]
-		if !ok {
-			return "", ErrNamespaceNotFound
+		for _, u := range us {
+			if ps.Enabled(u.ID, t) {
+				rs = append(rs, u)
+			}
 		}
 
-		name, ok := cs[platform]
-		if !ok {
-			return "", ErrPlatformNotFound
+		return rs, nil
+	}
+
+	isEnabled = func(ns string, userID uint64, t preference.Type) (bool, error) {
+		ps, err := preferences.Query(ns, preference.QueryOptions{
+			Types: []preference.Type{
+				t,
+			},
+			UserIDs: []uint64{
+				userID,
+			},
+		})
+		if err != nil {
+			return false, err
 		}
 
-		return name, nil
+		return ps.Enabled(userID, t), nil
 	}
 
 	prepareDeviceEndpoint = func(ns string, d *device.Device) (*device.Device, error) {
@@ -660,49 +681,6 @@ func main() {
 		return d, nil
 	}
 
-	pushAndroid = func(arn, platformName string, msg *message) error {
-		var (
-			urn = fmt.Sprintf(fmtURN, platformName, msg.urn)
-			m   = fmt.Sprintf(msgAndroid, msg.message, urn)
-		)
-
-		_, err := snsService.Publish(&sns.PublishInput{
-			Message:          aws.String(m),
-			MessageStructure: aws.String("json"),
-			TargetArn:        aws.String(arn),
-		})
-		if err != nil {
-			if awsErr, ok := err.(awserr.RequestFailure); ok {
-				if awsErr.StatusCode() == 400 {
-					return ErrDeliveryFailure
-				}
-			}
-		}
-
-		return nil
-	}
-
-	pushAPNSSandbox = func(arn, platformName string, msg *message) error {
-		var (
-			urn = fmt.Sprintf(fmtURN, platformName, msg.urn)
-			m   = fmt.Sprintf(msgAPNSSandbox, msg.message, urn)
-		)
-
-		_, err = snsService.Publish(&sns.PublishInput{
-			Message:          aws.String(m),
-			MessageStructure: aws.String("json"),
-			TargetArn:        aws.String(arn),
-		})
-		if err != nil {
-			if awsErr, ok := err.(awserr.RequestFailure); ok {
-				if awsErr.StatusCode() == 400 {
-					return ErrDeliveryFailure
-				}
-			}
-		}
-		return nil
-	}
-
 	updateToken = func(arn, token string) error {
 		_, err := snsService.SetEndpointAttributes(&sns.SetEndpointAttributesInput{
 			Attributes: map[string]*string{
@@ -749,9 +727,9 @@ func main() {
 		err := consumeConnection(
 			conSource,
 			batchc,
-			conRuleFollower(fetchUser),
-			conRuleFriendConfirmed(fetchUser),
-			conRuleFriendRequest(fetchUser),
+			conRuleFollower(fetchUser, isEnabled),
+			conRuleFriendConfirmed(fetchUser, isEnabled),
+			conRuleFriendRequest(fetchUser, isEnabled),
 		)
 		if err != nil {
 			logger.Log("err", err, "lifecycle", "abort")
@@ -763,7 +741,8 @@ func main() {
 		err := consumeEvent(
 			eventSource,
 			batchc,
-			eventRuleLikeCreated(fetchFollowerIDs, fetchFriendIDs, fetchObject, fetchUser, fetchUsers),
+			eventRuleLikeCreated(fetchFollowerIDs, fetchFriendIDs, fetchObject, fetchUser, fetchUsers, filterEnabled),
+			eventRuleMentionCreated(fetchObject, fetchUser, isEnabled),
 		)
 		if err != nil {
 			logger.Log("err", err, "lifecycle", "abort")
@@ -775,8 +754,9 @@ func main() {
 		err := consumeObject(
 			objectSource,
 			batchc,
-			objectRuleCommentCreated(fetchFollowerIDs, fetchFriendIDs, fetchObject, fetchUser, fetchUsers),
-			objectRulePostCreated(fetchFollowerIDs, fetchFriendIDs, fetchUser, fetchUsers),
+			objectRuleCommentCreated(fetchFollowerIDs, fetchFriendIDs, fetchObject, fetchUser, fetchUsers, filterEnabled),
+			objectRulePostCreated(fetchFollowerIDs, fetchFriendIDs, fetchUser, fetchUsers, filterEnabled),
+			objectRuleReplyCreated(fetchObject, fetchUser, isEnabled),
 		)
 		if err != nil {
 			logger.Log("err", err, "lifecycle", "abort")
@@ -785,7 +765,8 @@ func main() {
 	}()
 
 	cs := []channelFunc{
-		channelPush(getUserDevices, getPlatformName, pushAndroid, pushAPNSSandbox),
+		channelInbox(renderTemplate(fetchTemplates), notifications.Put),
+		channelPush(getUserDevices, getPlatformName, renderTemplate(fetchTemplates), getBackend, getPusher, invalidateDevice),
 	}
 
 	for batch := range batchc {
@@ -793,6 +774,15 @@ func main() {
 			for _, channel := range cs {
 				err := channel(batch.namespace, msg)
 				if err != nil {
+					if isTemplateError(err) {
+						logger.Log(
+							"err", err,
+							"namespace", batch.namespace,
+							"urn", msg.urn,
+						)
+						continue
+					}
+
 					logger.Log("err", err, "lifecycle", "abort")
 					os.Exit(1)
 				}
@@ -809,11 +799,41 @@ func main() {
 	logger.Log("lifecycle", "stop")
 }
 
+func channelInbox(
+	renderMessage renderMessageFunc,
+	putNotification putNotificationFunc,
+) channelFunc {
+	return func(ns string, msg *message) error {
+		text, err := renderMessage(ns, device.DefaultLanguage, msg)
+		if err != nil {
+			return err
+		}
+
+		n := &notification.Notification{
+			Message:  text,
+			OriginID: msg.origin.ID,
+			Type:     msg.template,
+			URN:      msg.urn,
+			UserID:   msg.recipient,
+		}
+
+		if msg.post != nil {
+			n.ObjectID = msg.post.ID
+		}
+
+		_, err = putNotification(ns, n)
+
+		return err
+	}
+}
+
 func channelPush(
 	getUserDevices getUserDevicesFunc,
 	getPlatformName getPlatformNameFunc,
-	pushAndroid pushAndroidFunc,
-	pushAPNSSandbox pushAPNSSandboxFunc,
+	renderMessage renderMessageFunc,
+	getBackend getBackendFunc,
+	getPusher getPusherFunc,
+	invalidateDevice invalidateDeviceFunc,
 ) channelFunc {
 	return func(ns string, msg *message) error {
 		// find devices
@@ -825,39 +845,62 @@ func channelPush(
 			return nil
 		}
 
+		alerts := map[string]string{}
+
 		// publish to devices
 		for _, d := range ds {
-			switch d.Platform {
-			case device.PlatformIOSSandbox:
-				name, err := getPlatformName(ns, d.Platform)
+			alert, ok := alerts[d.Language]
+			if !ok {
+				alert, err = renderMessage(ns, d.Language, msg)
 				if err != nil {
 					return err
 				}
 
-				err = pushAPNSSandbox(d.EndpointARN, name, msg)
-				if err != nil {
-					if isDeliveryFailure(err) {
-						return nil
-					}
+				alerts[d.Language] = alert
+			}
 
-					return err
-				}
-			case device.PlatformAndroid:
-				name, err := getPlatformName(ns, d.Platform)
-				if err != nil {
-					return err
+			name, err := getPlatformName(ns, d.Platform)
+			if err != nil {
+				return err
+			}
+
+			backend, err := getBackend(ns, d.Platform)
+			if err != nil {
+				return err
+			}
+
+			pusher, err := getPusher(ns, d.Platform)
+			if err != nil {
+				return err
+			}
+
+			target := d.Token
+
+			if backend == app.BackendSNS {
+				target = d.EndpointARN
+			}
+
+			err = pushRetry(pusher, target, &push.Notification{
+				Alert: alert,
+				URN:   fmt.Sprintf(fmtURN, name, msg.urn),
+			})
+			if err != nil {
+				// Devices are skipped when the service stays unavailable, so an
+				// outage doesn't hold up the queue.
+				if push.IsDeliveryFailure(err) || push.IsUnavailable(err) {
+					continue
 				}
 
-				err = pushAndroid(d.EndpointARN, name, msg)
-				if err != nil {
-					if isDeliveryFailure(err) {
-						return nil
+				// Endpoints of SNS are disabled through the endpoint changes.
+				if push.IsInvalidTarget(err) && backend != app.BackendSNS {
+					if err := invalidateDevice(ns, d); err != nil {
+						return err
 					}
 
-					return err
+					continue
 				}
-			default:
-				return fmt.Errorf("platform not supported")
+
+				return err
 			}
 		}
 
@@ -865,8 +908,20 @@ func channelPush(
 	}
 }
 
-func isDeliveryFailure(err error) bool {
-	return err == ErrDeliveryFailure
+// pushRetry delivers the notification and retries with backoff as long as the
+// service is unavailable.
+func pushRetry(pusher push.Pusher, target string, n *push.Notification) error {
+	backoff := pushBackoff
+
+	for i := 1; ; i++ {
+		err := pusher.Push(target, n)
+		if err == nil || !push.IsUnavailable(err) || i == pushAttempts {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
 }
 
 func isEndpointDisabled(err error) bool {
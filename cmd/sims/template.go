@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/device"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+// templatesDefault are used for every app which doesn't provide its own
+// template for a language.
+var templatesDefault = app.Templates{
+	app.TemplateCommentPost: {
+		device.DefaultLanguage: "{{.Origin.Name}} commented on a Post.",
+	},
+	app.TemplateCommentPostOwn: {
+		device.DefaultLanguage: "{{.Origin.Name}} commented on your Post.",
+	},
+	app.TemplateCommentReply: {
+		device.DefaultLanguage: "{{.Origin.Name}} replied to your comment.",
+	},
+	app.TemplateFollow: {
+		device.DefaultLanguage: "{{.Origin.Name}} started following you",
+	},
+	app.TemplateFriendConfirmed: {
+		device.DefaultLanguage: "{{.Origin.Name}} accepted your friend request.",
+	},
+	app.TemplateFriendRequest: {
+		device.DefaultLanguage: "{{.Origin.Name}} sent you a friend request.",
+	},
+	app.TemplateLikePost: {
+		device.DefaultLanguage: "{{.Origin.Name}} liked a Post.",
+	},
+	app.TemplateLikePostOwn: {
+		device.DefaultLanguage: "{{.Origin.Name}} liked your Post.",
+	},
+	app.TemplateMention: {
+		device.DefaultLanguage: "{{.Origin.Name}} mentioned you.",
+	},
+	app.TemplatePostCreated: {
+		device.DefaultLanguage: "{{.Origin.Name}} created a new Post.",
+	},
+}
+
+type fetchTemplatesFunc func(namespace string) (app.Templates, error)
+type renderMessageFunc func(namespace, language string, msg *message) (string, error)
+
+// templateError is returned when the template of a message can't be rendered.
+// The message is skipped, as retrying won't render it either.
+type templateError struct {
+	name string
+	err  error
+}
+
+func (e *templateError) Error() string {
+	return fmt.Sprintf("template '%s': %s", e.name, e.err)
+}
+
+func isTemplateError(err error) bool {
+	_, ok := err.(*templateError)
+	return ok
+}
+
+func renderTemplate(fetchTemplates fetchTemplatesFunc) renderMessageFunc {
+	return func(ns, lang string, msg *message) (string, error) {
+		ts, err := fetchTemplates(ns)
+		if err != nil {
+			return "", err
+		}
+
+		text, ok := lookupTemplate(msg.template, lang, ts, templatesDefault)
+		if !ok {
+			return "", &templateError{
+				name: msg.template,
+				err:  fmt.Errorf("missing for '%s'", lang),
+			}
+		}
+
+		data := &app.TemplateData{}
+
+		if msg.origin != nil {
+			data.Origin = templateUser(msg.origin)
+		}
+
+		if msg.post != nil {
+			data.Post = templatePost(msg.post, lang)
+		}
+
+		if msg.target != nil {
+			data.Target = templateUser(msg.target)
+		}
+
+		text, err = app.ExecuteTemplate(msg.template, text, data)
+		if err != nil {
+			return "", &templateError{name: msg.template, err: err}
+		}
+
+		return text, nil
+	}
+}
+
+// templatePost returns the view of the post for templates with the contents of
+// its attachments in the given language.
+func templatePost(post *object.Object, lang string) *app.TemplatePost {
+	contents := map[string]string{}
+
+	for _, a := range post.Attachments {
+		if _, ok := contents[a.Name]; ok {
+			continue
+		}
+
+		for _, l := range languages(lang) {
+			if c, ok := a.Contents[l]; ok {
+				contents[a.Name] = c
+				break
+			}
+		}
+	}
+
+	return app.NewTemplatePost(contents)
+}
+
+// templateUser returns the view of the user for templates.
+func templateUser(u *user.User) *app.TemplateUser {
+	return &app.TemplateUser{
+		Firstname: u.Firstname,
+		Lastname:  u.Lastname,
+		Username:  u.Username,
+	}
+}
+
+// languages returns the language tags to try in order for the given language,
+// which are the language itself, its base and the default language.
+func languages(lang string) []string {
+	ls := []string{lang}
+
+	if tag, err := language.Parse(lang); err == nil {
+		if base, _ := tag.Base(); base.String() != lang {
+			ls = append(ls, base.String())
+		}
+	}
+
+	if lang != device.DefaultLanguage {
+		ls = append(ls, device.DefaultLanguage)
+	}
+
+	return ls
+}
+
+// lookupTemplate returns the first template found for the name across the
+// given sets, trying every language of the fallback chain in order.
+func lookupTemplate(name, lang string, sets ...app.Templates) (string, bool) {
+	for _, l := range languages(lang) {
+		for _, ts := range sets {
+			if text, ok := ts.Template(name, l); ok {
+				return text, true
+			}
+		}
+	}
+
+	return "", false
+}
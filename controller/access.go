@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/tapglue/multiverse/platform/access"
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/session"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+// AccessGrant is a short-lived access token with the refresh token to obtain
+// the next one.
+type AccessGrant struct {
+	AccessToken  string
+	ExpiresAt    time.Time
+	RefreshToken string
+}
+
+// AccessRefreshFunc exchanges a refresh token for a new grant.
+type AccessRefreshFunc func(
+	currentApp *app.App,
+	refreshToken string,
+) (*AccessGrant, error)
+
+// AccessRefresh rotates the refresh token, every refresh token can only be used
+// once. Presenting one which was already used ends all sessions of the user on
+// its device, as either the client or an attacker holds a stolen copy.
+func AccessRefresh(
+	sessions session.Service,
+	signer access.Signer,
+	users user.Service,
+) AccessRefreshFunc {
+	return func(
+		currentApp *app.App,
+		refreshToken string,
+	) (*AccessGrant, error) {
+		if !currentApp.TokenRules().Enabled || signer == nil {
+			return nil, wrapError(ErrUnauthorized, "access tokens not enabled")
+		}
+
+		ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+			IDs: []string{
+				refreshToken,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ss) != 1 {
+			return nil, wrapError(ErrUnauthorized, "invalid refresh token")
+		}
+
+		s := ss[0]
+
+		// Only one of concurrent refreshes with the same token flips the
+		// session, every other use counts as reuse.
+		err = sessions.Disable(currentApp.Namespace(), s.ID)
+		if err != nil {
+			if err != session.ErrNotFound {
+				return nil, err
+			}
+
+			err := sessionsDisableDevice(sessions, currentApp, s.UserID, s.DeviceID)
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, wrapError(ErrUnauthorized, "refresh token reused")
+		}
+
+		idle, max := sessionLifetimes(currentApp)
+
+		if s.Expired(time.Now(), idle, max) {
+			return nil, wrapError(ErrUnauthorized, "refresh token expired")
+		}
+
+		us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+			Enabled: &defaultEnabled,
+			IDs: []uint64{
+				s.UserID,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(us) != 1 {
+			return nil, wrapError(ErrUnauthorized, "user not found")
+		}
+
+		s, err = sessions.Put(currentApp.Namespace(), &session.Session{
+			DeviceID: s.DeviceID,
+			Enabled:  true,
+			UserID:   s.UserID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return accessGrant(signer, currentApp, s)
+	}
+}
+
+// accessGrant signs an access token for the session, whose id serves as refresh
+// token.
+func accessGrant(
+	signer access.Signer,
+	currentApp *app.App,
+	s *session.Session,
+) (*AccessGrant, error) {
+	// Token times have second precision, the grant reports the same expiry.
+	now := time.Now().UTC().Truncate(time.Second)
+
+	c := &access.Claims{
+		ExpiresAt: now.Add(currentApp.TokenRules().Access()),
+		IssuedAt:  now,
+		Namespace: currentApp.Namespace(),
+		SessionID: s.PublicID(),
+		UserID:    s.UserID,
+	}
+
+	token, err := signer.Sign(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessGrant{
+		AccessToken:  token,
+		ExpiresAt:    c.ExpiresAt,
+		RefreshToken: s.ID,
+	}, nil
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/access"
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/session"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+func TestAccessRefresh(t *testing.T) {
+	var (
+		currentApp, sessions, users, s = testSetupAccess(t)
+		signer                         = testSigner(t)
+		refresh                        = AccessRefresh(sessions, signer, users)
+	)
+
+	g, err := refresh(currentApp, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.RefreshToken == s.ID {
+		t.Errorf("have %v, want rotated refresh token", g.RefreshToken)
+	}
+
+	claims, err := signer.Verify(g.AccessToken, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := claims.UserID, s.UserID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		Enabled: &defaultEnabled,
+		IDs: []string{
+			g.RefreshToken,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ss), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := ss[0].DeviceID, s.DeviceID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := claims.SessionID, ss[0].PublicID(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestAccessRefreshReuse(t *testing.T) {
+	var (
+		currentApp, sessions, users, s = testSetupAccess(t)
+		refresh                        = AccessRefresh(sessions, testSigner(t), users)
+	)
+
+	_, err := refresh(currentApp, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = refresh(currentApp, s.ID)
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	active, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		Enabled: &defaultEnabled,
+		UserIDs: []uint64{
+			s.UserID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(active), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestAccessRefreshConcurrent(t *testing.T) {
+	var (
+		currentApp, sessions, users, s = testSetupAccess(t)
+		stale                          = &testStaleSessions{Service: sessions, stale: s}
+		refresh                        = AccessRefresh(stale, testSigner(t), users)
+	)
+
+	_, err := refresh(currentApp, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second refresh read the session before the first one disabled it.
+	_, err = refresh(currentApp, s.ID)
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestAccessRefreshInvalid(t *testing.T) {
+	var (
+		currentApp, sessions, users, s = testSetupAccess(t)
+		refresh                        = AccessRefresh(sessions, testSigner(t), users)
+	)
+
+	_, err := refresh(currentApp, "unknown")
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	// Without a signer access tokens are disabled for all apps.
+	_, err = AccessRefresh(sessions, nil, users)(currentApp, s.ID)
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	currentApp.Tokens.Enabled = false
+
+	_, err = refresh(currentApp, s.ID)
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testSetupAccess(
+	t *testing.T,
+) (*app.App, session.Service, user.Service, *session.Session) {
+	var (
+		currentApp = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+			Tokens: &app.Tokens{
+				Enabled: true,
+			},
+		}
+		sessions = session.NewMemService()
+		users    = user.NewMemService()
+	)
+
+	u, err := users.Put(currentApp.Namespace(), testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := sessions.Put(currentApp.Namespace(), &session.Session{
+		DeviceID: session.DeviceIDUnknown,
+		Enabled:  true,
+		UserID:   u.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return currentApp, sessions, users, s
+}
+
+func testSigner(t *testing.T) access.Signer {
+	signer, err := access.HMACSigner([]byte(strings.Repeat("a", 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+// testStaleSessions answers lookups by id with the session as it was before
+// any refresh.
+type testStaleSessions struct {
+	session.Service
+
+	stale *session.Session
+}
+
+func (s *testStaleSessions) Query(
+	ns string,
+	opts session.QueryOptions,
+) (session.List, error) {
+	if len(opts.IDs) == 1 && opts.IDs[0] == s.stale.ID {
+		stale := *s.stale
+
+		return session.List{&stale}, nil
+	}
+
+	return s.Service.Query(ns, opts)
+}
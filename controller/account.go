@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/mail"
+	"github.com/tapglue/multiverse/platform/password"
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/session"
+	"github.com/tapglue/multiverse/service/token"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+const (
+	passwordResetTTL = time.Hour
+	verificationTTL  = 24 * time.Hour
+
+	mailPasswordReset = `Hi %s,
+
+someone asked to reset the password of your %s account. Use this token to
+choose a new one:
+
+%s
+
+The token expires in one hour. If you didn't ask for a reset you can ignore
+this mail.`
+	mailVerification = `Hi %s,
+
+please confirm the email address of your %s account with this token:
+
+%s
+
+The token expires in 24 hours.`
+)
+
+// EmailVerificationRequestFunc sends a token to the email address of the
+// origin to confirm that it belongs to them.
+type EmailVerificationRequestFunc func(
+	currentApp *app.App,
+	origin *user.User,
+) error
+
+// EmailVerificationRequest sends a verification token to the origin, unless
+// their email is already verified.
+func EmailVerificationRequest(
+	mails mail.Sender,
+	tokens token.Service,
+) EmailVerificationRequestFunc {
+	return func(currentApp *app.App, origin *user.User) error {
+		if origin.Email == "" {
+			return wrapError(ErrInvalidEntity, "user has no email")
+		}
+
+		if origin.Private != nil && origin.Private.Verified {
+			return nil
+		}
+
+		t, err := tokenCreate(tokens, currentApp, origin, token.TypeVerification, verificationTTL)
+		if err != nil {
+			return err
+		}
+
+		return mails.Send(&mail.Message{
+			Body:    fmt.Sprintf(mailVerification, userGreeting(origin), currentApp.Name, t.ID),
+			Subject: fmt.Sprintf("Verify your %s email address", currentApp.Name),
+			To:      origin.Email,
+		})
+	}
+}
+
+// EmailVerifyFunc marks the email of the user the token was issued to as
+// verified.
+type EmailVerifyFunc func(currentApp *app.App, tokenID string) error
+
+// EmailVerify uses up the verification token and flags the email of its user
+// as verified, as long as it is still the address the token was sent to.
+func EmailVerify(tokens token.Service, users user.Service) EmailVerifyFunc {
+	return func(currentApp *app.App, tokenID string) error {
+		t, u, err := tokenUse(tokens, users, currentApp, tokenID, token.TypeVerification)
+		if err != nil {
+			return err
+		}
+
+		if t.Email != u.Email {
+			return wrapError(ErrUnauthorized, "email changed since the token was sent")
+		}
+
+		if u.Private == nil {
+			u.Private = &user.Private{}
+		}
+
+		u.Private.Verified = true
+
+		_, err = users.Put(currentApp.Namespace(), u)
+		return err
+	}
+}
+
+// PasswordResetFunc sets a new password for the user the token was issued to.
+type PasswordResetFunc func(
+	currentApp *app.App,
+	tokenID string,
+	password string,
+) error
+
+// PasswordReset uses up the reset token, replaces the password of its user and
+// ends all their sessions.
+func PasswordReset(
+	hasher password.Hasher,
+	sessions session.Service,
+	tokens token.Service,
+	users user.Service,
+) PasswordResetFunc {
+	return func(currentApp *app.App, tokenID, pw string) error {
+		if pw == "" {
+			return wrapError(ErrInvalidEntity, "password must be set")
+		}
+
+		_, u, err := tokenUse(tokens, users, currentApp, tokenID, token.TypePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		u.Password, err = hasher.Hash(pw)
+		if err != nil {
+			return err
+		}
+
+		_, err = users.Put(currentApp.Namespace(), u)
+		if err != nil {
+			return err
+		}
+
+		ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+			Enabled: &defaultEnabled,
+			UserIDs: []uint64{
+				u.ID,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range ss {
+			s.Enabled = false
+
+			_, err := sessions.Put(currentApp.Namespace(), s)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// PasswordResetRequestFunc sends a token to reset the password to the user
+// with the given email.
+type PasswordResetRequestFunc func(currentApp *app.App, email string) error
+
+// PasswordResetRequest sends a reset token to the user with the given email.
+// Unknown emails are not reported, to not reveal which addresses have an
+// account.
+func PasswordResetRequest(
+	mails mail.Sender,
+	tokens token.Service,
+	users user.Service,
+) PasswordResetRequestFunc {
+	return func(currentApp *app.App, email string) error {
+		if email == "" {
+			return wrapError(ErrInvalidEntity, "email must be set")
+		}
+
+		us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+			Enabled: &defaultEnabled,
+			Emails: []string{
+				email,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(us) != 1 {
+			return nil
+		}
+
+		u := us[0]
+
+		t, err := tokenCreate(tokens, currentApp, u, token.TypePasswordReset, passwordResetTTL)
+		if err != nil {
+			return err
+		}
+
+		return mails.Send(&mail.Message{
+			Body:    fmt.Sprintf(mailPasswordReset, userGreeting(u), currentApp.Name, t.ID),
+			Subject: fmt.Sprintf("Reset your %s password", currentApp.Name),
+			To:      u.Email,
+		})
+	}
+}
+
+// tokenCreate issues a new token and disables all former tokens of the same
+// type for the user, so only the latest one sent can be used.
+func tokenCreate(
+	tokens token.Service,
+	currentApp *app.App,
+	u *user.User,
+	ty string,
+	ttl time.Duration,
+) (*token.Token, error) {
+	ts, err := tokens.Query(currentApp.Namespace(), token.QueryOptions{
+		Enabled: &defaultEnabled,
+		Types: []string{
+			ty,
+		},
+		UserIDs: []uint64{
+			u.ID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range ts {
+		// A concurrent use might have disabled the token already.
+		err := tokens.Disable(currentApp.Namespace(), t.ID)
+		if err != nil && err != token.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	return tokens.Put(currentApp.Namespace(), &token.Token{
+		Email:     u.Email,
+		Enabled:   true,
+		ExpiresAt: time.Now().Add(ttl),
+		Type:      ty,
+		UserID:    u.ID,
+	})
+}
+
+// tokenUse disables the valid token with the given id and returns it together
+// with its user. Disabling happens first and only succeeds for one of
+// concurrent uses, so a token can't be redeemed twice.
+func tokenUse(
+	tokens token.Service,
+	users user.Service,
+	currentApp *app.App,
+	tokenID string,
+	ty string,
+) (*token.Token, *user.User, error) {
+	if tokenID == "" {
+		return nil, nil, wrapError(ErrInvalidEntity, "token must be set")
+	}
+
+	ts, err := tokens.Query(currentApp.Namespace(), token.QueryOptions{
+		Enabled: &defaultEnabled,
+		IDs: []string{
+			tokenID,
+		},
+		Types: []string{
+			ty,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(ts) != 1 || ts[0].Expired(time.Now()) {
+		return nil, nil, wrapError(ErrUnauthorized, "invalid or expired token")
+	}
+
+	err = tokens.Disable(currentApp.Namespace(), tokenID)
+	if err != nil {
+		if err == token.ErrNotFound {
+			return nil, nil, wrapError(ErrUnauthorized, "invalid or expired token")
+		}
+
+		return nil, nil, err
+	}
+
+	us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+		Enabled: &defaultEnabled,
+		IDs: []uint64{
+			ts[0].UserID,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(us) != 1 {
+		return nil, nil, wrapError(ErrUnauthorized, "invalid or expired token")
+	}
+
+	return ts[0], us[0], nil
+}
+
+func userGreeting(u *user.User) string {
+	if u.Firstname != "" {
+		return u.Firstname
+	}
+
+	if u.Username != "" {
+		return u.Username
+	}
+
+	return "there"
+}
@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/tapglue/multiverse/platform/mail"
+	"github.com/tapglue/multiverse/platform/password"
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/session"
+	"github.com/tapglue/multiverse/service/token"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+func TestEmailVerify(t *testing.T) {
+	var (
+		currentApp, mails, tokens, users, u = testSetupAccount(t)
+		request                             = EmailVerificationRequest(mails, tokens)
+		verify                              = EmailVerify(tokens, users)
+	)
+
+	err := request(currentApp, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := testAccountToken(t, tokens, currentApp, u.ID, token.TypeVerification)
+
+	ms := mails.Messages()
+
+	if have, want := len(ms), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := ms[0].To, u.Email; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if !strings.Contains(ms[0].Body, id) {
+		t.Errorf("token missing in %q", ms[0].Body)
+	}
+
+	err = verify(currentApp, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+		IDs: []uint64{u.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := us[0].Private.Verified, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = verify(currentApp, id)
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = request(currentApp, us[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(mails.Messages()), 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestEmailVerifyChangedEmail(t *testing.T) {
+	var (
+		currentApp, mails, tokens, users, u = testSetupAccount(t)
+		request                             = EmailVerificationRequest(mails, tokens)
+		verify                              = EmailVerify(tokens, users)
+	)
+
+	err := request(currentApp, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := testAccountToken(t, tokens, currentApp, u.ID, token.TypeVerification)
+
+	u.Email = "mallory@example.com"
+
+	_, err = users.Put(currentApp.Namespace(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = verify(currentApp, id)
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+		IDs: []uint64{u.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if us[0].Private != nil && us[0].Private.Verified {
+		t.Errorf("email verified with token of former address")
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	var (
+		currentApp, mails, tokens, users, u = testSetupAccount(t)
+		sessions                            = session.NewMemService()
+		request                             = PasswordResetRequest(mails, tokens, users)
+		reset                               = PasswordReset(password.DefaultHasher, sessions, tokens, users)
+	)
+
+	s, err := sessions.Put(currentApp.Namespace(), &session.Session{
+		DeviceID: session.DeviceIDUnknown,
+		Enabled:  true,
+		UserID:   u.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = request(currentApp, "unknown@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(mails.Messages()), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = request(currentApp, u.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := testAccountToken(t, tokens, currentApp, u.ID, token.TypePasswordReset)
+
+	err = request(currentApp, u.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(mails.Messages()), 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	id := testAccountToken(t, tokens, currentApp, u.ID, token.TypePasswordReset)
+
+	err = reset(currentApp, old, "new secret")
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = reset(currentApp, id, "")
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = reset(currentApp, id, "new secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+		IDs: []uint64{u.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, _, err := password.DefaultHasher.Verify("new secret", us[0].Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := valid, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		Enabled: &defaultEnabled,
+		IDs:     []string{s.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ss), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = reset(currentApp, id, "another secret")
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testAccountToken(
+	t *testing.T,
+	tokens token.Service,
+	currentApp *app.App,
+	userID uint64,
+	ty string,
+) string {
+	ts, err := tokens.Query(currentApp.Namespace(), token.QueryOptions{
+		Enabled: &defaultEnabled,
+		Types:   []string{ty},
+		UserIDs: []uint64{userID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ts), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	return ts[0].ID
+}
+
+func testSetupAccount(
+	t *testing.T,
+) (*app.App, *mail.MemSender, token.Service, user.Service, *user.User) {
+	var (
+		currentApp = &app.App{
+			ID:    uint64(rand.Int63()),
+			Name:  "Example",
+			OrgID: uint64(rand.Int63()),
+		}
+		users = user.NewMemService()
+	)
+
+	u, err := users.Put(currentApp.Namespace(), &user.User{
+		Enabled:  true,
+		Email:    "alice@example.com",
+		Password: "secret",
+		Username: "alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return currentApp, mail.NewMemSender(), token.NewMemService(), users, u
+}
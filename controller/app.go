@@ -6,11 +6,69 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/tapglue/multiverse/platform/access"
 	"github.com/tapglue/multiverse/platform/generate"
 	"github.com/tapglue/multiverse/service/app"
 	v04_entity "github.com/tapglue/multiverse/v04/entity"
 )
 
+// AppConnectionsRetrieveFunc returns the connection rules of an App.
+type AppConnectionsRetrieveFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (*app.Connections, error)
+
+// AppConnectionsRetrieve returns the connection rules of an App.
+func AppConnectionsRetrieve(apps app.Service) AppConnectionsRetrieveFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+	) (*app.Connections, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		rules := a.ConnectionRules()
+
+		return &rules, nil
+	}
+}
+
+// AppConnectionsUpdateFunc replaces the connection rules of an App.
+type AppConnectionsUpdateFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+	rules *app.Connections,
+) (*app.Connections, error)
+
+// AppConnectionsUpdate replaces the connection rules of an App.
+func AppConnectionsUpdate(apps app.Service) AppConnectionsUpdateFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+		rules *app.Connections,
+	) (*app.Connections, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Connections = rules
+
+		a, err = apps.Put(app.NamespaceDefault, a)
+		if err != nil {
+			if app.IsInvalidApp(err) {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			return nil, err
+		}
+
+		return a.Connections, nil
+	}
+}
+
 // AppCreateFunc creates an application for the current Org.
 type AppCreateFunc func(
 	org *v04_entity.Organization,
@@ -94,11 +152,199 @@ func AppList(apps app.Service) AppListFunc {
 	}
 }
 
+// AppPushRetrieveFunc returns the push configuration of an App.
+type AppPushRetrieveFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (*app.Push, error)
+
+// AppPushRetrieve returns the push configuration of an App.
+func AppPushRetrieve(apps app.Service) AppPushRetrieveFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+	) (*app.Push, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.Push == nil {
+			return &app.Push{}, nil
+		}
+
+		return a.Push, nil
+	}
+}
+
+// AppPushUpdateFunc replaces the push configuration of an App.
+type AppPushUpdateFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+	push *app.Push,
+) (*app.Push, error)
+
+// AppPushUpdate replaces the push configuration of an App.
+func AppPushUpdate(apps app.Service) AppPushUpdateFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+		push *app.Push,
+	) (*app.Push, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Credentials are write-only, keep the stored secrets if they are
+		// omitted on update.
+		if a.Push != nil && a.Push.APNS != nil &&
+			push.APNS != nil && push.APNS.Key == "" {
+			push.APNS.Key = a.Push.APNS.Key
+		}
+
+		if a.Push != nil && a.Push.FCM != nil &&
+			push.FCM != nil && push.FCM.PrivateKey == "" {
+			push.FCM.PrivateKey = a.Push.FCM.PrivateKey
+		}
+
+		a.Push = push
+
+		a, err = apps.Put(app.NamespaceDefault, a)
+		if err != nil {
+			if app.IsInvalidApp(err) {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			return nil, err
+		}
+
+		return a.Push, nil
+	}
+}
+
+// AppProvidersRetrieveFunc returns the identity providers of an App.
+type AppProvidersRetrieveFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (app.Providers, error)
+
+// AppProvidersRetrieve returns the identity providers of an App.
+func AppProvidersRetrieve(apps app.Service) AppProvidersRetrieveFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+	) (app.Providers, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.Providers == nil {
+			return app.Providers{}, nil
+		}
+
+		return a.Providers, nil
+	}
+}
+
+// AppProvidersUpdateFunc replaces the identity providers of an App.
+type AppProvidersUpdateFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+	providers app.Providers,
+) (app.Providers, error)
+
+// AppProvidersUpdate replaces the identity providers of an App.
+func AppProvidersUpdate(apps app.Service) AppProvidersUpdateFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+		providers app.Providers,
+	) (app.Providers, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Providers = providers
+
+		a, err = apps.Put(app.NamespaceDefault, a)
+		if err != nil {
+			if app.IsInvalidApp(err) {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			return nil, err
+		}
+
+		return a.Providers, nil
+	}
+}
+
+// AppSessionsRetrieveFunc returns the session rules of an App.
+type AppSessionsRetrieveFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (*app.Sessions, error)
+
+// AppSessionsRetrieve returns the session rules of an App.
+func AppSessionsRetrieve(apps app.Service) AppSessionsRetrieveFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+	) (*app.Sessions, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		rules := a.SessionRules()
+
+		return &rules, nil
+	}
+}
+
+// AppSessionsUpdateFunc replaces the session rules of an App.
+type AppSessionsUpdateFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+	rules *app.Sessions,
+) (*app.Sessions, error)
+
+// AppSessionsUpdate replaces the session rules of an App.
+func AppSessionsUpdate(apps app.Service) AppSessionsUpdateFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+		rules *app.Sessions,
+	) (*app.Sessions, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Sessions = rules
+
+		a, err = apps.Put(app.NamespaceDefault, a)
+		if err != nil {
+			if app.IsInvalidApp(err) {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			return nil, err
+		}
+
+		return a.Sessions, nil
+	}
+}
+
 // AppUpdateFunc updates the values of an App..
 type AppUpdateFunc func(
 	currentOrg *v04_entity.Organization,
 	publiID string,
 	name, description string,
+	reportThreshold *int,
 ) (*app.App, error)
 
 // AppUpdate updates the values of an App.
@@ -107,6 +353,7 @@ func AppUpdate(apps app.Service) AppUpdateFunc {
 		currentOrg *v04_entity.Organization,
 		publiID string,
 		name, description string,
+		reportThreshold *int,
 	) (*app.App, error) {
 		as, err := apps.Query(app.NamespaceDefault, app.QueryOptions{
 			Enabled: &defaultEnabled,
@@ -126,6 +373,14 @@ func AppUpdate(apps app.Service) AppUpdateFunc {
 		a.Name = name
 		a.Description = description
 
+		if reportThreshold != nil {
+			a.ReportThreshold = *reportThreshold
+		}
+
+		if err := a.Validate(); err != nil {
+			return nil, wrapError(ErrInvalidEntity, "%s", err)
+		}
+
 		return apps.Put(app.NamespaceDefault, a)
 	}
 }
@@ -152,3 +407,150 @@ func generateTokens() (string, string, error) {
 		fmt.Sprintf("%x", backendHash.Sum(nil))[:12],
 	), nil
 }
+
+// AppTemplatesRetrieveFunc returns the notification templates of an App.
+type AppTemplatesRetrieveFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (app.Templates, error)
+
+// AppTemplatesRetrieve returns the notification templates of an App.
+func AppTemplatesRetrieve(apps app.Service) AppTemplatesRetrieveFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+	) (app.Templates, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.Templates == nil {
+			return app.Templates{}, nil
+		}
+
+		return a.Templates, nil
+	}
+}
+
+// AppTemplatesUpdateFunc replaces the notification templates of an App.
+type AppTemplatesUpdateFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+	templates app.Templates,
+) (app.Templates, error)
+
+// AppTemplatesUpdate replaces the notification templates of an App.
+func AppTemplatesUpdate(apps app.Service) AppTemplatesUpdateFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+		templates app.Templates,
+	) (app.Templates, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Templates = templates
+
+		a, err = apps.Put(app.NamespaceDefault, a)
+		if err != nil {
+			if app.IsInvalidApp(err) {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			return nil, err
+		}
+
+		return a.Templates, nil
+	}
+}
+
+// orgApp returns the enabled App with the given public id owned by the Org.
+// AppTokensRetrieveFunc returns the access token rules of an App.
+type AppTokensRetrieveFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (*app.Tokens, error)
+
+// AppTokensRetrieve returns the access token rules of an App.
+func AppTokensRetrieve(apps app.Service) AppTokensRetrieveFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+	) (*app.Tokens, error) {
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		rules := a.TokenRules()
+
+		return &rules, nil
+	}
+}
+
+// AppTokensUpdateFunc replaces the access token rules of an App.
+type AppTokensUpdateFunc func(
+	currentOrg *v04_entity.Organization,
+	publicID string,
+	rules *app.Tokens,
+) (*app.Tokens, error)
+
+// AppTokensUpdate replaces the access token rules of an App. Access tokens can
+// only be enabled when a signer is configured.
+func AppTokensUpdate(apps app.Service, signer access.Signer) AppTokensUpdateFunc {
+	return func(
+		currentOrg *v04_entity.Organization,
+		publicID string,
+		rules *app.Tokens,
+	) (*app.Tokens, error) {
+		if rules.Enabled && signer == nil {
+			return nil, wrapError(ErrInvalidEntity, "access tokens not configured")
+		}
+
+		a, err := orgApp(apps, currentOrg, publicID)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Tokens = rules
+
+		a, err = apps.Put(app.NamespaceDefault, a)
+		if err != nil {
+			if app.IsInvalidApp(err) {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			return nil, err
+		}
+
+		return a.Tokens, nil
+	}
+}
+
+func orgApp(
+	apps app.Service,
+	currentOrg *v04_entity.Organization,
+	publicID string,
+) (*app.App, error) {
+	as, err := apps.Query(app.NamespaceDefault, app.QueryOptions{
+		Enabled: &defaultEnabled,
+		OrgIDs: []uint64{
+			uint64(currentOrg.ID),
+		},
+		PublicIDs: []string{
+			publicID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(as) != 1 {
+		return nil, ErrNotFound
+	}
+
+	return as[0], nil
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
 	"github.com/tapglue/multiverse/service/object"
 	"github.com/tapglue/multiverse/service/user"
 )
@@ -12,17 +13,24 @@ const (
 	TypeComment = "tg_comment"
 
 	attachmentContent = "content"
+
+	// maxReplyDepth is the number of levels replies can be nested below a
+	// top-level comment.
+	maxReplyDepth = 3
 )
 
-// CommentFeed is a collection of comments with their referneced users.
+// CommentFeed is a collection of comments with their referneced users and the
+// number of replies per comment.
 type CommentFeed struct {
-	Comments object.List
-	UserMap  user.Map
+	Comments    object.List
+	ReplyCounts map[uint64]int
+	UserMap     user.Map
 }
 
 // CommentController bundles the business constraints for comemnts on posts.
 type CommentController struct {
 	connections connection.Service
+	events      event.Service
 	objects     object.Service
 	users       user.Service
 }
@@ -30,11 +38,13 @@ type CommentController struct {
 // NewCommentController returns a controller instance.
 func NewCommentController(
 	connections connection.Service,
+	events event.Service,
 	objects object.Service,
 	users user.Service,
 ) *CommentController {
 	return &CommentController{
 		connections: connections,
+		events:      events,
 		objects:     objects,
 		users:       users,
 	}
@@ -94,7 +104,47 @@ func (c *CommentController) Create(
 		return nil, err
 	}
 
-	return c.objects.Put(currentApp.Namespace(), comment)
+	if input.ParentID != 0 {
+		depth, err := c.depth(currentApp, origin.UserID, postID, input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+
+		if depth >= maxReplyDepth {
+			return nil, wrapError(
+				ErrInvalidEntity,
+				"replies can only be nested %d levels deep",
+				maxReplyDepth,
+			)
+		}
+
+		comment.ParentID = input.ParentID
+	}
+
+	comment.Mentions, err = resolveMentions(
+		c.connections,
+		c.users,
+		currentApp,
+		origin.UserID,
+		post,
+		input.Mentions,
+		comment.Attachments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err = c.objects.Put(currentApp.Namespace(), comment)
+	if err != nil {
+		return nil, err
+	}
+
+	err = notifyMentions(c.events, currentApp, comment, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
 }
 
 // Delete flags the Comment as deleted.
@@ -136,51 +186,29 @@ func (c *CommentController) Delete(
 	return nil
 }
 
-// List returns all comemnts for the given post id.
+// List returns all top-level comments for the given post id.
 func (c *CommentController) List(
 	currentApp *app.App,
 	origin uint64,
 	postID uint64,
 	opts object.QueryOptions,
 ) (*CommentFeed, error) {
-	ps, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
-		ID:    &postID,
-		Owned: &defaultOwned,
-		Types: []string{TypePost},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	if len(ps) == 0 {
-		return nil, ErrNotFound
-	}
-
-	if err := isPostVisible(c.connections, currentApp, ps[0], origin); err != nil {
-		return nil, err
-	}
-
-	cs, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
-		Before: opts.Before,
-		Limit:  opts.Limit,
-		ObjectIDs: []uint64{
-			postID,
-		},
-		Types: []string{
-			TypeComment,
-		},
-		Owned: &defaultOwned,
-	})
-	if err != nil {
-		return nil, err
-	}
+	return c.list(currentApp, origin, postID, 0, opts)
+}
 
-	um, err := user.MapFromIDs(c.users, currentApp.Namespace(), cs.OwnerIDs()...)
+// ListReplies returns all replies to the given comment.
+func (c *CommentController) ListReplies(
+	currentApp *app.App,
+	origin uint64,
+	postID, commentID uint64,
+	opts object.QueryOptions,
+) (*CommentFeed, error) {
+	_, err := c.depth(currentApp, origin, postID, commentID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CommentFeed{Comments: cs, UserMap: um}, nil
+	return c.list(currentApp, origin, postID, commentID, opts)
 }
 
 // Retrieve returns the comment given id.
@@ -259,7 +287,220 @@ func (c *CommentController) Update(
 		old.Private = new.Private
 	}
 
-	return c.objects.Put(currentApp.Namespace(), old)
+	ps, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+		ID:    &postID,
+		Owned: &defaultOwned,
+		Types: []string{TypePost},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ps) != 1 {
+		return nil, ErrNotFound
+	}
+
+	mentioned := old.Mentions
+
+	old.Mentions, err = resolveMentions(
+		c.connections,
+		c.users,
+		currentApp,
+		origin.UserID,
+		ps[0],
+		new.Mentions,
+		old.Attachments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := c.objects.Put(currentApp.Namespace(), old)
+	if err != nil {
+		return nil, err
+	}
+
+	err = notifyMentions(c.events, currentApp, updated, mentioned)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// depth returns the number of ancestors of the comment, at most maxReplyDepth.
+func (c *CommentController) depth(
+	currentApp *app.App,
+	origin uint64,
+	postID, commentID uint64,
+) (int, error) {
+	depth := 0
+
+	for id := commentID; ; depth++ {
+		cs, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+			ID: &id,
+			ObjectIDs: []uint64{
+				postID,
+			},
+			Owned: &defaultOwned,
+			Types: []string{
+				TypeComment,
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if len(cs) != 1 {
+			return 0, ErrNotFound
+		}
+
+		if depth == 0 && conditionObjectHidden(origin)(cs[0]) {
+			return 0, ErrNotFound
+		}
+
+		if cs[0].ParentID == 0 || depth == maxReplyDepth {
+			return depth, nil
+		}
+
+		id = cs[0].ParentID
+	}
+}
+
+// list returns the comments of the post with the given parent, top-level
+// comments have no parent.
+func (c *CommentController) list(
+	currentApp *app.App,
+	origin uint64,
+	postID, parentID uint64,
+	opts object.QueryOptions,
+) (*CommentFeed, error) {
+	ps, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+		ID:    &postID,
+		Owned: &defaultOwned,
+		Types: []string{TypePost},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ps) == 0 {
+		return nil, ErrNotFound
+	}
+
+	if err := isPostVisible(c.connections, currentApp, ps[0], origin); err != nil {
+		return nil, err
+	}
+
+	cs, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+		Before: opts.Before,
+		Limit:  opts.Limit,
+		ObjectIDs: []uint64{
+			postID,
+		},
+		ParentIDs: []uint64{
+			parentID,
+		},
+		Types: []string{
+			TypeComment,
+		},
+		Owned: &defaultOwned,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := blockedIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	cs = filterObjects(
+		cs,
+		conditionObjectHidden(origin),
+		conditionObjectOwner(bs),
+	)
+
+	counts := map[uint64]int{}
+
+	for _, comment := range cs {
+		count, err := c.countReplies(currentApp, origin, postID, comment.ID, bs)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[comment.ID] = count
+	}
+
+	um, err := user.MapFromIDs(c.users, currentApp.Namespace(), cs.OwnerIDs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommentFeed{
+		Comments:    cs,
+		ReplyCounts: counts,
+		UserMap:     um,
+	}, nil
+}
+
+// countReplies returns the number of direct replies to the comment which are
+// visible to the origin. Hidden replies only count for their owner and replies
+// of blocked users are left out.
+func (c *CommentController) countReplies(
+	currentApp *app.App,
+	origin uint64,
+	postID, commentID uint64,
+	blocked map[uint64]struct{},
+) (int, error) {
+	var (
+		hidden = true
+		opts   = object.QueryOptions{
+			Hidden: &defaultHidden,
+			ObjectIDs: []uint64{
+				postID,
+			},
+			ParentIDs: []uint64{
+				commentID,
+			},
+			Types: []string{
+				TypeComment,
+			},
+			Owned: &defaultOwned,
+		}
+	)
+
+	count, err := c.objects.Count(currentApp.Namespace(), opts)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(blocked) > 0 {
+		opts.OwnerIDs = []uint64{}
+
+		for id := range blocked {
+			opts.OwnerIDs = append(opts.OwnerIDs, id)
+		}
+
+		bc, err := c.objects.Count(currentApp.Namespace(), opts)
+		if err != nil {
+			return 0, err
+		}
+
+		count -= bc
+	}
+
+	opts.Hidden = &hidden
+	opts.OwnerIDs = []uint64{
+		origin,
+	}
+
+	oc, err := c.objects.Count(currentApp.Namespace(), opts)
+	if err != nil {
+		return 0, err
+	}
+
+	return count + oc, nil
 }
 
 func constrainCommentPrivate(origin Origin, private *object.Private) error {
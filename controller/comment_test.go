@@ -7,6 +7,7 @@ import (
 
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
 	"github.com/tapglue/multiverse/service/object"
 	"github.com/tapglue/multiverse/service/user"
 )
@@ -166,6 +167,126 @@ func TestCommentControllerList(t *testing.T) {
 	}
 }
 
+func TestCommentControllerListReplies(t *testing.T) {
+	app, owner, c := testSetupCommentController(t)
+
+	post, err := c.objects.Put(app.Namespace(), testPost(owner.ID).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, err := c.Create(app, Origin{UserID: owner.ID}, post.ID, testComment(owner.ID, post))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		reply := testComment(owner.ID, post)
+		reply.ParentID = parent.ID
+
+		_, err := c.Create(app, Origin{UserID: owner.ID}, post.ID, reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blockedID := uint64(rand.Int63())
+
+	for _, private := range []*object.Private{nil, {Visible: false}} {
+		hidden := testComment(owner.ID, post)
+		hidden.ParentID = parent.ID
+		hidden.Private = private
+
+		if private == nil {
+			hidden.OwnerID = blockedID
+		}
+
+		_, err := c.objects.Put(app.Namespace(), hidden)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = c.connections.Put(app.Namespace(), &connection.Connection{
+		Enabled: true,
+		FromID:  owner.ID,
+		State:   connection.StateConfirmed,
+		ToID:    blockedID,
+		Type:    connection.TypeBlock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := c.List(app, owner.ID, post.ID, object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list.Comments), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := list.ReplyCounts[parent.ID], 4; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	replies, err := c.ListReplies(app, owner.ID, post.ID, parent.ID, object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(replies.Comments), 4; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	list, err = c.List(app, uint64(rand.Int63()), post.ID, object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := list.ReplyCounts[parent.ID], 4; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.ListReplies(app, owner.ID, post.ID, parent.ID+1, object.QueryOptions{})
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestCommentControllerCreateReplyDepth(t *testing.T) {
+	app, owner, c := testSetupCommentController(t)
+
+	post, err := c.objects.Put(app.Namespace(), testPost(owner.ID).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, err := c.Create(app, Origin{UserID: owner.ID}, post.ID, testComment(owner.ID, post))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < maxReplyDepth; i++ {
+		reply := testComment(owner.ID, post)
+		reply.ParentID = parent.ID
+
+		parent, err = c.Create(app, Origin{UserID: owner.ID}, post.ID, reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reply := testComment(owner.ID, post)
+	reply.ParentID = parent.ID
+
+	_, err = c.Create(app, Origin{UserID: owner.ID}, post.ID, reply)
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func TestCommentControllerRetrieve(t *testing.T) {
 	app, owner, c := testSetupCommentController(t)
 
@@ -282,6 +403,7 @@ func testSetupCommentController(
 			OrgID: uint64(rand.Int63()),
 		}
 		connections = connection.NewMemService()
+		events      = event.NewMemService()
 		objects     = object.NewMemService()
 		users       = user.NewMemService()
 		user        = &user.User{
@@ -289,12 +411,17 @@ func testSetupCommentController(
 		}
 	)
 
-	err := objects.Setup(a.Namespace())
+	err := events.Setup(a.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = objects.Setup(a.Namespace())
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	return a, user, NewCommentController(connections, objects, users)
+	return a, user, NewCommentController(connections, events, objects, users)
 }
 
 func testComment(ownerID uint64, post *object.Object) *object.Object {
@@ -2,12 +2,57 @@ package controller
 
 import (
 	"sort"
+	"time"
 
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/user"
 )
 
+// socialTypes are the connection types which relate users with each other, as
+// opposed to blocks and mutes.
+var socialTypes = []connection.Type{
+	connection.TypeFollow,
+	connection.TypeFriend,
+}
+
+// limitExpire is the page size in which expired requests are rejected.
+const limitExpire = 100
+
+// Outcomes of connecting the origin with a single user during an import.
+const (
+	ImportCreated ImportResult = "created"
+	ImportExisted ImportResult = "existed"
+	ImportFailed  ImportResult = "failed"
+)
+
+// ConnectionImport is the outcome of connecting the origin with the user, the
+// reason is given for failures.
+type ConnectionImport struct {
+	Reason string
+	Result ImportResult
+	User   *user.User
+}
+
+// ConnectionImports is a ConnectionImport collection.
+type ConnectionImports []*ConnectionImport
+
+// Users returns the users connected with the origin after the import.
+func (is ConnectionImports) Users() user.List {
+	us := user.List{}
+
+	for _, i := range is {
+		if i.Result != ImportFailed {
+			us = append(us, i.User)
+		}
+	}
+
+	return us
+}
+
+// ImportResult describes the outcome of a connection import.
+type ImportResult string
+
 // ConnectionFeed is the composite to transport information relevant for
 // connections.
 type ConnectionFeed struct {
@@ -53,6 +98,7 @@ func (c *ConnectionController) ByState(
 		FromIDs: []uint64{originID},
 		Limit:   opts.Limit,
 		States:  []connection.State{state},
+		Types:   socialTypes,
 	})
 	if err != nil {
 		return nil, err
@@ -64,6 +110,7 @@ func (c *ConnectionController) ByState(
 		States:  []connection.State{state},
 		Limit:   opts.Limit,
 		ToIDs:   []uint64{originID},
+		Types:   socialTypes,
 	})
 	if err != nil {
 		return nil, err
@@ -109,14 +156,63 @@ func (c *ConnectionController) ByState(
 	}, nil
 }
 
+// ByType returns the blocks or mutes the origin has in place.
+func (c *ConnectionController) ByType(
+	currentApp *app.App,
+	originID uint64,
+	connectionType connection.Type,
+	opts connection.QueryOptions,
+) (*ConnectionFeed, error) {
+	switch connectionType {
+	case connection.TypeBlock, connection.TypeMute:
+		// valid
+	default:
+		return nil, wrapError(
+			ErrInvalidEntity,
+			"unsupported type %s",
+			string(connectionType),
+		)
+	}
+
+	cs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Before:  opts.Before,
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{originID},
+		Limit:   opts.Limit,
+		States:  []connection.State{connection.StateConfirmed},
+		Types:   []connection.Type{connectionType},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cs) == 0 {
+		return &ConnectionFeed{
+			Connections: connection.List{},
+			UserMap:     user.Map{},
+		}, nil
+	}
+
+	um, err := user.MapFromIDs(c.users, currentApp.Namespace(), cs.ToIDs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConnectionFeed{
+		Connections: cs,
+		UserMap:     um,
+	}, nil
+}
+
 // CreateSocial connects the origin with the users matching the platform ids.
+// All connections are stored at once and the outcome is reported per user.
 func (c *ConnectionController) CreateSocial(
 	currentApp *app.App,
 	originID uint64,
 	connectionType connection.Type,
 	connectionState connection.State,
 	opts user.QueryOptions,
-) (user.List, error) {
+) (ConnectionImports, error) {
 	opts.Enabled = &defaultEnabled
 
 	us, err := c.users.Query(currentApp.Namespace(), opts)
@@ -124,29 +220,114 @@ func (c *ConnectionController) CreateSocial(
 		return nil, err
 	}
 
+	bs, err := blockedIDs(c.connections, currentApp, originID)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err = filterUsers(us, conditionHidden(bs))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(us) == 0 {
+		return ConnectionImports{}, nil
+	}
+
+	ids := []uint64{}
+
 	for _, u := range us {
-		_, err := c.connections.Put(currentApp.Namespace(), &connection.Connection{
-			Enabled: true,
-			FromID:  originID,
-			ToID:    u.ID,
-			State:   connectionState,
-			Type:    connectionType,
-		})
+		ids = append(ids, u.ID)
+	}
+
+	es, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		FromIDs: []uint64{
+			originID,
+		},
+		ToIDs: ids,
+		Types: []connection.Type{
+			connectionType,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[uint64]*connection.Connection{}
+
+	for _, con := range es {
+		if old := storedConnection(connection.List{con}, originID, con.ToID); old != nil {
+			existing[con.ToID] = old
+		}
+	}
+
+	var (
+		cs    = connection.List{}
+		is    = ConnectionImports{}
+		rules = currentApp.ConnectionRules()
+	)
+
+	for _, u := range us {
+		var (
+			i   = &ConnectionImport{User: u}
+			con = &connection.Connection{
+				Enabled: true,
+				FromID:  originID,
+				ToID:    u.ID,
+				State:   connectionState,
+				Type:    connectionType,
+			}
+			old = existing[u.ID]
+		)
+
+		is = append(is, i)
+
+		if requiresApproval(rules, u, con) &&
+			(old == nil || !old.Enabled || old.State != connection.StateConfirmed) {
+			con.State = connection.StatePending
+		}
+
+		if old != nil && old.Enabled && old.State == con.State {
+			i.Result = ImportExisted
+			continue
+		}
+
+		err := con.Validate()
+		if err == nil && old != nil {
+			err = validateConTransition(rules, old, con)
+		}
 		if err != nil {
-			return nil, err
+			i.Reason = err.Error()
+			i.Result = ImportFailed
+			continue
 		}
 
-		r, err := queryRelation(c.connections, currentApp, originID, u.ID)
+		i.Result = ImportCreated
+		cs = append(cs, con)
+	}
+
+	_, err = c.connections.PutBatch(currentApp.Namespace(), cs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range is {
+		if i.Result == ImportFailed {
+			continue
+		}
+
+		r, err := queryRelation(c.connections, currentApp, originID, i.User.ID)
 		if err != nil {
 			return nil, err
 		}
 
-		u.IsFollower = r.isFollower
-		u.IsFollowing = r.isFollowing
-		u.IsFriend = r.isFriend
+		i.User.IsFollower = r.isFollower
+		i.User.IsFollowing = r.isFollowing
+		i.User.IsRequested = r.isRequested
+		i.User.IsFriend = r.isFriend
 	}
 
-	return us, nil
+	return is, nil
 }
 
 // Delete disables the given connection.
@@ -195,6 +376,10 @@ func (c *ConnectionController) Followers(
 	userID uint64,
 	opts connection.QueryOptions,
 ) (*ConnectionFeed, error) {
+	if err := constrainBlocked(c.connections, currentApp, origin, userID); err != nil {
+		return nil, err
+	}
+
 	cs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
 		Before:  opts.Before,
 		Enabled: &defaultEnabled,
@@ -224,6 +409,62 @@ func (c *ConnectionController) Followers(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, us)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConnectionFeed{
+		Connections: cs,
+		Users:       us,
+	}, nil
+}
+
+// Mutuals returns the users the origin is connected with who are connected with
+// the user as well.
+func (c *ConnectionController) Mutuals(
+	currentApp *app.App,
+	origin uint64,
+	userID uint64,
+	opts connection.QueryOptions,
+) (*ConnectionFeed, error) {
+	if err := constrainBlocked(c.connections, currentApp, origin, userID); err != nil {
+		return nil, err
+	}
+
+	cs, err := mutualConnections(c.connections, currentApp, origin, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint64{}
+
+	for _, con := range cs {
+		ids = append(ids, counterpartID(con, userID))
+	}
+
+	us, err := user.ListFromIDs(c.users, currentApp.Namespace(), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range us {
+		err := enrichConnectionCounts(c.connections, c.users, currentApp, u)
+		if err != nil {
+			return nil, err
+		}
+
+		err = enrichRelation(c.connections, currentApp, origin, u)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = enrichMutualCounts(c.connections, currentApp, origin, us)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ConnectionFeed{
 		Connections: cs,
 		Users:       us,
@@ -237,6 +478,10 @@ func (c *ConnectionController) Followings(
 	userID uint64,
 	opts connection.QueryOptions,
 ) (*ConnectionFeed, error) {
+	if err := constrainBlocked(c.connections, currentApp, origin, userID); err != nil {
+		return nil, err
+	}
+
 	cs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
 		Before:  opts.Before,
 		Enabled: &defaultEnabled,
@@ -266,6 +511,11 @@ func (c *ConnectionController) Followings(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, us)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ConnectionFeed{
 		Connections: cs,
 		Users:       us,
@@ -279,6 +529,10 @@ func (c *ConnectionController) Friends(
 	userID uint64,
 	opts connection.QueryOptions,
 ) (*ConnectionFeed, error) {
+	if err := constrainBlocked(c.connections, currentApp, origin, userID); err != nil {
+		return nil, err
+	}
+
 	fs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
 		Before:  opts.Before,
 		Enabled: &defaultEnabled,
@@ -342,6 +596,11 @@ func (c *ConnectionController) Friends(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, us)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ConnectionFeed{
 		Connections: cs,
 		Users:       us,
@@ -367,7 +626,15 @@ func (c *ConnectionController) Update(
 		return nil, ErrNotFound
 	}
 
+	if new.Type == connection.TypeFollow || new.Type == connection.TypeFriend {
+		err := constrainBlocked(c.connections, currentApp, new.FromID, new.ToID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var (
+		rules   = currentApp.ConnectionRules()
 		fromIDs = []uint64{new.FromID}
 		toIDs   = []uint64{new.ToID}
 	)
@@ -378,9 +645,7 @@ func (c *ConnectionController) Update(
 	}
 
 	cs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
-		Enabled: &defaultEnabled,
 		FromIDs: fromIDs,
-		Limit:   1,
 		ToIDs:   toIDs,
 		Types:   []connection.Type{new.Type},
 	})
@@ -388,29 +653,520 @@ func (c *ConnectionController) Update(
 		return nil, err
 	}
 
-	if len(cs) > 0 && cs[0].State == new.State {
-		return cs[0], nil
-	}
+	old := storedConnection(cs, new.FromID, new.ToID)
 
-	var old *connection.Connection
+	// Follows which are not yet approved stay pending until the followed user
+	// answers them.
+	if requiresApproval(rules, us[0], new) &&
+		(old == nil || old.State != connection.StateConfirmed) {
+		new.State = connection.StatePending
+	}
 
-	if len(cs) > 0 {
-		old = cs[0]
+	if old != nil && old.Enabled && old.State == new.State {
+		return old, nil
+	}
 
+	if old != nil {
 		new.FromID = old.FromID
 		new.ToID = old.ToID
 	}
 
 	new.Enabled = true
 
-	if err := validateConTransition(old, new); err != nil {
+	if err := validateConTransition(rules, old, new); err != nil {
 		return nil, err
 	}
 
+	if new.Type == connection.TypeBlock {
+		err := c.disconnect(currentApp, new.FromID, new.ToID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return c.connections.Put(currentApp.Namespace(), new)
 }
 
-func validateConTransition(old, new *connection.Connection) error {
+// Answer confirms or rejects the pending request of the given type the user
+// with fromID sent to the origin.
+func (c *ConnectionController) Answer(
+	currentApp *app.App,
+	origin, fromID uint64,
+	t connection.Type,
+	state connection.State,
+) (*connection.Connection, error) {
+	if state != connection.StateConfirmed && state != connection.StateRejected {
+		return nil, wrapError(
+			ErrInvalidEntity,
+			"requests can only be confirmed or rejected",
+		)
+	}
+
+	cs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			fromID,
+		},
+		Limit: 1,
+		States: []connection.State{
+			connection.StatePending,
+		},
+		ToIDs: []uint64{
+			origin,
+		},
+		Types: []connection.Type{
+			t,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var (
+		old = cs[0]
+		new = *old
+	)
+
+	new.State = state
+
+	err = validateConTransition(currentApp.ConnectionRules(), old, &new)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.connections.Put(currentApp.Namespace(), &new)
+}
+
+// ConnectionExpireFunc rejects the pending requests of the App which are older
+// than the request expiry of its connection rules.
+type ConnectionExpireFunc func(currentApp *app.App) (connection.List, error)
+
+// ConnectionExpire rejects expired requests, the state changes are propagated
+// by the given connection service.
+func ConnectionExpire(connections connection.Service) ConnectionExpireFunc {
+	return func(currentApp *app.App) (connection.List, error) {
+		expiry := currentApp.ConnectionRules().Expiry()
+
+		if expiry == 0 {
+			return connection.List{}, nil
+		}
+
+		var (
+			before  = time.Now().UTC().Add(-expiry)
+			expired = connection.List{}
+		)
+
+		// Rejected requests drop out of the query, so every page starts over.
+		for {
+			cs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+				Before:  before,
+				Enabled: &defaultEnabled,
+				Limit:   limitExpire,
+				States: []connection.State{
+					connection.StatePending,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, con := range cs {
+				con.State = connection.StateRejected
+
+				con, err := connections.Put(currentApp.Namespace(), con)
+				if err != nil {
+					return nil, err
+				}
+
+				expired = append(expired, con)
+			}
+
+			if len(cs) < limitExpire {
+				return expired, nil
+			}
+		}
+	}
+}
+
+// disconnect disables all follow and friend connections between the two users.
+func (c *ConnectionController) disconnect(
+	currentApp *app.App,
+	origin, userID uint64,
+) error {
+	cs, err := c.connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			origin,
+			userID,
+		},
+		ToIDs: []uint64{
+			origin,
+			userID,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, con := range cs {
+		con.Enabled = false
+
+		_, err := c.connections.Put(currentApp.Namespace(), con)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blockedIDs returns the ids of all users the origin has blocked or has been
+// blocked by.
+func blockedIDs(
+	connections connection.Service,
+	currentApp *app.App,
+	origin uint64,
+) (map[uint64]struct{}, error) {
+	return restrictedIDs(connections, currentApp, origin, false)
+}
+
+// hiddenIDs returns the ids of all users whose content is hidden from the
+// origin, which are the blocked users and the ones muted by the origin.
+func hiddenIDs(
+	connections connection.Service,
+	currentApp *app.App,
+	origin uint64,
+) (map[uint64]struct{}, error) {
+	return restrictedIDs(connections, currentApp, origin, true)
+}
+
+func restrictedIDs(
+	connections connection.Service,
+	currentApp *app.App,
+	origin uint64,
+	muted bool,
+) (map[uint64]struct{}, error) {
+	ts := []connection.Type{connection.TypeBlock}
+
+	if muted {
+		ts = append(ts, connection.TypeMute)
+	}
+
+	os, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			origin,
+		},
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		Types: ts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	is, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			origin,
+		},
+		Types: []connection.Type{
+			connection.TypeBlock,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[uint64]struct{}{}
+
+	for _, id := range append(os.ToIDs(), is.FromIDs()...) {
+		ids[id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// counterpartID returns the id of the user on the other end of the connection.
+func counterpartID(con *connection.Connection, id uint64) uint64 {
+	if con.FromID == id {
+		return con.ToID
+	}
+
+	return con.FromID
+}
+
+// mutualConnections returns the connections between the user and the ones the
+// origin is connected with, one per mutual user.
+func mutualConnections(
+	connections connection.Service,
+	currentApp *app.App,
+	origin, userID uint64,
+	opts connection.QueryOptions,
+) (connection.List, error) {
+	ids, err := socialIDs(connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(ids, userID)
+
+	if len(ids) == 0 {
+		return connection.List{}, nil
+	}
+
+	is := []uint64{}
+
+	for id := range ids {
+		is = append(is, id)
+	}
+
+	cs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Before:  opts.Before,
+		Enabled: &defaultEnabled,
+		FromIDs: is,
+		Limit:   opts.Limit,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			userID,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Before:  opts.Before,
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			userID,
+		},
+		Limit: opts.Limit,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: is,
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs = append(cs, fs...)
+
+	sort.Sort(cs)
+
+	var (
+		ms   = connection.List{}
+		seen = map[uint64]struct{}{}
+	)
+
+	for _, con := range cs {
+		id := counterpartID(con, userID)
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+
+		ms = append(ms, con)
+	}
+
+	if opts.Limit > 0 && len(ms) > opts.Limit {
+		ms = ms[:opts.Limit]
+	}
+
+	return ms, nil
+}
+
+// secondDegree returns the users connected to any of the given users, mapped to
+// the ones they are connected through.
+func secondDegree(
+	connections connection.Service,
+	currentApp *app.App,
+	ids map[uint64]struct{},
+) (map[uint64]map[uint64]struct{}, error) {
+	mutuals := map[uint64]map[uint64]struct{}{}
+
+	if len(ids) == 0 {
+		return mutuals, nil
+	}
+
+	is := []uint64{}
+
+	for id := range ids {
+		is = append(is, id)
+	}
+
+	os, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: is,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: is,
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	add := func(id, through uint64) {
+		if _, ok := mutuals[id]; !ok {
+			mutuals[id] = map[uint64]struct{}{}
+		}
+
+		mutuals[id][through] = struct{}{}
+	}
+
+	for _, c := range os {
+		add(c.ToID, c.FromID)
+	}
+
+	for _, c := range fs {
+		add(c.FromID, c.ToID)
+	}
+
+	return mutuals, nil
+}
+
+// socialIDs returns the ids of all users the origin follows or is friends
+// with.
+func socialIDs(
+	connections connection.Service,
+	currentApp *app.App,
+	origin uint64,
+) (map[uint64]struct{}, error) {
+	os, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			origin,
+		},
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			origin,
+		},
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[uint64]struct{}{}
+
+	for _, id := range append(os.ToIDs(), fs.FromIDs()...) {
+		ids[id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// constrainBlocked returns ErrNotFound if either of the users blocked the
+// other, which makes them invisible to each other.
+func constrainBlocked(
+	connections connection.Service,
+	currentApp *app.App,
+	origin, userID uint64,
+) error {
+	r, err := queryRelation(connections, currentApp, origin, userID)
+	if err != nil {
+		return err
+	}
+
+	if r.isBlocked {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// requiresApproval reports if the connection has to wait for the approval of
+// the target user, which is the case for follows of private users or if the
+// App rules demand it.
+func requiresApproval(
+	rules app.Connections,
+	target *user.User,
+	con *connection.Connection,
+) bool {
+	return (rules.FollowApproval || target.IsPrivate) &&
+		con.Type == connection.TypeFollow &&
+		con.State == connection.StateConfirmed
+}
+
+// validateConTransition checks the state change of a connection against the
+// state machine configured by the connection rules of the App.
+// storedConnection returns the connection a new one between the two users
+// transitions from. Enabled connections take precedence, deleted ones are only
+// considered when they were rejected, otherwise deleting a rejected request
+// would allow to send it again regardless of the Rerequest rule.
+func storedConnection(
+	cs connection.List,
+	fromID, toID uint64,
+) *connection.Connection {
+	for _, con := range cs {
+		if con.Enabled {
+			return con
+		}
+	}
+
+	for _, con := range cs {
+		if con.FromID == fromID &&
+			con.ToID == toID &&
+			con.State == connection.StateRejected {
+			return con
+		}
+	}
+
+	return nil
+}
+
+func validateConTransition(
+	rules app.Connections,
+	old, new *connection.Connection,
+) error {
 	if old == nil {
 		return nil
 	}
@@ -457,6 +1213,10 @@ func validateConTransition(old, new *connection.Connection) error {
 		case connection.StateRejected:
 			return nil
 		}
+	case connection.StateRejected:
+		if rules.Rerequest && new.State == connection.StatePending {
+			return nil
+		}
 	}
 
 	return wrapError(
@@ -468,9 +1228,12 @@ func validateConTransition(old, new *connection.Connection) error {
 }
 
 type relation struct {
+	isBlocked   bool
 	isFriend    bool
 	isFollower  bool
 	isFollowing bool
+	isMuted     bool
+	isRequested bool
 	isSelf      bool
 }
 
@@ -491,6 +1254,7 @@ func queryRelation(
 		},
 		States: []connection.State{
 			connection.StateConfirmed,
+			connection.StatePending,
 		},
 		ToIDs: []uint64{
 			origin,
@@ -504,6 +1268,16 @@ func queryRelation(
 	r := &relation{}
 
 	for _, c := range cs {
+		// Pending connections don't relate the users yet, only the follow
+		// request of the origin is reflected.
+		if c.State == connection.StatePending {
+			if c.Type == connection.TypeFollow && c.FromID == origin {
+				r.isRequested = true
+			}
+
+			continue
+		}
+
 		if c.Type == connection.TypeFriend {
 			r.isFriend = true
 		}
@@ -515,6 +1289,14 @@ func queryRelation(
 		if c.Type == connection.TypeFollow && c.ToID == origin {
 			r.isFollower = true
 		}
+
+		if c.Type == connection.TypeBlock {
+			r.isBlocked = true
+		}
+
+		if c.Type == connection.TypeMute && c.FromID == origin {
+			r.isMuted = true
+		}
 	}
 
 	return r, nil
@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"math/rand"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
 )
 
 func TestValidateConTransition(t *testing.T) {
@@ -83,9 +89,566 @@ func TestValidateConTransition(t *testing.T) {
 	}
 
 	for old, new := range cases {
-		err := validateConTransition(old, new)
+		err := validateConTransition(app.Connections{}, old, new)
 		if have, want := err, ErrInvalidEntity; !IsInvalidEntity(err) {
 			t.Errorf("have %v, want %v", have, want)
 		}
 	}
 }
+
+func TestValidateConTransitionRerequest(t *testing.T) {
+	var (
+		old = &connection.Connection{
+			FromID: 1,
+			ToID:   2,
+			State:  connection.StateRejected,
+			Type:   connection.TypeFriend,
+		}
+		new = &connection.Connection{
+			FromID: 1,
+			ToID:   2,
+			State:  connection.StatePending,
+			Type:   connection.TypeFriend,
+		}
+		rules = app.Connections{Rerequest: true}
+	)
+
+	if err := validateConTransition(rules, old, new); err != nil {
+		t.Error(err)
+	}
+
+	new.State = connection.StateConfirmed
+
+	err := validateConTransition(rules, old, new)
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(err) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionUpdateBlock(t *testing.T) {
+	var (
+		app, c = testSetupConnectionController(t)
+		origin = testConnectionUser(t, app, c)
+		target = testConnectionUser(t, app, c)
+	)
+
+	_, err := c.Update(app, &connection.Connection{
+		FromID: origin.ID,
+		State:  connection.StateConfirmed,
+		ToID:   target.ID,
+		Type:   connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Update(app, &connection.Connection{
+		FromID: target.ID,
+		State:  connection.StateConfirmed,
+		ToID:   origin.ID,
+		Type:   connection.TypeBlock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := c.connections.Query(app.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		Types:   socialTypes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(cs), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	for _, con := range []*connection.Connection{
+		{
+			FromID: origin.ID,
+			State:  connection.StateConfirmed,
+			ToID:   target.ID,
+			Type:   connection.TypeFollow,
+		},
+		{
+			FromID: target.ID,
+			State:  connection.StatePending,
+			ToID:   origin.ID,
+			Type:   connection.TypeFriend,
+		},
+	} {
+		_, err := c.Update(app, con)
+		if have, want := err, ErrNotFound; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	_, err = c.Followers(app, origin.ID, target.ID, connection.QueryOptions{
+		Limit: 10,
+	})
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionUpdateFollowApproval(t *testing.T) {
+	var (
+		currentApp, c = testSetupConnectionController(t)
+		origin        = testConnectionUser(t, currentApp, c)
+		target        = testConnectionUser(t, currentApp, c)
+	)
+
+	currentApp.Connections = &app.Connections{
+		FollowApproval: true,
+	}
+
+	con, err := c.Update(currentApp, &connection.Connection{
+		FromID: origin.ID,
+		State:  connection.StateConfirmed,
+		ToID:   target.ID,
+		Type:   connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := con.State, connection.StatePending; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	// Following back creates a request of its own instead of answering.
+	back, err := c.Update(currentApp, &connection.Connection{
+		FromID: target.ID,
+		State:  connection.StateConfirmed,
+		ToID:   origin.ID,
+		Type:   connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := back.FromID, target.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := back.State, connection.StatePending; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	con, err = c.Answer(currentApp, target.ID, origin.ID, connection.TypeFollow, connection.StateConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := con.FromID, origin.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := con.State, connection.StateConfirmed; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	r, err := queryRelation(c.connections, currentApp, origin.ID, target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := r.isFollowing, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.Answer(currentApp, target.ID, origin.ID, connection.TypeFollow, connection.StateRejected)
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.Answer(currentApp, origin.ID, target.ID, connection.TypeFollow, connection.StatePending)
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionUpdatePrivate(t *testing.T) {
+	var (
+		currentApp, c = testSetupConnectionController(t)
+		origin        = testConnectionUser(t, currentApp, c)
+		target        = testConnectionUser(t, currentApp, c)
+		post          = testPost(target.ID).Object
+	)
+
+	target.IsPrivate = true
+
+	target, err := c.users.Put(currentApp.Namespace(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post.Visibility = object.VisibilityConnection
+
+	con, err := c.Update(currentApp, &connection.Connection{
+		FromID: origin.ID,
+		State:  connection.StateConfirmed,
+		ToID:   target.ID,
+		Type:   connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := con.State, connection.StatePending; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = enrichRelation(c.connections, currentApp, origin.ID, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := target.IsFollowing, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := target.IsRequested, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = isPostVisible(c.connections, currentApp, post, origin.ID)
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.Answer(currentApp, target.ID, origin.ID, connection.TypeFollow, connection.StateConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = enrichRelation(c.connections, currentApp, origin.ID, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := target.IsRequested, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if err := isPostVisible(c.connections, currentApp, post, origin.ID); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnectionUpdateRejectedDeleted(t *testing.T) {
+	var (
+		currentApp, c = testSetupConnectionController(t)
+		origin        = testConnectionUser(t, currentApp, c)
+		target        = testConnectionUser(t, currentApp, c)
+		request       = func() (*connection.Connection, error) {
+			return c.Update(currentApp, &connection.Connection{
+				FromID: origin.ID,
+				State:  connection.StateConfirmed,
+				ToID:   target.ID,
+				Type:   connection.TypeFollow,
+			})
+		}
+	)
+
+	currentApp.Connections = &app.Connections{
+		FollowApproval: true,
+	}
+
+	if _, err := request(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.Answer(currentApp, target.ID, origin.ID, connection.TypeFollow, connection.StateRejected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Delete(currentApp, &connection.Connection{
+		FromID: origin.ID,
+		ToID:   target.ID,
+		Type:   connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = request()
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	// The target is not bound by the rejection of the origin's request.
+	back, err := c.Update(currentApp, &connection.Connection{
+		FromID: target.ID,
+		State:  connection.StateConfirmed,
+		ToID:   origin.ID,
+		Type:   connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := back.State, connection.StatePending; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	currentApp.Connections.Rerequest = true
+
+	con, err := request()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := con.State, connection.StatePending; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := con.Enabled, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionExpire(t *testing.T) {
+	var (
+		currentApp, c = testSetupConnectionController(t)
+		origin        = testConnectionUser(t, currentApp, c)
+		target        = testConnectionUser(t, currentApp, c)
+		fn            = ConnectionExpire(c.connections)
+	)
+
+	currentApp.Connections = &app.Connections{
+		RequestExpiry: 3600,
+	}
+
+	expired, err := c.connections.Put(currentApp.Namespace(), &connection.Connection{
+		Enabled:   true,
+		FromID:    origin.ID,
+		State:     connection.StatePending,
+		ToID:      target.ID,
+		Type:      connection.TypeFriend,
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.connections.Put(currentApp.Namespace(), &connection.Connection{
+		Enabled: true,
+		FromID:  target.ID,
+		State:   connection.StatePending,
+		ToID:    origin.ID,
+		Type:    connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := fn(currentApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(cs), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := cs[0].FromID, expired.FromID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := cs[0].State, connection.StateRejected; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionExpirePaging(t *testing.T) {
+	var (
+		currentApp, c = testSetupConnectionController(t)
+		target        = testConnectionUser(t, currentApp, c)
+		fn            = ConnectionExpire(c.connections)
+	)
+
+	currentApp.Connections = &app.Connections{
+		RequestExpiry: 3600,
+	}
+
+	for i := 0; i < limitExpire+5; i++ {
+		_, err := c.connections.Put(currentApp.Namespace(), &connection.Connection{
+			Enabled:   true,
+			FromID:    uint64(rand.Int63()),
+			State:     connection.StatePending,
+			ToID:      target.ID,
+			Type:      connection.TypeFollow,
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cs, err := fn(currentApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(cs), limitExpire+5; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionCreateSocial(t *testing.T) {
+	var (
+		app, c  = testSetupConnectionController(t)
+		origin  = testConnectionUser(t, app, c)
+		us      = user.List{}
+		results = map[uint64]ImportResult{}
+	)
+
+	for _, id := range []string{"known", "new", "rejected"} {
+		u := testUser()
+		u.SocialIDs = map[string]string{
+			"facebook": id,
+		}
+
+		u, err := c.users.Put(app.Namespace(), u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		us = append(us, u)
+	}
+
+	for _, con := range []*connection.Connection{
+		{ToID: us[0].ID, State: connection.StateConfirmed},
+		{ToID: us[2].ID, State: connection.StateRejected},
+	} {
+		con.Enabled = true
+		con.FromID = origin.ID
+		con.Type = connection.TypeFollow
+
+		_, err := c.connections.Put(app.Namespace(), con)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	is, err := c.CreateSocial(
+		app,
+		origin.ID,
+		connection.TypeFollow,
+		connection.StateConfirmed,
+		user.QueryOptions{
+			SocialIDs: map[string][]string{
+				"facebook": {"known", "new", "rejected"},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, i := range is {
+		results[i.User.ID] = i.Result
+	}
+
+	want := map[uint64]ImportResult{
+		us[0].ID: ImportExisted,
+		us[1].ID: ImportCreated,
+		us[2].ID: ImportFailed,
+	}
+
+	if have := results; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := len(is.Users()), 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConnectionMutuals(t *testing.T) {
+	var (
+		app, c   = testSetupConnectionController(t)
+		origin   = testConnectionUser(t, app, c)
+		target   = testConnectionUser(t, app, c)
+		follower = testConnectionUser(t, app, c)
+		friend   = testConnectionUser(t, app, c)
+		stranger = testConnectionUser(t, app, c)
+	)
+
+	for _, con := range []*connection.Connection{
+		{FromID: origin.ID, ToID: follower.ID, Type: connection.TypeFollow},
+		{FromID: friend.ID, ToID: origin.ID, Type: connection.TypeFriend},
+		{FromID: follower.ID, ToID: target.ID, Type: connection.TypeFollow},
+		{FromID: target.ID, ToID: friend.ID, Type: connection.TypeFriend},
+		{FromID: stranger.ID, ToID: target.ID, Type: connection.TypeFollow},
+	} {
+		con.Enabled = true
+		con.State = connection.StateConfirmed
+
+		_, err := c.connections.Put(app.Namespace(), con)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	feed, err := c.Mutuals(app, origin.ID, target.ID, connection.QueryOptions{
+		Limit: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Users), 2; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	for _, u := range feed.Users {
+		if u.ID != follower.ID && u.ID != friend.ID {
+			t.Errorf("unexpected mutual %d", u.ID)
+		}
+	}
+
+	err = enrichMutualCounts(c.connections, app, origin.ID, user.List{target, stranger})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := target.MutualCount, 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := stranger.MutualCount, 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testConnectionUser(
+	t *testing.T,
+	currentApp *app.App,
+	c *ConnectionController,
+) *user.User {
+	u, err := c.users.Put(currentApp.Namespace(), testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func testSetupConnectionController(
+	t *testing.T,
+) (*app.App, *ConnectionController) {
+	var (
+		a = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		connections = connection.NewMemService()
+		users       = user.NewMemService()
+	)
+
+	return a, NewConnectionController(connections, users)
+}
@@ -115,6 +115,10 @@ func (c *EventController) List(
 			return nil, err
 		}
 
+		if r.isBlocked {
+			return nil, ErrNotFound
+		}
+
 		if r.isFriend || r.isFollowing {
 			opts.Visibilities = append(opts.Visibilities, event.VisibilityConnection)
 		}
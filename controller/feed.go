@@ -10,6 +10,7 @@ import (
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/event"
 	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/timeline"
 	"github.com/tapglue/multiverse/service/user"
 )
 
@@ -175,6 +176,10 @@ func (a affiliations) users() user.List {
 // the list.
 type condition func(int, *event.Event) bool
 
+// conditionObject given an object determines if it should be removed from the
+// list.
+type conditionObject func(*object.Object) bool
+
 // source represents an event generator of varying origin.
 type source func() (event.List, error)
 
@@ -191,20 +196,24 @@ type FeedController struct {
 	connections connection.Service
 	events      event.Service
 	objects     object.Service
+	timelines   timeline.Service
 	users       user.Service
 }
 
-// NewFeedController returns a controller instance.
+// NewFeedController returns a controller instance. The timelines are optional,
+// if nil the feeds are always computed from the social graph.
 func NewFeedController(
 	connections connection.Service,
 	events event.Service,
 	objects object.Service,
+	timelines timeline.Service,
 	users user.Service,
 ) *FeedController {
 	return &FeedController{
 		connections: connections,
 		events:      events,
 		objects:     objects,
+		timelines:   timelines,
 		users:       users,
 	}
 }
@@ -215,40 +224,23 @@ func (c *FeedController) Events(
 	origin uint64,
 	opts event.QueryOptions,
 ) (*Feed, error) {
-	am, err := c.neighbours(currentApp, origin, 0, opts)
+	materialised, err := c.materialised(currentApp, origin)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		neighbours = am.filterFollowers(origin)
-		sources    = []source{
-			sourceConnection(append(am.followers(origin), am.friends(origin)...), opts),
-			sourceGlobal(c.events, currentApp, opts),
-			sourceNeighbours(
-				c.events,
-				currentApp,
-				opts,
-				am.filterFollowers(origin).userIDs()...,
-			),
-			sourceTarget(c.events, currentApp, origin, opts),
-		}
-	)
-
-	us := am.users()
-
-	for _, u := range neighbours {
-		a, err := c.neighbours(currentApp, u.ID, origin, opts)
-		if err != nil {
-			return nil, err
-		}
-
-		cs := append(a.followings(u.ID), a.friends(u.ID)...)
+	am, sources, err := c.eventSources(currentApp, origin, materialised, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		sources = append(sources, sourceConnection(cs, opts))
-		us = append(us, am.users()...)
+	hs, err := hiddenIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
 	}
 
+	us := am.users()
+
 	es, err := collect(sources...)
 	if err != nil {
 		return nil, err
@@ -259,6 +251,8 @@ func (c *FeedController) Events(
 		return nil, err
 	}
 
+	ps = filterPosts(ps, conditionObjectHidden(origin), conditionObjectOwner(hs))
+
 	err = enrichCounts(c.events, c.objects, currentApp, ps)
 	if err != nil {
 		return nil, err
@@ -274,14 +268,11 @@ func (c *FeedController) Events(
 	es = filter(
 		es,
 		conditionDuplicate(),
+		conditionOwnerHidden(hs),
 		conditionPostMissing(pm),
 	)
 
-	sort.Sort(es)
-
-	if len(es) > opts.Limit {
-		es = es[:opts.Limit]
-	}
+	es = limitEvents(es, opts)
 
 	um, err := fillupUsersForEvents(c.users, currentApp, origin, us.ToMap(), es)
 	if err != nil {
@@ -300,6 +291,11 @@ func (c *FeedController) Events(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, um.ToList())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Feed{
 		Events:  es,
 		PostMap: pm,
@@ -315,40 +311,23 @@ func (c *FeedController) News(
 	eventOpts event.QueryOptions,
 	postOpts object.QueryOptions,
 ) (*Feed, error) {
-	am, err := c.neighbours(currentApp, origin, 0, eventOpts)
+	materialised, err := c.materialised(currentApp, origin)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		neighbours = am.filterFollowers(origin)
-		sources    = []source{
-			sourceConnection(append(am.followers(origin), am.friends(origin)...), eventOpts),
-			sourceGlobal(c.events, currentApp, eventOpts),
-			sourceNeighbours(
-				c.events,
-				currentApp,
-				eventOpts,
-				am.filterFollowers(origin).userIDs()...,
-			),
-			sourceTarget(c.events, currentApp, origin, eventOpts),
-		}
-	)
-
-	us := am.users()
-
-	for _, u := range neighbours {
-		a, err := c.neighbours(currentApp, u.ID, origin, eventOpts)
-		if err != nil {
-			return nil, err
-		}
-
-		cs := append(a.followings(u.ID), a.friends(u.ID)...)
+	am, sources, err := c.eventSources(currentApp, origin, materialised, eventOpts)
+	if err != nil {
+		return nil, err
+	}
 
-		sources = append(sources, sourceConnection(cs, eventOpts))
-		us = append(us, am.users()...)
+	hs, err := hiddenIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
 	}
 
+	us := am.users()
+
 	es, err := collect(sources...)
 	if err != nil {
 		return nil, err
@@ -359,6 +338,8 @@ func (c *FeedController) News(
 		return nil, err
 	}
 
+	ps = filterPosts(ps, conditionObjectHidden(origin), conditionObjectOwner(hs))
+
 	err = enrichCounts(c.events, c.objects, currentApp, ps)
 	if err != nil {
 		return nil, err
@@ -374,14 +355,11 @@ func (c *FeedController) News(
 	es = filter(
 		es,
 		conditionDuplicate(),
+		conditionOwnerHidden(hs),
 		conditionPostMissing(pm),
 	)
 
-	sort.Sort(es)
-
-	if len(es) > eventOpts.Limit {
-		es = es[:eventOpts.Limit]
-	}
+	es = limitEvents(es, eventOpts)
 
 	um, err := fillupUsersForEvents(c.users, currentApp, origin, us.ToMap(), es)
 	if err != nil {
@@ -393,7 +371,7 @@ func (c *FeedController) News(
 		return nil, err
 	}
 
-	ps, err = c.connectionPosts(currentApp, postOpts, neighbours.userIDs()...)
+	ps, err = c.neighbourPosts(currentApp, origin, materialised, am, postOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -404,12 +382,9 @@ func (c *FeedController) News(
 	}
 
 	ps = append(ps, gs...)
+	ps = filterPosts(ps, conditionObjectHidden(origin), conditionObjectOwner(hs))
 
-	sort.Sort(ps)
-
-	if len(ps) > postOpts.Limit {
-		ps = ps[:postOpts.Limit]
-	}
+	ps = limitPosts(ps, postOpts)
 
 	um, err = fillupUsersForPosts(c.users, currentApp, origin, um, gs)
 	if err != nil {
@@ -442,6 +417,11 @@ func (c *FeedController) News(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, um.ToList())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Feed{
 		Events:  es,
 		Posts:   ps,
@@ -482,12 +462,23 @@ func (c *FeedController) NotificationsSelf(
 		return nil, err
 	}
 
-	sort.Sort(es)
+	hs, err := hiddenIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	es = filter(es, conditionOwnerHidden(hs))
 
-	if len(es) > opts.Limit {
-		es = es[:opts.Limit]
+	ms, err := mentionedPosts(c.connections, c.objects, currentApp, origin, ps, es)
+	if err != nil {
+		return nil, err
 	}
 
+	ps = append(ps, ms...)
+
+	es = filter(es, conditionMentionMissing(ps.toMap()))
+	es = limitEvents(es, opts)
+
 	um, err := fillupUsersForEvents(c.users, currentApp, origin, fs.users().ToMap(), es)
 	if err != nil {
 		return nil, err
@@ -510,6 +501,11 @@ func (c *FeedController) NotificationsSelf(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, um.ToList())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Feed{
 		Events:  es,
 		PostMap: ps.toMap(),
@@ -523,17 +519,30 @@ func (c *FeedController) Posts(
 	origin uint64,
 	opts object.QueryOptions,
 ) (*Feed, error) {
-	am, err := c.neighbours(currentApp, origin, 0, event.QueryOptions{
-		Before: opts.Before,
-		Limit:  opts.Limit,
-	})
+	materialised, err := c.materialised(currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	hs, err := hiddenIDs(c.connections, currentApp, origin)
 	if err != nil {
 		return nil, err
 	}
 
-	neighbours := am.filterFollowers(origin)
+	am := affiliations{}
 
-	ps, err := c.connectionPosts(currentApp, opts, neighbours.userIDs()...)
+	if !materialised {
+		am, err = c.neighbours(currentApp, origin, 0, event.QueryOptions{
+			After:  opts.After,
+			Before: opts.Before,
+			Limit:  opts.Limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ps, err := c.neighbourPosts(currentApp, origin, materialised, am, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -544,12 +553,9 @@ func (c *FeedController) Posts(
 	}
 
 	ps = append(ps, gs...)
+	ps = filterPosts(ps, conditionObjectHidden(origin), conditionObjectOwner(hs))
 
-	sort.Sort(ps)
-
-	if len(ps) > opts.Limit {
-		ps = ps[:opts.Limit]
-	}
+	ps = limitPosts(ps, opts)
 
 	um, err := fillupUsersForPosts(c.users, currentApp, origin, am.users().ToMap(), ps)
 	if err != nil {
@@ -573,6 +579,11 @@ func (c *FeedController) Posts(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, um.ToList())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Feed{
 		Posts:   ps,
 		UserMap: um,
@@ -604,6 +615,57 @@ func (c *FeedController) connectionPosts(
 	return postsFromObjects(os), nil
 }
 
+// eventSources returns the sources for the events of the social graph of the
+// origin. Materialised timelines are read directly, otherwise the graph is
+// walked and the returned affiliations hold the neighbours of the origin.
+func (c *FeedController) eventSources(
+	currentApp *app.App,
+	origin uint64,
+	materialised bool,
+	opts event.QueryOptions,
+) (affiliations, []source, error) {
+	if materialised {
+		return affiliations{}, []source{
+			sourceGlobal(c.events, currentApp, opts),
+			sourceTarget(c.events, currentApp, origin, opts),
+			sourceTimeline(c.events, c.timelines, currentApp, origin, opts),
+		}, nil
+	}
+
+	am, err := c.neighbours(currentApp, origin, 0, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		neighbours = am.filterFollowers(origin)
+		sources    = []source{
+			sourceConnection(append(am.followers(origin), am.friends(origin)...), opts),
+			sourceGlobal(c.events, currentApp, opts),
+			sourceNeighbours(
+				c.events,
+				currentApp,
+				opts,
+				neighbours.userIDs()...,
+			),
+			sourceTarget(c.events, currentApp, origin, opts),
+		}
+	)
+
+	for _, u := range neighbours {
+		a, err := c.neighbours(currentApp, u.ID, origin, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cs := append(a.followings(u.ID), a.friends(u.ID)...)
+
+		sources = append(sources, sourceConnection(cs, opts))
+	}
+
+	return am, sources, nil
+}
+
 func (c *FeedController) globalPosts(
 	currentApp *app.App,
 	opts object.QueryOptions,
@@ -622,6 +684,72 @@ func (c *FeedController) globalPosts(
 	return postsFromObjects(os), nil
 }
 
+// materialised reports if the timeline of the origin is maintained by the
+// fan-out. Timelines are only used once all connections of the user existing
+// before the fan-out are backfilled, until then the feed is computed from the
+// social graph.
+func (c *FeedController) materialised(
+	currentApp *app.App,
+	origin uint64,
+) (bool, error) {
+	if c.timelines == nil {
+		return false, nil
+	}
+
+	return c.timelines.Backfilled(currentApp.Namespace(), origin)
+}
+
+// neighbourPosts returns the posts of the social graph of the origin, either
+// from its materialised timeline or from the given affiliations.
+func (c *FeedController) neighbourPosts(
+	currentApp *app.App,
+	origin uint64,
+	materialised bool,
+	am affiliations,
+	opts object.QueryOptions,
+) (PostList, error) {
+	if !materialised {
+		return c.connectionPosts(
+			currentApp,
+			opts,
+			am.filterFollowers(origin).userIDs()...,
+		)
+	}
+
+	es, err := c.timelines.Query(currentApp.Namespace(), origin, timeline.QueryOptions{
+		After:  opts.After,
+		Before: opts.Before,
+		Limit:  opts.Limit,
+		Types: []timeline.Type{
+			timeline.TypePost,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := es.ItemIDs(timeline.TypePost)
+
+	if len(ids) == 0 {
+		return PostList{}, nil
+	}
+
+	opts.IDs = ids
+	opts.Owned = &defaultOwned
+	opts.Types = []string{TypePost}
+	opts.Visibilities = []object.Visibility{
+		object.VisibilityConnection,
+		object.VisibilityPublic,
+	}
+
+	os, err := c.objects.Query(currentApp.Namespace(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return postsFromObjects(os), nil
+}
+
 func (c *FeedController) neighbours(
 	currentApp *app.App,
 	origin uint64,
@@ -791,6 +919,48 @@ func conditionDuplicate() condition {
 	}
 }
 
+// conditionObjectHidden reports true when moderation hides the object from
+// everybody but its owner.
+func conditionObjectHidden(origin uint64) conditionObject {
+	return func(o *object.Object) bool {
+		return o.OwnerID != origin && isHidden(o)
+	}
+}
+
+// conditionObjectOwner reports true when the object is owned by one of the
+// hidden users.
+func conditionObjectOwner(ids map[uint64]struct{}) conditionObject {
+	return func(o *object.Object) bool {
+		_, ok := ids[o.OwnerID]
+
+		return ok
+	}
+}
+
+// conditionOwnerHidden reports true when the Event was created by one of the
+// hidden users.
+func conditionOwnerHidden(ids map[uint64]struct{}) condition {
+	return func(idx int, event *event.Event) bool {
+		_, ok := ids[event.UserID]
+
+		return ok
+	}
+}
+
+// conditionMentionMissing reports true when the post a mention event refers to
+// can't be found in the given ids.
+func conditionMentionMissing(pm PostMap) condition {
+	return func(idx int, event *event.Event) bool {
+		if event.Type != TypeMention {
+			return false
+		}
+
+		_, ok := pm[event.ObjectID]
+
+		return !ok
+	}
+}
+
 // conditionPostMissing reports true when the ObjectID of the event can't be
 // found in the given ids.
 func conditionPostMissing(pm PostMap) condition {
@@ -930,6 +1100,79 @@ func filter(events event.List, cs ...condition) event.List {
 	return es
 }
 
+// filterObjects removes the objects for which one of the conditions is true.
+func filterObjects(objects object.List, cs ...conditionObject) object.List {
+	os := object.List{}
+
+	for _, o := range objects {
+		if !keepObject(o, cs...) {
+			continue
+		}
+
+		os = append(os, o)
+	}
+
+	return os
+}
+
+// filterPosts removes the posts for which one of the conditions is true.
+func filterPosts(posts PostList, cs ...conditionObject) PostList {
+	ps := PostList{}
+
+	for _, post := range posts {
+		if !keepObject(post.Object, cs...) {
+			continue
+		}
+
+		ps = append(ps, post)
+	}
+
+	return ps
+}
+
+func keepObject(o *object.Object, cs ...conditionObject) bool {
+	for _, c := range cs {
+		if c(o) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// limitEvents sorts the events and cuts them to the limit. When paging forward
+// the events closest to the after cursor are kept, so consecutive pages don't
+// skip over any.
+func limitEvents(es event.List, opts event.QueryOptions) event.List {
+	sort.Sort(es)
+
+	if len(es) <= opts.Limit {
+		return es
+	}
+
+	if !opts.After.IsZero() {
+		return es[len(es)-opts.Limit:]
+	}
+
+	return es[:opts.Limit]
+}
+
+// limitPosts sorts the posts and cuts them to the limit. When paging forward
+// the posts closest to the after cursor are kept.
+func limitPosts(ps PostList, opts object.QueryOptions) PostList {
+	sort.Sort(ps)
+
+	if len(ps) <= opts.Limit {
+		return ps
+	}
+
+	if !opts.After.IsZero() {
+		return ps[len(ps)-opts.Limit:]
+	}
+
+	return ps[:opts.Limit]
+}
+
 // sourceComment creates comment events for the given posts.
 func sourceComment(
 	objects object.Service,
@@ -1155,6 +1398,138 @@ func sourceTarget(
 	}
 }
 
+// sourceTimeline returns the events and connections materialised in the
+// timeline of the origin.
+func sourceTimeline(
+	events event.Service,
+	timelines timeline.Service,
+	currentApp *app.App,
+	origin uint64,
+	opts event.QueryOptions,
+) source {
+	return func() (event.List, error) {
+		ts, err := timelines.Query(currentApp.Namespace(), origin, timeline.QueryOptions{
+			After:  opts.After,
+			Before: opts.Before,
+			Limit:  opts.Limit,
+			Types: []timeline.Type{
+				timeline.TypeEvent,
+				timeline.TypeFollow,
+				timeline.TypeFriend,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		es := event.List{}
+
+		if ids := ts.ItemIDs(timeline.TypeEvent); len(ids) > 0 {
+			opts.Enabled = &defaultEnabled
+			opts.IDs = ids
+			opts.Visibilities = []event.Visibility{
+				event.VisibilityConnection,
+				event.VisibilityPublic,
+			}
+
+			es, err = events.Query(currentApp.Namespace(), opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, t := range ts {
+			if t.Type != timeline.TypeFollow && t.Type != timeline.TypeFriend {
+				continue
+			}
+
+			id, err := flake.NextID("connection-events")
+			if err != nil {
+				return nil, err
+			}
+
+			ty := event.TypeFollow
+
+			if t.Type == timeline.TypeFriend {
+				ty = event.TypeFriend
+			}
+
+			es = append(es, &event.Event{
+				Enabled: true,
+				ID:      id,
+				Owned:   true,
+				Target: &event.Target{
+					ID:   strconv.FormatUint(t.TargetID, 10),
+					Type: event.TargetUser,
+				},
+				Type:       ty,
+				UserID:     t.OwnerID,
+				Visibility: event.VisibilityPrivate,
+				CreatedAt:  t.CreatedAt,
+				UpdatedAt:  t.CreatedAt,
+			})
+		}
+
+		return es, nil
+	}
+}
+
+// mentionedPosts returns the posts referenced by mention events which are
+// visible for the origin and not already part of the given posts.
+func mentionedPosts(
+	connections connection.Service,
+	objects object.Service,
+	currentApp *app.App,
+	origin uint64,
+	known PostList,
+	es event.List,
+) (PostList, error) {
+	var (
+		ids = []uint64{}
+		pm  = known.toMap()
+	)
+
+	for _, e := range es {
+		if _, ok := pm[e.ObjectID]; ok || e.Type != TypeMention {
+			continue
+		}
+
+		ids = append(ids, e.ObjectID)
+	}
+
+	if len(ids) == 0 {
+		return PostList{}, nil
+	}
+
+	os, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+		IDs:   ids,
+		Owned: &defaultOwned,
+		Types: []string{
+			TypePost,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps := PostList{}
+
+	for _, o := range os {
+		err := isPostVisible(connections, currentApp, o, origin)
+		if err != nil {
+			if err == ErrNotFound {
+				continue
+			}
+
+			return nil, err
+		}
+
+		ps = append(ps, &Post{Object: o})
+	}
+
+	return ps, nil
+}
+
 func userPosts(
 	objects object.Service,
 	currentApp *app.App,
@@ -9,6 +9,7 @@ import (
 
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
 	"github.com/tapglue/multiverse/service/user"
 )
 
@@ -140,6 +141,78 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestLimitEvents(t *testing.T) {
+	var (
+		now = time.Now().UTC()
+		es  = event.List{}
+	)
+
+	for i := 0; i < 10; i++ {
+		es = append(es, &event.Event{
+			ID:        uint64(i + 1),
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	before := limitEvents(append(event.List{}, es...), event.QueryOptions{
+		Limit: 3,
+	})
+
+	if have, want := len(before), 3; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := before[0].ID, uint64(10); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	after := limitEvents(append(event.List{}, es...), event.QueryOptions{
+		After: now,
+		Limit: 3,
+	})
+
+	if have, want := len(after), 3; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := after[0].ID, uint64(3); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := after[2].ID, uint64(1); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestLimitPosts(t *testing.T) {
+	var (
+		now = time.Now().UTC()
+		ps  = PostList{}
+	)
+
+	for i := 0; i < 10; i++ {
+		ps = append(ps, &Post{
+			Object: &object.Object{
+				ID:        uint64(i + 1),
+				CreatedAt: now.Add(time.Duration(i) * time.Minute),
+			},
+		})
+	}
+
+	after := limitPosts(ps, object.QueryOptions{
+		After: now,
+		Limit: 4,
+	})
+
+	if have, want := len(after), 4; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := after[0].ID, uint64(4); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func TestSourceConnection(t *testing.T) {
 	var (
 		from = uint64(rand.Int63())
@@ -218,6 +218,16 @@ func (c *LikeController) List(
 			TypeLike,
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := blockedIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	es = filter(es, conditionOwnerHidden(bs))
 
 	um, err := user.MapFromIDs(c.users, currentApp.Namespace(), es.UserIDs()...)
 	if err != nil {
@@ -258,6 +268,10 @@ func LikesUser(
 			return nil, err
 		}
 
+		if r.isBlocked {
+			return nil, ErrNotFound
+		}
+
 		opts.Visibilities = eventVisibilitiesForRelation(r)
 
 		ls, err := events.Query(currentApp.Namespace(), opts)
@@ -270,6 +284,8 @@ func LikesUser(
 			return nil, err
 		}
 
+		ps = filterPosts(ps, conditionObjectHidden(origin))
+
 		ls = filter(ls, conditionPostMissing(ps.toMap()))
 
 		sort.Sort(ls)
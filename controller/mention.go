@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+const (
+	// MetadataCommentID references the comment of a mention event, mentions in
+	// posts don't carry it.
+	MetadataCommentID = "comment_id"
+
+	// TypeMention identifies an event as the mention of a user in a post or
+	// comment.
+	TypeMention = "tg_mention"
+)
+
+// mentionPattern matches @username which is not part of a word or email.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_.\-]+)`)
+
+// parseMentions returns the usernames mentioned in the text attachments.
+func parseMentions(as []object.Attachment) []string {
+	var (
+		names = []string{}
+		seen  = map[string]struct{}{}
+	)
+
+	for _, a := range as {
+		if a.Type != object.AttachmentTypeText {
+			continue
+		}
+
+		for _, content := range a.Contents {
+			for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+				// Punctuation at the end of a sentence is not part of the username.
+				name := strings.TrimRight(m[1], ".-")
+
+				if _, ok := seen[name]; ok || name == "" {
+					continue
+				}
+
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// resolveMentions returns the ids of the declared users together with the
+// ones of the usernames mentioned in the attachments. Declared users have to
+// exist, while mentions of unknown usernames are ignored as they might not be
+// meant as a mention. Users blocked by or blocking the author and users who
+// can't see the post are dropped.
+func resolveMentions(
+	connections connection.Service,
+	users user.Service,
+	currentApp *app.App,
+	author uint64,
+	post *object.Object,
+	declared []uint64,
+	as []object.Attachment,
+) ([]uint64, error) {
+	var (
+		ids  = []uint64{}
+		seen = map[uint64]struct{}{}
+	)
+
+	if len(declared) > 0 {
+		us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+			Enabled: &defaultEnabled,
+			IDs:     declared,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		um := us.ToMap()
+
+		for _, id := range declared {
+			if _, ok := um[id]; !ok {
+				return nil, wrapError(ErrInvalidEntity, "mentioned user %d not found", id)
+			}
+
+			if _, ok := seen[id]; ok {
+				continue
+			}
+
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+
+	names := parseMentions(as)
+
+	if len(names) > 0 {
+		us, err := users.Query(currentApp.Namespace(), user.QueryOptions{
+			Enabled:   &defaultEnabled,
+			Usernames: names,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range us {
+			if _, ok := seen[u.ID]; ok {
+				continue
+			}
+
+			seen[u.ID] = struct{}{}
+			ids = append(ids, u.ID)
+		}
+	}
+
+	return filterMentions(connections, currentApp, author, post, ids)
+}
+
+// filterMentions drops the ids of users who are blocked by or block the
+// author, as well as the ones the post is not visible to.
+func filterMentions(
+	connections connection.Service,
+	currentApp *app.App,
+	author uint64,
+	post *object.Object,
+	ids []uint64,
+) ([]uint64, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	blocked, err := blockedIDs(connections, currentApp, author)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := []uint64{}
+
+	for _, id := range ids {
+		if _, ok := blocked[id]; ok {
+			continue
+		}
+
+		err := isPostVisible(connections, currentApp, post, id)
+		if err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		fs = append(fs, id)
+	}
+
+	return fs, nil
+}
+
+// notifyMentions emits a mention event for every user newly mentioned in the
+// post or comment and disables the events of users no longer mentioned.
+func notifyMentions(
+	events event.Service,
+	currentApp *app.App,
+	o *object.Object,
+	old []uint64,
+) error {
+	var (
+		postID   = o.ID
+		metadata = event.Metadata{}
+	)
+
+	if o.Type == TypeComment {
+		postID = o.ObjectID
+		metadata[MetadataCommentID] = strconv.FormatUint(o.ID, 10)
+	}
+
+	removed := []string{}
+
+	for _, id := range old {
+		if !inIDs(id, o.Mentions) {
+			removed = append(removed, strconv.FormatUint(id, 10))
+		}
+	}
+
+	if len(removed) > 0 {
+		es, err := events.Query(currentApp.Namespace(), event.QueryOptions{
+			Enabled: &defaultEnabled,
+			ObjectIDs: []uint64{
+				postID,
+			},
+			Owned:     &defaultOwned,
+			TargetIDs: removed,
+			TargetTypes: []string{
+				event.TargetUser,
+			},
+			Types: []string{
+				TypeMention,
+			},
+			UserIDs: []uint64{
+				o.OwnerID,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range es {
+			if e.Metadata[MetadataCommentID] != metadata[MetadataCommentID] {
+				continue
+			}
+
+			e.Enabled = false
+
+			_, err = events.Put(currentApp.Namespace(), e)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, id := range o.Mentions {
+		if id == o.OwnerID || inIDs(id, old) {
+			continue
+		}
+
+		e := &event.Event{
+			Enabled:  true,
+			ObjectID: postID,
+			Owned:    true,
+			Target: &event.Target{
+				ID:   strconv.FormatUint(id, 10),
+				Type: event.TargetUser,
+			},
+			Type:       TypeMention,
+			UserID:     o.OwnerID,
+			Visibility: event.VisibilityPrivate,
+		}
+
+		if len(metadata) > 0 {
+			e.Metadata = metadata
+		}
+
+		_, err := events.Put(currentApp.Namespace(), e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func inIDs(id uint64, ids []uint64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
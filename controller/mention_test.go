@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+func TestParseMentions(t *testing.T) {
+	cases := map[string][]string{
+		"no mentions here":                  {},
+		"@alice was here":                   {"alice"},
+		"thanks @alice and @bob.":           {"alice", "bob"},
+		"@alice @alice":                     {"alice"},
+		"mail alice@example.com":            {},
+		"(@carol_1) @dave.smith, @@eve":     {"carol_1", "dave.smith"},
+		"ping @jürgen":                      {"jürgen"},
+		"trailing @ is not a mention @ all": {},
+	}
+
+	for text, want := range cases {
+		have := parseMentions([]object.Attachment{
+			object.NewTextAttachment("body", object.Contents{
+				"en": text,
+			}),
+		})
+
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("%q: have %v, want %v", text, have, want)
+		}
+	}
+
+	have := parseMentions([]object.Attachment{
+		object.NewURLAttachment("link", object.Contents{
+			"en": "http://example.com/@alice",
+		}),
+	})
+
+	if want := []string{}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestPostControllerCreateMention(t *testing.T) {
+	var (
+		currentApp, owner, c = testSetupPostController(t)
+		alice                = testMentionUser(t, c.users, currentApp.Namespace(), "alice")
+		bob                  = testMentionUser(t, c.users, currentApp.Namespace(), "bob")
+		post                 = testPost(owner.ID)
+	)
+
+	post.Attachments = []object.Attachment{
+		object.NewTextAttachment("body", object.Contents{
+			"en": "Lunch with @alice and @unknown.",
+		}),
+	}
+	post.Mentions = []uint64{bob.ID}
+
+	created, err := c.Create(currentApp, Origin{UserID: owner.ID}, post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := created.Mentions, []uint64{bob.ID, alice.ID}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	es := testMentionEvents(t, c.events, currentApp.Namespace(), created.ID)
+
+	if have, want := len(es), 2; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	update := testPost(owner.ID)
+	update.Attachments = []object.Attachment{
+		object.NewTextAttachment("body", object.Contents{
+			"en": "Lunch with @alice.",
+		}),
+	}
+
+	updated, err := c.Update(currentApp, Origin{UserID: owner.ID}, created.ID, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := updated.Mentions, []uint64{alice.ID}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	es = testMentionEvents(t, c.events, currentApp.Namespace(), created.ID)
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := es[0].Target.ID, strconv.FormatUint(alice.ID, 10); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	post = testPost(owner.ID)
+	post.Mentions = []uint64{bob.ID + 1}
+
+	_, err = c.Create(currentApp, Origin{UserID: owner.ID}, post)
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestPostControllerCreateMentionRestricted(t *testing.T) {
+	var (
+		currentApp, owner, c = testSetupPostController(t)
+		alice                = testMentionUser(t, c.users, currentApp.Namespace(), "alice")
+		bob                  = testMentionUser(t, c.users, currentApp.Namespace(), "bob")
+		carol                = testMentionUser(t, c.users, currentApp.Namespace(), "carol")
+		post                 = testPost(owner.ID)
+	)
+
+	for _, con := range []*connection.Connection{
+		{FromID: alice.ID, ToID: owner.ID, Type: connection.TypeBlock},
+		{FromID: bob.ID, ToID: owner.ID, Type: connection.TypeFollow},
+	} {
+		con.Enabled = true
+		con.State = connection.StateConfirmed
+
+		_, err := c.connections.Put(currentApp.Namespace(), con)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post.Attachments = []object.Attachment{
+		object.NewTextAttachment("body", object.Contents{
+			"en": "Lunch with @alice, @bob and @carol.",
+		}),
+	}
+	post.Visibility = object.VisibilityConnection
+
+	created, err := c.Create(currentApp, Origin{UserID: owner.ID}, post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := created.Mentions, []uint64{bob.ID}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	es := testMentionEvents(t, c.events, currentApp.Namespace(), created.ID)
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := es[0].Target.ID, strconv.FormatUint(bob.ID, 10); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	update := testPost(owner.ID)
+	update.Attachments = post.Attachments
+
+	updated, err := c.Update(currentApp, Origin{UserID: owner.ID}, created.ID, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(updated.Mentions), 2; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	for _, id := range []uint64{bob.ID, carol.ID} {
+		if !inIDs(id, updated.Mentions) {
+			t.Errorf("have %v, want %v", updated.Mentions, id)
+		}
+	}
+}
+
+func TestCommentControllerCreateMention(t *testing.T) {
+	var (
+		currentApp, owner, c = testSetupCommentController(t)
+		alice                = testMentionUser(t, c.users, currentApp.Namespace(), "alice")
+	)
+
+	post, err := c.objects.Put(currentApp.Namespace(), testPost(owner.ID).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := testComment(owner.ID, post)
+	comment.Attachments = []object.Attachment{
+		object.NewTextAttachment("content", object.Contents{
+			"en": "@alice have a look",
+		}),
+	}
+
+	created, err := c.Create(currentApp, Origin{UserID: owner.ID}, post.ID, comment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es := testMentionEvents(t, c.events, currentApp.Namespace(), post.ID)
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := es[0].Target.ID, strconv.FormatUint(alice.ID, 10); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := es[0].Metadata[MetadataCommentID], strconv.FormatUint(created.ID, 10); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestConditionMentionMissing(t *testing.T) {
+	es := event.List{
+		{ObjectID: 1, Type: TypeMention},
+		{ObjectID: 2, Type: TypeMention},
+		{ObjectID: 3, Type: TypeLike},
+	}
+
+	es = filter(es, conditionMentionMissing(PostMap{1: {}}))
+
+	if have, want := len(es), 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testMentionEvents(
+	t *testing.T,
+	events event.Service,
+	namespace string,
+	postID uint64,
+) event.List {
+	es, err := events.Query(namespace, event.QueryOptions{
+		Enabled: &defaultEnabled,
+		ObjectIDs: []uint64{
+			postID,
+		},
+		Types: []string{
+			TypeMention,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return es
+}
+
+func testMentionUser(
+	t *testing.T,
+	users user.Service,
+	namespace, username string,
+) *user.User {
+	u, err := users.Put(namespace, &user.User{
+		Enabled:  true,
+		Email:    username + "@example.com",
+		Password: "secret",
+		Username: username,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
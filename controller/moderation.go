@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+// TypeReport identifies an object as a report of a post or comment.
+const TypeReport = "tg_report"
+
+const attachmentReason = "reason"
+
+// ModerationFeed is the composite answer for the moderation queue.
+type ModerationFeed struct {
+	Objects      object.List
+	ReportCounts map[uint64]int
+	UserMap      user.Map
+}
+
+// ModerationListFunc returns the reported content waiting for review.
+type ModerationListFunc func(
+	currentApp *app.App,
+	origin Origin,
+	opts object.QueryOptions,
+) (*ModerationFeed, error)
+
+// ModerationList returns the reported content waiting for review.
+func ModerationList(
+	objects object.Service,
+	users user.Service,
+) ModerationListFunc {
+	return func(
+		currentApp *app.App,
+		origin Origin,
+		opts object.QueryOptions,
+	) (*ModerationFeed, error) {
+		if err := constrainModeration(origin); err != nil {
+			return nil, err
+		}
+
+		os, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+			Before: opts.Before,
+			Limit:  opts.Limit,
+			Owned:  &defaultOwned,
+			States: []object.State{
+				object.StatePending,
+			},
+			Types: []string{
+				TypeComment,
+				TypePost,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(os) == 0 {
+			return &ModerationFeed{
+				Objects:      object.List{},
+				ReportCounts: map[uint64]int{},
+				UserMap:      user.Map{},
+			}, nil
+		}
+
+		ids := []uint64{}
+
+		for _, o := range os {
+			ids = append(ids, o.ID)
+		}
+
+		rs, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+			ObjectIDs: ids,
+			Owned:     &defaultOwned,
+			Types: []string{
+				TypeReport,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		counts := map[uint64]int{}
+
+		for _, r := range rs {
+			counts[r.ObjectID]++
+		}
+
+		um, err := user.MapFromIDs(users, currentApp.Namespace(), os.OwnerIDs()...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ModerationFeed{
+			Objects:      os,
+			ReportCounts: counts,
+			UserMap:      um,
+		}, nil
+	}
+}
+
+// ModerationReviewFunc concludes the review of reported content, confirmed
+// content is visible again while declined content stays hidden.
+type ModerationReviewFunc func(
+	currentApp *app.App,
+	origin Origin,
+	objectID uint64,
+	state object.State,
+) (*object.Object, error)
+
+// ModerationReview concludes the review of reported content.
+func ModerationReview(objects object.Service) ModerationReviewFunc {
+	return func(
+		currentApp *app.App,
+		origin Origin,
+		objectID uint64,
+		state object.State,
+	) (*object.Object, error) {
+		if err := constrainModeration(origin); err != nil {
+			return nil, err
+		}
+
+		switch state {
+		case object.StateConfirmed, object.StateDeclined:
+			// valid
+		default:
+			return nil, wrapError(ErrInvalidEntity, "unsupported state %d", state)
+		}
+
+		os, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+			ID:    &objectID,
+			Owned: &defaultOwned,
+			Types: []string{
+				TypeComment,
+				TypePost,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(os) != 1 {
+			return nil, ErrNotFound
+		}
+
+		o := os[0]
+		o.Private = &object.Private{
+			State:   state,
+			Visible: state == object.StateConfirmed,
+		}
+
+		return objects.Put(currentApp.Namespace(), o)
+	}
+}
+
+// ReportCreateFunc flags a post, or one of its comments if the comment id is
+// given, as inappropriate on behalf of the origin.
+type ReportCreateFunc func(
+	currentApp *app.App,
+	origin Origin,
+	postID, commentID uint64,
+	reason string,
+) (*object.Object, error)
+
+// ReportCreate stores a report for the post or comment and puts it into the
+// moderation queue. Once the reports reach the threshold of the App the content
+// is hidden until reviewed.
+func ReportCreate(
+	connections connection.Service,
+	objects object.Service,
+) ReportCreateFunc {
+	return func(
+		currentApp *app.App,
+		origin Origin,
+		postID, commentID uint64,
+		reason string,
+	) (*object.Object, error) {
+		ps, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+			ID:    &postID,
+			Owned: &defaultOwned,
+			Types: []string{
+				TypePost,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ps) != 1 {
+			return nil, ErrNotFound
+		}
+
+		post := ps[0]
+
+		if err := isPostVisible(connections, currentApp, post, origin.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := constrainReportRestriction(post.Restrictions); err != nil {
+			return nil, err
+		}
+
+		target := post
+
+		if commentID != 0 {
+			cs, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+				ID: &commentID,
+				ObjectIDs: []uint64{
+					postID,
+				},
+				Owned: &defaultOwned,
+				Types: []string{
+					TypeComment,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if len(cs) != 1 || conditionObjectHidden(origin.UserID)(cs[0]) {
+				return nil, ErrNotFound
+			}
+
+			target = cs[0]
+		}
+
+		if target.OwnerID == origin.UserID {
+			return nil, wrapError(ErrInvalidEntity, "own content can't be reported")
+		}
+
+		rs, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+			ObjectIDs: []uint64{
+				target.ID,
+			},
+			Owned: &defaultOwned,
+			OwnerIDs: []uint64{
+				origin.UserID,
+			},
+			Types: []string{
+				TypeReport,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Reporting the same content again should be idempotent.
+		if len(rs) > 0 {
+			return rs[0], nil
+		}
+
+		report := &object.Object{
+			ObjectID:   target.ID,
+			Owned:      true,
+			OwnerID:    origin.UserID,
+			Type:       TypeReport,
+			Visibility: object.VisibilityPrivate,
+		}
+
+		if reason != "" {
+			report.Attachments = []object.Attachment{
+				object.NewTextAttachment(attachmentReason, object.Contents{
+					object.DefaultLanguage: reason,
+				}),
+			}
+		}
+
+		if err := report.Validate(); err != nil {
+			return nil, wrapError(ErrInvalidEntity, "invalid Report: %s", err)
+		}
+
+		report, err = objects.Put(currentApp.Namespace(), report)
+		if err != nil {
+			return nil, err
+		}
+
+		err = moderate(objects, currentApp, target)
+		if err != nil {
+			return nil, err
+		}
+
+		return report, nil
+	}
+}
+
+func constrainModeration(origin Origin) error {
+	if !origin.IsBackend() {
+		return wrapError(
+			ErrUnauthorized,
+			"moderation only allowed for backend integration",
+		)
+	}
+
+	return nil
+}
+
+func constrainReportRestriction(restrictions *object.Restrictions) error {
+	if restrictions != nil && restrictions.Report {
+		return wrapError(
+			ErrUnauthorized,
+			"reports not allowed for this post",
+		)
+	}
+
+	return nil
+}
+
+// isHidden reports if the object was hidden by moderation.
+func isHidden(o *object.Object) bool {
+	return o.IsHidden()
+}
+
+// moderate puts reported content into the moderation queue and hides it once
+// the report threshold of the App is reached. Content which already passed a
+// review is left untouched.
+func moderate(
+	objects object.Service,
+	currentApp *app.App,
+	o *object.Object,
+) error {
+	if o.Private != nil && o.Private.State != object.StatePending {
+		return nil
+	}
+
+	rs, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+		ObjectIDs: []uint64{
+			o.ID,
+		},
+		Owned: &defaultOwned,
+		Types: []string{
+			TypeReport,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	private := &object.Private{
+		State:   object.StatePending,
+		Visible: true,
+	}
+
+	if o.Private != nil {
+		private.Visible = o.Private.Visible
+	}
+
+	if currentApp.ReportThreshold > 0 && len(rs) >= currentApp.ReportThreshold {
+		private.Visible = false
+	}
+
+	if o.Private != nil && *o.Private == *private {
+		return nil
+	}
+
+	o.Private = private
+
+	_, err = objects.Put(currentApp.Namespace(), o)
+
+	return err
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+func TestModerationListUnauthorized(t *testing.T) {
+	var (
+		currentApp, _, objects = testSetupModeration(t)
+		fn                     = ModerationList(objects, user.NewMemService())
+	)
+
+	_, err := fn(currentApp, Origin{
+		Integration: IntegrationApplication,
+		UserID:      uint64(rand.Int63()),
+	}, object.QueryOptions{})
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestReportCreateThreshold(t *testing.T) {
+	var (
+		currentApp, connections, objects = testSetupModeration(t)
+		ownerID                          = uint64(rand.Int63())
+		fn                               = ReportCreate(connections, objects)
+	)
+
+	post, err := objects.Put(currentApp.Namespace(), testPost(ownerID).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fn(currentApp, Origin{UserID: ownerID}, post.ID, 0, "")
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	origin := Origin{UserID: uint64(rand.Int63())}
+
+	r, err := fn(currentApp, origin, post.ID, 0, "spam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := fn(currentApp, origin, post.ID, 0, "spam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := again.ID, r.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = fn(currentApp, Origin{UserID: uint64(rand.Int63())}, post.ID, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+		ID: &post.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := isHidden(ps[0]), true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = isPostVisible(connections, currentApp, ps[0], uint64(rand.Int63()))
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	backend := Origin{Integration: IntegrationBackend}
+
+	feed, err := ModerationList(objects, user.NewMemService())(
+		currentApp,
+		backend,
+		object.QueryOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Objects), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := feed.ReportCounts[post.ID], currentApp.ReportThreshold; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	reviewed, err := ModerationReview(objects)(
+		currentApp,
+		backend,
+		post.ID,
+		object.StateConfirmed,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := isHidden(reviewed), false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testSetupModeration(
+	t *testing.T,
+) (*app.App, connection.Service, object.Service) {
+	var (
+		currentApp = &app.App{
+			ID:              uint64(rand.Int63()),
+			OrgID:           uint64(rand.Int63()),
+			ReportThreshold: 2,
+		}
+		objects = object.NewMemService()
+	)
+
+	err := objects.Setup(currentApp.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return currentApp, connection.NewMemService(), objects
+}
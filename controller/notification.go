@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/notification"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+var defaultUnread = false
+
+// NotificationFeed is a collection of notifications with their referenced
+// users.
+type NotificationFeed struct {
+	Notifications notification.List
+	UserMap       user.Map
+}
+
+// NotificationListFunc returns the notifications of a user.
+type NotificationListFunc func(
+	*app.App,
+	Origin,
+	notification.QueryOptions,
+) (*NotificationFeed, error)
+
+// NotificationList returns the notifications addressed to the origin, newest
+// first, together with the users who caused them.
+func NotificationList(
+	notifications notification.Service,
+	users user.Service,
+) NotificationListFunc {
+	return func(
+		currentApp *app.App,
+		origin Origin,
+		opts notification.QueryOptions,
+	) (*NotificationFeed, error) {
+		opts.UserIDs = []uint64{
+			origin.UserID,
+		}
+
+		ns, err := notifications.Query(currentApp.Namespace(), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		um, err := user.MapFromIDs(users, currentApp.Namespace(), ns.OriginIDs()...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &NotificationFeed{
+			Notifications: ns,
+			UserMap:       um,
+		}, nil
+	}
+}
+
+// NotificationReadFunc marks a single notification as read.
+type NotificationReadFunc func(*app.App, Origin, uint64) error
+
+// NotificationRead marks the notification with the given id as read if it is
+// addressed to the origin.
+func NotificationRead(notifications notification.Service) NotificationReadFunc {
+	return func(currentApp *app.App, origin Origin, id uint64) error {
+		opts := notification.QueryOptions{
+			IDs: []uint64{
+				id,
+			},
+			UserIDs: []uint64{
+				origin.UserID,
+			},
+		}
+
+		count, err := notifications.Count(currentApp.Namespace(), opts)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return ErrNotFound
+		}
+
+		return notifications.MarkRead(currentApp.Namespace(), opts)
+	}
+}
+
+// NotificationReadAllFunc marks all notifications of a user as read.
+type NotificationReadAllFunc func(*app.App, Origin) error
+
+// NotificationReadAll marks all notifications addressed to the origin as read.
+func NotificationReadAll(
+	notifications notification.Service,
+) NotificationReadAllFunc {
+	return func(currentApp *app.App, origin Origin) error {
+		return notifications.MarkRead(
+			currentApp.Namespace(),
+			notification.QueryOptions{
+				UserIDs: []uint64{
+					origin.UserID,
+				},
+			},
+		)
+	}
+}
+
+// NotificationUnreadCountFunc returns the number of unread notifications of a
+// user.
+type NotificationUnreadCountFunc func(*app.App, Origin) (int, error)
+
+// NotificationUnreadCount returns the number of unread notifications addressed
+// to the origin.
+func NotificationUnreadCount(
+	notifications notification.Service,
+) NotificationUnreadCountFunc {
+	return func(currentApp *app.App, origin Origin) (int, error) {
+		return notifications.Count(
+			currentApp.Namespace(),
+			notification.QueryOptions{
+				Read: &defaultUnread,
+				UserIDs: []uint64{
+					origin.UserID,
+				},
+			},
+		)
+	}
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/notification"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+func TestNotificationRead(t *testing.T) {
+	var (
+		a = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		notifications = notification.NewMemService()
+		origin        = Origin{
+			UserID: uint64(rand.Int63()),
+		}
+		ns = a.Namespace()
+	)
+
+	ids := []uint64{}
+
+	for i := 0; i < 3; i++ {
+		n, err := notifications.Put(ns, testNotification(origin.UserID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, n.ID)
+	}
+
+	other, err := notifications.Put(ns, testNotification(uint64(rand.Int63())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := NotificationUnreadCount(notifications)(a, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 3; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = NotificationRead(notifications)(a, origin, other.ID)
+	if have, want := err, ErrNotFound; !IsNotFound(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = NotificationRead(notifications)(a, origin, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err = NotificationUnreadCount(notifications)(a, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = NotificationReadAll(notifications)(a, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err = NotificationUnreadCount(notifications)(a, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	count, err = NotificationUnreadCount(notifications)(a, Origin{
+		UserID: other.UserID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestNotificationList(t *testing.T) {
+	var (
+		a = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		notifications = notification.NewMemService()
+		users         = user.NewMemService()
+		ns            = a.Namespace()
+	)
+
+	actor, err := users.Put(ns, testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipient, err := users.Put(ns, testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := testNotification(recipient.ID)
+	n.OriginID = actor.ID
+
+	_, err = notifications.Put(ns, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := NotificationList(notifications, users)(a, Origin{
+		UserID: recipient.ID,
+	}, notification.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Notifications), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if _, ok := feed.UserMap[actor.ID]; !ok {
+		t.Errorf("want user %d in map", actor.ID)
+	}
+}
+
+func testNotification(userID uint64) *notification.Notification {
+	return &notification.Notification{
+		Message:  "Alice liked your Post.",
+		OriginID: uint64(rand.Int63()),
+		Type:     "like_post_own",
+		URN:      "tapglue/posts/1",
+		UserID:   userID,
+	}
+}
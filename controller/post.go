@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"time"
+
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/event"
@@ -31,6 +33,11 @@ type PostCounts struct {
 type PostFeed struct {
 	Posts   PostList
 	UserMap user.Map
+	// Fetched is the number of posts the feed was built from, including the
+	// ones hidden from the origin, and Before the creation time of the last of
+	// them. Paging has to be based on those to not stop at filtered pages.
+	Fetched int
+	Before  time.Time
 }
 
 // PostMap is the user collection indexed by their ids.
@@ -143,11 +150,31 @@ func (c *PostController) Create(
 		return nil, wrapError(ErrInvalidEntity, "%s", err)
 	}
 
+	ms, err := resolveMentions(
+		c.connections,
+		c.users,
+		currentApp,
+		origin.UserID,
+		post.Object,
+		post.Mentions,
+		post.Attachments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Mentions = ms
+
 	o, err := c.objects.Put(currentApp.Namespace(), post.Object)
 	if err != nil {
 		return nil, err
 	}
 
+	err = notifyMentions(c.events, currentApp, o, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Post{Object: o}, nil
 }
 
@@ -207,34 +234,45 @@ func (c *PostController) ListAll(
 		return nil, err
 	}
 
-	ps := postsFromObjects(os)
+	return c.publicFeed(currentApp, origin, os)
+}
 
-	err = enrichCounts(c.events, c.objects, currentApp, ps)
-	if err != nil {
-		return nil, err
+// ListNearby returns all public posts located within the Radius or
+// BoundingBox of the options.
+func (c *PostController) ListNearby(
+	currentApp *app.App,
+	origin uint64,
+	opts object.QueryOptions,
+) (*PostFeed, error) {
+	if opts.Radius == nil && opts.BoundingBox == nil {
+		return nil, wrapError(ErrInvalidEntity, "missing radius or bounding box")
 	}
 
-	err = enrichIsLiked(c.events, currentApp, origin, ps)
-	if err != nil {
-		return nil, err
+	if opts.Radius != nil {
+		if err := opts.Radius.Validate(); err != nil {
+			return nil, wrapError(ErrInvalidEntity, "invalid radius: %s", err)
+		}
 	}
 
-	um, err := user.MapFromIDs(c.users, currentApp.Namespace(), ps.OwnerIDs()...)
-	if err != nil {
-		return nil, err
+	if opts.BoundingBox != nil {
+		if err := opts.BoundingBox.Validate(); err != nil {
+			return nil, wrapError(ErrInvalidEntity, "invalid bounding box: %s", err)
+		}
 	}
 
-	for _, u := range um {
-		err = enrichRelation(c.connections, currentApp, origin, u)
-		if err != nil {
-			return nil, err
-		}
+	opts.Owned = &defaultOwned
+	opts.Types = []string{TypePost}
+	opts.Visibilities = []object.Visibility{
+		object.VisibilityPublic,
+		object.VisibilityGlobal,
 	}
 
-	return &PostFeed{
-		Posts:   ps,
-		UserMap: um,
-	}, nil
+	os, err := c.objects.Query(currentApp.Namespace(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.publicFeed(currentApp, origin, os)
 }
 
 // ListUser returns all posts for the given user id as visible by the
@@ -257,6 +295,10 @@ func (c *PostController) ListUser(
 			return nil, err
 		}
 
+		if r.isBlocked {
+			return nil, ErrNotFound
+		}
+
 		if r.isFriend || r.isFollowing {
 			vs = append(vs, object.VisibilityConnection)
 		}
@@ -277,7 +319,7 @@ func (c *PostController) ListUser(
 		return nil, err
 	}
 
-	ps := postsFromObjects(os)
+	ps := filterPosts(postsFromObjects(os), conditionObjectHidden(origin))
 
 	err = enrichCounts(c.events, c.objects, currentApp, ps)
 	if err != nil {
@@ -301,12 +343,45 @@ func (c *PostController) ListUser(
 		}
 	}
 
+	err = enrichMutualCounts(c.connections, currentApp, origin, um.ToList())
+	if err != nil {
+		return nil, err
+	}
+
 	return &PostFeed{
 		Posts:   ps,
 		UserMap: um,
 	}, nil
 }
 
+// Search returns the posts which text attachments in the given language match
+// the query, ordered by relevance and recency. The same visibility rules as
+// for ListAll apply.
+func (c *PostController) Search(
+	currentApp *app.App,
+	origin uint64,
+	lang, query string,
+	opts object.QueryOptions,
+) (*PostFeed, error) {
+	if query == "" {
+		return nil, wrapError(ErrInvalidEntity, "missing query")
+	}
+
+	opts.Owned = &defaultOwned
+	opts.Types = []string{TypePost}
+	opts.Visibilities = []object.Visibility{
+		object.VisibilityPublic,
+		object.VisibilityGlobal,
+	}
+
+	os, err := c.objects.Search(currentApp.Namespace(), lang, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.publicFeed(currentApp, origin, os)
+}
+
 // Retrieve returns the Post for the given id.
 func (c *PostController) Retrieve(
 	currentApp *app.App,
@@ -378,10 +453,20 @@ func (c *PostController) Update(
 
 	// Preserve information.
 	p := ps[0]
+	mentioned := p.Mentions
 	p.Attachments = post.Attachments
 	p.Tags = post.Tags
 	p.Visibility = post.Visibility
 
+	if post.Latitude != 0 || post.Longitude != 0 {
+		p.Latitude = post.Latitude
+		p.Longitude = post.Longitude
+	}
+
+	if post.Location != "" {
+		p.Location = post.Location
+	}
+
 	if post.Restrictions != nil {
 		p.Restrictions = post.Restrictions
 	}
@@ -391,6 +476,19 @@ func (c *PostController) Update(
 		return nil, err
 	}
 
+	p.Mentions, err = resolveMentions(
+		c.connections,
+		c.users,
+		currentApp,
+		origin.UserID,
+		p,
+		post.Mentions,
+		post.Attachments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := p.Validate(); err != nil {
 		return nil, wrapError(ErrInvalidEntity, "%s", err)
 	}
@@ -400,9 +498,72 @@ func (c *PostController) Update(
 		return nil, err
 	}
 
+	err = notifyMentions(c.events, currentApp, o, mentioned)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Post{Object: o}, nil
 }
 
+// publicFeed filters the public objects for the origin and enriches them to a
+// PostFeed.
+func (c *PostController) publicFeed(
+	currentApp *app.App,
+	origin uint64,
+	os object.List,
+) (*PostFeed, error) {
+	bs, err := blockedIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := filterPosts(
+		postsFromObjects(os),
+		conditionObjectHidden(origin),
+		conditionObjectOwner(bs),
+	)
+
+	err = enrichCounts(c.events, c.objects, currentApp, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enrichIsLiked(c.events, currentApp, origin, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	um, err := user.MapFromIDs(c.users, currentApp.Namespace(), ps.OwnerIDs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range um {
+		err = enrichRelation(c.connections, currentApp, origin, u)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = enrichMutualCounts(c.connections, currentApp, origin, um.ToList())
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &PostFeed{
+		Fetched: len(os),
+		Posts:   ps,
+		UserMap: um,
+	}
+
+	if len(os) > 0 {
+		feed.Before = os[len(os)-1].CreatedAt
+	}
+
+	return feed, nil
+}
+
 func constrainPostRestrictions(origin Origin, restrictions *object.Restrictions) error {
 	if !origin.IsBackend() && restrictions != nil {
 		return wrapError(
@@ -509,10 +670,7 @@ func isPostVisible(
 		return nil
 	}
 
-	switch post.Visibility {
-	case object.VisibilityGlobal, object.VisibilityPublic:
-		return nil
-	case object.VisibilityPrivate:
+	if post.Visibility == object.VisibilityPrivate || isHidden(post) {
 		return ErrNotFound
 	}
 
@@ -521,6 +679,15 @@ func isPostVisible(
 		return err
 	}
 
+	if r.isBlocked {
+		return ErrNotFound
+	}
+
+	switch post.Visibility {
+	case object.VisibilityGlobal, object.VisibilityPublic:
+		return nil
+	}
+
 	if !r.isFriend && !r.isFollowing {
 		return ErrNotFound
 	}
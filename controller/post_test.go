@@ -160,6 +160,57 @@ func TestPostControllerListAll(t *testing.T) {
 	}
 }
 
+func TestPostControllerListNearby(t *testing.T) {
+	app, owner, c := testSetupPostController(t)
+
+	_, err := c.ListNearby(app, owner.ID, object.QueryOptions{})
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.ListNearby(app, owner.ID, object.QueryOptions{
+		Radius: &object.Radius{
+			Distance: 1000,
+			Latitude: 91,
+		},
+	})
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	for i, post := range testPostSet(owner.ID) {
+		post.Latitude = 52.52
+		post.Longitude = 13.405 + float64(i)*0.01
+
+		_, err = c.objects.Put(app.Namespace(), post)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	feed, err := c.ListNearby(app, owner.ID, object.QueryOptions{
+		OrderDistance: true,
+		Radius: &object.Radius{
+			Distance:  100000,
+			Latitude:  52.52,
+			Longitude: 13.405,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Posts), 3; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	for i := 1; i < len(feed.Posts); i++ {
+		if have, want := feed.Posts[i].Longitude, feed.Posts[i-1].Longitude; have < want {
+			t.Errorf("have %v, want >= %v", have, want)
+		}
+	}
+}
+
 func TestPostControllerListUser(t *testing.T) {
 	app, owner, c := testSetupPostController(t)
 
@@ -189,6 +240,75 @@ func TestPostControllerListUser(t *testing.T) {
 	}
 }
 
+func TestPostControllerSearch(t *testing.T) {
+	app, owner, c := testSetupPostController(t)
+
+	_, err := c.Search(app, owner.ID, "en", "", object.QueryOptions{})
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	for _, post := range testPostSet(owner.ID) {
+		post.Attachments = []object.Attachment{
+			object.NewTextAttachment("body", object.Contents{
+				"en": "Test body.",
+			}),
+		}
+
+		_, err = c.objects.Put(app.Namespace(), post)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	feed, err := c.Search(app, owner.ID, "en", "body", object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Posts), 3; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	feed, err = c.Search(app, owner.ID, "de", "body", object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Posts), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	origin := uint64(rand.Int63())
+
+	for _, id := range []uint64{owner.ID - 1, owner.ID, owner.ID + 1} {
+		_, err = c.connections.Put(app.Namespace(), &connection.Connection{
+			Enabled: true,
+			FromID:  origin,
+			State:   connection.StateConfirmed,
+			ToID:    id,
+			Type:    connection.TypeBlock,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Posts hidden from the origin still count for paging.
+	feed, err = c.Search(app, origin, "en", "body", object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Posts), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := feed.Fetched, 3; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func TestPostControllerRetrieve(t *testing.T) {
 	var (
 		app, owner, c = testSetupPostController(t)
@@ -215,18 +335,64 @@ func TestPostControllerRetrieve(t *testing.T) {
 	}
 }
 
+func TestPostControllerRetrieveBlocked(t *testing.T) {
+	var (
+		app, owner, c = testSetupPostController(t)
+		post          = testPost(owner.ID)
+		blockedID     = uint64(rand.Int63())
+	)
+
+	created, err := c.objects.Put(app.Namespace(), post.Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.connections.Put(app.Namespace(), &connection.Connection{
+		Enabled: true,
+		FromID:  owner.ID,
+		State:   connection.StateConfirmed,
+		ToID:    blockedID,
+		Type:    connection.TypeBlock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Retrieve(app, blockedID, created.ID)
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	feed, err := c.ListAll(app, blockedID, object.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Posts), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func TestPostControllerUpdate(t *testing.T) {
 	var (
 		app, owner, c = testSetupPostController(t)
 		post          = testPost(owner.ID)
 	)
 
+	post.Latitude = 52.5200
+	post.Location = "Berlin"
+	post.Longitude = 13.4050
+
 	created, err := c.objects.Put(app.Namespace(), post.Object)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	created.OwnerID = 0
+	update := *created
+	update.Latitude = 0
+	update.Location = ""
+	update.Longitude = 0
+	update.OwnerID = 0
 
 	_, err = c.Update(
 		app,
@@ -235,7 +401,7 @@ func TestPostControllerUpdate(t *testing.T) {
 			UserID:      owner.ID,
 		},
 		created.ID,
-		&Post{Object: created},
+		&Post{Object: &update},
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -265,6 +431,18 @@ func TestPostControllerUpdate(t *testing.T) {
 	if have, want := updated.Visibility, post.Visibility; have != want {
 		t.Errorf("have %v, want %v", have, want)
 	}
+
+	if have, want := updated.Latitude, post.Latitude; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := updated.Location, post.Location; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := updated.Longitude, post.Longitude; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
 }
 
 func TestPostUpdateConstrainVisibility(t *testing.T) {
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/preference"
+)
+
+// PreferenceRetrieveFunc returns the notification preferences of a user.
+type PreferenceRetrieveFunc func(*app.App, Origin) (preference.List, error)
+
+// PreferenceRetrieve returns the preferences of the origin for every supported
+// notification type, types without a stored preference are enabled.
+func PreferenceRetrieve(preferences preference.Service) PreferenceRetrieveFunc {
+	return func(currentApp *app.App, origin Origin) (preference.List, error) {
+		return userPreferences(preferences, currentApp, origin.UserID)
+	}
+}
+
+// PreferenceUpdateFunc stores the notification preferences of a user.
+type PreferenceUpdateFunc func(
+	*app.App,
+	Origin,
+	map[preference.Type]bool,
+) (preference.List, error)
+
+// PreferenceUpdate stores the given notification preferences of the origin,
+// types not present are left untouched. Nothing is stored if one of the
+// preferences is invalid.
+func PreferenceUpdate(preferences preference.Service) PreferenceUpdateFunc {
+	return func(
+		currentApp *app.App,
+		origin Origin,
+		enabled map[preference.Type]bool,
+	) (preference.List, error) {
+		ps := preference.List{}
+
+		for t, e := range enabled {
+			p := &preference.Preference{
+				Enabled: e,
+				Type:    t,
+				UserID:  origin.UserID,
+			}
+
+			if err := p.Validate(); err != nil {
+				return nil, wrapError(ErrInvalidEntity, "%s", err)
+			}
+
+			ps = append(ps, p)
+		}
+
+		for _, p := range ps {
+			_, err := preferences.Put(currentApp.Namespace(), p)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return userPreferences(preferences, currentApp, origin.UserID)
+	}
+}
+
+func userPreferences(
+	preferences preference.Service,
+	currentApp *app.App,
+	userID uint64,
+) (preference.List, error) {
+	ps, err := preferences.Query(currentApp.Namespace(), preference.QueryOptions{
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l := preference.List{}
+
+	for _, t := range preference.Types {
+		l = append(l, &preference.Preference{
+			Enabled: ps.Enabled(userID, t),
+			Type:    t,
+			UserID:  userID,
+		})
+	}
+
+	return l, nil
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/preference"
+)
+
+func TestPreferenceUpdate(t *testing.T) {
+	var (
+		a = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		origin = Origin{
+			UserID: uint64(rand.Int63()),
+		}
+		preferences = preference.NewMemService()
+	)
+
+	ps, err := PreferenceRetrieve(preferences)(a, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ps), len(preference.Types); have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	for _, p := range ps {
+		if have, want := p.Enabled, true; have != want {
+			t.Errorf("%s: have %v, want %v", p.Type, have, want)
+		}
+	}
+
+	ps, err = PreferenceUpdate(preferences)(a, origin, map[preference.Type]bool{
+		preference.TypeLike: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range ps {
+		if have, want := p.Enabled, p.Type != preference.TypeLike; have != want {
+			t.Errorf("%s: have %v, want %v", p.Type, have, want)
+		}
+	}
+
+	_, err = PreferenceUpdate(preferences)(a, origin, map[preference.Type]bool{
+		preference.TypeFollow: false,
+		"unknown":             false,
+	})
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ps, err = PreferenceRetrieve(preferences)(a, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range ps {
+		if have, want := p.Enabled, p.Type != preference.TypeLike; have != want {
+			t.Errorf("%s: have %v, want %v", p.Type, have, want)
+		}
+	}
+}
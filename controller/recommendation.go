@@ -2,20 +2,30 @@ package controller
 
 import (
 	"math/rand"
+	"sort"
 
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
 	"github.com/tapglue/multiverse/service/user"
 )
 
+// Weights of the signals contributing to the score of a recommendation.
+const (
+	scoreInteraction = 2
+	scoreMutual      = 3
+	scorePlatform    = 1
+)
+
 // conditionUser determines if the user should be filtered.
 type conditionUser func(*user.User) (bool, error)
 
 // RecommendationController bundles the business constriants for recommendations.
 type RecommendationController struct {
 	connections connection.Service
-	events      event.AggregateService
+	events      event.Service
+	objects     object.Service
 	users       user.Service
 }
 
@@ -23,15 +33,138 @@ type RecommendationController struct {
 func NewRecommendationController(
 	connections connection.Service,
 	events event.Service,
+	objects object.Service,
 	users user.Service,
 ) *RecommendationController {
 	return &RecommendationController{
 		connections: connections,
 		events:      events,
+		objects:     objects,
 		users:       users,
 	}
 }
 
+// UserRecommendation is a user suggested to the origin together with the score
+// and the number of mutual connections it is ranked by.
+type UserRecommendation struct {
+	MutualCount int
+	Score       int
+	User        *user.User
+}
+
+// UserRecommendations is a UserRecommendation collection.
+type UserRecommendations []*UserRecommendation
+
+func (rs UserRecommendations) Len() int {
+	return len(rs)
+}
+
+func (rs UserRecommendations) Less(i, j int) bool {
+	if rs[i].Score == rs[j].Score {
+		return rs[i].MutualCount > rs[j].MutualCount
+	}
+
+	return rs[i].Score > rs[j].Score
+}
+
+func (rs UserRecommendations) Swap(i, j int) {
+	rs[i], rs[j] = rs[j], rs[i]
+}
+
+// Users returns the people the origin may know, ranked by mutual connections,
+// recent interactions through likes and comments in the last month and shared
+// social platforms.
+func (c *RecommendationController) Users(
+	currentApp *app.App,
+	origin uint64,
+	limit int,
+) (UserRecommendations, error) {
+	connected, err := socialIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	mutuals, err := secondDegree(c.connections, currentApp, connected)
+	if err != nil {
+		return nil, err
+	}
+
+	interactions, err := c.interactions(currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint64{}
+
+	for id := range mutuals {
+		ids = append(ids, id)
+	}
+
+	for id := range interactions {
+		if _, ok := mutuals[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	us, err := user.ListFromIDs(c.users, currentApp.Namespace(), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := hiddenIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err = filterUsers(
+		us,
+		conditionOrigin(origin),
+		conditionHidden(connected),
+		conditionHidden(bs),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	os, err := user.ListFromIDs(c.users, currentApp.Namespace(), origin)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := map[string]string{}
+
+	if len(os) == 1 {
+		platforms = os[0].SocialIDs
+	}
+
+	rs := UserRecommendations{}
+
+	for _, u := range us {
+		r := &UserRecommendation{
+			MutualCount: len(mutuals[u.ID]),
+			User:        u,
+		}
+
+		r.Score = r.MutualCount*scoreMutual + interactions[u.ID]*scoreInteraction
+
+		for platform := range u.SocialIDs {
+			if _, ok := platforms[platform]; ok {
+				r.Score += scorePlatform
+			}
+		}
+
+		rs = append(rs, r)
+	}
+
+	sort.Sort(rs)
+
+	if limit > 0 && len(rs) > limit {
+		rs = rs[:limit]
+	}
+
+	return rs, nil
+}
+
 // UsersActive returns the list of users with activity in the time period.
 func (c *RecommendationController) UsersActive(
 	currentApp *app.App,
@@ -48,10 +181,16 @@ func (c *RecommendationController) UsersActive(
 		return nil, err
 	}
 
+	bs, err := blockedIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
 	us, err = filterUsers(
 		us,
 		conditionOrigin(origin),
 		conditionConnection(c.connections, currentApp, origin),
+		conditionHidden(bs),
 	)
 	if err != nil {
 		return nil, err
@@ -62,6 +201,138 @@ func (c *RecommendationController) UsersActive(
 	return us, nil
 }
 
+// interactions returns the number of likes and comments exchanged between the
+// origin and other users in the last month.
+func (c *RecommendationController) interactions(
+	currentApp *app.App,
+	origin uint64,
+) (map[uint64]int, error) {
+	since, err := periodStart(event.ByMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	interactions := map[uint64]int{}
+
+	ps, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+		Owned: &defaultOwned,
+		OwnerIDs: []uint64{
+			origin,
+		},
+		Types: []string{
+			TypePost,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ps) > 0 {
+		// Likes and comments received on posts of the origin.
+		es, err := c.events.Query(currentApp.Namespace(), event.QueryOptions{
+			After:     since,
+			Enabled:   &defaultEnabled,
+			ObjectIDs: ps.IDs(),
+			Owned:     &defaultOwned,
+			Types: []string{
+				TypeLike,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range es {
+			interactions[e.UserID]++
+		}
+
+		cs, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+			After:     since,
+			ObjectIDs: ps.IDs(),
+			Owned:     &defaultOwned,
+			Types: []string{
+				TypeComment,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, comment := range cs {
+			interactions[comment.OwnerID]++
+		}
+	}
+
+	// Likes and comments given by the origin on posts of others.
+	es, err := c.events.Query(currentApp.Namespace(), event.QueryOptions{
+		After:   since,
+		Enabled: &defaultEnabled,
+		Owned:   &defaultOwned,
+		Types: []string{
+			TypeLike,
+		},
+		UserIDs: []uint64{
+			origin,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	postIDs := []uint64{}
+
+	for _, e := range es {
+		postIDs = append(postIDs, e.ObjectID)
+	}
+
+	cs, err := c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+		After: since,
+		Owned: &defaultOwned,
+		OwnerIDs: []uint64{
+			origin,
+		},
+		Types: []string{
+			TypeComment,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range cs {
+		postIDs = append(postIDs, comment.ObjectID)
+	}
+
+	if len(postIDs) == 0 {
+		return interactions, nil
+	}
+
+	ps, err = c.objects.Query(currentApp.Namespace(), object.QueryOptions{
+		IDs:   postIDs,
+		Owned: &defaultOwned,
+		Types: []string{
+			TypePost,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pm := map[uint64]uint64{}
+
+	for _, p := range ps {
+		pm[p.ID] = p.OwnerID
+	}
+
+	for _, id := range postIDs {
+		if ownerID, ok := pm[id]; ok {
+			interactions[ownerID]++
+		}
+	}
+
+	return interactions, nil
+}
+
 func conditionConnection(
 	connections connection.Service,
 	currentApp *app.App,
@@ -81,6 +352,14 @@ func conditionConnection(
 	}
 }
 
+func conditionHidden(ids map[uint64]struct{}) conditionUser {
+	return func(user *user.User) (bool, error) {
+		_, ok := ids[user.ID]
+
+		return ok, nil
+	}
+}
+
 func conditionOrigin(
 	origin uint64,
 ) conditionUser {
@@ -2,8 +2,13 @@ package controller
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
 	"github.com/tapglue/multiverse/service/user"
 )
 
@@ -27,6 +32,138 @@ func TestFilterUsersError(t *testing.T) {
 	}
 }
 
+func TestRecommendationUsers(t *testing.T) {
+	var (
+		currentApp = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		connections = connection.NewMemService()
+		events      = event.NewMemService()
+		objects     = object.NewMemService()
+		users       = user.NewMemService()
+		c           = NewRecommendationController(
+			connections,
+			events,
+			objects,
+			users,
+		)
+		us = user.List{}
+	)
+
+	err := events.Setup(currentApp.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = objects.Setup(currentApp.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 6; i++ {
+		u := testUser()
+
+		// Only the origin and one of the candidates share a platform.
+		if i == 0 || i == 2 {
+			u.SocialIDs = map[string]string{
+				"facebook": fmt.Sprintf("%d", i),
+			}
+		}
+
+		u, err := users.Put(currentApp.Namespace(), u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		us = append(us, u)
+	}
+
+	var (
+		origin, friend, first, second, fan, blocked = us[0], us[1], us[2], us[3], us[4], us[5]
+	)
+
+	for _, con := range []*connection.Connection{
+		{FromID: origin.ID, ToID: friend.ID, Type: connection.TypeFriend},
+		{FromID: friend.ID, ToID: first.ID, Type: connection.TypeFollow},
+		{FromID: second.ID, ToID: friend.ID, Type: connection.TypeFriend},
+		{FromID: friend.ID, ToID: blocked.ID, Type: connection.TypeFollow},
+		{FromID: origin.ID, ToID: blocked.ID, Type: connection.TypeBlock},
+	} {
+		con.Enabled = true
+		con.State = connection.StateConfirmed
+
+		_, err := connections.Put(currentApp.Namespace(), con)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post, err := objects.Put(currentApp.Namespace(), testPost(origin.ID).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = events.Put(currentApp.Namespace(), &event.Event{
+		Enabled:    true,
+		ObjectID:   post.ID,
+		Owned:      true,
+		Type:       TypeLike,
+		UserID:     second.ID,
+		Visibility: event.VisibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = objects.Put(currentApp.Namespace(), &object.Object{
+		Attachments: []object.Attachment{
+			object.NewTextAttachment("content", object.Contents{
+				"en": "Nice!",
+			}),
+		},
+		ObjectID:   post.ID,
+		OwnerID:    fan.ID,
+		Owned:      true,
+		Type:       TypeComment,
+		Visibility: object.VisibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := c.Users(currentApp, origin.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(rs), 3; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	for i, want := range []struct {
+		id     uint64
+		mutual int
+		score  int
+	}{
+		{id: second.ID, mutual: 1, score: scoreMutual + scoreInteraction},
+		{id: first.ID, mutual: 1, score: scoreMutual + scorePlatform},
+		{id: fan.ID, mutual: 0, score: scoreInteraction},
+	} {
+		if have, want := rs[i].User.ID, want.id; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+
+		if have, want := rs[i].MutualCount, want.mutual; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+
+		if have, want := rs[i].Score, want.score; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
+
 func testConditionUserEven(user *user.User) (bool, error) {
 	return user.ID%2 == 0, nil
 }
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/device"
+	"github.com/tapglue/multiverse/service/session"
+)
+
+// SessionFeed is the collection of active sessions of a user with the devices
+// they were created on indexed by device id.
+type SessionFeed struct {
+	Devices  map[string]*device.Device
+	Sessions session.List
+}
+
+// SessionListFunc returns all active sessions of the origin.
+type SessionListFunc func(
+	currentApp *app.App,
+	origin Origin,
+) (*SessionFeed, error)
+
+// SessionList returns all enabled and not yet expired sessions of the origin.
+func SessionList(
+	devices device.Service,
+	sessions session.Service,
+) SessionListFunc {
+	return func(currentApp *app.App, origin Origin) (*SessionFeed, error) {
+		ss, err := sessionsActive(sessions, currentApp, origin.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ss) == 0 {
+			return &SessionFeed{Sessions: ss}, nil
+		}
+
+		deviceIDs := []string{}
+
+		for _, s := range ss {
+			deviceIDs = append(deviceIDs, s.DeviceID)
+		}
+
+		ds, err := devices.Query(currentApp.Namespace(), device.QueryOptions{
+			Deleted:   &defaultDeleted,
+			DeviceIDs: deviceIDs,
+			UserIDs: []uint64{
+				origin.UserID,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		dm := map[string]*device.Device{}
+
+		for _, d := range ds {
+			dm[d.DeviceID] = d
+		}
+
+		return &SessionFeed{
+			Devices:  dm,
+			Sessions: ss,
+		}, nil
+	}
+}
+
+// SessionRevokeFunc ends the session of the origin with the given public id.
+type SessionRevokeFunc func(
+	currentApp *app.App,
+	origin Origin,
+	publicID string,
+) error
+
+// SessionRevoke disables the session of the origin identified by its public
+// id.
+func SessionRevoke(sessions session.Service) SessionRevokeFunc {
+	return func(currentApp *app.App, origin Origin, publicID string) error {
+		ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+			Enabled: &defaultEnabled,
+			UserIDs: []uint64{
+				origin.UserID,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range ss {
+			if s.PublicID() == publicID {
+				return sessionDisable(sessions, currentApp, s)
+			}
+		}
+
+		return ErrNotFound
+	}
+}
+
+// SessionRevokeOthersFunc ends all sessions of the origin but the one with the
+// given public id.
+type SessionRevokeOthersFunc func(
+	currentApp *app.App,
+	origin Origin,
+	publicID string,
+) error
+
+// SessionRevokeOthers disables all sessions of the origin except the one the
+// request was made with.
+func SessionRevokeOthers(sessions session.Service) SessionRevokeOthersFunc {
+	return func(currentApp *app.App, origin Origin, publicID string) error {
+		ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+			Enabled: &defaultEnabled,
+			UserIDs: []uint64{
+				origin.UserID,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range ss {
+			if s.PublicID() == publicID {
+				continue
+			}
+
+			if err := sessionDisable(sessions, currentApp, s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// SessionSeenFlushFunc persists the buffered last seen times of sessions.
+type SessionSeenFlushFunc func() error
+
+// SessionSeenFlush moves the times recorded by the tracker to the session
+// service. A failing namespace doesn't hold back the others, the last error is
+// returned.
+func SessionSeenFlush(
+	sessions session.Service,
+	tracker session.Tracker,
+) SessionSeenFlushFunc {
+	return func() error {
+		seen, err := tracker.Flush()
+		if err != nil {
+			return err
+		}
+
+		for ns, ts := range seen {
+			if e := sessions.PutLastSeen(ns, ts); e != nil {
+				err = e
+			}
+		}
+
+		return err
+	}
+}
+
+func sessionDisable(
+	sessions session.Service,
+	currentApp *app.App,
+	s *session.Session,
+) error {
+	s.Enabled = false
+
+	_, err := sessions.Put(currentApp.Namespace(), s)
+	return err
+}
+
+// sessionLifetimes returns the idle and absolute lifetime of sessions. With
+// access tokens enabled sessions hold the refresh token, which is replaced on
+// every refresh and lives for the refresh ttl.
+func sessionLifetimes(currentApp *app.App) (time.Duration, time.Duration) {
+	var (
+		rules  = currentApp.SessionRules()
+		tokens = currentApp.TokenRules()
+	)
+
+	if tokens.Enabled {
+		return rules.Idle(), tokens.Refresh()
+	}
+
+	return rules.Idle(), rules.Max()
+}
+
+// sessionsDisableDevice ends all sessions of the user on the device.
+func sessionsDisableDevice(
+	sessions session.Service,
+	currentApp *app.App,
+	userID uint64,
+	deviceID string,
+) error {
+	ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		DeviceIDs: []string{
+			deviceID,
+		},
+		Enabled: &defaultEnabled,
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range ss {
+		if err := sessionDisable(sessions, currentApp, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sessionsActive returns the enabled sessions of the user which are within the
+// lifetimes configured for the app.
+func sessionsActive(
+	sessions session.Service,
+	currentApp *app.App,
+	userID uint64,
+) (session.List, error) {
+	ss, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		Enabled: &defaultEnabled,
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		active    = session.List{}
+		now       = time.Now()
+		idle, max = sessionLifetimes(currentApp)
+	)
+
+	for _, s := range ss {
+		if s.Expired(now, idle, max) {
+			continue
+		}
+
+		active = append(active, s)
+	}
+
+	return active, nil
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/generate"
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/session"
+)
+
+func TestSessionRevoke(t *testing.T) {
+	var (
+		currentApp, sessions, origin = testSetupSession(t)
+		revoke                       = SessionRevoke(sessions)
+	)
+
+	ss := testSessions(t, sessions, currentApp, origin, 2)
+
+	err := revoke(currentApp, origin, "unknown")
+	if have, want := err, ErrNotFound; !IsNotFound(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = revoke(currentApp, origin, ss[0].PublicID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		Enabled: &defaultEnabled,
+		UserIDs: []uint64{
+			origin.UserID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(active), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := active[0].ID, ss[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = revoke(currentApp, origin, ss[0].PublicID())
+	if have, want := err, ErrNotFound; !IsNotFound(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestSessionRevokeOthers(t *testing.T) {
+	var (
+		currentApp, sessions, origin = testSetupSession(t)
+		revoke                       = SessionRevokeOthers(sessions)
+	)
+
+	ss := testSessions(t, sessions, currentApp, origin, 3)
+
+	err := revoke(currentApp, origin, ss[1].PublicID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		Enabled: &defaultEnabled,
+		UserIDs: []uint64{
+			origin.UserID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(active), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := active[0].ID, ss[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestSessionSeenFlush(t *testing.T) {
+	var (
+		currentApp, sessions, origin = testSetupSession(t)
+		tracker                      = session.NewMemTracker()
+		flush                        = SessionSeenFlush(sessions, tracker)
+		seen                         = time.Now().Add(time.Minute).UTC()
+	)
+
+	ss := testSessions(t, sessions, currentApp, origin, 1)
+
+	_, err := tracker.Touch(currentApp.Namespace(), ss[0].ID, seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := sessions.Query(currentApp.Namespace(), session.QueryOptions{
+		IDs: []string{
+			ss[0].ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := rs[0].LastSeenAt, seen; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testSessions(
+	t *testing.T,
+	sessions session.Service,
+	currentApp *app.App,
+	origin Origin,
+	n int,
+) session.List {
+	ss := session.List{}
+
+	for i := 0; i < n; i++ {
+		s, err := sessions.Put(currentApp.Namespace(), &session.Session{
+			DeviceID: generate.RandomString(24),
+			Enabled:  true,
+			UserID:   origin.UserID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss
+}
+
+func testSetupSession(t *testing.T) (*app.App, session.Service, Origin) {
+	var (
+		currentApp = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		origin = Origin{
+			Integration: IntegrationApplication,
+			UserID:      uint64(rand.Int63()),
+		}
+	)
+
+	return currentApp, session.NewMemService(), origin
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+)
+
+// limitTrendingLiked bounds the number of liked posts whose likes count
+// towards trending tags to the most liked ones.
+const limitTrendingLiked = 1000
+
+var defaultHidden = false
+
+// TagSearchFunc returns the tags of posts starting with the given prefix,
+// ordered by usage.
+type TagSearchFunc func(
+	currentApp *app.App,
+	prefix string,
+	limit int,
+) (object.TagCounts, error)
+
+// TagSearch returns the tags of visible public posts starting with the given prefix.
+func TagSearch(objects object.Service) TagSearchFunc {
+	return func(
+		currentApp *app.App,
+		prefix string,
+		limit int,
+	) (object.TagCounts, error) {
+		return objects.TagCounts(currentApp.Namespace(), prefix, object.QueryOptions{
+			Hidden: &defaultHidden,
+			Limit:  limit,
+			Owned:  &defaultOwned,
+			Types: []string{
+				TypePost,
+			},
+			Visibilities: []object.Visibility{
+				object.VisibilityPublic,
+				object.VisibilityGlobal,
+			},
+		})
+	}
+}
+
+// TagTrendingFunc returns the most popular tags in the given period.
+type TagTrendingFunc func(
+	currentApp *app.App,
+	period event.Period,
+	limit int,
+) (object.TagCounts, error)
+
+// TagTrending returns the most popular tags of public posts in the given
+// period. Every post created and every like given in the period counts towards
+// the tags of the post, likes only for the most liked posts.
+func TagTrending(
+	events event.Service,
+	objects object.Service,
+) TagTrendingFunc {
+	return func(
+		currentApp *app.App,
+		period event.Period,
+		limit int,
+	) (object.TagCounts, error) {
+		since, err := periodStart(period)
+		if err != nil {
+			return nil, err
+		}
+
+		created, err := objects.TagCounts(currentApp.Namespace(), "", object.QueryOptions{
+			After:  since,
+			Hidden: &defaultHidden,
+			Owned:  &defaultOwned,
+			Types: []string{
+				TypePost,
+			},
+			Visibilities: []object.Visibility{
+				object.VisibilityPublic,
+				object.VisibilityGlobal,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		counts := map[string]int{}
+
+		for _, t := range created {
+			counts[t.Tag] = t.Count
+		}
+
+		likes, err := events.ObjectCounts(currentApp.Namespace(), event.QueryOptions{
+			After:   since,
+			Enabled: &defaultEnabled,
+			Limit:   limitTrendingLiked,
+			Owned:   &defaultOwned,
+			Types: []string{
+				TypeLike,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(likes) > 0 {
+			ids := []uint64{}
+
+			for id := range likes {
+				ids = append(ids, id)
+			}
+
+			ps, err := objects.Query(currentApp.Namespace(), object.QueryOptions{
+				Hidden: &defaultHidden,
+				IDs:    ids,
+				Owned:  &defaultOwned,
+				Types: []string{
+					TypePost,
+				},
+				Visibilities: []object.Visibility{
+					object.VisibilityPublic,
+					object.VisibilityGlobal,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range ps {
+				for _, t := range p.Tags {
+					counts[t] += likes[p.ID]
+				}
+			}
+		}
+
+		ts := object.TagCounts{}
+
+		for t, c := range counts {
+			ts = append(ts, &object.TagCount{
+				Count: c,
+				Tag:   t,
+			})
+		}
+
+		sort.Sort(ts)
+
+		if limit > 0 && len(ts) > limit {
+			ts = ts[:limit]
+		}
+
+		return ts, nil
+	}
+}
+
+// periodStart returns the beginning of the period relative to now.
+func periodStart(period event.Period) (time.Time, error) {
+	now := time.Now().UTC()
+
+	switch period {
+	case event.ByDay:
+		return now.AddDate(0, 0, -1), nil
+	case event.ByWeek:
+		return now.AddDate(0, 0, -7), nil
+	case event.ByMonth:
+		return now.AddDate(0, -1, 0), nil
+	}
+
+	return time.Time{}, wrapError(ErrInvalidEntity, "period %s not supported", period)
+}
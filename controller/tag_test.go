@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+)
+
+func TestTagTrending(t *testing.T) {
+	var (
+		currentApp = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		events  = event.NewMemService()
+		objects = object.NewMemService()
+		ownerID = uint64(rand.Int63())
+		fn      = TagTrending(events, objects)
+	)
+
+	err := events.Setup(currentApp.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = objects.Setup(currentApp.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := testPost(ownerID).Object
+	old.CreatedAt = time.Now().AddDate(0, 0, -3)
+	old.Tags = []string{"diy"}
+
+	old, err = objects.Put(currentApp.Namespace(), old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := testPost(ownerID).Object
+	post.Tags = []string{"review", "travel"}
+
+	_, err = objects.Put(currentApp.Namespace(), post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = events.Put(currentApp.Namespace(), &event.Event{
+			Enabled:    true,
+			ObjectID:   old.ID,
+			Owned:      true,
+			Type:       TypeLike,
+			UserID:     uint64(rand.Int63()),
+			Visibility: event.VisibilityPublic,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts, err := fn(currentApp, event.ByDay, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := object.TagCounts{
+		{Count: 2, Tag: "diy"},
+		{Count: 1, Tag: "review"},
+		{Count: 1, Tag: "travel"},
+	}
+
+	if have := ts; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ts, err = fn(currentApp, event.ByWeek, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = object.TagCounts{
+		{Count: 3, Tag: "diy"},
+	}
+
+	if have := ts; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = fn(currentApp, event.Period("1 year"), 10)
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
@@ -0,0 +1,544 @@
+package controller
+
+import (
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/timeline"
+)
+
+// timelineBackfill is the number of events and posts copied into a timeline
+// when a new connection is established.
+const timelineBackfill = 200
+
+// TimelineController bundles the business constraints to materialise the
+// timelines of users on write.
+type TimelineController struct {
+	connections connection.Service
+	events      event.Service
+	objects     object.Service
+	timelines   timeline.Service
+}
+
+// NewTimelineController returns a controller instance.
+func NewTimelineController(
+	connections connection.Service,
+	events event.Service,
+	objects object.Service,
+	timelines timeline.Service,
+) *TimelineController {
+	return &TimelineController{
+		connections: connections,
+		events:      events,
+		objects:     objects,
+		timelines:   timelines,
+	}
+}
+
+// ConnectionChange fans out confirmed connections to the timelines of the
+// users involved and their audiences. It also backfills or purges the
+// activities of the target in the timeline of the origin.
+func (c *TimelineController) ConnectionChange(
+	change *connection.StateChange,
+) error {
+	var (
+		ns       = change.Namespace
+		wasValid = isConnectionFanout(change.Old)
+		isValid  = isConnectionFanout(change.New)
+	)
+
+	switch {
+	case !wasValid && isValid:
+		con := change.New
+
+		rs, err := c.connectionRecipients(ns, con)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range rs {
+			err := c.timelinePut(ns, id, timeline.List{entryConnection(con)})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = c.backfill(ns, con.FromID, con.ToID)
+		if err != nil {
+			return err
+		}
+
+		if con.Type == connection.TypeFriend {
+			return c.backfill(ns, con.ToID, con.FromID)
+		}
+	case wasValid && !isValid:
+		con := change.Old
+
+		rs, err := c.connectionRecipients(ns, con)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range rs {
+			err := c.timelines.Remove(ns, id, timeline.List{entryConnection(con)})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = c.purge(ns, con.FromID, con.ToID)
+		if err != nil {
+			return err
+		}
+
+		if con.Type == connection.TypeFriend {
+			return c.purge(ns, con.ToID, con.FromID)
+		}
+	}
+
+	return nil
+}
+
+// EventChange fans out events visible to connections to the timelines of the
+// audience of the owner.
+func (c *TimelineController) EventChange(change *event.StateChange) error {
+	switch {
+	case isEventFanout(change.New):
+		return c.put(change.Namespace, change.New.UserID, entryEvent(change.New))
+	case isEventFanout(change.Old):
+		return c.remove(change.Namespace, change.Old.UserID, entryEvent(change.Old))
+	}
+
+	return nil
+}
+
+// ObjectChange fans out posts visible to connections to the timelines of the
+// audience of the owner.
+func (c *TimelineController) ObjectChange(change *object.StateChange) error {
+	switch {
+	case isPostFanout(change.New):
+		return c.put(change.Namespace, change.New.OwnerID, entryPost(change.New))
+	case isPostFanout(change.Old):
+		return c.remove(change.Namespace, change.Old.OwnerID, entryPost(change.Old))
+	}
+
+	return nil
+}
+
+// audience returns the ids of all users who see the activities of the origin
+// in their feed, which are its followers and friends.
+func (c *TimelineController) audience(
+	ns string,
+	origin uint64,
+) ([]uint64, error) {
+	fs, err := c.connections.Query(ns, connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			origin,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	is, err := c.connections.Query(ns, connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			origin,
+		},
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ids  = []uint64{}
+		seen = map[uint64]struct{}{}
+	)
+
+	for _, con := range append(fs, is...) {
+		id := con.FromID
+
+		if con.FromID == origin {
+			id = con.ToID
+		}
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		ids = append(ids, id)
+		seen[id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// backfill copies the recent events and posts of the target into the timeline
+// of the origin.
+func (c *TimelineController) backfill(ns string, origin, target uint64) error {
+	es, err := c.events.Query(ns, event.QueryOptions{
+		Enabled: &defaultEnabled,
+		Limit:   timelineBackfill,
+		UserIDs: []uint64{
+			target,
+		},
+		Visibilities: []event.Visibility{
+			event.VisibilityConnection,
+			event.VisibilityPublic,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	os, err := c.objects.Query(ns, object.QueryOptions{
+		Limit: timelineBackfill,
+		Owned: &defaultOwned,
+		OwnerIDs: []uint64{
+			target,
+		},
+		Types: []string{
+			TypePost,
+		},
+		Visibilities: []object.Visibility{
+			object.VisibilityConnection,
+			object.VisibilityPublic,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	entries := timeline.List{}
+
+	for _, e := range es {
+		entries = append(entries, entryEvent(e))
+	}
+
+	for _, o := range os {
+		if o.Deleted {
+			continue
+		}
+
+		entries = append(entries, entryPost(o))
+	}
+
+	return c.timelines.Put(ns, origin, entries)
+}
+
+// connected reports if the origin still sees the activities of the target
+// through any confirmed connection.
+func (c *TimelineController) connected(
+	ns string,
+	origin, target uint64,
+) (bool, error) {
+	cs, err := c.connections.Query(ns, connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			origin,
+		},
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			target,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(cs) > 0 {
+		return true, nil
+	}
+
+	cs, err = c.connections.Query(ns, connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			target,
+		},
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			origin,
+		},
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(cs) > 0, nil
+}
+
+// connectionRecipients returns the ids of all users whose timeline holds the
+// given connection.
+func (c *TimelineController) connectionRecipients(
+	ns string,
+	con *connection.Connection,
+) ([]uint64, error) {
+	var (
+		ids  = []uint64{con.ToID}
+		seen = map[uint64]struct{}{con.ToID: struct{}{}}
+	)
+
+	owners := []uint64{con.FromID}
+
+	if con.Type == connection.TypeFriend {
+		ids = append(ids, con.FromID)
+		seen[con.FromID] = struct{}{}
+		owners = append(owners, con.ToID)
+	}
+
+	for _, owner := range owners {
+		as, err := c.audience(ns, owner)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range as {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+
+			ids = append(ids, id)
+			seen[id] = struct{}{}
+		}
+	}
+
+	return ids, nil
+}
+
+// purge removes the events and posts of the target from the timeline of the
+// origin unless they are still connected.
+func (c *TimelineController) purge(ns string, origin, target uint64) error {
+	ok, err := c.connected(ns, origin, target)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return nil
+	}
+
+	es, err := c.timelines.Query(ns, origin, timeline.QueryOptions{
+		OwnerIDs: []uint64{
+			target,
+		},
+		Types: []timeline.Type{
+			timeline.TypeEvent,
+			timeline.TypePost,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.timelines.Remove(ns, origin, es)
+}
+
+func (c *TimelineController) put(
+	ns string,
+	owner uint64,
+	entry *timeline.Entry,
+) error {
+	ids, err := c.audience(ns, owner)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := c.timelinePut(ns, id, timeline.List{entry})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *TimelineController) remove(
+	ns string,
+	owner uint64,
+	entry *timeline.Entry,
+) error {
+	ids, err := c.audience(ns, owner)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := c.timelines.Remove(ns, id, timeline.List{entry})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timelinePut adds the entries to the timeline of the user. Timelines which
+// aren't backfilled yet first get the activities of all existing connections
+// of the user, so they are complete once the feed switches over to them.
+func (c *TimelineController) timelinePut(
+	ns string,
+	userID uint64,
+	entries timeline.List,
+) error {
+	ok, err := c.timelines.Backfilled(ns, userID)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		ids, err := c.neighbours(ns, userID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := c.backfill(ns, userID, id); err != nil {
+				return err
+			}
+		}
+
+		if err := c.timelines.PutBackfilled(ns, userID); err != nil {
+			return err
+		}
+	}
+
+	return c.timelines.Put(ns, userID, entries)
+}
+
+// neighbours returns the ids of all users whose activities are shown in the
+// feed of the origin, which are its followings and friends.
+func (c *TimelineController) neighbours(
+	ns string,
+	origin uint64,
+) ([]uint64, error) {
+	os, err := c.connections.Query(ns, connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: []uint64{
+			origin,
+		},
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		Types: socialTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	is, err := c.connections.Query(ns, connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: []uint64{
+			origin,
+		},
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ids  = []uint64{}
+		seen = map[uint64]struct{}{}
+	)
+
+	for _, con := range append(os, is...) {
+		id := con.ToID
+
+		if con.ToID == origin {
+			id = con.FromID
+		}
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		ids = append(ids, id)
+		seen[id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+func entryConnection(con *connection.Connection) *timeline.Entry {
+	t := timeline.TypeFollow
+
+	if con.Type == connection.TypeFriend {
+		t = timeline.TypeFriend
+	}
+
+	return &timeline.Entry{
+		CreatedAt: con.UpdatedAt,
+		OwnerID:   con.FromID,
+		TargetID:  con.ToID,
+		Type:      t,
+	}
+}
+
+func entryEvent(e *event.Event) *timeline.Entry {
+	return &timeline.Entry{
+		CreatedAt: e.CreatedAt,
+		ItemID:    e.ID,
+		OwnerID:   e.UserID,
+		Type:      timeline.TypeEvent,
+	}
+}
+
+func entryPost(o *object.Object) *timeline.Entry {
+	return &timeline.Entry{
+		CreatedAt: o.CreatedAt,
+		ItemID:    o.ID,
+		OwnerID:   o.OwnerID,
+		Type:      timeline.TypePost,
+	}
+}
+
+func isConnectionFanout(con *connection.Connection) bool {
+	if con == nil || !con.Enabled || con.State != connection.StateConfirmed {
+		return false
+	}
+
+	return con.Type == connection.TypeFollow || con.Type == connection.TypeFriend
+}
+
+func isEventFanout(e *event.Event) bool {
+	if e == nil || !e.Enabled {
+		return false
+	}
+
+	return e.Visibility == event.VisibilityConnection ||
+		e.Visibility == event.VisibilityPublic
+}
+
+func isPostFanout(o *object.Object) bool {
+	if o == nil || o.Deleted || !o.Owned || o.Type != TypePost {
+		return false
+	}
+
+	return o.Visibility == object.VisibilityConnection ||
+		o.Visibility == object.VisibilityPublic
+}
@@ -0,0 +1,397 @@
+package controller
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/connection"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/timeline"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+func TestTimelineControllerConnectionChange(t *testing.T) {
+	var (
+		a, c     = testSetupTimelineController(t)
+		ns       = a.Namespace()
+		owner    = uint64(rand.Int63())
+		follower = uint64(rand.Int63())
+	)
+
+	e, err := c.events.Put(ns, &event.Event{
+		Enabled:    true,
+		Type:       "review",
+		UserID:     owner,
+		Visibility: event.VisibilityConnection,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	con, err := c.connections.Put(ns, &connection.Connection{
+		Enabled: true,
+		FromID:  follower,
+		State:   connection.StateConfirmed,
+		ToID:    owner,
+		Type:    connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.ConnectionChange(&connection.StateChange{
+		Namespace: ns,
+		New:       con,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es, err := c.timelines.Query(ns, follower, timeline.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := es[0].ItemID, e.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	es, err = c.timelines.Query(ns, owner, timeline.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := es[0].Type, timeline.TypeFollow; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	old := *con
+	con.Enabled = false
+
+	con, err = c.connections.Put(ns, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.ConnectionChange(&connection.StateChange{
+		Namespace: ns,
+		New:       con,
+		Old:       &old,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []uint64{follower, owner} {
+		es, err := c.timelines.Query(ns, id, timeline.QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := len(es), 0; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
+
+func TestTimelineControllerEventChange(t *testing.T) {
+	var (
+		a, c     = testSetupTimelineController(t)
+		ns       = a.Namespace()
+		owner    = uint64(rand.Int63())
+		follower = testTimelineFollower(t, c, ns, owner)
+	)
+
+	e, err := c.events.Put(ns, &event.Event{
+		Enabled:    true,
+		Type:       "review",
+		UserID:     owner,
+		Visibility: event.VisibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.EventChange(&event.StateChange{
+		Namespace: ns,
+		New:       e,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es, err := c.timelines.Query(ns, follower, timeline.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	old := *e
+	e.Visibility = event.VisibilityPrivate
+
+	err = c.EventChange(&event.StateChange{
+		Namespace: ns,
+		New:       e,
+		Old:       &old,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es, err = c.timelines.Query(ns, follower, timeline.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(es), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestTimelineControllerObjectChange(t *testing.T) {
+	var (
+		a, c     = testSetupTimelineController(t)
+		ns       = a.Namespace()
+		owner    = uint64(rand.Int63())
+		follower = testTimelineFollower(t, c, ns, owner)
+	)
+
+	post, err := c.objects.Put(ns, testPost(owner).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.ObjectChange(&object.StateChange{
+		Namespace: ns,
+		New:       post,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es, err := c.timelines.Query(ns, follower, timeline.QueryOptions{
+		Types: []timeline.Type{
+			timeline.TypePost,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(es), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	old := *post
+	post.Deleted = true
+
+	err = c.ObjectChange(&object.StateChange{
+		Namespace: ns,
+		New:       post,
+		Old:       &old,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es, err = c.timelines.Query(ns, follower, timeline.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(es), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestFeedControllerEventsTimeline(t *testing.T) {
+	var (
+		a, c     = testSetupTimelineController(t)
+		ns       = a.Namespace()
+		users    = user.NewMemService()
+		owner    = uint64(rand.Int63())
+		follower = testTimelineFollower(t, c, ns, owner)
+		feeds    = NewFeedController(
+			c.connections,
+			c.events,
+			c.objects,
+			c.timelines,
+			users,
+		)
+	)
+
+	e, err := c.events.Put(ns, &event.Event{
+		Enabled:    true,
+		Type:       "review",
+		UserID:     owner,
+		Visibility: event.VisibilityConnection,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.EventChange(&event.StateChange{
+		Namespace: ns,
+		New:       e,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := feeds.Events(a, follower, event.QueryOptions{
+		Limit: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Events), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := feed.Events[0].ID, e.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestTimelineControllerBackfillExisting(t *testing.T) {
+	var (
+		a, c     = testSetupTimelineController(t)
+		ns       = a.Namespace()
+		owner    = uint64(rand.Int63())
+		other    = uint64(rand.Int63())
+		follower = testTimelineFollower(t, c, ns, owner)
+	)
+
+	// The follower predates the fan-out and only gets its first entry now.
+	_, err := c.connections.Put(ns, &connection.Connection{
+		Enabled: true,
+		FromID:  follower,
+		State:   connection.StateConfirmed,
+		ToID:    other,
+		Type:    connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing, err := c.objects.Put(ns, testPost(other).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := c.timelines.Backfilled(ns, follower)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	post, err := c.objects.Put(ns, testPost(owner).Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.ObjectChange(&object.StateChange{
+		Namespace: ns,
+		New:       post,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = c.timelines.Backfilled(ns, follower)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	es, err := c.timelines.Query(ns, follower, timeline.QueryOptions{
+		Types: []timeline.Type{
+			timeline.TypePost,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[uint64]bool{}
+
+	for _, id := range es.ItemIDs(timeline.TypePost) {
+		ids[id] = true
+	}
+
+	if !ids[existing.ID] || !ids[post.ID] {
+		t.Errorf("have %v, want %v and %v", ids, existing.ID, post.ID)
+	}
+}
+
+func testSetupTimelineController(t *testing.T) (*app.App, *TimelineController) {
+	var (
+		a = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+		}
+		connections = connection.NewMemService()
+		events      = event.NewMemService()
+		objects     = object.NewMemService()
+		timelines   = timeline.NewMemService()
+	)
+
+	err := connections.Setup(a.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = events.Setup(a.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = objects.Setup(a.Namespace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a, NewTimelineController(connections, events, objects, timelines)
+}
+
+func testTimelineFollower(
+	t *testing.T,
+	c *TimelineController,
+	ns string,
+	owner uint64,
+) uint64 {
+	follower := uint64(rand.Int63())
+
+	_, err := c.connections.Put(ns, &connection.Connection{
+		Enabled: true,
+		FromID:  follower,
+		State:   connection.StateConfirmed,
+		ToID:    owner,
+		Type:    connection.TypeFollow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return follower
+}
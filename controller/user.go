@@ -1,35 +1,58 @@
 package controller
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/tapglue/multiverse/platform/generate"
+	"github.com/tapglue/multiverse/platform/access"
+	"github.com/tapglue/multiverse/platform/oidc"
+	"github.com/tapglue/multiverse/platform/password"
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/session"
 	"github.com/tapglue/multiverse/service/user"
 )
 
+// UserFeed is the composite answer for user list methods.
+type UserFeed struct {
+	Users user.List
+	// Fetched is the number of users the feed was built from, including the
+	// ones hidden from the origin, paging has to be based on it.
+	Fetched int
+}
+
 // UserController bundles the business constraints of Users.
 type UserController struct {
 	connections connection.Service
+	hasher      password.Hasher
 	sessions    session.Service
+	signer      access.Signer
 	users       user.Service
+	verifier    oidc.Verifier
 }
 
-// NewUserController returns a controller instance.
+// NewUserController returns a controller instance. New passwords are secured
+// with the given hasher, ID tokens of social logins are checked with the
+// verifier and access tokens are issued with the signer.
 func NewUserController(
 	connections connection.Service,
+	hasher password.Hasher,
 	sessions session.Service,
+	signer access.Signer,
 	users user.Service,
+	verifier oidc.Verifier,
 ) *UserController {
 	return &UserController{
 		connections: connections,
+		hasher:      hasher,
 		sessions:    sessions,
+		signer:      signer,
 		users:       users,
+		verifier:    verifier,
 	}
 }
 
@@ -43,6 +66,8 @@ func (c *UserController) Create(
 		return nil, err
 	}
 
+	constrainSocialIDs(currentApp, origin, nil, u)
+
 	if err := c.constrainUniqueEmail(currentApp, u); err != nil {
 		if !IsInvalidEntity(err) {
 			return nil, err
@@ -63,7 +88,7 @@ func (c *UserController) Create(
 		return nil, wrapError(ErrInvalidEntity, "%s", err)
 	}
 
-	epw, err := passwordSecure(u.Password)
+	epw, err := c.hasher.Hash(u.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +146,7 @@ func (c *UserController) ListByEmails(
 		u.IsFriend = r.isFriend
 		u.IsFollower = r.isFollower
 		u.IsFollowing = r.isFollowing
+		u.IsRequested = r.isRequested
 	}
 
 	return us, nil
@@ -151,6 +177,7 @@ func (c *UserController) ListByPlatformIDs(
 		u.IsFriend = r.isFriend
 		u.IsFollower = r.isFollower
 		u.IsFollowing = r.isFollowing
+		u.IsRequested = r.isRequested
 	}
 
 	return us, nil
@@ -180,6 +207,60 @@ func (c *UserController) LoginEmail(
 	return c.login(currentApp, us[0], password, origin.DeviceID)
 }
 
+// LoginSocial verifies the ID token issued by the identity provider configured
+// for the platform and returns the user linked to its subject with a valid
+// session token. Unknown subjects get a new user.
+func (c *UserController) LoginSocial(
+	currentApp *app.App,
+	origin Origin,
+	platform string,
+	idToken string,
+) (*user.User, error) {
+	claims, err := c.verifySocial(currentApp, platform, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := c.users.Query(currentApp.Namespace(), user.QueryOptions{
+		Enabled: &defaultEnabled,
+		SocialIDs: map[string][]string{
+			platform: {
+				claims.Subject,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(us) > 1 {
+		return nil, wrapError(ErrInvalidEntity, "%s id linked to multiple users", platform)
+	}
+
+	var u *user.User
+
+	if len(us) == 1 {
+		u = us[0]
+	} else {
+		u, err = c.createSocial(currentApp, platform, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.enrichSessionToken(currentApp, u, origin.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enrichConnectionCounts(c.connections, c.users, currentApp, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
 // LoginUsername finds the user by username and returns it with a valid session
 // token.
 func (c *UserController) LoginUsername(
@@ -205,17 +286,14 @@ func (c *UserController) LoginUsername(
 	return c.login(currentApp, us[0], password, origin.DeviceID)
 }
 
-// Logout destroys the session stored under token.
+// Logout destroys the session of the user with the given public id.
 func (c *UserController) Logout(
 	currentApp *app.App,
 	origin uint64,
-	token string,
+	publicID string,
 ) error {
 	ss, err := c.sessions.Query(currentApp.Namespace(), session.QueryOptions{
 		Enabled: &defaultEnabled,
-		IDs: []string{
-			token,
-		},
 		UserIDs: []uint64{
 			origin,
 		},
@@ -224,14 +302,88 @@ func (c *UserController) Logout(
 		return err
 	}
 
-	if len(ss) == 0 {
-		return nil
+	for _, s := range ss {
+		if s.PublicID() == publicID {
+			return sessionDisable(c.sessions, currentApp, s)
+		}
 	}
 
-	s := ss[0]
-	s.Enabled = false
+	return nil
+}
 
-	_, err = c.sessions.Put(currentApp.Namespace(), s)
+// SocialLink verifies the ID token issued by the identity provider configured
+// for the platform and links its subject to the origin.
+func (c *UserController) SocialLink(
+	currentApp *app.App,
+	origin *user.User,
+	platform string,
+	idToken string,
+) (*user.User, error) {
+	claims, err := c.verifySocial(currentApp, platform, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := c.users.Query(currentApp.Namespace(), user.QueryOptions{
+		Enabled: &defaultEnabled,
+		SocialIDs: map[string][]string{
+			platform: {
+				claims.Subject,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range us {
+		if u.ID != origin.ID {
+			return nil, wrapError(ErrInvalidEntity, "%s id linked to another user", platform)
+		}
+	}
+
+	if origin.SocialIDs == nil {
+		origin.SocialIDs = map[string]string{}
+	}
+
+	origin.SocialIDs[platform] = claims.Subject
+
+	return c.users.Put(currentApp.Namespace(), origin)
+}
+
+// SocialUnlink removes the link of the origin to the identity provider
+// configured for the platform. The last provider of users without email can't
+// be unlinked, as they would be left without a way to log in.
+func (c *UserController) SocialUnlink(
+	currentApp *app.App,
+	origin *user.User,
+	platform string,
+) error {
+	if _, ok := currentApp.Providers[platform]; !ok {
+		return wrapError(ErrNotFound, "provider '%s' not configured", platform)
+	}
+
+	if _, ok := origin.SocialIDs[platform]; !ok {
+		return wrapError(ErrNotFound, "%s not linked", platform)
+	}
+
+	if origin.Email == "" {
+		linked := 0
+
+		for p := range currentApp.Providers {
+			if _, ok := origin.SocialIDs[p]; ok {
+				linked++
+			}
+		}
+
+		if linked == 1 {
+			return wrapError(ErrInvalidEntity, "last login method can't be unlinked")
+		}
+	}
+
+	delete(origin.SocialIDs, platform)
+
+	_, err := c.users.Put(currentApp.Namespace(), origin)
 	return err
 }
 
@@ -257,7 +409,21 @@ func (c *UserController) Retrieve(
 
 	u := us[0]
 
-	err = enrichRelation(c.connections, currentApp, origin.UserID, u)
+	r, err := queryRelation(c.connections, currentApp, origin.UserID, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.isBlocked {
+		return nil, ErrNotFound
+	}
+
+	u.IsFriend = r.isFriend
+	u.IsFollower = r.isFollower
+	u.IsFollowing = r.isFollowing
+	u.IsRequested = r.isRequested
+
+	err = enrichMutualCounts(c.connections, currentApp, origin.UserID, user.List{u})
 	if err != nil {
 		return nil, err
 	}
@@ -277,28 +443,39 @@ func (c *UserController) Retrieve(
 	return u, nil
 }
 
-// Search returns all users for the given query.
+// Search returns the users matching the query ordered by relevance, prefix
+// matches on username, first name and last name rank highest.
 func (c *UserController) Search(
 	currentApp *app.App,
 	origin uint64,
 	query string,
 	opts user.QueryOptions,
-) (user.List, error) {
+) (*UserFeed, error) {
 	t := []string{query}
 
-	us, err := c.users.Search(currentApp.Namespace(), user.QueryOptions{
-		Before:     opts.Before,
+	fetched, err := c.users.Search(currentApp.Namespace(), user.QueryOptions{
 		Enabled:    &defaultEnabled,
 		Emails:     t,
 		Firstnames: t,
 		Lastnames:  t,
 		Limit:      opts.Limit,
+		Offset:     opts.Offset,
 		Usernames:  t,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	bs, err := blockedIDs(c.connections, currentApp, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := filterUsers(fetched, conditionHidden(bs))
+	if err != nil {
+		return nil, err
+	}
+
 	for _, u := range us {
 		err = enrichConnectionCounts(c.connections, c.users, currentApp, u)
 		if err != nil {
@@ -311,7 +488,15 @@ func (c *UserController) Search(
 		}
 	}
 
-	return us, nil
+	err = enrichMutualCounts(c.connections, currentApp, origin, us)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserFeed{
+		Fetched: len(fetched),
+		Users:   us,
+	}, nil
 }
 
 // Update stores the new attributes for the user.
@@ -326,11 +511,13 @@ func (c *UserController) Update(
 		return nil, err
 	}
 
+	constrainSocialIDs(currentApp, origin, old, new)
+
 	new.Enabled = true
 	new.ID = old.ID
 
 	if new.Password != "" {
-		epw, err := passwordSecure(new.Password)
+		epw, err := c.hasher.Hash(new.Password)
 		if err != nil {
 			return nil, err
 		}
@@ -347,8 +534,15 @@ func (c *UserController) Update(
 		}
 	}
 
-	if new.Private == nil {
-		new.Private = old.Private
+	if new.Private == nil && old.Private != nil {
+		p := *old.Private
+
+		// A new email has to be verified again.
+		if old.Email != new.Email {
+			p.Verified = false
+		}
+
+		new.Private = &p
 	}
 
 	if old.Username != new.Username {
@@ -376,6 +570,106 @@ func (c *UserController) Update(
 	return u, nil
 }
 
+// createSocial stores a new user for the verified identity. Emails are only
+// taken over if the provider verified them, a user registered with the same
+// email has to log in and link the provider instead. The random password can
+// be replaced through a password reset.
+func (c *UserController) createSocial(
+	currentApp *app.App,
+	platform string,
+	claims *oidc.Claims,
+) (*user.User, error) {
+	raw := make([]byte, 32)
+
+	_, err := rand.Read(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	epw, err := c.hasher.Hash(base64.RawURLEncoding.EncodeToString(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	u := &user.User{
+		Enabled:  true,
+		Password: epw,
+		SocialIDs: map[string]string{
+			platform: claims.Subject,
+		},
+	}
+
+	if claims.EmailVerified && claims.Email != "" {
+		u.Email = claims.Email
+		u.Private = &user.Private{
+			Verified: true,
+		}
+
+		err := c.constrainUniqueEmail(currentApp, u)
+		if err != nil {
+			if IsInvalidEntity(err) {
+				return nil, wrapError(
+					ErrInvalidEntity,
+					"email in use, log in and link %s instead",
+					platform,
+				)
+			}
+
+			return nil, err
+		}
+	} else {
+		sum := sha256.Sum256([]byte(claims.Subject))
+		u.Username = fmt.Sprintf("%s_%s", platform, hex.EncodeToString(sum[:8]))
+
+		err := c.constrainUniqueUsername(currentApp, u)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(claims.GivenName) <= 40 {
+		u.Firstname = claims.GivenName
+	}
+
+	if len(claims.FamilyName) <= 40 {
+		u.Lastname = claims.FamilyName
+	}
+
+	if err := u.Validate(); err != nil {
+		return nil, wrapError(ErrInvalidEntity, "%s", err)
+	}
+
+	return c.users.Put(currentApp.Namespace(), u)
+}
+
+// verifySocial checks the ID token against the identity provider configured
+// for the platform.
+func (c *UserController) verifySocial(
+	currentApp *app.App,
+	platform string,
+	idToken string,
+) (*oidc.Claims, error) {
+	p, ok := currentApp.Providers[platform]
+	if !ok {
+		return nil, wrapError(ErrInvalidEntity, "provider '%s' not configured", platform)
+	}
+
+	claims, err := c.verifier.Verify(p.OIDC(), idToken)
+	if err != nil {
+		if oidc.IsInvalidToken(err) {
+			return nil, wrapError(ErrUnauthorized, "%s", err)
+		}
+
+		if oidc.IsInvalidProvider(err) {
+			return nil, wrapError(ErrInvalidEntity, "%s", err)
+		}
+
+		return nil, err
+	}
+
+	return claims, nil
+}
+
 func (c *UserController) constrainUniqueEmail(
 	currentApp *app.App,
 	u *user.User,
@@ -527,6 +821,9 @@ func enrichConnectionCounts(
 	return nil
 }
 
+// enrichSessionToken attaches the session of the device to the user. Apps with
+// access tokens enabled get a signed access token and the session id as
+// refresh token instead of the session token.
 func (c *UserController) enrichSessionToken(
 	currentApp *app.App,
 	u *user.User,
@@ -545,11 +842,20 @@ func (c *UserController) enrichSessionToken(
 		return err
 	}
 
-	var s *session.Session
+	var (
+		idle, max = sessionLifetimes(currentApp)
+		now       = time.Now()
+		s         *session.Session
+	)
 
-	if len(ss) > 0 {
-		s = ss[0]
-	} else {
+	for _, es := range ss {
+		if !es.Expired(now, idle, max) {
+			s = es
+			break
+		}
+	}
+
+	if s == nil {
 		s, err = c.sessions.Put(currentApp.Namespace(), &session.Session{
 			DeviceID: deviceID,
 			Enabled:  true,
@@ -560,7 +866,19 @@ func (c *UserController) enrichSessionToken(
 		}
 	}
 
-	u.SessionToken = s.ID
+	if !currentApp.TokenRules().Enabled || c.signer == nil {
+		u.SessionToken = s.ID
+		return nil
+	}
+
+	g, err := accessGrant(c.signer, currentApp, s)
+	if err != nil {
+		return err
+	}
+
+	u.AccessExpiry = g.ExpiresAt
+	u.AccessToken = g.AccessToken
+	u.RefreshToken = g.RefreshToken
 
 	return nil
 }
@@ -571,7 +889,7 @@ func (c *UserController) login(
 	password string,
 	deviceID string,
 ) (*user.User, error) {
-	valid, err := passwordCompare(password, u.Password)
+	valid, outdated, err := c.hasher.Verify(password, u.Password)
 	if err != nil {
 		return nil, ErrNotFound
 	}
@@ -580,6 +898,20 @@ func (c *UserController) login(
 		return nil, wrapError(ErrUnauthorized, "wrong credentials")
 	}
 
+	// Hashes created with a former algorithm or cost are replaced while the
+	// plain password is at hand.
+	if outdated {
+		u.Password, err = c.hasher.Hash(password)
+		if err != nil {
+			return nil, err
+		}
+
+		u, err = c.users.Put(currentApp.Namespace(), u)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = c.enrichSessionToken(currentApp, u, deviceID)
 	if err != nil {
 		return nil, err
@@ -593,6 +925,42 @@ func (c *UserController) login(
 	return u, nil
 }
 
+// constrainSocialIDs keeps the social ids of platforms with a configured
+// identity provider, as they are only changed by verified links. Backend
+// integrations are trusted to set them.
+func constrainSocialIDs(
+	currentApp *app.App,
+	origin Origin,
+	old *user.User,
+	new *user.User,
+) {
+	if origin.IsBackend() {
+		return
+	}
+
+	for platform := range currentApp.Providers {
+		var (
+			id string
+			ok bool
+		)
+
+		if old != nil {
+			id, ok = old.SocialIDs[platform]
+		}
+
+		if !ok {
+			delete(new.SocialIDs, platform)
+			continue
+		}
+
+		if new.SocialIDs == nil {
+			new.SocialIDs = map[string]string{}
+		}
+
+		new.SocialIDs[platform] = id
+	}
+}
+
 func constrainUserPrivate(origin Origin, private *user.Private) error {
 	if !origin.IsBackend() && private != nil {
 		return wrapError(
@@ -622,76 +990,93 @@ func enrichRelation(
 	u.IsFriend = r.isFriend
 	u.IsFollower = r.isFollower
 	u.IsFollowing = r.isFollowing
+	u.IsRequested = r.isRequested
 
 	return nil
 }
 
-func passwordCompare(dec, enc string) (bool, error) {
-	d, err := base64.StdEncoding.DecodeString(enc)
+// enrichMutualCounts sets the number of users the origin and each of the users
+// are both connected with, in a fixed number of queries for all users.
+func enrichMutualCounts(
+	connections connection.Service,
+	currentApp *app.App,
+	origin uint64,
+	us user.List,
+) error {
+	ids, err := socialIDs(connections, currentApp, origin)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	ps := strings.SplitN(string(d), ":", 3)
+	targets := []uint64{}
 
-	epw, err := base64.StdEncoding.DecodeString(ps[2])
-	if err != nil {
-		return false, err
+	for _, u := range us {
+		if u.ID != origin {
+			targets = append(targets, u.ID)
+		}
 	}
 
-	salt, err := base64.StdEncoding.DecodeString(ps[0])
-	if err != nil {
-		return false, err
+	if len(ids) == 0 || len(targets) == 0 {
+		return nil
 	}
 
-	ts, err := base64.StdEncoding.DecodeString(ps[1])
-	if err != nil {
-		return false, err
-	}
+	is := []uint64{}
 
-	esalt := []byte{}
-	esalt = append(esalt, []byte(salt)...)
-	esalt = append(esalt, []byte(":")...)
-	esalt = append(esalt, []byte(ts)...)
+	for id := range ids {
+		is = append(is, id)
+	}
 
-	ipw, err := generate.EncryptPassword([]byte(dec), esalt)
+	cs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: is,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: targets,
+		Types: socialTypes,
+	})
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	return string(epw) == string(ipw), nil
-}
-
-func passwordSecure(pw string) (string, error) {
-	// create Salt
-	salt, err := generate.Salt()
+	fs, err := connections.Query(currentApp.Namespace(), connection.QueryOptions{
+		Enabled: &defaultEnabled,
+		FromIDs: targets,
+		States: []connection.State{
+			connection.StateConfirmed,
+		},
+		ToIDs: is,
+		Types: []connection.Type{
+			connection.TypeFriend,
+		},
+	})
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// create scrypt salt
-	var (
-		esalt = []byte{}
-		ts    = []byte(time.Now().Format(time.RFC3339))
-	)
+	mutuals := map[uint64]map[uint64]struct{}{}
 
-	esalt = append(esalt, salt...)
-	esalt = append(esalt, []byte(":")...)
-	esalt = append(esalt, ts...)
+	add := func(userID, mutualID uint64) {
+		if _, ok := mutuals[userID]; !ok {
+			mutuals[userID] = map[uint64]struct{}{}
+		}
 
-	// encrypt
-	epw, err := generate.EncryptPassword([]byte(pw), esalt)
-	if err != nil {
-		return "", err
+		mutuals[userID][mutualID] = struct{}{}
 	}
 
-	// encode
-	enc := fmt.Sprintf(
-		"%s:%s:%s",
-		base64.StdEncoding.EncodeToString(salt),
-		base64.StdEncoding.EncodeToString(ts),
-		base64.StdEncoding.EncodeToString(epw),
-	)
+	for _, con := range cs {
+		add(con.ToID, con.FromID)
+	}
 
-	return base64.StdEncoding.EncodeToString([]byte(enc)), nil
+	for _, con := range fs {
+		add(con.FromID, con.ToID)
+	}
+
+	for _, u := range us {
+		if u.ID != origin {
+			u.MutualCount = len(mutuals[u.ID])
+		}
+	}
+
+	return nil
 }
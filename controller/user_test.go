@@ -1,11 +1,21 @@
 package controller
 
 import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"testing"
+	"time"
+
+	crand "crypto/rand"
 
 	"github.com/tapglue/multiverse/platform/generate"
+	"github.com/tapglue/multiverse/platform/oidc"
+	"github.com/tapglue/multiverse/platform/password"
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
 	"github.com/tapglue/multiverse/service/session"
@@ -61,15 +71,136 @@ func TestUserUpdateConstrainPrivate(t *testing.T) {
 	}
 }
 
-func TestPassword(t *testing.T) {
-	password := "foobar"
+func TestUserSearch(t *testing.T) {
+	var (
+		app, c  = testSetupUserController(t)
+		origin  = testUser()
+		blocked = testUser()
+		users   = []*user.User{}
+	)
+
+	origin.Username = "origin"
+	blocked.Username = "sammy_blocked"
+
+	for _, u := range []*user.User{origin, blocked} {
+		_, err := c.users.Put(app.Namespace(), u)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, username := range []string{"jsamuel", "sam_jones"} {
+		u := testUser()
+		u.Username = username
+
+		u, err := c.users.Put(app.Namespace(), u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users = append(users, u)
+	}
+
+	_, err := c.connections.Put(app.Namespace(), &connection.Connection{
+		Enabled: true,
+		FromID:  blocked.ID,
+		State:   connection.StateConfirmed,
+		ToID:    origin.ID,
+		Type:    connection.TypeBlock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := c.Search(app, origin.ID, "sam", user.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := feed.Fetched, 3; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := len(feed.Users), 2; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := feed.Users[0].ID, users[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	// A page emptied by the block still counts for paging.
+	feed, err = c.Search(app, origin.ID, "sam", user.QueryOptions{
+		Limit:  1,
+		Offset: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := feed.Fetched, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := len(feed.Users), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	feed, err = c.Search(app, origin.ID, "sam", user.QueryOptions{
+		Limit:  1,
+		Offset: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(feed.Users), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := feed.Users[0].ID, users[0].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestUserControllerLoginRehash(t *testing.T) {
+	var (
+		app, c = testSetupUserController(t)
+		old    = password.Hasher{Algorithm: password.AlgorithmBcrypt, Cost: 4}
+	)
+
+	epw, err := old.Hash("foobar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := c.users.Put(app.Namespace(), &user.User{
+		Enabled:  true,
+		Password: epw,
+		Username: "alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.LoginUsername(app, Origin{DeviceID: "device"}, u.Username, "wrong")
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.LoginUsername(app, Origin{DeviceID: "device"}, u.Username, "foobar")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	epw, err := passwordSecure(password)
+	us, err := c.users.Query(app.Namespace(), user.QueryOptions{
+		IDs: []uint64{u.ID},
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	valid, err := passwordCompare(password, epw)
+	valid, outdated, err := c.hasher.Verify("foobar", us[0].Password)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -77,6 +208,294 @@ func TestPassword(t *testing.T) {
 	if have, want := valid, true; have != want {
 		t.Errorf("have %v, want %v", have, want)
 	}
+
+	if have, want := outdated, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestUserControllerLoginAccess(t *testing.T) {
+	currentApp, c := testSetupUserController(t)
+
+	currentApp.Tokens = &app.Tokens{
+		Enabled: true,
+	}
+
+	origin := Origin{
+		DeviceID:    session.DeviceIDUnknown,
+		Integration: IntegrationApplication,
+	}
+
+	created, err := c.Create(currentApp, origin, testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := created.SessionToken, ""; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if created.RefreshToken == "" {
+		t.Error("want refresh token")
+	}
+
+	claims, err := c.signer.Verify(created.AccessToken, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := claims.UserID, created.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := claims.Namespace, currentApp.Namespace(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := claims.ExpiresAt, created.AccessExpiry; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestUserControllerLoginSocial(t *testing.T) {
+	var (
+		app, c, key = testSetupUserControllerSocial(t)
+		origin      = Origin{
+			DeviceID:    session.DeviceIDUnknown,
+			Integration: IntegrationApplication,
+		}
+	)
+
+	_, err := c.LoginSocial(app, origin, "unknown", testIDToken(t, key, "1234", "alice@example.com"))
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.LoginSocial(app, origin, "google", "invalid")
+	if have, want := err, ErrUnauthorized; !IsUnauthorized(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	created, err := c.LoginSocial(app, origin, "google", testIDToken(t, key, "1234", "alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.SessionToken == "" {
+		t.Error("session token missing")
+	}
+
+	if have, want := created.Email, "alice@example.com"; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := created.Private.Verified, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	u, err := c.LoginSocial(app, origin, "google", testIDToken(t, key, "1234", "alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := u.ID, created.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = c.LoginSocial(app, origin, "google", testIDToken(t, key, "5678", "alice@example.com"))
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	u, err = c.LoginSocial(app, origin, "google", testIDToken(t, key, "5678", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.ID == created.ID {
+		t.Errorf("have %v, want new user", u.ID)
+	}
+
+	if u.Username == "" {
+		t.Error("username missing")
+	}
+}
+
+func TestUserControllerSocialLink(t *testing.T) {
+	var (
+		app, c, key = testSetupUserControllerSocial(t)
+		origin      = Origin{
+			DeviceID:    session.DeviceIDUnknown,
+			Integration: IntegrationApplication,
+		}
+	)
+
+	alice, err := c.Create(app, origin, testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := c.Create(app, origin, testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.SocialLink(app, alice, "google", testIDToken(t, key, "1234", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.SocialLink(app, bob, "google", testIDToken(t, key, "1234", ""))
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	u, err := c.LoginSocial(app, origin, "google", testIDToken(t, key, "1234", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := u.ID, alice.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = c.SocialUnlink(app, u, "google")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SocialUnlink(app, u, "google")
+	if have, want := err, ErrNotFound; !IsNotFound(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	social, err := c.LoginSocial(app, origin, "google", testIDToken(t, key, "5678", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SocialUnlink(app, social, "google")
+	if have, want := err, ErrInvalidEntity; !IsInvalidEntity(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestUserUpdateConstrainSocialIDs(t *testing.T) {
+	var (
+		app, c, _ = testSetupUserControllerSocial(t)
+		origin    = Origin{
+			DeviceID:    session.DeviceIDUnknown,
+			Integration: IntegrationApplication,
+		}
+	)
+
+	old, err := c.Create(app, origin, testUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	new := *old
+	new.Password = ""
+	new.SocialIDs = map[string]string{
+		"facebook": "9876",
+		"google":   "1234",
+	}
+
+	u, err := c.Update(app, origin, old, &new)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := u.SocialIDs["facebook"], "9876"; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if _, ok := u.SocialIDs["google"]; ok {
+		t.Errorf("google id set through update")
+	}
+}
+
+func TestUserUpdateEmailUnverified(t *testing.T) {
+	var (
+		app, c = testSetupUserController(t)
+		origin = Origin{
+			DeviceID:    session.DeviceIDUnknown,
+			Integration: IntegrationApplication,
+		}
+		u = testUser()
+	)
+
+	u.Private = &user.Private{
+		Verified: true,
+	}
+
+	old, err := c.users.Put(app.Namespace(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	new := *old
+	new.Password = ""
+	new.Private = nil
+
+	updated, err := c.Update(app, origin, old, &new)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := updated.Private.Verified, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	new = *updated
+	new.Email = "changed@example.com"
+	new.Password = ""
+	new.Private = nil
+
+	updated, err = c.Update(app, origin, updated, &new)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := updated.Private.Verified, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := old.Private.Verified, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testIDToken(t *testing.T, key *rsa.PrivateKey, subject, email string) string {
+	enc := base64.RawURLEncoding
+
+	h, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud":            "client",
+		"email":          email,
+		"email_verified": email != "",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iss":            "https://id.example.com",
+		"sub":            subject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := enc.EncodeToString(h) + "." + enc.EncodeToString(claims)
+	digest := crypto.SHA256.New()
+	_, _ = digest.Write([]byte(signed))
+
+	sig, err := rsa.SignPKCS1v15(crand.Reader, key, crypto.SHA256, digest.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + enc.EncodeToString(sig)
 }
 
 func testSetupUserController(
@@ -90,9 +509,70 @@ func testSetupUserController(
 		connections = connection.NewMemService()
 		sessions    = session.NewMemService()
 		users       = user.NewMemService()
+		verifier    = oidc.NewVerifier(func(url string) ([]byte, error) {
+			return []byte(`{"keys":[]}`), nil
+		}, time.Hour)
+	)
+
+	return a, NewUserController(
+		connections,
+		password.DefaultHasher,
+		sessions,
+		testSigner(t),
+		users,
+		verifier,
+	)
+}
+
+// testSetupUserControllerSocial returns a controller which accepts ID tokens
+// signed with the returned key for the google platform of the app.
+func testSetupUserControllerSocial(
+	t *testing.T,
+) (*app.App, *UserController, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(crand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := json.Marshal(map[string][]map[string]string{
+		"keys": {
+			{
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		a = &app.App{
+			ID:    uint64(rand.Int63()),
+			OrgID: uint64(rand.Int63()),
+			Providers: app.Providers{
+				"google": {
+					ClientIDs: []string{"client"},
+					Issuer:    "https://id.example.com",
+					JWKSURL:   "https://id.example.com/keys",
+				},
+			},
+		}
+		verifier = oidc.NewVerifier(func(url string) ([]byte, error) {
+			return set, nil
+		}, time.Hour)
 	)
 
-	return a, NewUserController(connections, sessions, users)
+	return a, NewUserController(
+		connection.NewMemService(),
+		password.DefaultHasher,
+		session.NewMemService(),
+		testSigner(t),
+		user.NewMemService(),
+		verifier,
+	), key
 }
 
 func testUser() *user.User {
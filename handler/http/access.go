@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+)
+
+const tokenTypeBearer = "Bearer"
+
+// AccessRefresh exchanges a refresh token for a new access and refresh token.
+func AccessRefresh(fn controller.AccessRefreshFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			p          = payloadRefreshToken{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		g, err := fn(currentApp, p.token)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadAccessGrant{grant: g})
+	}
+}
+
+type payloadRefreshToken struct {
+	token string
+}
+
+func (p *payloadRefreshToken) UnmarshalJSON(raw []byte) error {
+	f := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	if f.RefreshToken == "" {
+		return fmt.Errorf("refresh_token must be set")
+	}
+
+	p.token = f.RefreshToken
+
+	return nil
+}
+
+type payloadAccessGrant struct {
+	grant *controller.AccessGrant
+}
+
+func (p *payloadAccessGrant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		AccessToken  string    `json:"access_token"`
+		ExpiresAt    time.Time `json:"expires_at"`
+		RefreshToken string    `json:"refresh_token"`
+		TokenType    string    `json:"token_type"`
+	}{
+		AccessToken:  p.grant.AccessToken,
+		ExpiresAt:    p.grant.ExpiresAt,
+		RefreshToken: p.grant.RefreshToken,
+		TokenType:    tokenTypeBearer,
+	})
+}
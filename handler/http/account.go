@@ -0,0 +1,145 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+)
+
+// EmailVerificationRequest sends a token to confirm the email of the current
+// user.
+func EmailVerificationRequest(fn controller.EmailVerificationRequestFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp  = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+		)
+
+		err := fn(currentApp, currentUser)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// EmailVerify confirms the email of the user the token from the payload was
+// issued to.
+func EmailVerify(fn controller.EmailVerifyFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			p          = payloadAccountToken{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		err = fn(currentApp, p.token)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// PasswordReset replaces the password of the user the token from the payload
+// was issued to.
+func PasswordReset(fn controller.PasswordResetFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			p          = payloadAccountToken{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		err = fn(currentApp, p.token, p.password)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// PasswordResetRequest sends a reset token to the email from the payload. The
+// response is the same whether an account exists for the email or not.
+func PasswordResetRequest(fn controller.PasswordResetRequestFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			p          = payloadPasswordResetRequest{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		err = fn(currentApp, p.email)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+type payloadAccountToken struct {
+	password string
+	token    string
+}
+
+func (p *payloadAccountToken) UnmarshalJSON(raw []byte) error {
+	f := struct {
+		Password string `json:"password"`
+		Token    string `json:"token"`
+	}{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	p.password = f.Password
+	p.token = f.Token
+
+	return nil
+}
+
+type payloadPasswordResetRequest struct {
+	email string
+}
+
+func (p *payloadPasswordResetRequest) UnmarshalJSON(raw []byte) error {
+	f := struct {
+		Email string `json:"email"`
+	}{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	p.email = f.Email
+
+	return nil
+}
@@ -10,8 +10,52 @@ import (
 
 	"github.com/tapglue/multiverse/controller"
 	"github.com/tapglue/multiverse/service/app"
+	"github.com/tapglue/multiverse/service/device"
 )
 
+// AppConnectionsRetrieve returns the connection rules of an App.
+func AppConnectionsRetrieve(fn controller.AppConnectionsRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+		)
+
+		rules, err := fn(currentOrg, publicID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, rules)
+	}
+}
+
+// AppConnectionsUpdate replaces the connection rules of an App.
+func AppConnectionsUpdate(fn controller.AppConnectionsUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+			rules      = &app.Connections{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(rules)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		rules, err = fn(currentOrg, publicID, rules)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, rules)
+	}
+}
+
 // AppCreate creates an application for the current Org.
 func AppCreate(fn controller.AppCreateFunc) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -101,6 +145,135 @@ func AppList(fn controller.AppListFunc) Handler {
 	}
 }
 
+// AppPushRetrieve returns the push configuration of an App.
+func AppPushRetrieve(fn controller.AppPushRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+		)
+
+		push, err := fn(currentOrg, publicID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadAppPush{push: push})
+	}
+}
+
+// AppPushUpdate replaces the push configuration of an App.
+func AppPushUpdate(fn controller.AppPushUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+			p          = payloadAppPush{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		push, err := fn(currentOrg, publicID, p.push)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadAppPush{push: push})
+	}
+}
+
+// AppProvidersRetrieve returns the identity providers of an App.
+func AppProvidersRetrieve(fn controller.AppProvidersRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+		)
+
+		providers, err := fn(currentOrg, publicID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, providers)
+	}
+}
+
+// AppProvidersUpdate replaces the identity providers of an App.
+func AppProvidersUpdate(fn controller.AppProvidersUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+			providers  = app.Providers{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&providers)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		providers, err = fn(currentOrg, publicID, providers)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, providers)
+	}
+}
+
+// AppSessionsRetrieve returns the session rules of an App.
+func AppSessionsRetrieve(fn controller.AppSessionsRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+		)
+
+		rules, err := fn(currentOrg, publicID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, rules)
+	}
+}
+
+// AppSessionsUpdate replaces the session rules of an App.
+func AppSessionsUpdate(fn controller.AppSessionsUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+			rules      = &app.Sessions{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(rules)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		rules, err = fn(currentOrg, publicID, rules)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, rules)
+	}
+}
+
 // AppUpdate updates the values of an App.
 func AppUpdate(fn controller.AppUpdateFunc) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -116,7 +289,7 @@ func AppUpdate(fn controller.AppUpdateFunc) Handler {
 			return
 		}
 
-		app, err := fn(currentOrg, publicID, p.name, p.description)
+		app, err := fn(currentOrg, publicID, p.name, p.description, p.reportThreshold)
 		if err != nil {
 			respondError(w, 0, err)
 			return
@@ -127,36 +300,39 @@ func AppUpdate(fn controller.AppUpdateFunc) Handler {
 }
 
 type payloadApp struct {
-	app         *app.App
-	description string
-	name        string
+	app             *app.App
+	description     string
+	name            string
+	reportThreshold *int
 }
 
 func (p *payloadApp) MarshalJSON() ([]byte, error) {
 	f := struct {
-		BackendToken string    `json:"backend_token"`
-		Description  string    `json:"description"`
-		Enabled      bool      `json:"enabled"`
-		InProduction bool      `json:"in_production"`
-		Name         string    `json:"name"`
-		OrgID        string    `json:"account_id"`
-		PublicID     string    `json:"id"`
-		Token        string    `json:"token"`
-		URL          string    `json:"url"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
+		BackendToken    string    `json:"backend_token"`
+		Description     string    `json:"description"`
+		Enabled         bool      `json:"enabled"`
+		InProduction    bool      `json:"in_production"`
+		Name            string    `json:"name"`
+		OrgID           string    `json:"account_id"`
+		PublicID        string    `json:"id"`
+		ReportThreshold int       `json:"report_threshold"`
+		Token           string    `json:"token"`
+		URL             string    `json:"url"`
+		CreatedAt       time.Time `json:"created_at"`
+		UpdatedAt       time.Time `json:"updated_at"`
 	}{
-		BackendToken: p.app.BackendToken,
-		Description:  p.app.Description,
-		Enabled:      p.app.Enabled,
-		InProduction: p.app.InProduction,
-		Name:         p.app.Name,
-		OrgID:        p.app.PublicOrgID,
-		PublicID:     p.app.PublicID,
-		Token:        p.app.Token,
-		URL:          p.app.URL,
-		CreatedAt:    p.app.CreatedAt,
-		UpdatedAt:    p.app.UpdatedAt,
+		BackendToken:    p.app.BackendToken,
+		Description:     p.app.Description,
+		Enabled:         p.app.Enabled,
+		InProduction:    p.app.InProduction,
+		Name:            p.app.Name,
+		OrgID:           p.app.PublicOrgID,
+		PublicID:        p.app.PublicID,
+		ReportThreshold: p.app.ReportThreshold,
+		Token:           p.app.Token,
+		URL:             p.app.URL,
+		CreatedAt:       p.app.CreatedAt,
+		UpdatedAt:       p.app.UpdatedAt,
 	}
 
 	return json.Marshal(&f)
@@ -164,8 +340,9 @@ func (p *payloadApp) MarshalJSON() ([]byte, error) {
 
 func (p *payloadApp) UnmarshalJSON(raw []byte) error {
 	f := struct {
-		Description string `json:"description"`
-		Name        string `json:"name"`
+		Description     string `json:"description"`
+		Name            string `json:"name"`
+		ReportThreshold *int   `json:"report_threshold"`
 	}{}
 
 	err := json.Unmarshal(raw, &f)
@@ -175,10 +352,230 @@ func (p *payloadApp) UnmarshalJSON(raw []byte) error {
 
 	p.description = f.Description
 	p.name = f.Name
+	p.reportThreshold = f.ReportThreshold
 
 	return nil
 }
 
+// AppTemplatesRetrieve returns the notification templates of an App.
+func AppTemplatesRetrieve(fn controller.AppTemplatesRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+		)
+
+		ts, err := fn(currentOrg, publicID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, ts)
+	}
+}
+
+// AppTemplatesUpdate replaces the notification templates of an App.
+func AppTemplatesUpdate(fn controller.AppTemplatesUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+			ts         = app.Templates{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&ts)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		ts, err = fn(currentOrg, publicID, ts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, ts)
+	}
+}
+
+// AppTokensRetrieve returns the access token rules of an App.
+func AppTokensRetrieve(fn controller.AppTokensRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+		)
+
+		rules, err := fn(currentOrg, publicID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, rules)
+	}
+}
+
+// AppTokensUpdate replaces the access token rules of an App.
+func AppTokensUpdate(fn controller.AppTokensUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentOrg = orgFromContext(ctx)
+			publicID   = mux.Vars(r)["appID"]
+			rules      = &app.Tokens{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(rules)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		rules, err = fn(currentOrg, publicID, rules)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, rules)
+	}
+}
+
+type payloadAppPush struct {
+	push *app.Push
+}
+
+func (p *payloadAppPush) MarshalJSON() ([]byte, error) {
+	f := struct {
+		Android    string                   `json:"android,omitempty"`
+		APNS       *payloadAppPushAPNS      `json:"apns,omitempty"`
+		Backends   *payloadAppPushPlatforms `json:"backends"`
+		Enabled    bool                     `json:"enabled"`
+		FCM        *payloadAppPushFCM       `json:"fcm,omitempty"`
+		IOS        string                   `json:"ios,omitempty"`
+		IOSSandbox string                   `json:"ios_sandbox,omitempty"`
+		Scheme     string                   `json:"scheme"`
+	}{
+		Android: p.push.ARNs[device.PlatformAndroid],
+		Backends: &payloadAppPushPlatforms{
+			Android:    p.push.Backend(device.PlatformAndroid),
+			IOS:        p.push.Backend(device.PlatformIOS),
+			IOSSandbox: p.push.Backend(device.PlatformIOSSandbox),
+		},
+		Enabled:    p.push.Enabled,
+		IOS:        p.push.ARNs[device.PlatformIOS],
+		IOSSandbox: p.push.ARNs[device.PlatformIOSSandbox],
+		Scheme:     p.push.Scheme,
+	}
+
+	// Credentials are write-only, only their identifiers are exposed.
+	if p.push.APNS != nil {
+		f.APNS = &payloadAppPushAPNS{
+			KeyID:  p.push.APNS.KeyID,
+			TeamID: p.push.APNS.TeamID,
+			Topic:  p.push.APNS.Topic,
+		}
+	}
+
+	if p.push.FCM != nil {
+		f.FCM = &payloadAppPushFCM{
+			ClientEmail: p.push.FCM.ClientEmail,
+			ProjectID:   p.push.FCM.ProjectID,
+		}
+	}
+
+	return json.Marshal(&f)
+}
+
+func (p *payloadAppPush) UnmarshalJSON(raw []byte) error {
+	f := struct {
+		Android    string                   `json:"android"`
+		APNS       *payloadAppPushAPNS      `json:"apns"`
+		Backends   *payloadAppPushPlatforms `json:"backends"`
+		Enabled    bool                     `json:"enabled"`
+		FCM        *payloadAppPushFCM       `json:"fcm"`
+		IOS        string                   `json:"ios"`
+		IOSSandbox string                   `json:"ios_sandbox"`
+		Scheme     string                   `json:"scheme"`
+	}{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	p.push = &app.Push{
+		ARNs: platformMap(&payloadAppPushPlatforms{
+			Android:    f.Android,
+			IOS:        f.IOS,
+			IOSSandbox: f.IOSSandbox,
+		}),
+		Backends: platformMap(f.Backends),
+		Enabled:  f.Enabled,
+		Scheme:   f.Scheme,
+	}
+
+	if f.APNS != nil {
+		p.push.APNS = &app.APNS{
+			Key:    f.APNS.Key,
+			KeyID:  f.APNS.KeyID,
+			TeamID: f.APNS.TeamID,
+			Topic:  f.APNS.Topic,
+		}
+	}
+
+	if f.FCM != nil {
+		p.push.FCM = &app.FCM{
+			ClientEmail: f.FCM.ClientEmail,
+			PrivateKey:  f.FCM.PrivateKey,
+			ProjectID:   f.FCM.ProjectID,
+		}
+	}
+
+	return nil
+}
+
+type payloadAppPushAPNS struct {
+	Key    string `json:"key,omitempty"`
+	KeyID  string `json:"key_id"`
+	TeamID string `json:"team_id"`
+	Topic  string `json:"topic"`
+}
+
+type payloadAppPushFCM struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key,omitempty"`
+	ProjectID   string `json:"project_id"`
+}
+
+type payloadAppPushPlatforms struct {
+	Android    string `json:"android,omitempty"`
+	IOS        string `json:"ios,omitempty"`
+	IOSSandbox string `json:"ios_sandbox,omitempty"`
+}
+
+func platformMap(p *payloadAppPushPlatforms) map[device.Platform]string {
+	m := map[device.Platform]string{}
+
+	if p == nil {
+		return m
+	}
+
+	for platform, v := range map[device.Platform]string{
+		device.PlatformAndroid:    p.Android,
+		device.PlatformIOS:        p.IOS,
+		device.PlatformIOSSandbox: p.IOSSandbox,
+	} {
+		if v != "" {
+			m[platform] = v
+		}
+	}
+
+	return m
+}
+
 type payloadApps struct {
 	apps       app.List
 	pagination *payloadPagination
@@ -129,7 +129,72 @@ func CommentList(c *controller.CommentController) Handler {
 				commentCursorBefore(feed.Comments, opts.Limit),
 				nil,
 			),
-			userMap: feed.UserMap,
+			replyCounts: feed.ReplyCounts,
+			userMap:     feed.UserMap,
+		})
+	}
+}
+
+// CommentListReplies returns all replies to the given comment.
+func CommentListReplies(c *controller.CommentController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+		)
+
+		postID, err := extractPostID(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		commentID, err := extractCommentID(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts, err := extractCommentOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Before, err = extractTimeCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		feed, err := c.ListReplies(app, currentUser.ID, postID, commentID, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(feed.Comments) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadComments{
+			comments: feed.Comments,
+			pagination: pagination(
+				r,
+				opts.Limit,
+				commentCursorAfter(feed.Comments, opts.Limit),
+				commentCursorBefore(feed.Comments, opts.Limit),
+				nil,
+			),
+			replyCounts: feed.ReplyCounts,
+			userMap:     feed.UserMap,
 		})
 	}
 }
@@ -214,36 +279,54 @@ func CommentUpdate(c *controller.CommentController) Handler {
 type payloadComment struct {
 	contents object.Contents
 	comment  *object.Object
+	replies  *int
 }
 
 func (p *payloadComment) MarshalJSON() ([]byte, error) {
-	c := p.comment
+	var (
+		c = p.comment
+		f = struct {
+			Content   string                `json:"content"`
+			Contents  object.Contents       `json:"contents"`
+			Counts    *payloadCommentCounts `json:"counts,omitempty"`
+			ID        string                `json:"id"`
+			Mentions  payloadMentions       `json:"mentions,omitempty"`
+			ParentID  string                `json:"parent_id,omitempty"`
+			PostID    string                `json:"post_id"`
+			Private   *object.Private       `json:"private,omitempty"`
+			UserID    string                `json:"user_id"`
+			CreatedAt time.Time             `json:"created_at"`
+			UpdatedAt time.Time             `json:"updated_at"`
+		}{
+			Content:   c.Attachments[0].Contents[object.DefaultLanguage],
+			Contents:  c.Attachments[0].Contents,
+			ID:        strconv.FormatUint(c.ID, 10),
+			Mentions:  c.Mentions,
+			PostID:    strconv.FormatUint(c.ObjectID, 10),
+			Private:   c.Private,
+			UserID:    strconv.FormatUint(c.OwnerID, 10),
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		}
+	)
 
-	return json.Marshal(struct {
-		Content   string          `json:"content"`
-		Contents  object.Contents `json:"contents"`
-		ID        string          `json:"id"`
-		PostID    string          `json:"post_id"`
-		Private   *object.Private `json:"private,omitempty"`
-		UserID    string          `json:"user_id"`
-		CreatedAt time.Time       `json:"created_at"`
-		UpdatedAt time.Time       `json:"updated_at"`
-	}{
-		Content:   c.Attachments[0].Contents[object.DefaultLanguage],
-		Contents:  c.Attachments[0].Contents,
-		ID:        strconv.FormatUint(c.ID, 10),
-		PostID:    strconv.FormatUint(c.ObjectID, 10),
-		Private:   c.Private,
-		UserID:    strconv.FormatUint(c.OwnerID, 10),
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
-	})
+	if c.ParentID != 0 {
+		f.ParentID = strconv.FormatUint(c.ParentID, 10)
+	}
+
+	if p.replies != nil {
+		f.Counts = &payloadCommentCounts{Replies: *p.replies}
+	}
+
+	return json.Marshal(&f)
 }
 
 func (p *payloadComment) UnmarshalJSON(raw []byte) error {
 	f := struct {
 		Content  string            `json:"content"`
 		Contents map[string]string `json:"contents"`
+		Mentions payloadMentions   `json:"mentions"`
+		ParentID string            `json:"parent_id"`
 		Private  *object.Private   `json:"private,omitempty"`
 	}{}
 
@@ -262,29 +345,52 @@ func (p *payloadComment) UnmarshalJSON(raw []byte) error {
 		}
 	}
 
+	var parentID uint64
+
+	if f.ParentID != "" {
+		parentID, err = strconv.ParseUint(f.ParentID, 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+
 	p.comment = &object.Object{
 		Attachments: []object.Attachment{
 			{
 				Contents: f.Contents,
 			},
 		},
-		Private: f.Private,
+		Mentions: f.Mentions,
+		ParentID: parentID,
+		Private:  f.Private,
 	}
 
 	return nil
 }
 
+type payloadCommentCounts struct {
+	Replies int `json:"replies"`
+}
+
 type payloadComments struct {
-	comments   object.List
-	pagination *payloadPagination
-	userMap    user.Map
+	comments    object.List
+	pagination  *payloadPagination
+	replyCounts map[uint64]int
+	userMap     user.Map
 }
 
 func (p *payloadComments) MarshalJSON() ([]byte, error) {
 	cs := []*payloadComment{}
 
 	for _, comment := range p.comments {
-		cs = append(cs, &payloadComment{comment: comment})
+		pc := &payloadComment{comment: comment}
+
+		if p.replyCounts != nil {
+			replies := p.replyCounts[comment.ID]
+			pc.replies = &replies
+		}
+
+		cs = append(cs, pc)
 	}
 
 	return json.Marshal(struct {
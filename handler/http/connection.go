@@ -17,6 +17,49 @@ import (
 
 const namespaceStepz = "app_374_501"
 
+// ConnectionAnswer confirms or rejects a pending request sent to the current
+// user.
+func ConnectionAnswer(c *controller.ConnectionController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+			p           = payloadConnectionAnswer{}
+		)
+
+		fromID, err := strconv.ParseUint(mux.Vars(r)["fromID"], 10, 64)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		err = json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		con, err := c.Answer(
+			app,
+			currentUser.ID,
+			fromID,
+			connection.Type(mux.Vars(r)["type"]),
+			p.State,
+		)
+		if err != nil {
+			if controller.IsInvalidEntity(err) {
+				respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			} else {
+				respondError(w, 0, err)
+			}
+
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadConnection{con: con})
+	}
+}
+
 // ConnectionByState returns all connections for a user for a certain state.
 func ConnectionByState(c *controller.ConnectionController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -70,6 +113,61 @@ func ConnectionByState(c *controller.ConnectionController) Handler {
 	}
 }
 
+// ConnectionByType returns the blocks or mutes of the current user.
+func ConnectionByType(
+	c *controller.ConnectionController,
+	connectionType connection.Type,
+) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+		)
+
+		opts, err := extractConnectionOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Before, err = extractTimeCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		f, err := c.ByType(app, currentUser.ID, connectionType, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(f.Connections) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadConnections{
+			cons:   f.Connections,
+			origin: currentUser.ID,
+			pagination: pagination(
+				r,
+				opts.Limit,
+				connectionCursorAfter(f.Connections, opts.Limit),
+				connectionCursorBefore(f.Connections, opts.Limit),
+				nil,
+			),
+			userMap: f.UserMap,
+		})
+	}
+}
+
 // ConnectionDelete flags the given connection as disabled.
 func ConnectionDelete(c *controller.ConnectionController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -87,7 +185,7 @@ func ConnectionDelete(c *controller.ConnectionController) Handler {
 		con := &connection.Connection{
 			FromID: currentUser.ID,
 			ToID:   toID,
-			State:  connection.StatePending,
+			State:  connection.StateConfirmed,
 			Type:   connection.Type(mux.Vars(r)["type"]),
 		}
 
@@ -167,6 +265,63 @@ func ConnectionFollowers(c *controller.ConnectionController) Handler {
 	}
 }
 
+// ConnectionMutuals returns the list of users the current user is connected
+// with who are also connected with the user with the id.
+func ConnectionMutuals(c *controller.ConnectionController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+		)
+
+		userID, err := extractUserID(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, "invalid user id"))
+			return
+		}
+
+		opts, err := extractConnectionOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Before, err = extractTimeCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		feed, err := c.Mutuals(app, currentUser.ID, userID, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(feed.Users) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadUsers{
+			pagination: pagination(
+				r,
+				opts.Limit,
+				connectionCursorAfter(feed.Connections, opts.Limit),
+				connectionCursorBefore(feed.Connections, opts.Limit),
+				nil,
+			),
+			users: feed.Users,
+		})
+	}
+}
+
 // ConnectionFollowersMe returns the list of users who follow the user with the id.
 func ConnectionFollowersMe(c *controller.ConnectionController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -482,7 +637,7 @@ func ConnectionSocial(c *controller.ConnectionController) Handler {
 			p.Platform: p.ConnectionIDs,
 		}
 
-		us, err := c.CreateSocial(
+		is, err := c.CreateSocial(
 			app,
 			currentUser.ID,
 			p.Type,
@@ -494,12 +649,15 @@ func ConnectionSocial(c *controller.ConnectionController) Handler {
 			return
 		}
 
-		if len(us) == 0 {
+		if len(is) == 0 {
 			respondJSON(w, http.StatusNoContent, nil)
 			return
 		}
 
-		respondJSON(w, http.StatusOK, &payloadUsers{
+		us := is.Users()
+
+		respondJSON(w, http.StatusOK, &payloadConnectionImports{
+			imports: is,
 			pagination: pagination(
 				r,
 				opts.Limit,
@@ -550,6 +708,59 @@ func ConnectionUpdate(c *controller.ConnectionController) Handler {
 	}
 }
 
+type payloadConnectionAnswer struct {
+	State connection.State `json:"state"`
+}
+
+type payloadConnectionImport struct {
+	connectionImport *controller.ConnectionImport
+}
+
+func (p *payloadConnectionImport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Reason string `json:"reason,omitempty"`
+		Result string `json:"result"`
+		UserID string `json:"user_id"`
+	}{
+		Reason: p.connectionImport.Reason,
+		Result: string(p.connectionImport.Result),
+		UserID: strconv.FormatUint(p.connectionImport.User.ID, 10),
+	})
+}
+
+type payloadConnectionImports struct {
+	imports    controller.ConnectionImports
+	pagination *payloadPagination
+	users      user.List
+}
+
+func (p *payloadConnectionImports) MarshalJSON() ([]byte, error) {
+	var (
+		is = []*payloadConnectionImport{}
+		us = []*payloadUser{}
+	)
+
+	for _, i := range p.imports {
+		is = append(is, &payloadConnectionImport{connectionImport: i})
+	}
+
+	for _, u := range p.users {
+		us = append(us, &payloadUser{user: u})
+	}
+
+	return json.Marshal(struct {
+		Pagination *payloadPagination         `json:"paging"`
+		Results    []*payloadConnectionImport `json:"results"`
+		Users      []*payloadUser             `json:"users"`
+		UsersCount int                        `json:"users_count"`
+	}{
+		Pagination: p.pagination,
+		Results:    is,
+		Users:      us,
+		UsersCount: len(us),
+	})
+}
+
 type payloadConnection struct {
 	con *connection.Connection
 }
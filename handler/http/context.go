@@ -10,15 +10,16 @@ import (
 )
 
 const (
-	ctxKeyApp       = "app"
-	ctxKeyDeviceID  = "deviceID"
-	ctxKeyMember    = "member"
-	ctxKeyOrg       = "org"
-	ctxKeyRoute     = "route"
-	ctxKeyToken     = "token"
-	ctxKeyTokenType = "tokenType"
-	ctxKeyUser      = "user"
-	ctxKeyVersion   = "version"
+	ctxKeyApp        = "app"
+	ctxKeyDeviceID   = "deviceID"
+	ctxKeyMember     = "member"
+	ctxKeyOrg        = "org"
+	ctxKeyRoute      = "route"
+	ctxKeySessionID  = "sessionID"
+	ctxKeyTokenType  = "tokenType"
+	ctxKeyUser       = "user"
+	ctxKeyUserLoaded = "userLoaded"
+	ctxKeyVersion    = "version"
 
 	tokenApplication = "application"
 	tokenBackend     = "backend"
@@ -74,12 +75,16 @@ func routeInContext(ctx context.Context, route string) context.Context {
 	return context.WithValue(ctx, ctxKeyRoute, route)
 }
 
-func tokenFromContext(ctx context.Context) string {
-	return ctx.Value(ctxKeyToken).(string)
+// sessionIDFromContext returns the public id of the session the request was
+// made with, requests with a backend token have none.
+func sessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKeySessionID).(string)
+
+	return id
 }
 
-func tokenInContext(ctx context.Context, token string) context.Context {
-	return context.WithValue(ctx, ctxKeyToken, token)
+func sessionIDInContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKeySessionID, id)
 }
 
 func tokenTypeFromContext(ctx context.Context) string {
@@ -98,6 +103,14 @@ func userInContext(ctx context.Context, user *user.User) context.Context {
 	return context.WithValue(ctx, ctxKeyUser, user)
 }
 
+func userLoadedFromContext(ctx context.Context) bool {
+	return ctx.Value(ctxKeyUserLoaded).(bool)
+}
+
+func userLoadedInContext(ctx context.Context, loaded bool) context.Context {
+	return context.WithValue(ctx, ctxKeyUserLoaded, loaded)
+}
+
 func versionFromContext(ctx context.Context) string {
 	return ctx.Value(ctxKeyVersion).(string)
 }
@@ -28,6 +28,12 @@ func FeedEvents(c *controller.FeedController) Handler {
 			return
 		}
 
+		opts.After, err = extractTimeCursorAfter(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
 		opts.Before, err = extractTimeCursorBefore(r)
 		if err != nil {
 			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
@@ -87,12 +93,21 @@ func FeedNews(c *controller.FeedController) Handler {
 			return
 		}
 
-		eventOpts.Before, postOpts.Before, err = extractNewsCursor(r)
+		after, err := extractNewsCursorAfter(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		before, err := extractNewsCursorBefore(r)
 		if err != nil {
 			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
 			return
 		}
 
+		eventOpts.After, postOpts.After = after.Events, after.Posts
+		eventOpts.Before, postOpts.Before = before.Events, before.Posts
+
 		limit, err := extractLimit(r)
 		if err != nil {
 			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
@@ -112,13 +127,15 @@ func FeedNews(c *controller.FeedController) Handler {
 			return
 		}
 
-		after, err := newsCursorAfter(feed.Events, feed.Posts, limit)
+		position := newsCursorPosition(after, before)
+
+		nextAfter, err := newsCursorAfter(feed.Events, feed.Posts, position)
 		if err != nil {
 			respondError(w, 0, err)
 			return
 		}
 
-		before, err := newsCursorBefore(feed.Events, feed.Posts, limit)
+		nextBefore, err := newsCursorBefore(feed.Events, feed.Posts, position)
 		if err != nil {
 			respondError(w, 0, err)
 			return
@@ -126,7 +143,7 @@ func FeedNews(c *controller.FeedController) Handler {
 
 		respondJSON(w, http.StatusOK, &payloadFeedNews{
 			events:     feed.Events,
-			pagination: pagination(r, limit, after, before, nil),
+			pagination: pagination(r, limit, nextAfter, nextBefore, nil),
 			posts:      feed.Posts,
 			postMap:    feed.PostMap,
 			userMap:    feed.UserMap,
@@ -203,6 +220,12 @@ func FeedPosts(c *controller.FeedController) Handler {
 			return
 		}
 
+		opts.After, err = extractTimeCursorAfter(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
 		opts.Before, err = extractTimeCursorBefore(r)
 		if err != nil {
 			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
@@ -419,44 +442,80 @@ type postWhere struct {
 	Tags []string `json:"tags"`
 }
 
+// newsCursor tracks the position in the event and post timelines separately,
+// as both are paged independently and merged into one response.
 type newsCursor struct {
 	Events time.Time `json:"events"`
 	Posts  time.Time `json:"posts"`
 }
 
-func extractNewsCursor(r *http.Request) (time.Time, time.Time, error) {
+func extractNewsCursorAfter(r *http.Request) (newsCursor, error) {
+	cursor := newsCursor{}
+
+	param := r.URL.Query().Get(keyCursorAfter)
+	if param == "" {
+		return cursor, nil
+	}
+
+	err := decodeNewsCursor(param, &cursor)
+
+	return cursor, err
+}
+
+func extractNewsCursorBefore(r *http.Request) (newsCursor, error) {
 	var (
 		before = time.Now().UTC()
-		cursor = &newsCursor{
+		cursor = newsCursor{
 			Events: before,
 			Posts:  before,
 		}
-		param = r.URL.Query().Get(keyCursorBefore)
 	)
 
+	param := r.URL.Query().Get(keyCursorBefore)
 	if param == "" {
-		return before, before, nil
+		return cursor, nil
 	}
 
+	err := decodeNewsCursor(param, &cursor)
+
+	return cursor, err
+}
+
+func decodeNewsCursor(param string, cursor *newsCursor) error {
 	raw, err := cursorEncoding.DecodeString(param)
 	if err != nil {
-		return before, before, err
+		return err
 	}
 
-	err = json.Unmarshal(raw, cursor)
-	if err != nil {
-		return before, before, err
+	return json.Unmarshal(raw, cursor)
+}
+
+// newsCursorPosition returns the position the request was made from for both
+// timelines, which is the after cursor when paging forward and the before
+// cursor otherwise.
+func newsCursorPosition(after, before newsCursor) newsCursor {
+	position := before
+
+	if !after.Events.IsZero() {
+		position.Events = after.Events
 	}
 
-	return cursor.Events, cursor.Posts, nil
+	if !after.Posts.IsZero() {
+		position.Posts = after.Posts
+	}
+
+	return position
 }
 
+// newsCursorAfter encodes the positions of the newest event and post. If one
+// of the timelines is empty its position stays where the request started, so
+// the next page doesn't restart that timeline from the top.
 func newsCursorAfter(
 	es event.List,
 	ps controller.PostList,
-	limit int,
+	position newsCursor,
 ) (string, error) {
-	cursor := newsCursor{}
+	cursor := position
 
 	if len(es) > 0 {
 		cursor.Events = es[0].CreatedAt
@@ -474,12 +533,14 @@ func newsCursorAfter(
 	return cursorEncoding.EncodeToString(r), nil
 }
 
+// newsCursorBefore encodes the positions of the oldest event and post. If one
+// of the timelines is empty its position stays where the request started.
 func newsCursorBefore(
 	es event.List,
 	ps controller.PostList,
-	limit int,
+	position newsCursor,
 ) (string, error) {
-	cursor := newsCursor{}
+	cursor := position
 
 	if len(es) > 0 {
 		cursor.Events = es[len(es)-1].CreatedAt
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// payloadMentions transports the ids of mentioned users as strings.
+type payloadMentions []uint64
+
+func (p payloadMentions) MarshalJSON() ([]byte, error) {
+	ids := []string{}
+
+	for _, id := range p {
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+
+	return json.Marshal(ids)
+}
+
+func (p *payloadMentions) UnmarshalJSON(raw []byte) error {
+	ids := []string{}
+
+	err := json.Unmarshal(raw, &ids)
+	if err != nil {
+		return err
+	}
+
+	ms := payloadMentions{}
+
+	for _, id := range ids {
+		m, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		ms = append(ms, m)
+	}
+
+	*p = ms
+
+	return nil
+}
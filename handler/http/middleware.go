@@ -16,6 +16,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/tapglue/multiverse/limiter"
+	"github.com/tapglue/multiverse/platform/access"
 	"github.com/tapglue/multiverse/platform/metrics"
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/member"
@@ -179,8 +180,22 @@ func CtxPrepare(version string) Middleware {
 }
 
 // CtxUser extracts the user from the Authentication header and adds it to the
-// Context.
-func CtxUser(sessions session.Service, users user.Service) Middleware {
+// Context. Sessions are marked as seen through the tracker and disabled once
+// they exceed the idle or absolute lifetime configured for the app. Tracking
+// is best effort, failures are logged and fall back to the stored last seen
+// time. Apps with access tokens enabled verify signed access tokens with the
+// signer and check them against the revocations instead of looking up the
+// session, the user is only built from the claims then and needs CtxUserLoad
+// for handlers which depend on more than its id. Opaque session tokens issued
+// before access tokens were enabled are still looked up as sessions.
+func CtxUser(
+	logger log.Logger,
+	revocations session.Revocations,
+	sessions session.Service,
+	signer access.Signer,
+	tracker session.Tracker,
+	users user.Service,
+) Middleware {
 	return func(next Handler) Handler {
 		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 			var (
@@ -203,6 +218,46 @@ func CtxUser(sessions session.Service, users user.Service) Middleware {
 
 			switch tokenType {
 			case tokenApplication:
+				if app.TokenRules().Enabled &&
+					signer != nil &&
+					access.IsToken(token) {
+					c, err := signer.Verify(token, time.Now())
+					if err != nil {
+						if access.IsExpiredToken(err) {
+							respondError(w, 4007, wrapError(ErrUnauthorized, "access token expired"))
+							return
+						}
+
+						respondError(w, 4007, wrapError(ErrUnauthorized, "invalid access token"))
+						return
+					}
+
+					if c.Namespace != app.Namespace() {
+						respondError(w, 4007, wrapError(ErrUnauthorized, "invalid access token"))
+						return
+					}
+
+					revoked, err := revocations.Contains(app.Namespace(), c.SessionID)
+					if err != nil {
+						respondError(w, 0, err)
+						return
+					}
+
+					if revoked {
+						respondError(w, 4007, wrapError(ErrUnauthorized, "session revoked"))
+						return
+					}
+
+					ctx = sessionIDInContext(ctx, c.SessionID)
+					ctx = userLoadedInContext(ctx, false)
+
+					next(userInContext(ctx, &user.User{
+						Enabled: true,
+						ID:      c.UserID,
+					}), w, r)
+					return
+				}
+
 				ss, err := sessions.Query(app.Namespace(), session.QueryOptions{
 					Enabled: &defaultEnabled,
 					IDs:     []string{token},
@@ -217,8 +272,40 @@ func CtxUser(sessions session.Service, users user.Service) Middleware {
 					return
 				}
 
-				ctx = tokenInContext(ctx, token)
-				id = ss[0].UserID
+				var (
+					now   = time.Now()
+					rules = app.SessionRules()
+					s     = ss[0]
+				)
+
+				seen, err := tracker.Touch(app.Namespace(), s.ID, now)
+				if err != nil {
+					logger.Log(
+						"err", err,
+						"middleware", "CtxUser",
+						"user", s.UserID,
+					)
+				}
+
+				if seen.After(s.LastSeenAt) {
+					s.LastSeenAt = seen
+				}
+
+				if s.Expired(now, rules.Idle(), rules.Max()) {
+					s.Enabled = false
+
+					_, err := sessions.Put(app.Namespace(), s)
+					if err != nil {
+						respondError(w, 0, err)
+						return
+					}
+
+					respondError(w, 4007, wrapError(ErrUnauthorized, "session expired"))
+					return
+				}
+
+				ctx = sessionIDInContext(ctx, s.PublicID())
+				id = s.UserID
 			case tokenBackend:
 				var err error
 
@@ -248,6 +335,46 @@ func CtxUser(sessions session.Service, users user.Service) Middleware {
 				return
 			}
 
+			ctx = userLoadedInContext(ctx, true)
+
+			next(userInContext(ctx, us[0]), w, r)
+		}
+	}
+}
+
+// CtxUserLoad replaces the user of the Context with the stored one if it was
+// only built from the claims of an access token.
+func CtxUserLoad(users user.Service) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if userLoadedFromContext(ctx) {
+				next(ctx, w, r)
+				return
+			}
+
+			var (
+				app         = appFromContext(ctx)
+				currentUser = userFromContext(ctx)
+			)
+
+			us, err := users.Query(app.Namespace(), user.QueryOptions{
+				Enabled: &defaultEnabled,
+				IDs: []uint64{
+					currentUser.ID,
+				},
+			})
+			if err != nil {
+				respondError(w, 0, err)
+				return
+			}
+
+			if len(us) != 1 {
+				respondError(w, 4007, wrapError(ErrUnauthorized, "user not found"))
+				return
+			}
+
+			ctx = userLoadedInContext(ctx, true)
+
 			next(userInContext(ctx, us[0]), w, r)
 		}
 	}
@@ -0,0 +1,266 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/service/object"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+// ModerationList returns the reported content waiting for review.
+func ModerationList(fn controller.ModerationListFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			deviceID   = deviceIDFromContext(ctx)
+			tokenType  = tokenTypeFromContext(ctx)
+
+			origin = createOrigin(deviceID, tokenType, 0)
+		)
+
+		opts, err := extractModerationOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Before, err = extractTimeCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		feed, err := fn(currentApp, origin, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(feed.Objects) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadModerationQueue{
+			counts:  feed.ReportCounts,
+			objects: feed.Objects,
+			pagination: pagination(
+				r,
+				opts.Limit,
+				commentCursorAfter(feed.Objects, opts.Limit),
+				commentCursorBefore(feed.Objects, opts.Limit),
+				nil,
+			),
+			userMap: feed.UserMap,
+		})
+	}
+}
+
+// ModerationReview concludes the review of the reported content with the
+// given state.
+func ModerationReview(fn controller.ModerationReviewFunc, state object.State) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			deviceID   = deviceIDFromContext(ctx)
+			tokenType  = tokenTypeFromContext(ctx)
+
+			origin = createOrigin(deviceID, tokenType, 0)
+		)
+
+		objectID, err := extractObjectID(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		o, err := fn(currentApp, origin, objectID, state)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadModerationObject{object: o})
+	}
+}
+
+// ReportComment flags the comment as inappropriate.
+func ReportComment(fn controller.ReportCreateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		commentID, err := extractCommentID(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		reportCreate(ctx, w, r, fn, commentID)
+	}
+}
+
+// ReportPost flags the post as inappropriate.
+func ReportPost(fn controller.ReportCreateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		reportCreate(ctx, w, r, fn, 0)
+	}
+}
+
+func reportCreate(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	fn controller.ReportCreateFunc,
+	commentID uint64,
+) {
+	var (
+		currentApp = appFromContext(ctx)
+		origin     = originFromContext(ctx)
+		p          = payloadReport{}
+	)
+
+	postID, err := extractPostID(r)
+	if err != nil {
+		respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+		return
+	}
+
+	// The reason is optional and so is the body.
+	err = json.NewDecoder(r.Body).Decode(&p)
+	if err != nil && err != io.EOF {
+		respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+		return
+	}
+
+	report, err := fn(currentApp, origin, postID, commentID, p.reason)
+	if err != nil {
+		respondError(w, 0, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, &payloadReport{report: report})
+}
+
+type payloadModerationObject struct {
+	count  int
+	object *object.Object
+}
+
+func (p *payloadModerationObject) MarshalJSON() ([]byte, error) {
+	var (
+		o = p.object
+		f = struct {
+			Attachments  []object.Attachment `json:"attachments"`
+			ID           string              `json:"id"`
+			ObjectID     string              `json:"object_id,omitempty"`
+			Private      *object.Private     `json:"private,omitempty"`
+			ReportsCount int                 `json:"reports_count"`
+			Type         string              `json:"type"`
+			UserID       string              `json:"user_id"`
+			CreatedAt    time.Time           `json:"created_at"`
+			UpdatedAt    time.Time           `json:"updated_at"`
+		}{
+			Attachments:  o.Attachments,
+			ID:           strconv.FormatUint(o.ID, 10),
+			Private:      o.Private,
+			ReportsCount: p.count,
+			Type:         o.Type,
+			UserID:       strconv.FormatUint(o.OwnerID, 10),
+			CreatedAt:    o.CreatedAt,
+			UpdatedAt:    o.UpdatedAt,
+		}
+	)
+
+	if o.ObjectID != 0 {
+		f.ObjectID = strconv.FormatUint(o.ObjectID, 10)
+	}
+
+	return json.Marshal(&f)
+}
+
+type payloadModerationQueue struct {
+	counts     map[uint64]int
+	objects    object.List
+	pagination *payloadPagination
+	userMap    user.Map
+}
+
+func (p *payloadModerationQueue) MarshalJSON() ([]byte, error) {
+	os := []*payloadModerationObject{}
+
+	for _, o := range p.objects {
+		os = append(os, &payloadModerationObject{
+			count:  p.counts[o.ID],
+			object: o,
+		})
+	}
+
+	return json.Marshal(struct {
+		Objects      []*payloadModerationObject `json:"objects"`
+		ObjectsCount int                        `json:"objects_count"`
+		Pagination   *payloadPagination         `json:"paging"`
+		UserMap      *payloadUserMap            `json:"users"`
+		UsersCount   int                        `json:"users_count"`
+	}{
+		Objects:      os,
+		ObjectsCount: len(os),
+		Pagination:   p.pagination,
+		UserMap:      &payloadUserMap{userMap: p.userMap},
+		UsersCount:   len(p.userMap),
+	})
+}
+
+type payloadReport struct {
+	reason string
+	report *object.Object
+}
+
+func (p *payloadReport) MarshalJSON() ([]byte, error) {
+	var (
+		r = p.report
+		f = struct {
+			ID        string    `json:"id"`
+			ObjectID  string    `json:"object_id"`
+			Reason    string    `json:"reason,omitempty"`
+			UserID    string    `json:"user_id"`
+			CreatedAt time.Time `json:"created_at"`
+		}{
+			ID:        strconv.FormatUint(r.ID, 10),
+			ObjectID:  strconv.FormatUint(r.ObjectID, 10),
+			UserID:    strconv.FormatUint(r.OwnerID, 10),
+			CreatedAt: r.CreatedAt,
+		}
+	)
+
+	if len(r.Attachments) > 0 {
+		f.Reason = r.Attachments[0].Contents[object.DefaultLanguage]
+	}
+
+	return json.Marshal(&f)
+}
+
+func (p *payloadReport) UnmarshalJSON(raw []byte) error {
+	f := struct {
+		Reason string `json:"reason"`
+	}{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	p.reason = f.Reason
+
+	return nil
+}
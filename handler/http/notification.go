@@ -0,0 +1,217 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/service/notification"
+	"github.com/tapglue/multiverse/service/user"
+)
+
+// NotificationList returns the notifications of the current user.
+func NotificationList(fn controller.NotificationListFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+			opts       = notification.QueryOptions{}
+			err        error
+		)
+
+		opts.After, err = extractTimeCursorAfter(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Before, err = extractTimeCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		feed, err := fn(currentApp, origin, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(feed.Notifications) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadNotifications{
+			notifications: feed.Notifications,
+			pagination: pagination(
+				r,
+				opts.Limit,
+				notificationCursorAfter(feed.Notifications, opts.Limit),
+				notificationCursorBefore(feed.Notifications, opts.Limit),
+				nil,
+			),
+			userMap: feed.UserMap,
+		})
+	}
+}
+
+// NotificationRead marks a notification of the current user as read.
+func NotificationRead(fn controller.NotificationReadFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+		)
+
+		id, err := extractNotificationID(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		err = fn(currentApp, origin, id)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// NotificationReadAll marks all notifications of the current user as read.
+func NotificationReadAll(fn controller.NotificationReadAllFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+		)
+
+		err := fn(currentApp, origin)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// NotificationUnreadCount returns the number of unread notifications of the
+// current user.
+func NotificationUnreadCount(fn controller.NotificationUnreadCountFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+		)
+
+		count, err := fn(currentApp, origin)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		f := struct {
+			Count int `json:"unread_count"`
+		}{
+			Count: count,
+		}
+
+		respondJSON(w, http.StatusOK, &f)
+	}
+}
+
+type payloadNotification struct {
+	notification *notification.Notification
+}
+
+func (p *payloadNotification) MarshalJSON() ([]byte, error) {
+	n := p.notification
+
+	f := struct {
+		CreatedAt time.Time `json:"created_at"`
+		ID        string    `json:"id"`
+		Message   string    `json:"message"`
+		ObjectID  string    `json:"object_id,omitempty"`
+		OriginID  string    `json:"origin_id"`
+		Read      bool      `json:"read"`
+		Type      string    `json:"type"`
+		URN       string    `json:"urn"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}{
+		CreatedAt: n.CreatedAt,
+		ID:        strconv.FormatUint(n.ID, 10),
+		Message:   n.Message,
+		OriginID:  strconv.FormatUint(n.OriginID, 10),
+		Read:      n.Read,
+		Type:      n.Type,
+		URN:       n.URN,
+		UpdatedAt: n.UpdatedAt,
+	}
+
+	if n.ObjectID != 0 {
+		f.ObjectID = strconv.FormatUint(n.ObjectID, 10)
+	}
+
+	return json.Marshal(&f)
+}
+
+type payloadNotifications struct {
+	notifications notification.List
+	pagination    *payloadPagination
+	userMap       user.Map
+}
+
+func (p *payloadNotifications) MarshalJSON() ([]byte, error) {
+	ns := []*payloadNotification{}
+
+	for _, n := range p.notifications {
+		ns = append(ns, &payloadNotification{notification: n})
+	}
+
+	return json.Marshal(struct {
+		Notifications      []*payloadNotification `json:"notifications"`
+		NotificationsCount int                    `json:"notifications_count"`
+		Pagination         *payloadPagination     `json:"paging"`
+		UserMap            *payloadUserMap        `json:"users"`
+		UserCount          int                    `json:"users_count"`
+	}{
+		Notifications:      ns,
+		NotificationsCount: len(ns),
+		Pagination:         p.pagination,
+		UserMap:            &payloadUserMap{userMap: p.userMap},
+		UserCount:          len(p.userMap),
+	})
+}
+
+func notificationCursorAfter(ns notification.List, limit int) string {
+	var after string
+
+	if len(ns) > 0 {
+		after = toTimeCursor(ns[0].CreatedAt)
+	}
+
+	return after
+}
+
+func notificationCursorBefore(ns notification.List, limit int) string {
+	var before string
+
+	if len(ns) > 0 {
+		before = toTimeCursor(ns[len(ns)-1].CreatedAt)
+	}
+
+	return before
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -174,6 +175,218 @@ func PostListAll(c *controller.PostController) Handler {
 	}
 }
 
+// PostSearch returns all publicly visible posts matching the search query.
+func PostSearch(c *controller.PostController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+			query       = r.URL.Query().Get(keyQuery)
+		)
+
+		if query == "" {
+			respondError(w, 0, wrapError(ErrBadRequest, "missing query"))
+			return
+		}
+
+		lang, err := extractLang(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts, err := extractPostOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Offset, err = extractOffsetCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		feed, err := c.Search(app, currentUser.ID, lang, query, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if feed.Fetched == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadPosts{
+			pagination: pagination(
+				r,
+				opts.Limit,
+				searchCursorAfter(opts.Offset, opts.Limit),
+				searchCursorBefore(opts.Offset, opts.Limit, feed.Fetched),
+				url.Values{
+					keyLang:  []string{lang},
+					keyQuery: []string{query},
+				},
+			),
+			posts:   feed.Posts,
+			userMap: feed.UserMap,
+		})
+	}
+}
+
+// PostListNearby returns all publicly visible posts located around the given
+// coordinates or within the given bounding box.
+func PostListNearby(c *controller.PostController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+			params      = url.Values{}
+		)
+
+		opts, err := extractPostOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.BoundingBox, err = extractBoundingBox(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Radius, err = extractRadius(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.OrderDistance, err = extractOrderDistance(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		// Distance can only be measured from the centre of a radius.
+		opts.OrderDistance = opts.OrderDistance && opts.Radius != nil
+
+		if opts.OrderDistance {
+			opts.Offset, err = extractOffsetCursorBefore(r)
+		} else {
+			opts.Before, err = extractTimeCursorBefore(r)
+		}
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		feed, err := c.ListNearby(app, currentUser.ID, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if feed.Fetched == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		for _, key := range []string{
+			keyBoundingBox,
+			keyLatitude,
+			keyLongitude,
+			keyOrder,
+			keyRadius,
+			keyWhere,
+		} {
+			if v := r.URL.Query().Get(key); v != "" {
+				params.Set(key, v)
+			}
+		}
+
+		after := postCursorAfter(feed.Posts, opts.Limit)
+		before := toTimeCursor(feed.Before)
+
+		if opts.OrderDistance {
+			after = searchCursorAfter(opts.Offset, opts.Limit)
+			before = searchCursorBefore(opts.Offset, opts.Limit, feed.Fetched)
+		}
+
+		respondJSON(w, http.StatusOK, &payloadPosts{
+			pagination: pagination(r, opts.Limit, after, before, params),
+			posts:      feed.Posts,
+			userMap:    feed.UserMap,
+		})
+	}
+}
+
+// PostListTag returns all posts tagged with the tag from the path.
+func PostListTag(c *controller.PostController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+		)
+
+		opts, err := extractPostOpts(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Before, err = extractTimeCursorBefore(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Limit, err = extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		opts.Tags = append(opts.Tags, extractTag(r))
+
+		feed, err := c.ListAll(app, currentUser.ID, opts)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(feed.Posts) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadPosts{
+			pagination: pagination(
+				r,
+				opts.Limit,
+				postCursorAfter(feed.Posts, opts.Limit),
+				postCursorBefore(feed.Posts, opts.Limit),
+				nil,
+			),
+			posts:   feed.Posts,
+			userMap: feed.UserMap,
+		})
+	}
+}
+
 // PostListMe returns all posts of the current user.
 func PostListMe(c *controller.PostController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -356,6 +569,10 @@ func (p *payloadPost) MarshalJSON() ([]byte, error) {
 		CreatedAt    time.Time            `json:"created_at,omitempty"`
 		ID           string               `json:"id"`
 		IsLiked      bool                 `json:"is_liked"`
+		Latitude     float64              `json:"latitude"`
+		Location     string               `json:"location,omitempty"`
+		Longitude    float64              `json:"longitude"`
+		Mentions     payloadMentions      `json:"mentions,omitempty"`
 		Restrictions *object.Restrictions `json:"restrictions,omitempty"`
 		Tags         []string             `json:"tags,omitempty"`
 		UpdatedAt    time.Time            `json:"updated_at,omitempty"`
@@ -370,6 +587,10 @@ func (p *payloadPost) MarshalJSON() ([]byte, error) {
 		CreatedAt:    p.post.CreatedAt,
 		ID:           strconv.FormatUint(p.post.ID, 10),
 		IsLiked:      p.post.IsLiked,
+		Latitude:     p.post.Latitude,
+		Location:     p.post.Location,
+		Longitude:    p.post.Longitude,
+		Mentions:     p.post.Mentions,
 		Restrictions: p.post.Restrictions,
 		Tags:         p.post.Tags,
 		UpdatedAt:    p.post.UpdatedAt,
@@ -381,6 +602,10 @@ func (p *payloadPost) MarshalJSON() ([]byte, error) {
 func (p *payloadPost) UnmarshalJSON(raw []byte) error {
 	f := struct {
 		Attachments  []*payloadAttachment `json:"attachments"`
+		Latitude     float64              `json:"latitude"`
+		Location     string               `json:"location"`
+		Longitude    float64              `json:"longitude"`
+		Mentions     payloadMentions      `json:"mentions"`
 		Restrictions *object.Restrictions `json:"restrictions,omitempty"`
 		Tags         []string             `json:"tags,omitempty"`
 		Visibility   object.Visibility    `json:"visibility"`
@@ -399,6 +624,10 @@ func (p *payloadPost) UnmarshalJSON(raw []byte) error {
 
 	p.post = &controller.Post{Object: &object.Object{}}
 	p.post.Attachments = as
+	p.post.Latitude = f.Latitude
+	p.post.Location = f.Location
+	p.post.Longitude = f.Longitude
+	p.post.Mentions = f.Mentions
 	p.post.Restrictions = f.Restrictions
 	p.post.Tags = f.Tags
 	p.post.Visibility = f.Visibility
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/service/preference"
+)
+
+// PreferenceRetrieve returns the notification settings of the current user.
+func PreferenceRetrieve(fn controller.PreferenceRetrieveFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+		)
+
+		ps, err := fn(currentApp, origin)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadPreferences{preferences: ps})
+	}
+}
+
+// PreferenceUpdate stores the notification settings of the current user.
+func PreferenceUpdate(fn controller.PreferenceUpdateFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+			p          = payloadPreferenceUpdate{}
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		ps, err := fn(currentApp, origin, p.enabled)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadPreferences{preferences: ps})
+	}
+}
+
+type payloadPreferences struct {
+	preferences preference.List
+}
+
+func (p *payloadPreferences) MarshalJSON() ([]byte, error) {
+	f := map[preference.Type]bool{}
+
+	for _, pref := range p.preferences {
+		f[pref.Type] = pref.Enabled
+	}
+
+	return json.Marshal(f)
+}
+
+type payloadPreferenceUpdate struct {
+	enabled map[preference.Type]bool
+}
+
+func (p *payloadPreferenceUpdate) UnmarshalJSON(raw []byte) error {
+	f := map[preference.Type]bool{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	p.enabled = f
+
+	return nil
+}
@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"golang.org/x/text/language"
 
 	"github.com/tapglue/multiverse/service/app"
 	"github.com/tapglue/multiverse/service/connection"
@@ -20,18 +22,29 @@ import (
 )
 
 const (
-	cursorTimeFormat = time.RFC3339Nano
-	defaultLimit     = 100
-	keyCommentID     = "commentID"
-	keyCursorAfter   = "after"
-	keyCursorBefore  = "before"
-	keyLimit         = "limit"
-	keyPostID        = "postID"
-	keyQuery         = "q"
-	keyState         = "state"
-	keyUserID        = "userID"
-	keyWhere         = "where"
-	maxLimit         = 100
+	cursorTimeFormat  = time.RFC3339Nano
+	defaultLimit      = 100
+	keyBoundingBox    = "bbox"
+	keyCommentID      = "commentID"
+	keyCursorAfter    = "after"
+	keyCursorBefore   = "before"
+	keyLang           = "lang"
+	keyLatitude       = "lat"
+	keyLimit          = "limit"
+	keyLongitude      = "lon"
+	keyNotificationID = "notificationID"
+	keyObjectID       = "objectID"
+	keyOrder          = "order"
+	keyPostID         = "postID"
+	keyQuery          = "q"
+	keyRadius         = "radius"
+	keyState          = "state"
+	keyTag            = "tag"
+	keyUserID         = "userID"
+	keyWhere          = "where"
+	maxLimit          = 100
+	orderDistance     = "distance"
+	orderRecency      = "recency"
 
 	refFmt = "%s://%s%s?limit=%d&%s"
 )
@@ -129,6 +142,38 @@ func extractAppOpts(r *http.Request) (app.QueryOptions, error) {
 	return app.QueryOptions{}, nil
 }
 
+func extractBoundingBox(r *http.Request) (*object.BoundingBox, error) {
+	param := r.URL.Query().Get(keyBoundingBox)
+
+	if param == "" {
+		return nil, nil
+	}
+
+	ps := strings.Split(param, ",")
+
+	if len(ps) != 4 {
+		return nil, fmt.Errorf("bbox must be south,west,north,east")
+	}
+
+	cs := []float64{}
+
+	for _, p := range ps {
+		c, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox coordinate '%s'", p)
+		}
+
+		cs = append(cs, c)
+	}
+
+	return &object.BoundingBox{
+		East:  cs[3],
+		North: cs[2],
+		South: cs[0],
+		West:  cs[1],
+	}, nil
+}
+
 func extractCommentID(r *http.Request) (uint64, error) {
 	return strconv.ParseUint(mux.Vars(r)[keyCommentID], 10, 64)
 }
@@ -260,6 +305,45 @@ func extractIDCursorBefore(r *http.Request) (uint64, error) {
 	return strconv.ParseUint(string(cursor), 10, 64)
 }
 
+func extractOffsetCursorBefore(r *http.Request) (int, error) {
+	param := r.URL.Query().Get(keyCursorBefore)
+
+	if param == "" {
+		return 0, nil
+	}
+
+	cursor, err := cursorEncoding.DecodeString(param)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(string(cursor))
+	if err != nil {
+		return 0, err
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("offset can't be negative")
+	}
+
+	return offset, nil
+}
+
+func extractLang(r *http.Request) (string, error) {
+	lang := r.URL.Query().Get(keyLang)
+
+	if lang == "" {
+		return object.DefaultLanguage, nil
+	}
+
+	_, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("invalid language tag '%s'", lang)
+	}
+
+	return lang, nil
+}
+
 func extractLikeOpts(r *http.Request) (event.QueryOptions, error) {
 	return event.QueryOptions{}, nil
 }
@@ -283,6 +367,29 @@ func extractLimit(r *http.Request) (int, error) {
 	return limit, nil
 }
 
+func extractModerationOpts(r *http.Request) (object.QueryOptions, error) {
+	return object.QueryOptions{}, nil
+}
+
+func extractNotificationID(r *http.Request) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[keyNotificationID], 10, 64)
+}
+
+func extractObjectID(r *http.Request) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[keyObjectID], 10, 64)
+}
+
+func extractOrderDistance(r *http.Request) (bool, error) {
+	switch order := r.URL.Query().Get(keyOrder); order {
+	case "", orderDistance:
+		return true, nil
+	case orderRecency:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported order '%s'", order)
+	}
+}
+
 func extractPostID(r *http.Request) (uint64, error) {
 	return strconv.ParseUint(mux.Vars(r)[keyPostID], 10, 64)
 }
@@ -312,10 +419,68 @@ func extractPostOpts(r *http.Request) (object.QueryOptions, error) {
 	return opts, nil
 }
 
+func extractRadius(r *http.Request) (*object.Radius, error) {
+	var (
+		q      = r.URL.Query()
+		params = []string{
+			q.Get(keyLatitude),
+			q.Get(keyLongitude),
+			q.Get(keyRadius),
+		}
+	)
+
+	if params[0] == "" && params[1] == "" && params[2] == "" {
+		return nil, nil
+	}
+
+	vs := []float64{}
+
+	for i, key := range []string{keyLatitude, keyLongitude, keyRadius} {
+		if params[i] == "" {
+			return nil, fmt.Errorf("%s missing", key)
+		}
+
+		v, err := strconv.ParseFloat(params[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s'", key, params[i])
+		}
+
+		vs = append(vs, v)
+	}
+
+	return &object.Radius{
+		Distance:  vs[2],
+		Latitude:  vs[0],
+		Longitude: vs[1],
+	}, nil
+}
+
 func extractState(r *http.Request) connection.State {
 	return connection.State(mux.Vars(r)[keyState])
 }
 
+func extractTag(r *http.Request) string {
+	return mux.Vars(r)[keyTag]
+}
+
+func extractTimeCursorAfter(r *http.Request) (time.Time, error) {
+	var (
+		after time.Time
+		param = r.URL.Query().Get(keyCursorAfter)
+	)
+
+	if param == "" {
+		return after, nil
+	}
+
+	cursor, err := cursorEncoding.DecodeString(param)
+	if err != nil {
+		return after, err
+	}
+
+	return time.Parse(cursorTimeFormat, string(cursor))
+}
+
 func extractTimeCursorBefore(r *http.Request) (time.Time, error) {
 	var (
 		before = time.Now()
@@ -346,6 +511,10 @@ func toIDCursor(id uint64) string {
 	return cursorEncoding.EncodeToString([]byte(strconv.FormatUint(id, 10)))
 }
 
+func toOffsetCursor(offset int) string {
+	return cursorEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
 func toTimeCursor(t time.Time) string {
 	return cursorEncoding.EncodeToString([]byte(t.Format(cursorTimeFormat)))
 }
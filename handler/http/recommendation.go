@@ -1,6 +1,7 @@
 package http
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"golang.org/x/net/context"
@@ -9,6 +10,35 @@ import (
 	"github.com/tapglue/multiverse/service/event"
 )
 
+// RecommendUsers returns a ranked list of people the current user may know.
+func RecommendUsers(c *controller.RecommendationController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			app         = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+		)
+
+		limit, err := extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		rs, err := c.Users(app, currentUser.ID, limit)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(rs) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadRecommendations{recommendations: rs})
+	}
+}
+
 // RecommendUsersActiveDay returns a list of active users in the last day.
 func RecommendUsersActiveDay(c *controller.RecommendationController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -77,3 +107,39 @@ func RecommendUsersActiveMonth(c *controller.RecommendationController) Handler {
 		respondJSON(w, http.StatusOK, &payloadUsers{users: us})
 	}
 }
+
+type payloadRecommendation struct {
+	recommendation *controller.UserRecommendation
+}
+
+func (p *payloadRecommendation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		MutualCount int          `json:"mutual_count"`
+		Score       int          `json:"score"`
+		User        *payloadUser `json:"user"`
+	}{
+		MutualCount: p.recommendation.MutualCount,
+		Score:       p.recommendation.Score,
+		User:        &payloadUser{user: p.recommendation.User},
+	})
+}
+
+type payloadRecommendations struct {
+	recommendations controller.UserRecommendations
+}
+
+func (p *payloadRecommendations) MarshalJSON() ([]byte, error) {
+	rs := []*payloadRecommendation{}
+
+	for _, r := range p.recommendations {
+		rs = append(rs, &payloadRecommendation{recommendation: r})
+	}
+
+	return json.Marshal(struct {
+		Recommendations      []*payloadRecommendation `json:"recommendations"`
+		RecommendationsCount int                      `json:"recommendations_count"`
+	}{
+		Recommendations:      rs,
+		RecommendationsCount: len(rs),
+	})
+}
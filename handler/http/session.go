@@ -0,0 +1,140 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/service/device"
+	"github.com/tapglue/multiverse/service/session"
+)
+
+// SessionList returns all active sessions of the current user.
+func SessionList(fn controller.SessionListFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+			sessionID  = sessionIDFromContext(ctx)
+		)
+
+		feed, err := fn(currentApp, origin)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(feed.Sessions) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadSessions{
+			current:  sessionID,
+			devices:  feed.Devices,
+			sessions: feed.Sessions,
+		})
+	}
+}
+
+// SessionRevoke ends the session of the current user with the given id.
+func SessionRevoke(fn controller.SessionRevokeFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+			sessionID  = mux.Vars(r)["sessionID"]
+		)
+
+		err := fn(currentApp, origin, sessionID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// SessionRevokeOthers ends all sessions of the current user but the one the
+// request was made with, requests with a backend token end all of them.
+func SessionRevokeOthers(fn controller.SessionRevokeOthersFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			origin     = originFromContext(ctx)
+			sessionID  = sessionIDFromContext(ctx)
+		)
+
+		err := fn(currentApp, origin, sessionID)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
+type payloadSession struct {
+	current bool
+	device  *device.Device
+	session *session.Session
+}
+
+func (p *payloadSession) MarshalJSON() ([]byte, error) {
+	s := p.session
+
+	f := struct {
+		CreatedAt  time.Time       `json:"created_at"`
+		Current    bool            `json:"current"`
+		DeviceID   string          `json:"device_id"`
+		ID         string          `json:"id"`
+		Language   string          `json:"language,omitempty"`
+		LastSeenAt time.Time       `json:"last_seen_at"`
+		Platform   device.Platform `json:"platform,omitempty"`
+	}{
+		CreatedAt:  s.CreatedAt,
+		Current:    p.current,
+		DeviceID:   s.DeviceID,
+		ID:         s.PublicID(),
+		LastSeenAt: s.LastSeenAt,
+	}
+
+	if p.device != nil {
+		f.Language = p.device.Language
+		f.Platform = p.device.Platform
+	}
+
+	return json.Marshal(&f)
+}
+
+type payloadSessions struct {
+	current  string
+	devices  map[string]*device.Device
+	sessions session.List
+}
+
+func (p *payloadSessions) MarshalJSON() ([]byte, error) {
+	ss := []*payloadSession{}
+
+	for _, s := range p.sessions {
+		ss = append(ss, &payloadSession{
+			current: p.current != "" && s.PublicID() == p.current,
+			device:  p.devices[s.DeviceID],
+			session: s,
+		})
+	}
+
+	return json.Marshal(struct {
+		Sessions      []*payloadSession `json:"sessions"`
+		SessionsCount int               `json:"sessions_count"`
+	}{
+		Sessions:      ss,
+		SessionsCount: len(ss),
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/tapglue/multiverse/controller"
+	"github.com/tapglue/multiverse/service/event"
+	"github.com/tapglue/multiverse/service/object"
+)
+
+// TagSearch returns the tags starting with the query for autocompletion.
+func TagSearch(fn controller.TagSearchFunc) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			query      = r.URL.Query().Get(keyQuery)
+		)
+
+		if len(query) == 0 {
+			respondError(w, 0, wrapError(ErrBadRequest, "query must be provided"))
+			return
+		}
+
+		limit, err := extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		ts, err := fn(currentApp, query, limit)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(ts) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadTags{tags: ts})
+	}
+}
+
+// TagTrending returns the most popular tags for the period.
+func TagTrending(fn controller.TagTrendingFunc, period event.Period) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		currentApp := appFromContext(ctx)
+
+		limit, err := extractLimit(r)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		ts, err := fn(currentApp, period, limit)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		if len(ts) == 0 {
+			respondJSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadTags{tags: ts})
+	}
+}
+
+type payloadTag struct {
+	tag *object.TagCount
+}
+
+func (p *payloadTag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Count int    `json:"count"`
+		Tag   string `json:"tag"`
+	}{
+		Count: p.tag.Count,
+		Tag:   p.tag.Tag,
+	})
+}
+
+type payloadTags struct {
+	tags object.TagCounts
+}
+
+func (p *payloadTags) MarshalJSON() ([]byte, error) {
+	ts := []*payloadTag{}
+
+	for _, t := range p.tags {
+		ts = append(ts, &payloadTag{tag: t})
+	}
+
+	return json.Marshal(struct {
+		Tags      []*payloadTag `json:"tags"`
+		TagsCount int           `json:"tags_count"`
+	}{
+		Tags:      ts,
+		TagsCount: len(ts),
+	})
+}
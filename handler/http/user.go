@@ -117,13 +117,47 @@ func UserLogin(c *controller.UserController) Handler {
 	}
 }
 
+// UserLoginSocial logs in the user linked to the identity of the ID token
+// issued by the provider of the platform, unknown identities sign up.
+func UserLoginSocial(c *controller.UserController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp = appFromContext(ctx)
+			deviceID   = deviceIDFromContext(ctx)
+			p          = payloadIDToken{}
+			tokenType  = tokenTypeFromContext(ctx)
+
+			origin = createOrigin(deviceID, tokenType, 0)
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		if p.platform == "" {
+			respondError(w, 0, wrapError(ErrBadRequest, "platform must be set"))
+			return
+		}
+
+		u, err := c.LoginSocial(currentApp, origin, p.platform, p.idToken)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusCreated, &payloadUser{user: u})
+	}
+}
+
 // UserLogout finds the session of the user and destroys it.
 func UserLogout(c *controller.UserController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 		var (
 			currentApp  = appFromContext(ctx)
 			currentUser = userFromContext(ctx)
-			token       = tokenFromContext(ctx)
+			sessionID   = sessionIDFromContext(ctx)
 			tokenType   = tokenTypeFromContext(ctx)
 		)
 
@@ -132,7 +166,7 @@ func UserLogout(c *controller.UserController) Handler {
 			return
 		}
 
-		err := c.Logout(currentApp, currentUser.ID, token)
+		err := c.Logout(currentApp, currentUser.ID, sessionID)
 		if err != nil {
 			respondError(w, 0, err)
 			return
@@ -276,7 +310,7 @@ func UserSearch(c *controller.UserController) Handler {
 			return
 		}
 
-		opts.Before, err = extractIDCursorBefore(r)
+		opts.Offset, err = extractOffsetCursorBefore(r)
 		if err != nil {
 			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
 			return
@@ -288,13 +322,13 @@ func UserSearch(c *controller.UserController) Handler {
 			return
 		}
 
-		us, err := c.Search(currentApp, currentUser.ID, query, opts)
+		feed, err := c.Search(currentApp, currentUser.ID, query, opts)
 		if err != nil {
 			respondError(w, 0, err)
 			return
 		}
 
-		if len(us) == 0 {
+		if feed.Fetched == 0 {
 			respondJSON(w, http.StatusNoContent, nil)
 			return
 		}
@@ -303,13 +337,13 @@ func UserSearch(c *controller.UserController) Handler {
 			pagination: pagination(
 				r,
 				opts.Limit,
-				userCursorAfter(us, opts.Limit),
-				userCursorBefore(us, opts.Limit),
+				searchCursorAfter(opts.Offset, opts.Limit),
+				searchCursorBefore(opts.Offset, opts.Limit, feed.Fetched),
 				url.Values{
 					keyQuery: []string{query},
 				},
 			),
-			users: us,
+			users: feed.Users,
 		})
 	}
 }
@@ -381,6 +415,53 @@ func UserSearchPlatform(c *controller.UserController) Handler {
 	}
 }
 
+// UserSocialLink links the identity of the ID token issued by the provider of
+// the platform to the current user.
+func UserSocialLink(c *controller.UserController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp  = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+			p           = payloadIDToken{}
+			platform    = mux.Vars(r)["platform"]
+		)
+
+		err := json.NewDecoder(r.Body).Decode(&p)
+		if err != nil {
+			respondError(w, 0, wrapError(ErrBadRequest, err.Error()))
+			return
+		}
+
+		u, err := c.SocialLink(currentApp, currentUser, platform, p.idToken)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, &payloadUser{user: u})
+	}
+}
+
+// UserSocialUnlink removes the link of the current user to the provider of the
+// platform.
+func UserSocialUnlink(c *controller.UserController) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var (
+			currentApp  = appFromContext(ctx)
+			currentUser = userFromContext(ctx)
+			platform    = mux.Vars(r)["platform"]
+		)
+
+		err := c.SocialUnlink(currentApp, currentUser, platform)
+		if err != nil {
+			respondError(w, 0, err)
+			return
+		}
+
+		respondJSON(w, http.StatusNoContent, nil)
+	}
+}
+
 // UserUpdate stores the new attributes given.
 func UserUpdate(c *controller.UserController) Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -413,6 +494,32 @@ func UserUpdate(c *controller.UserController) Handler {
 	}
 }
 
+type payloadIDToken struct {
+	idToken  string
+	platform string
+}
+
+func (p *payloadIDToken) UnmarshalJSON(raw []byte) error {
+	f := struct {
+		IDToken  string `json:"id_token"`
+		Platform string `json:"platform"`
+	}{}
+
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return err
+	}
+
+	if f.IDToken == "" {
+		return fmt.Errorf("id_token must be set")
+	}
+
+	p.idToken = f.IDToken
+	p.platform = f.Platform
+
+	return nil
+}
+
 type payloadLogin struct {
 	email    string
 	password string
@@ -465,8 +572,10 @@ type payloadUser struct {
 }
 
 func (p *payloadUser) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
+	f := struct {
 		About          string                `json:"about"`
+		AccessExpiry   *time.Time            `json:"access_token_expires_at,omitempty"`
+		AccessToken    string                `json:"access_token,omitempty"`
 		CustomID       string                `json:"custom_id,omitempty"`
 		Email          string                `json:"email"`
 		Firstname      string                `json:"first_name"`
@@ -479,9 +588,13 @@ func (p *payloadUser) MarshalJSON() ([]byte, error) {
 		IsFollower     bool                  `json:"is_follower"`
 		IsFollowing    bool                  `json:"is_followed"`
 		IsFriend       bool                  `json:"is_friend"`
+		IsPrivate      bool                  `json:"is_private"`
+		IsRequested    bool                  `json:"is_requested"`
 		Lastname       string                `json:"last_name"`
 		Metadata       user.Metadata         `json:"metadata,omitempty"`
+		MutualCount    int                   `json:"mutual_count"`
 		Private        *user.Private         `json:"private,omitempty"`
+		RefreshToken   string                `json:"refresh_token,omitempty"`
 		SessionToken   string                `json:"session_token,omitempty"`
 		SocialIDs      map[string]string     `json:"social_ids,omitempty"`
 		URL            string                `json:"url,omitempty"`
@@ -490,6 +603,7 @@ func (p *payloadUser) MarshalJSON() ([]byte, error) {
 		UpdatedAt      time.Time             `json:"updated_at"`
 	}{
 		About:          p.user.About,
+		AccessToken:    p.user.AccessToken,
 		CustomID:       p.user.CustomID,
 		Email:          p.user.Email,
 		Firstname:      p.user.Firstname,
@@ -502,16 +616,26 @@ func (p *payloadUser) MarshalJSON() ([]byte, error) {
 		IsFollower:     p.user.IsFollower,
 		IsFollowing:    p.user.IsFollowing,
 		IsFriend:       p.user.IsFriend,
+		IsPrivate:      p.user.IsPrivate,
+		IsRequested:    p.user.IsRequested,
 		Lastname:       p.user.Lastname,
 		Metadata:       p.user.Metadata,
+		MutualCount:    p.user.MutualCount,
 		Private:        p.user.Private,
+		RefreshToken:   p.user.RefreshToken,
 		SessionToken:   p.user.SessionToken,
 		SocialIDs:      p.user.SocialIDs,
 		URL:            p.user.URL,
 		Username:       p.user.Username,
 		CreatedAt:      p.user.CreatedAt,
 		UpdatedAt:      p.user.UpdatedAt,
-	})
+	}
+
+	if !p.user.AccessExpiry.IsZero() {
+		f.AccessExpiry = &p.user.AccessExpiry
+	}
+
+	return json.Marshal(f)
 }
 
 func (p *payloadUser) UnmarshalJSON(raw []byte) error {
@@ -521,6 +645,7 @@ func (p *payloadUser) UnmarshalJSON(raw []byte) error {
 		Email     string                `json:"email"`
 		Firstname string                `json:"first_name"`
 		Images    map[string]user.Image `json:"images,omitempty"`
+		IsPrivate bool                  `json:"is_private"`
 		Lastname  string                `json:"last_name"`
 		Metadata  user.Metadata         `json:"metadata,omitempty"`
 		Password  string                `json:"password,omitempty"`
@@ -545,6 +670,7 @@ func (p *payloadUser) UnmarshalJSON(raw []byte) error {
 		Email:     f.Email,
 		Firstname: f.Firstname,
 		Images:    f.Images,
+		IsPrivate: f.IsPrivate,
 		Lastname:  f.Lastname,
 		Metadata:  f.Metadata,
 		Password:  f.Password,
@@ -596,6 +722,30 @@ func (p *payloadUserMap) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// searchCursorAfter returns the cursor to the previous page of search results,
+// which are ordered by relevance and paged by offset.
+func searchCursorAfter(offset, limit int) string {
+	if offset == 0 {
+		return ""
+	}
+
+	if offset < limit {
+		return toOffsetCursor(0)
+	}
+
+	return toOffsetCursor(offset - limit)
+}
+
+// searchCursorBefore returns the cursor to the next page of search results,
+// which is omitted after a short page of count results.
+func searchCursorBefore(offset, limit, count int) string {
+	if count < limit {
+		return ""
+	}
+
+	return toOffsetCursor(offset + limit)
+}
+
 func userCursorAfter(us user.List, limit int) string {
 	var after string
 
@@ -0,0 +1,209 @@
+package access
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// Supported signing algorithms.
+const (
+	AlgorithmEd25519 = "EdDSA"
+	AlgorithmHMAC    = "HS256"
+)
+
+// minSecretLen is the shortest HMAC secret accepted, shorter secrets weaken
+// the signature below the strength of the hash.
+const minSecretLen = 32
+
+// Claims are the statements of an access token.
+type Claims struct {
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	Namespace string
+	SessionID string
+	UserID    uint64
+}
+
+// Expired reports if the token isn't valid anymore at the given time.
+func (c *Claims) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// Signer issues access tokens and verifies their signature.
+type Signer interface {
+	Sign(claims *Claims) (string, error)
+	Verify(token string, now time.Time) (*Claims, error)
+}
+
+// IsToken reports if the token has the form of an access token, which tells
+// them apart from opaque session tokens.
+func IsToken(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+type signer struct {
+	alg    string
+	header string
+	sign   func(signed []byte) []byte
+	verify func(signed, sig []byte) bool
+}
+
+// Ed25519Signer returns a Signer which signs with the given key.
+func Ed25519Signer(key ed25519.PrivateKey) (Signer, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, wrapError(ErrInvalidKey, "ed25519 key must be %d bytes", ed25519.PrivateKeySize)
+	}
+
+	pub := key.Public().(ed25519.PublicKey)
+
+	return newSigner(
+		AlgorithmEd25519,
+		func(signed []byte) []byte {
+			return ed25519.Sign(key, signed)
+		},
+		func(signed, sig []byte) bool {
+			return ed25519.Verify(pub, signed, sig)
+		},
+	), nil
+}
+
+// HMACSigner returns a Signer which signs with HMAC-SHA256 and the given
+// secret.
+func HMACSigner(secret []byte) (Signer, error) {
+	if len(secret) < minSecretLen {
+		return nil, wrapError(ErrInvalidKey, "hmac secret must be at least %d bytes", minSecretLen)
+	}
+
+	sign := func(signed []byte) []byte {
+		h := hmac.New(sha256.New, secret)
+		_, _ = h.Write(signed)
+
+		return h.Sum(nil)
+	}
+
+	return newSigner(
+		AlgorithmHMAC,
+		sign,
+		func(signed, sig []byte) bool {
+			return hmac.Equal(sign(signed), sig)
+		},
+	), nil
+}
+
+func newSigner(
+	alg string,
+	sign func([]byte) []byte,
+	verify func([]byte, []byte) bool,
+) *signer {
+	h, _ := json.Marshal(header{Alg: alg, Typ: "JWT"})
+
+	return &signer{
+		alg:    alg,
+		header: base64.RawURLEncoding.EncodeToString(h),
+		sign:   sign,
+		verify: verify,
+	}
+}
+
+func (s *signer) Sign(c *Claims) (string, error) {
+	raw, err := json.Marshal(claims{
+		Expiry:    c.ExpiresAt.Unix(),
+		IssuedAt:  c.IssuedAt.Unix(),
+		Namespace: c.Namespace,
+		SessionID: c.SessionID,
+		Subject:   strconv.FormatUint(c.UserID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := s.header + "." + base64.RawURLEncoding.EncodeToString(raw)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(s.sign([]byte(signed))), nil
+}
+
+func (s *signer) Verify(token string, now time.Time) (*Claims, error) {
+	ps := strings.Split(token, ".")
+	if len(ps) != 3 {
+		return nil, wrapError(ErrInvalidToken, "malformed token")
+	}
+
+	h := header{}
+
+	if err := decodeSegment(ps[0], &h); err != nil {
+		return nil, err
+	}
+
+	// Only the algorithm of the signer is accepted, so tokens can't pick a
+	// weaker one.
+	if h.Alg != s.alg {
+		return nil, wrapError(ErrInvalidToken, "algorithm '%s' not accepted", h.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(ps[2])
+	if err != nil {
+		return nil, wrapError(ErrInvalidToken, "malformed signature")
+	}
+
+	if !s.verify([]byte(ps[0]+"."+ps[1]), sig) {
+		return nil, wrapError(ErrInvalidToken, "invalid signature")
+	}
+
+	cs := claims{}
+
+	if err := decodeSegment(ps[1], &cs); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.ParseUint(cs.Subject, 10, 64)
+	if err != nil {
+		return nil, wrapError(ErrInvalidToken, "invalid subject '%s'", cs.Subject)
+	}
+
+	c := &Claims{
+		ExpiresAt: time.Unix(cs.Expiry, 0).UTC(),
+		IssuedAt:  time.Unix(cs.IssuedAt, 0).UTC(),
+		Namespace: cs.Namespace,
+		SessionID: cs.SessionID,
+		UserID:    userID,
+	}
+
+	if c.Expired(now) {
+		return nil, wrapError(ErrExpiredToken, "expired at %s", c.ExpiresAt)
+	}
+
+	return c, nil
+}
+
+type claims struct {
+	Expiry    int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+	Namespace string `json:"ns"`
+	SessionID string `json:"sid"`
+	Subject   string `json:"sub"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return wrapError(ErrInvalidToken, "malformed segment")
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return wrapError(ErrInvalidToken, "malformed segment: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package access
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestSignerVerify(t *testing.T) {
+	var (
+		now = time.Now().UTC().Truncate(time.Second)
+		c   = &Claims{
+			ExpiresAt: now.Add(time.Minute),
+			IssuedAt:  now,
+			Namespace: "app_1_2",
+			SessionID: "session",
+			UserID:    123,
+		}
+	)
+
+	for name, s := range testSigners(t) {
+		token, err := s.Sign(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !IsToken(token) {
+			t.Errorf("%s: %q not recognised as token", name, token)
+		}
+
+		have, err := s.Verify(token, now)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if want := c; !reflect.DeepEqual(have, want) {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+
+		_, err = s.Verify(token, now.Add(time.Minute))
+		if have, want := err, ErrExpiredToken; !IsExpiredToken(have) {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+	}
+}
+
+func TestSignerVerifyInvalid(t *testing.T) {
+	var (
+		now = time.Now()
+		c   = &Claims{
+			ExpiresAt: now.Add(time.Minute),
+			UserID:    123,
+		}
+		signers = testSigners(t)
+	)
+
+	other, err := HMACSigner([]byte(strings.Repeat("b", minSecretLen)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged, err := other.Sign(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eddsa, err := signers[AlgorithmEd25519].Sign(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps := strings.Split(forged, ".")
+	ps[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`))
+
+	tokens := map[string]string{
+		"malformed": "session-token",
+		"signature": forged,
+		"algorithm": eddsa,
+		"tampered":  strings.Join(ps, "."),
+	}
+
+	for name, token := range tokens {
+		_, err := signers[AlgorithmHMAC].Verify(token, now)
+		if have, want := err, ErrInvalidToken; !IsInvalidToken(have) {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+	}
+}
+
+func TestSignerInvalidKey(t *testing.T) {
+	_, err := HMACSigner([]byte("short"))
+	if have, want := err, ErrInvalidKey; !IsInvalidKey(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = Ed25519Signer(ed25519.PrivateKey("short"))
+	if have, want := err, ErrInvalidKey; !IsInvalidKey(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testSigners(t *testing.T) map[string]Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ed, err := Ed25519Signer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hs, err := HMACSigner([]byte(strings.Repeat("a", minSecretLen)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Signer{
+		AlgorithmEd25519: ed,
+		AlgorithmHMAC:    hs,
+	}
+}
@@ -0,0 +1,60 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+)
+
+const errFmt = "%s: %s"
+
+// Common errors for access tokens.
+var (
+	ErrExpiredToken = errors.New("token expired")
+	ErrInvalidKey   = errors.New("invalid key")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Error wraps common access token errors.
+type Error struct {
+	err error
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// IsExpiredToken indicates if err is ErrExpiredToken.
+func IsExpiredToken(err error) bool {
+	return unwrapError(err) == ErrExpiredToken
+}
+
+// IsInvalidKey indicates if err is ErrInvalidKey.
+func IsInvalidKey(err error) bool {
+	return unwrapError(err) == ErrInvalidKey
+}
+
+// IsInvalidToken indicates if err is ErrInvalidToken.
+func IsInvalidToken(err error) bool {
+	return unwrapError(err) == ErrInvalidToken
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.err
+	}
+
+	return err
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	return &Error{
+		err: err,
+		msg: fmt.Sprintf(
+			errFmt,
+			err,
+			fmt.Sprintf(format, args...),
+		),
+	}
+}
@@ -1,9 +1,14 @@
 package cache
 
+import "time"
+
 // KeySeparator is used to build complete keys out of parts.
 const KeySeparator = "."
 
-const countPrefix = "cache.count"
+const (
+	countPrefix = "cache.count"
+	leasePrefix = "cache.lease"
+)
 
 // CountService caches counts separated by namespace.
 type CountService interface {
@@ -13,3 +18,10 @@ type CountService interface {
 
 // CountServiceMiddleware is a chainable behaviour modifier for CountService.
 type CountServiceMiddleware func(CountService) CountService
+
+// LeaseService hands out exclusive leases on keys, so periodic work shared by
+// several processes only runs in one of them at a time.
+type LeaseService interface {
+	// Acquire reports if the lease for the key was obtained for ttl.
+	Acquire(key string, ttl time.Duration) (bool, error)
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/garyburd/redigo/redis"
 )
@@ -12,6 +13,8 @@ const (
 
 	redisCommandEX  = "EX"
 	redisCommandGET = "GET"
+	redisCommandNX  = "NX"
+	redisCommandPX  = "PX"
 	redisCommandSET = "SET"
 
 	errCode = -1
@@ -69,6 +72,36 @@ func (s *redisCountService) Set(ns, key string, count int) error {
 	return nil
 }
 
+type redisLeaseService struct {
+	pool *redis.Pool
+}
+
+// RedisLeaseService returns a Redis based LeaseService implementation.
+func RedisLeaseService(pool *redis.Pool) LeaseService {
+	return &redisLeaseService{
+		pool: pool,
+	}
+}
+
+func (s *redisLeaseService) Acquire(key string, ttl time.Duration) (bool, error) {
+	con := s.pool.Get()
+	defer con.Close()
+
+	res, err := con.Do(
+		redisCommandSET,
+		strings.Join([]string{leasePrefix, key}, KeySeparator),
+		1,
+		redisCommandNX,
+		redisCommandPX,
+		int64(ttl/time.Millisecond),
+	)
+	if err != nil {
+		return false, fmt.Errorf("lease acquire failed: %s", err)
+	}
+
+	return res != nil, nil
+}
+
 func prefixKey(ns, key string) string {
 	ps := []string{
 		countPrefix,
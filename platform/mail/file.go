@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+type fileSender struct {
+	dir  string
+	from string
+}
+
+// FileSender returns a Sender which writes every message as an .eml file to
+// the given directory, useful to inspect mails during local development.
+func FileSender(dir, from string) Sender {
+	return &fileSender{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (s *fileSender) Send(m *Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	err := ioutil.WriteFile(
+		filepath.Join(s.dir, fmt.Sprintf("%d.eml", now.UnixNano())),
+		encode(s.from, m, now),
+		0644,
+	)
+	if err != nil {
+		return wrapError(ErrDeliveryFailure, "%s", err)
+	}
+
+	return nil
+}
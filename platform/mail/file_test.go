@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSenderSend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = FileSender(dir, "noreply@example.com").Send(&Message{
+		Body:    "Your token.",
+		Subject: "Reset",
+		To:      "alice@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(fs), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	raw, err := ioutil.ReadFile(fs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(string(raw), "\r\n\r\nYour token.\r\n") {
+		t.Errorf("unexpected body: %q", raw)
+	}
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	Body    string
+	Subject string
+	To      string
+}
+
+// Validate performs semantic checks on the Message.
+func (m *Message) Validate() error {
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return wrapError(ErrInvalidMessage, "invalid recipient '%s'", m.To)
+	}
+
+	if m.Subject == "" {
+		return wrapError(ErrInvalidMessage, "Subject must be set")
+	}
+
+	return nil
+}
+
+// Sender delivers a Message.
+type Sender interface {
+	Send(m *Message) error
+}
+
+// encode returns the message in RFC 5322 format with the given sender.
+func encode(from string, m *Message, date time.Time) []byte {
+	var (
+		b    = &bytes.Buffer{}
+		body = strings.Replace(m.Body, "\r\n", "\n", -1)
+	)
+
+	fmt.Fprintf(b, "From: %s\r\n", from)
+	fmt.Fprintf(b, "To: %s\r\n", m.To)
+	fmt.Fprintf(b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(b, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(b, "\r\n")
+	fmt.Fprintf(b, "%s\r\n", strings.Replace(body, "\n", "\r\n", -1))
+
+	return b.Bytes()
+}
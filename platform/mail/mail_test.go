@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	var (
+		date = time.Date(2016, 5, 3, 12, 0, 0, 0, time.UTC)
+		m    = &Message{
+			Body:    "Hello,\nfollow the link.",
+			Subject: "Zurücksetzen\r\nBcc: evil@example.com",
+			To:      "alice@example.com",
+		}
+	)
+
+	raw := string(encode("noreply@example.com", m, date))
+
+	if !strings.HasPrefix(raw, "From: noreply@example.com\r\nTo: alice@example.com\r\n") {
+		t.Errorf("unexpected header: %q", raw)
+	}
+
+	if strings.Contains(raw, "\r\nBcc:") {
+		t.Errorf("header injected: %q", raw)
+	}
+
+	if have, want := raw[strings.Index(raw, "\r\n\r\n")+4:], "Hello,\r\nfollow the link.\r\n"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+func TestMessageValidate(t *testing.T) {
+	ms := []*Message{
+		{},
+		{Subject: "Reset"},
+		{Subject: "Reset", To: "alice"},
+		{Subject: "Reset", To: "alice@example.com\r\nBcc: bob@example.com"},
+		{To: "alice@example.com"},
+	}
+
+	for _, m := range ms {
+		if have, want := m.Validate(), ErrInvalidMessage; !IsInvalidMessage(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package mail
+
+import "sync"
+
+// MemSender keeps all messages in memory for inspection in tests.
+type MemSender struct {
+	sync.Mutex
+
+	messages []*Message
+}
+
+// NewMemSender returns an empty MemSender.
+func NewMemSender() *MemSender {
+	return &MemSender{
+		messages: []*Message{},
+	}
+}
+
+// Messages returns all messages sent so far.
+func (s *MemSender) Messages() []*Message {
+	s.Lock()
+	defer s.Unlock()
+
+	ms := make([]*Message, len(s.messages))
+	copy(ms, s.messages)
+
+	return ms
+}
+
+// Send stores the message.
+func (s *MemSender) Send(m *Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	msg := *m
+	s.messages = append(s.messages, &msg)
+
+	return nil
+}
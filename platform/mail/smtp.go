@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPConfig bundles the settings to deliver through an SMTP relay.
+type SMTPConfig struct {
+	// Addr is the host:port of the relay.
+	Addr string
+	// From is the sender address of all messages.
+	From string
+	// Username and Password are used for PLAIN authentication if set, which
+	// the relay only accepts over TLS or on localhost.
+	Password string
+	Username string
+}
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// SMTPSender returns a Sender which delivers through the configured relay.
+func SMTPSender(c SMTPConfig) (Sender, error) {
+	host, _, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &smtpSender{
+		addr: c.Addr,
+		from: c.From,
+	}
+
+	if c.Username != "" {
+		s.auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+
+	return s, nil
+}
+
+func (s *smtpSender) Send(m *Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	err := smtp.SendMail(
+		s.addr,
+		s.auth,
+		s.from,
+		[]string{m.To},
+		encode(s.from, m, time.Now()),
+	)
+	if err != nil {
+		return wrapError(ErrDeliveryFailure, "%s", err)
+	}
+
+	return nil
+}
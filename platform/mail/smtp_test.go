@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestSMTPSenderSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan []string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var (
+			c     = textproto.NewConn(conn)
+			lines = []string{}
+		)
+
+		_ = c.PrintfLine("220 localhost ESMTP")
+
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+
+			lines = append(lines, line)
+
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				_ = c.PrintfLine("250 localhost")
+			case strings.HasPrefix(line, "DATA"):
+				_ = c.PrintfLine("354 go ahead")
+
+				data, err := c.ReadDotLines()
+				if err != nil {
+					return
+				}
+
+				lines = append(lines, data...)
+
+				_ = c.PrintfLine("250 ok")
+			case strings.HasPrefix(line, "QUIT"):
+				_ = c.PrintfLine("221 bye")
+				received <- lines
+				return
+			default:
+				_ = c.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	s, err := SMTPSender(SMTPConfig{
+		Addr: l.Addr().String(),
+		From: "noreply@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Send(&Message{
+		Body:    "Your token.",
+		Subject: "Reset",
+		To:      "alice@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := <-received
+
+	for _, want := range []string{
+		"MAIL FROM:<noreply@example.com>",
+		"RCPT TO:<alice@example.com>",
+		"Subject: Reset",
+		"Your token.",
+	} {
+		if !containsLine(lines, want) {
+			t.Errorf("missing %q in %v", want, lines)
+		}
+	}
+}
+
+func TestSMTPSenderSendFailure(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	s, err := SMTPSender(SMTPConfig{Addr: addr, From: "noreply@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Send(&Message{Subject: "Reset", To: "alice@example.com"})
+	if have, want := err, ErrDeliveryFailure; !IsDeliveryFailure(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if strings.HasPrefix(l, want) {
+			return true
+		}
+	}
+
+	return false
+}
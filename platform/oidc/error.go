@@ -0,0 +1,54 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+)
+
+const errFmt = "%s: %s"
+
+// Common errors for token verification.
+var (
+	ErrInvalidProvider = errors.New("invalid provider")
+	ErrInvalidToken    = errors.New("invalid token")
+)
+
+// Error wraps common verification errors.
+type Error struct {
+	err error
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// IsInvalidProvider indicates if err is ErrInvalidProvider.
+func IsInvalidProvider(err error) bool {
+	return unwrapError(err) == ErrInvalidProvider
+}
+
+// IsInvalidToken indicates if err is ErrInvalidToken.
+func IsInvalidToken(err error) bool {
+	return unwrapError(err) == ErrInvalidToken
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.err
+	}
+
+	return err
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	return &Error{
+		err: err,
+		msg: fmt.Sprintf(
+			errFmt,
+			err,
+			fmt.Sprintf(format, args...),
+		),
+	}
+}
@@ -0,0 +1,290 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxKeySetSize caps the response read from a provider, key sets are
+	// usually a few kilobytes.
+	maxKeySetSize = 1 << 20
+
+	// refreshMin limits how often a key set is fetched again for unknown key
+	// ids, so tokens with made up ids can't flood the provider.
+	refreshMin = time.Minute
+)
+
+// FetchFunc returns the JSON Web Key Set published under url.
+type FetchFunc func(url string) ([]byte, error)
+
+// HTTPFetch returns a FetchFunc which gets key sets with the given client.
+func HTTPFetch(client *http.Client) FetchFunc {
+	return func(url string) ([]byte, error) {
+		res, err := client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("key set fetch failed: %s", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("key set fetch failed: status %d", res.StatusCode)
+		}
+
+		raw, err := ioutil.ReadAll(io.LimitReader(res.Body, maxKeySetSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("key set fetch failed: %s", err)
+		}
+
+		if len(raw) > maxKeySetSize {
+			return nil, fmt.Errorf("key set fetch failed: larger than %d bytes", maxKeySetSize)
+		}
+
+		return raw, nil
+	}
+}
+
+type keySet struct {
+	fetchedAt time.Time
+	keys      map[string]crypto.PublicKey
+}
+
+// keyFetch is a fetch of a key set in progress, which concurrent lookups of
+// the same url wait for instead of fetching themselves.
+type keyFetch struct {
+	done chan struct{}
+	err  error
+	set  *keySet
+}
+
+type keyCache struct {
+	sync.Mutex
+
+	fetch   FetchFunc
+	fetches map[string]*keyFetch
+	now     func() time.Time
+	sets    map[string]*keySet
+	ttl     time.Duration
+}
+
+func newKeyCache(fetch FetchFunc, ttl time.Duration) *keyCache {
+	return &keyCache{
+		fetch:   fetch,
+		fetches: map[string]*keyFetch{},
+		now:     time.Now,
+		sets:    map[string]*keySet{},
+		ttl:     ttl,
+	}
+}
+
+// get returns the key with the given id from the set under url. The set is
+// fetched again when it is older than the ttl or doesn't contain the key.
+// Fetches happen outside of the lock and only once per url at a time.
+func (c *keyCache) get(url, kid string) (crypto.PublicKey, error) {
+	c.Lock()
+
+	var (
+		now = c.now()
+		set = c.sets[url]
+	)
+
+	if set != nil && now.Sub(set.fetchedAt) < c.ttl {
+		if key, ok := set.lookup(kid); ok {
+			c.Unlock()
+			return key, nil
+		}
+
+		if now.Sub(set.fetchedAt) < refreshMin {
+			c.Unlock()
+			return nil, wrapError(ErrInvalidToken, "unknown key '%s'", kid)
+		}
+	}
+
+	f, ok := c.fetches[url]
+	if !ok {
+		f = &keyFetch{done: make(chan struct{})}
+		c.fetches[url] = f
+	}
+
+	c.Unlock()
+
+	if ok {
+		<-f.done
+	} else {
+		f.set, f.err = c.load(url, now)
+
+		c.Lock()
+		delete(c.fetches, url)
+
+		if f.err == nil {
+			c.sets[url] = f.set
+		}
+
+		c.Unlock()
+		close(f.done)
+	}
+
+	if f.err != nil {
+		// Keep serving known keys while the provider is unreachable.
+		if set != nil {
+			if key, ok := set.lookup(kid); ok {
+				return key, nil
+			}
+		}
+
+		return nil, f.err
+	}
+
+	key, ok := f.set.lookup(kid)
+	if !ok {
+		return nil, wrapError(ErrInvalidToken, "unknown key '%s'", kid)
+	}
+
+	return key, nil
+}
+
+// load fetches and parses the key set under url.
+func (c *keyCache) load(url string, now time.Time) (*keySet, error) {
+	raw, err := c.fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parseKeySet(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keySet{
+		fetchedAt: now,
+		keys:      keys,
+	}, nil
+}
+
+// lookup finds the key by id, tokens without id are accepted if the set holds
+// a single key.
+func (s *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+
+	return key, ok
+}
+
+type jwk struct {
+	Crv string `json:"crv"`
+	E   string `json:"e"`
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	Use string `json:"use"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.BitLen() > 31 {
+			return nil, fmt.Errorf("key '%s': exponent too large", k.Kid)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, nil
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("key '%s': point not on curve", k.Kid)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, nil
+}
+
+// parseKeySet returns the signing keys of the set by id, keys of unsupported
+// types are skipped.
+func parseKeySet(raw []byte) (map[string]crypto.PublicKey, error) {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("key set malformed: %s", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if key == nil {
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("malformed key parameter")
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
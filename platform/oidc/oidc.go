@@ -0,0 +1,301 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	// Register the hash functions used by the supported algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// leeway tolerates clock skew between the provider and us.
+const leeway = time.Minute
+
+// curves maps the ECDSA algorithms to the curve of their keys.
+var curves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+}
+
+// Claims are the identity attributes of a verified ID token.
+type Claims struct {
+	Email         string
+	EmailVerified bool
+	FamilyName    string
+	GivenName     string
+	Issuer        string
+	Subject       string
+}
+
+// Provider describes an OpenID Connect identity provider whose ID tokens are
+// accepted if they are issued by Issuer for one of the ClientIDs and signed
+// with a key published under JWKSURL.
+type Provider struct {
+	ClientIDs []string
+	Issuer    string
+	JWKSURL   string
+}
+
+// Validate performs semantic checks on the Provider.
+func (p Provider) Validate() error {
+	if len(p.ClientIDs) == 0 {
+		return wrapError(ErrInvalidProvider, "ClientIDs must be set")
+	}
+
+	if p.Issuer == "" {
+		return wrapError(ErrInvalidProvider, "Issuer must be set")
+	}
+
+	if !strings.HasPrefix(p.JWKSURL, "https://") &&
+		!strings.HasPrefix(p.JWKSURL, "http://") {
+		return wrapError(ErrInvalidProvider, "invalid JWKSURL '%s'", p.JWKSURL)
+	}
+
+	return nil
+}
+
+// Verifier checks signature and claims of ID tokens.
+type Verifier interface {
+	Verify(provider Provider, token string) (*Claims, error)
+}
+
+type verifier struct {
+	keys *keyCache
+	now  func() time.Time
+}
+
+// NewVerifier returns a Verifier which fetches the key sets of providers with
+// the given fetch function and caches them for ttl.
+func NewVerifier(fetch FetchFunc, ttl time.Duration) Verifier {
+	return &verifier{
+		keys: newKeyCache(fetch, ttl),
+		now:  time.Now,
+	}
+}
+
+func (v *verifier) Verify(p Provider, token string) (*Claims, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	ps := strings.Split(token, ".")
+	if len(ps) != 3 {
+		return nil, wrapError(ErrInvalidToken, "malformed token")
+	}
+
+	h := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+
+	if err := decodeSegment(ps[0], &h); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(ps[2])
+	if err != nil {
+		return nil, wrapError(ErrInvalidToken, "malformed signature")
+	}
+
+	key, err := v.keys.get(p.JWKSURL, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifySignature(h.Alg, key, []byte(ps[0]+"."+ps[1]), sig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := claims{}
+
+	if err := decodeSegment(ps[1], &c); err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(p, v.now()); err != nil {
+		return nil, err
+	}
+
+	verified, err := c.emailVerified()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		Email:         c.Email,
+		EmailVerified: verified,
+		FamilyName:    c.FamilyName,
+		GivenName:     c.GivenName,
+		Issuer:        c.Issuer,
+		Subject:       c.Subject,
+	}, nil
+}
+
+// audience is the aud claim, which is either a single string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(raw []byte) error {
+	var s string
+
+	if err := json.Unmarshal(raw, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	ss := []string{}
+
+	if err := json.Unmarshal(raw, &ss); err != nil {
+		return err
+	}
+
+	*a = ss
+
+	return nil
+}
+
+type claims struct {
+	Audience      audience        `json:"aud"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	Expiry        int64           `json:"exp"`
+	FamilyName    string          `json:"family_name"`
+	GivenName     string          `json:"given_name"`
+	IssuedAt      int64           `json:"iat"`
+	Issuer        string          `json:"iss"`
+	NotBefore     int64           `json:"nbf"`
+	Subject       string          `json:"sub"`
+}
+
+// emailVerified reads the email_verified claim, which some providers send as
+// string.
+func (c claims) emailVerified() (bool, error) {
+	if len(c.EmailVerified) == 0 {
+		return false, nil
+	}
+
+	var v interface{}
+
+	if err := json.Unmarshal(c.EmailVerified, &v); err != nil {
+		return false, wrapError(ErrInvalidToken, "malformed email_verified")
+	}
+
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return b == "true", nil
+	case nil:
+		return false, nil
+	}
+
+	return false, wrapError(ErrInvalidToken, "malformed email_verified")
+}
+
+func (c claims) validate(p Provider, now time.Time) error {
+	if c.Issuer != p.Issuer {
+		return wrapError(ErrInvalidToken, "issuer '%s' not accepted", c.Issuer)
+	}
+
+	if c.Subject == "" {
+		return wrapError(ErrInvalidToken, "subject missing")
+	}
+
+	accepted := false
+
+	for _, aud := range c.Audience {
+		for _, id := range p.ClientIDs {
+			if aud == id {
+				accepted = true
+			}
+		}
+	}
+
+	if !accepted {
+		return wrapError(ErrInvalidToken, "audience not accepted")
+	}
+
+	if c.Expiry == 0 || now.Add(-leeway).After(time.Unix(c.Expiry, 0)) {
+		return wrapError(ErrInvalidToken, "token expired")
+	}
+
+	if c.NotBefore != 0 && now.Add(leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return wrapError(ErrInvalidToken, "token not yet valid")
+	}
+
+	if c.IssuedAt != 0 && now.Add(leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return wrapError(ErrInvalidToken, "token issued in the future")
+	}
+
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return wrapError(ErrInvalidToken, "malformed segment")
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return wrapError(ErrInvalidToken, "malformed segment: %s", err)
+	}
+
+	return nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return wrapError(ErrInvalidToken, "algorithm '%s' not supported", alg)
+	}
+
+	h := hash.New()
+	_, _ = h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return wrapError(ErrInvalidToken, "algorithm '%s' doesn't match key", alg)
+		}
+
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, sig); err != nil {
+			return wrapError(ErrInvalidToken, "invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+
+		if curves[alg] != k.Curve || len(sig) != 2*size {
+			return wrapError(ErrInvalidToken, "algorithm '%s' doesn't match key", alg)
+		}
+
+		var (
+			r = new(big.Int).SetBytes(sig[:size])
+			s = new(big.Int).SetBytes(sig[size:])
+		)
+
+		if !ecdsa.Verify(k, digest, r, s) {
+			return wrapError(ErrInvalidToken, "invalid signature")
+		}
+	default:
+		return wrapError(ErrInvalidToken, "unsupported key type")
+	}
+
+	return nil
+}
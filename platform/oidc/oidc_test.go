@@ -0,0 +1,342 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	var (
+		rsaKey, ecKey = testKeys(t)
+		srv, fetches  = testJWKSServer(t, rsaKey, ecKey)
+		v             = NewVerifier(HTTPFetch(http.DefaultClient), time.Hour)
+		p             = testProvider(srv.URL)
+	)
+	defer srv.Close()
+
+	cases := map[string]struct {
+		alg string
+		key crypto.Signer
+		kid string
+	}{
+		"rsa": {"RS256", rsaKey, "rsa"},
+		"ec":  {"ES256", ecKey, "ec"},
+	}
+
+	for name, c := range cases {
+		token := testToken(t, c.key, c.alg, c.kid, testClaims())
+
+		claims, err := v.Verify(p, token)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if have, want := claims.Subject, "1234"; have != want {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+
+		if have, want := claims.EmailVerified, true; have != want {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+	}
+
+	if have, want := *fetches, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	var (
+		rsaKey, ecKey = testKeys(t)
+		srv, _        = testJWKSServer(t, rsaKey, ecKey)
+		v             = NewVerifier(HTTPFetch(http.DefaultClient), time.Hour)
+		p             = testProvider(srv.URL)
+	)
+	defer srv.Close()
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(k string, v interface{}) map[string]interface{} {
+		c := testClaims()
+		c[k] = v
+		return c
+	}
+
+	cases := map[string]string{
+		"malformed": "not.a.token",
+		"signature": testToken(t, other, "RS256", "rsa", testClaims()),
+		"kid":       testToken(t, rsaKey, "RS256", "unknown", testClaims()),
+		"alg":       testToken(t, rsaKey, "ES256", "rsa", testClaims()),
+		"none":      testToken(t, rsaKey, "none", "rsa", testClaims()),
+		"issuer":    testToken(t, rsaKey, "RS256", "rsa", claims("iss", "https://evil.example.com")),
+		"audience":  testToken(t, rsaKey, "RS256", "rsa", claims("aud", []string{"other"})),
+		"expired":   testToken(t, rsaKey, "RS256", "rsa", claims("exp", time.Now().Add(-time.Hour).Unix())),
+		"future":    testToken(t, rsaKey, "RS256", "rsa", claims("nbf", time.Now().Add(time.Hour).Unix())),
+		"subject":   testToken(t, rsaKey, "RS256", "rsa", claims("sub", "")),
+	}
+
+	for name, token := range cases {
+		_, err := v.Verify(p, token)
+		if have, want := err, ErrInvalidToken; !IsInvalidToken(have) {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+	}
+}
+
+func TestVerifyInvalidProvider(t *testing.T) {
+	var (
+		v  = NewVerifier(HTTPFetch(http.DefaultClient), time.Hour)
+		ps = []Provider{
+			{},
+			{ClientIDs: []string{"client"}},
+			{ClientIDs: []string{"client"}, Issuer: "https://id.example.com"},
+			{ClientIDs: []string{"client"}, Issuer: "https://id.example.com", JWKSURL: "file:///keys"},
+		}
+	)
+
+	for _, p := range ps {
+		_, err := v.Verify(p, "")
+		if have, want := err, ErrInvalidProvider; !IsInvalidProvider(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
+
+func TestKeyCacheRefresh(t *testing.T) {
+	var (
+		now     = time.Now()
+		fetches = 0
+		c       = newKeyCache(func(url string) ([]byte, error) {
+			fetches++
+			return []byte(`{"keys":[]}`), nil
+		}, time.Hour)
+	)
+
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := c.get("https://id.example.com/keys", "unknown")
+		if have, want := err, ErrInvalidToken; !IsInvalidToken(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	if have, want := fetches, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	now = now.Add(refreshMin)
+
+	_, _ = c.get("https://id.example.com/keys", "unknown")
+
+	if have, want := fetches, 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestKeyCacheConcurrentFetch(t *testing.T) {
+	var (
+		fetches = 0
+		release = make(chan struct{})
+		c       = newKeyCache(func(url string) ([]byte, error) {
+			fetches++
+			<-release
+			return []byte(`{"keys":[]}`), nil
+		}, time.Hour)
+		errs = make(chan error)
+	)
+
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := c.get("https://id.example.com/keys", "unknown")
+			errs <- err
+		}()
+	}
+
+	for {
+		c.Lock()
+		_, pending := c.fetches["https://id.example.com/keys"]
+		c.Unlock()
+
+		if pending {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	// Lookups of other urls are not blocked by the pending fetch.
+	c.Lock()
+	c.sets["https://other.example.com/keys"] = &keySet{
+		fetchedAt: time.Now(),
+		keys:      map[string]crypto.PublicKey{"known": nil},
+	}
+	c.Unlock()
+
+	_, err := c.get("https://other.example.com/keys", "known")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+
+	for i := 0; i < 3; i++ {
+		if have, want := <-errs, ErrInvalidToken; !IsInvalidToken(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	if have, want := fetches, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestHTTPFetchLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, maxKeySetSize+1))
+	}))
+	defer srv.Close()
+
+	_, err := HTTPFetch(http.DefaultClient)(srv.URL)
+	if err == nil {
+		t.Errorf("have %v, want %v", err, "error")
+	}
+}
+
+func testClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"aud":            "client",
+		"email":          "alice@example.com",
+		"email_verified": "true",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"iss":            "https://id.example.com",
+		"sub":            "1234",
+	}
+}
+
+func testJWKSServer(
+	t *testing.T,
+	rsaKey *rsa.PrivateKey,
+	ecKey *ecdsa.PrivateKey,
+) (*httptest.Server, *int) {
+	var (
+		enc     = base64.RawURLEncoding
+		fetches = 0
+		set     = map[string][]map[string]string{
+			"keys": {
+				{
+					"e":   enc.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+					"kid": "rsa",
+					"kty": "RSA",
+					"n":   enc.EncodeToString(rsaKey.N.Bytes()),
+					"use": "sig",
+				},
+				{
+					"crv": "P-256",
+					"kid": "ec",
+					"kty": "EC",
+					"x":   enc.EncodeToString(ecKey.X.Bytes()),
+					"y":   enc.EncodeToString(ecKey.Y.Bytes()),
+				},
+				{
+					"kid": "oct",
+					"kty": "oct",
+				},
+			},
+		}
+	)
+
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write(raw)
+	}))
+
+	return srv, &fetches
+}
+
+func testKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rsaKey, ecKey
+}
+
+func testProvider(url string) Provider {
+	return Provider{
+		ClientIDs: []string{"client"},
+		Issuer:    "https://id.example.com",
+		JWKSURL:   url,
+	}
+}
+
+func testToken(
+	t *testing.T,
+	key crypto.Signer,
+	alg, kid string,
+	claims map[string]interface{},
+) string {
+	enc := base64.RawURLEncoding
+
+	h, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := strings.Join([]string{enc.EncodeToString(h), enc.EncodeToString(c)}, ".")
+	digest := crypto.SHA256.New()
+	_, _ = digest.Write([]byte(signed))
+
+	var sig []byte
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	}
+
+	return signed + "." + enc.EncodeToString(sig)
+}
@@ -0,0 +1,54 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+const errFmt = "%s: %s"
+
+// Common errors for hashing and verification.
+var (
+	ErrInvalidHash   = errors.New("invalid hash")
+	ErrInvalidParams = errors.New("invalid params")
+)
+
+// Error wraps common password errors.
+type Error struct {
+	err error
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// IsInvalidHash indicates if err is ErrInvalidHash.
+func IsInvalidHash(err error) bool {
+	return unwrapError(err) == ErrInvalidHash
+}
+
+// IsInvalidParams indicates if err is ErrInvalidParams.
+func IsInvalidParams(err error) bool {
+	return unwrapError(err) == ErrInvalidParams
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.err
+	}
+
+	return err
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	return &Error{
+		err: err,
+		msg: fmt.Sprintf(
+			errFmt,
+			err,
+			fmt.Sprintf(format, args...),
+		),
+	}
+}
@@ -0,0 +1,286 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported hash algorithms.
+const (
+	AlgorithmBcrypt = "bcrypt"
+	AlgorithmScrypt = "scrypt"
+)
+
+// version of the encoding, which is bumped when the layout changes.
+const version = 1
+
+const (
+	keyLen  = 32
+	saltLen = 16
+
+	// Parameters of hashes created before the versioned format.
+	legacyKeyLen = 256
+	legacyN      = 32768
+	legacyP      = 1
+	legacyR      = 8
+)
+
+// DefaultHasher is the scheme used for new hashes if not configured otherwise.
+var DefaultHasher = Hasher{
+	Algorithm: AlgorithmScrypt,
+	N:         32768,
+	R:         8,
+	P:         1,
+}
+
+// Hasher secures passwords with the given algorithm and cost. The encoded
+// hashes carry algorithm and cost, so hashes created with a former
+// configuration can still be verified. Bcrypt hashes are stored in their
+// native $2a$<cost>$ format, which already carries both.
+type Hasher struct {
+	Algorithm string
+
+	// Cost is the cost of bcrypt.
+	Cost int
+
+	// N, R and P are the cost of scrypt.
+	N int
+	R int
+	P int
+}
+
+// Validate performs semantic checks on the Hasher configuration.
+func (h Hasher) Validate() error {
+	switch h.Algorithm {
+	case AlgorithmBcrypt:
+		if h.Cost < bcrypt.MinCost || h.Cost > bcrypt.MaxCost {
+			return wrapError(
+				ErrInvalidParams,
+				"cost must be between %d and %d",
+				bcrypt.MinCost,
+				bcrypt.MaxCost,
+			)
+		}
+	case AlgorithmScrypt:
+		if h.N <= 1 || h.N&(h.N-1) != 0 {
+			return wrapError(ErrInvalidParams, "N must be a power of 2 greater than 1")
+		}
+
+		if h.R < 1 || h.P < 1 {
+			return wrapError(ErrInvalidParams, "r and p must be positive")
+		}
+	default:
+		return wrapError(ErrInvalidParams, "algorithm '%s' not supported", h.Algorithm)
+	}
+
+	return nil
+}
+
+// Hash returns the encoded hash of the password with a random salt.
+func (h Hasher) Hash(password string) (string, error) {
+	if err := h.Validate(); err != nil {
+		return "", err
+	}
+
+	if h.Algorithm == AlgorithmBcrypt {
+		enc, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+		if err != nil {
+			return "", err
+		}
+
+		return string(enc), nil
+	}
+
+	salt := make([]byte, saltLen)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := h.key([]byte(password), salt, keyLen)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"$%s$v=%d$%s$%s$%s",
+		h.Algorithm,
+		version,
+		h.params(),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports if the password matches the encoded hash and if the hash was
+// created with a different algorithm or cost than configured, in which case it
+// should be replaced with a fresh one.
+func (h Hasher) Verify(password, encoded string) (valid bool, outdated bool, err error) {
+	if !strings.HasPrefix(encoded, "$") {
+		valid, err := verifyLegacy(password, encoded)
+		return valid, true, err
+	}
+
+	if strings.HasPrefix(encoded, "$2") {
+		return h.verifyBcrypt(password, encoded)
+	}
+
+	stored, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	k, err := stored.key([]byte(password), salt, len(key))
+	if err != nil {
+		return false, false, err
+	}
+
+	if subtle.ConstantTimeCompare(k, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, stored.Algorithm != h.Algorithm || stored.params() != h.params(), nil
+}
+
+// verifyBcrypt checks hashes in the native bcrypt format.
+func (h Hasher) verifyBcrypt(password, encoded string) (bool, bool, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, false, nil
+		}
+
+		return false, false, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	stored := Hasher{Algorithm: AlgorithmBcrypt, Cost: cost}
+
+	return true, stored.Algorithm != h.Algorithm || stored.params() != h.params(), nil
+}
+
+func (h Hasher) key(password, salt []byte, n int) ([]byte, error) {
+	switch h.Algorithm {
+	case AlgorithmScrypt:
+		return scrypt.Key(password, salt, h.N, h.R, h.P, n)
+	}
+
+	return nil, wrapError(ErrInvalidParams, "algorithm '%s' not supported", h.Algorithm)
+}
+
+func (h Hasher) params() string {
+	switch h.Algorithm {
+	case AlgorithmBcrypt:
+		return fmt.Sprintf("c=%d", h.Cost)
+	case AlgorithmScrypt:
+		return fmt.Sprintf("n=%d,r=%d,p=%d", h.N, h.R, h.P)
+	}
+
+	return ""
+}
+
+// decode splits the encoded hash of the form
+// $<algorithm>$v=<version>$<params>$<salt>$<key> into its parts.
+func decode(encoded string) (Hasher, []byte, []byte, error) {
+	ps := strings.Split(encoded, "$")
+
+	if len(ps) != 6 || ps[0] != "" {
+		return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "unexpected format")
+	}
+
+	if ps[2] != fmt.Sprintf("v=%d", version) {
+		return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "version '%s' not supported", ps[2])
+	}
+
+	h := Hasher{Algorithm: ps[1]}
+
+	for _, param := range strings.Split(ps[3], ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "invalid param '%s'", param)
+		}
+
+		v, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "invalid param '%s'", param)
+		}
+
+		switch kv[0] {
+		case "n":
+			h.N = v
+		case "p":
+			h.P = v
+		case "r":
+			h.R = v
+		default:
+			return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "unknown param '%s'", param)
+		}
+	}
+
+	if err := h.Validate(); err != nil {
+		return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(ps[4])
+	if err != nil {
+		return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "invalid salt: %s", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(ps[5])
+	if err != nil || len(key) == 0 {
+		return Hasher{}, nil, nil, wrapError(ErrInvalidHash, "invalid key")
+	}
+
+	return h, salt, key, nil
+}
+
+// verifyLegacy checks hashes of the original format, the base64 encoded triple
+// salt:timestamp:key with each part base64 encoded and the salt and timestamp
+// joined as scrypt salt.
+func verifyLegacy(password, encoded string) (bool, error) {
+	d, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	ps := strings.SplitN(string(d), ":", 3)
+	if len(ps) != 3 {
+		return false, wrapError(ErrInvalidHash, "unexpected legacy format")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(ps[0])
+	if err != nil {
+		return false, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	ts, err := base64.StdEncoding.DecodeString(ps[1])
+	if err != nil {
+		return false, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(ps[2])
+	if err != nil {
+		return false, wrapError(ErrInvalidHash, "%s", err)
+	}
+
+	esalt := append(append(salt, ':'), ts...)
+
+	k, err := scrypt.Key([]byte(password), esalt, legacyN, legacyR, legacyP, legacyKeyLen)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(k, key) == 1, nil
+}
@@ -0,0 +1,195 @@
+package password
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestHasherVerify(t *testing.T) {
+	hs := []Hasher{
+		{Algorithm: AlgorithmBcrypt, Cost: 4},
+		{Algorithm: AlgorithmScrypt, N: 16, R: 8, P: 1},
+	}
+
+	for _, h := range hs {
+		enc, err := h.Hash("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		valid, outdated, err := h.Verify("secret", enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := valid, true; have != want {
+			t.Errorf("%s: have %v, want %v", h.Algorithm, have, want)
+		}
+
+		if have, want := outdated, false; have != want {
+			t.Errorf("%s: have %v, want %v", h.Algorithm, have, want)
+		}
+
+		valid, _, err = h.Verify("wrong", enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := valid, false; have != want {
+			t.Errorf("%s: have %v, want %v", h.Algorithm, have, want)
+		}
+	}
+}
+
+func TestHasherVerifyOutdated(t *testing.T) {
+	var (
+		old   = Hasher{Algorithm: AlgorithmScrypt, N: 16, R: 8, P: 1}
+		cases = map[string]Hasher{
+			"cost":      {Algorithm: AlgorithmScrypt, N: 32, R: 8, P: 1},
+			"algorithm": {Algorithm: AlgorithmBcrypt, Cost: 4},
+			"unused":    {Algorithm: AlgorithmScrypt, Cost: 4, N: 16, R: 8, P: 1},
+		}
+		outdated = map[string]bool{
+			"cost":      true,
+			"algorithm": true,
+			"unused":    false,
+		}
+	)
+
+	enc, err := old.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, h := range cases {
+		valid, have, err := h.Verify("secret", enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !valid {
+			t.Errorf("%s: hash not valid", name)
+		}
+
+		if want := outdated[name]; have != want {
+			t.Errorf("%s: have %v, want %v", name, have, want)
+		}
+	}
+}
+
+func TestHasherVerifyLegacy(t *testing.T) {
+	enc := testLegacyHash(t, "secret")
+
+	valid, outdated, err := DefaultHasher.Verify("secret", enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := valid, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := outdated, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	valid, _, err = DefaultHasher.Verify("wrong", enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := valid, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestHasherVerifyInvalid(t *testing.T) {
+	hs := []string{
+		"",
+		"bm90IGEgaGFzaA==",
+		"$scrypt$v=2$n=16,r=8,p=1$c2FsdA$a2V5",
+		"$scrypt$v=1$n=15,r=8,p=1$c2FsdA$a2V5",
+		"$scrypt$v=1$n=16,r=8,x=1$c2FsdA$a2V5",
+		"$md5$v=1$i=1$c2FsdA$a2V5",
+		"$scrypt$v=1$n=16,r=8,p=1$c2FsdA$",
+		"$2a$04$tooshort",
+	}
+
+	for _, enc := range hs {
+		_, _, err := DefaultHasher.Verify("secret", enc)
+		if have, want := err, ErrInvalidHash; !IsInvalidHash(have) {
+			t.Errorf("%q: have %v, want %v", enc, have, want)
+		}
+	}
+}
+
+func TestHasherValidate(t *testing.T) {
+	hs := []Hasher{
+		{},
+		{Algorithm: "argon2"},
+		{Algorithm: AlgorithmBcrypt},
+		{Algorithm: AlgorithmBcrypt, Cost: 32},
+		{Algorithm: AlgorithmScrypt, N: 1000, R: 8, P: 1},
+		{Algorithm: AlgorithmScrypt, N: 1024, P: 1},
+	}
+
+	for _, h := range hs {
+		if have, want := h.Validate(), ErrInvalidParams; !IsInvalidParams(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
+
+func TestHasherVerifyBcryptOutdated(t *testing.T) {
+	var (
+		old = Hasher{Algorithm: AlgorithmBcrypt, Cost: 4}
+		h   = Hasher{Algorithm: AlgorithmBcrypt, Cost: 5}
+	)
+
+	enc, err := old.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, outdated, err := h.Verify("secret", enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := valid, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := outdated, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testLegacyHash(t *testing.T, password string) string {
+	var (
+		salt = []byte("legacy salt")
+		ts   = []byte("2016-05-03T12:00:00Z")
+	)
+
+	key, err := scrypt.Key(
+		[]byte(password),
+		append(append(append([]byte{}, salt...), ':'), ts...),
+		legacyN,
+		legacyR,
+		legacyP,
+		legacyKeyLen,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(
+		"%s:%s:%s",
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(ts),
+		base64.StdEncoding.EncodeToString(key),
+	)))
+}
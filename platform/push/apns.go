@@ -0,0 +1,172 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// APNs hosts.
+const (
+	APNSHostProduction = "https://api.push.apple.com"
+	APNSHostSandbox    = "https://api.sandbox.push.apple.com"
+)
+
+const (
+	apnsPath = "/3/device/%s"
+
+	// Apple rejects provider tokens older than an hour and updates more
+	// frequent than every 20 minutes.
+	apnsTokenTTL = 50 * time.Minute
+
+	apnsReasonBadDeviceToken = "BadDeviceToken"
+	apnsReasonUnregistered   = "Unregistered"
+)
+
+// APNSConfig bundles the credentials for token based APNs authentication.
+type APNSConfig struct {
+	// Host is one of APNSHostProduction or APNSHostSandbox.
+	Host string
+	// Key is the PEM encoded signing key issued by Apple.
+	Key    []byte
+	KeyID  string
+	TeamID string
+	// Topic is the bundle id of the app.
+	Topic string
+}
+
+type apnsPayload struct {
+	APS struct {
+		Alert string `json:"alert"`
+	} `json:"aps"`
+	URN string `json:"urn"`
+}
+
+func apnsPayloadFrom(n *Notification) apnsPayload {
+	p := apnsPayload{URN: n.URN}
+	p.APS.Alert = n.Alert
+
+	return p
+}
+
+type apnsPusher struct {
+	client *http.Client
+	host   string
+	key    *ecdsa.PrivateKey
+	keyID  string
+	teamID string
+	topic  string
+
+	sync.Mutex
+	token     string
+	tokenedAt time.Time
+}
+
+// APNSPusher returns a Pusher which delivers directly to the Apple Push
+// Notification service over HTTP/2.
+func APNSPusher(client *http.Client, c APNSConfig) (Pusher, error) {
+	key, err := ParseECKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apnsPusher{
+		client: client,
+		host:   c.Host,
+		key:    key,
+		keyID:  c.KeyID,
+		teamID: c.TeamID,
+		topic:  c.Topic,
+	}, nil
+}
+
+func (p *apnsPusher) Push(token string, n *Notification) error {
+	body, err := json.Marshal(apnsPayloadFrom(n))
+	if err != nil {
+		return err
+	}
+
+	auth, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		p.host+fmt.Sprintf(apnsPath, token),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("authorization", "bearer "+auth)
+	req.Header.Set("content-type", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return wrapError(ErrUnavailable, "apns: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	r := struct {
+		Reason string `json:"reason"`
+	}{}
+
+	_ = json.NewDecoder(res.Body).Decode(&r)
+
+	switch {
+	case res.StatusCode == http.StatusGone,
+		r.Reason == apnsReasonBadDeviceToken,
+		r.Reason == apnsReasonUnregistered:
+		return wrapError(ErrInvalidTarget, "apns %d: %s", res.StatusCode, r.Reason)
+	case isRejected(res.StatusCode):
+		return wrapError(ErrDeliveryFailure, "apns %d: %s", res.StatusCode, r.Reason)
+	case isUnavailable(res.StatusCode):
+		return wrapError(ErrUnavailable, "apns %d: %s", res.StatusCode, r.Reason)
+	}
+
+	return fmt.Errorf("apns %d: %s", res.StatusCode, r.Reason)
+}
+
+// providerToken returns the signed token authenticating requests, it is
+// reused until it expires.
+func (p *apnsPusher) providerToken() (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.token != "" && time.Since(p.tokenedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+
+	now := time.Now()
+
+	token, err := signJWT(
+		p.key,
+		map[string]string{
+			"alg": jwtAlgES256,
+			"kid": p.keyID,
+		},
+		map[string]interface{}{
+			"iat": now.Unix(),
+			"iss": p.teamID,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = token
+	p.tokenedAt = now
+
+	return token, nil
+}
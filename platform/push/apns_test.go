@@ -0,0 +1,232 @@
+package push
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPNSPusherPush(t *testing.T) {
+	var (
+		key, raw = testECKey(t)
+		payload  = apnsPayload{}
+		tokens   = []string{}
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.URL.Path, "/3/device/token1"; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+
+		if have, want := r.Header.Get("apns-topic"), "com.example.app"; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("authorization"), "bearer ")
+
+		testVerifyES256(t, &key.PublicKey, token)
+
+		tokens = append(tokens, token)
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer s.Close()
+
+	p, err := APNSPusher(http.DefaultClient, APNSConfig{
+		Host:   s.URL,
+		Key:    raw,
+		KeyID:  "key1",
+		TeamID: "team1",
+		Topic:  "com.example.app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &Notification{
+		Alert: `Alice "liked" your Post.`,
+		URN:   "example://tapglue/posts/123",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := p.Push("token1", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if have, want := payload.APS.Alert, n.Alert; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := payload.URN, n.URN; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := tokens[1], tokens[0]; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestAPNSPusherPushError(t *testing.T) {
+	_, raw := testECKey(t)
+
+	cases := []struct {
+		code   int
+		reason string
+		check  func(error) bool
+	}{
+		{http.StatusBadRequest, apnsReasonBadDeviceToken, IsInvalidTarget},
+		{http.StatusGone, apnsReasonUnregistered, IsInvalidTarget},
+		{http.StatusRequestEntityTooLarge, "PayloadTooLarge", IsDeliveryFailure},
+		{http.StatusServiceUnavailable, "ServiceUnavailable", IsUnavailable},
+		{http.StatusTooManyRequests, "TooManyRequests", IsUnavailable},
+	}
+
+	for _, c := range cases {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.code)
+			_ = json.NewEncoder(w).Encode(map[string]string{"reason": c.reason})
+		}))
+
+		p, err := APNSPusher(http.DefaultClient, APNSConfig{
+			Host: s.URL,
+			Key:  raw,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Push("token1", &Notification{}); !c.check(err) {
+			t.Errorf("%d: unexpected error %v", c.code, err)
+		}
+
+		s.Close()
+	}
+}
+
+func TestParseECKey(t *testing.T) {
+	_, raw := testRSAKey(t)
+
+	if _, err := ParseECKey(raw); !IsInvalidKey(err) {
+		t.Errorf("have %v, want %v", err, ErrInvalidKey)
+	}
+
+	if _, err := ParseECKey([]byte("garbage")); !IsInvalidKey(err) {
+		t.Errorf("have %v, want %v", err, ErrInvalidKey)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw = testPKCS8(t, key)
+
+	if _, err := ParseECKey(raw); !IsInvalidKey(err) {
+		t.Errorf("have %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func testECKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, testPKCS8(t, key)
+}
+
+// testPKCS8 encodes the key as PEM block of its PKCS #8 form, the way key
+// files are handed out by Apple and Google.
+func testPKCS8(t *testing.T, key crypto.PrivateKey) []byte {
+	var (
+		algo pkix.AlgorithmIdentifier
+		der  []byte
+		err  error
+	)
+
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		curve := asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+		if k.Curve == elliptic.P384() {
+			curve = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+		}
+
+		params, err := asn1.Marshal(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		algo = pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+			Parameters: asn1.RawValue{FullBytes: params},
+		}
+
+		der, err = x509.MarshalECPrivateKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *rsa.PrivateKey:
+		algo = pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1},
+			Parameters: asn1.RawValue{Tag: asn1.TagNull},
+		}
+
+		der = x509.MarshalPKCS1PrivateKey(k)
+	default:
+		t.Fatalf("unsupported key type %T", key)
+	}
+
+	der, err = asn1.Marshal(struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}{
+		Algo:       algo,
+		PrivateKey: der,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func testVerifyES256(t *testing.T, key *ecdsa.PublicKey, token string) {
+	ps := strings.Split(token, ".")
+	if len(ps) != 3 {
+		t.Fatalf("malformed token '%s'", token)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(ps[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		digest = sha256.Sum256([]byte(ps[0] + "." + ps[1]))
+		r      = new(big.Int).SetBytes(sig[:32])
+		s      = new(big.Int).SetBytes(sig[32:])
+	)
+
+	if !ecdsa.Verify(key, digest[:], r, s) {
+		t.Error("token signature invalid")
+	}
+}
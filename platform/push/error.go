@@ -0,0 +1,66 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+)
+
+const errFmt = "%s: %s"
+
+// Common errors for Pusher implementations.
+var (
+	ErrDeliveryFailure = errors.New("delivery failed")
+	ErrInvalidKey      = errors.New("invalid key")
+	ErrInvalidTarget   = errors.New("invalid target")
+	ErrUnavailable     = errors.New("service unavailable")
+)
+
+// Error wraps common Pusher errors.
+type Error struct {
+	err error
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// IsDeliveryFailure indicates if err is ErrDeliveryFailure.
+func IsDeliveryFailure(err error) bool {
+	return unwrapError(err) == ErrDeliveryFailure
+}
+
+// IsInvalidKey indicates if err is ErrInvalidKey.
+func IsInvalidKey(err error) bool {
+	return unwrapError(err) == ErrInvalidKey
+}
+
+// IsInvalidTarget indicates if err is ErrInvalidTarget.
+func IsInvalidTarget(err error) bool {
+	return unwrapError(err) == ErrInvalidTarget
+}
+
+// IsUnavailable indicates if err is ErrUnavailable.
+func IsUnavailable(err error) bool {
+	return unwrapError(err) == ErrUnavailable
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.err
+	}
+
+	return err
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	return &Error{
+		err: err,
+		msg: fmt.Sprintf(
+			errFmt,
+			err,
+			fmt.Sprintf(format, args...),
+		),
+	}
+}
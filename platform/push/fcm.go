@@ -0,0 +1,240 @@
+package push
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FCM defaults.
+const (
+	FCMHost     = "https://fcm.googleapis.com"
+	FCMTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+const (
+	fcmGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	fcmPath      = "/v1/projects/%s/messages:send"
+	fcmScope     = "https://www.googleapis.com/auth/firebase.messaging"
+
+	// fcmTokenLeeway is subtracted from the lifetime of access tokens to
+	// avoid using them right before they expire.
+	fcmTokenLeeway = time.Minute
+
+	fcmErrorUnregistered = "UNREGISTERED"
+)
+
+// FCMConfig bundles the service account credentials used to authenticate
+// against FCM.
+type FCMConfig struct {
+	ClientEmail string
+	// Host defaults to FCMHost.
+	Host string
+	// PrivateKey is the PEM encoded key of the service account.
+	PrivateKey []byte
+	ProjectID  string
+	// TokenURL defaults to FCMTokenURL.
+	TokenURL string
+}
+
+type fcmPusher struct {
+	client      *http.Client
+	clientEmail string
+	host        string
+	key         *rsa.PrivateKey
+	projectID   string
+	tokenURL    string
+
+	sync.Mutex
+	token          string
+	tokenExpiresAt time.Time
+}
+
+// FCMPusher returns a Pusher which delivers through the FCM HTTP v1 API.
+func FCMPusher(client *http.Client, c FCMConfig) (Pusher, error) {
+	key, err := ParseRSAKey(c.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &fcmPusher{
+		client:      client,
+		clientEmail: c.ClientEmail,
+		host:        c.Host,
+		key:         key,
+		projectID:   c.ProjectID,
+		tokenURL:    c.TokenURL,
+	}
+
+	if p.host == "" {
+		p.host = FCMHost
+	}
+
+	if p.tokenURL == "" {
+		p.tokenURL = FCMTokenURL
+	}
+
+	return p, nil
+}
+
+func (p *fcmPusher) Push(token string, n *Notification) error {
+	f := struct {
+		Message struct {
+			Data         map[string]string `json:"data"`
+			Notification struct {
+				Title string `json:"title"`
+			} `json:"notification"`
+			Token string `json:"token"`
+		} `json:"message"`
+	}{}
+
+	f.Message.Data = map[string]string{
+		"urn": n.URN,
+	}
+	f.Message.Notification.Title = n.Alert
+	f.Message.Token = token
+
+	body, err := json.Marshal(&f)
+	if err != nil {
+		return err
+	}
+
+	auth, err := p.accessToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		p.host+fmt.Sprintf(fcmPath, p.projectID),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+auth)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return wrapError(ErrUnavailable, "fcm: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	r := struct {
+		Error struct {
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}{}
+
+	_ = json.NewDecoder(res.Body).Decode(&r)
+
+	for _, d := range r.Error.Details {
+		if d.ErrorCode == fcmErrorUnregistered {
+			return wrapError(ErrInvalidTarget, "fcm %d: %s", res.StatusCode, r.Error.Message)
+		}
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return wrapError(ErrInvalidTarget, "fcm %d: %s", res.StatusCode, r.Error.Message)
+	}
+
+	if isRejected(res.StatusCode) {
+		return wrapError(ErrDeliveryFailure, "fcm %d: %s", res.StatusCode, r.Error.Message)
+	}
+
+	if isUnavailable(res.StatusCode) {
+		return wrapError(ErrUnavailable, "fcm %d: %s", res.StatusCode, r.Error.Message)
+	}
+
+	return fmt.Errorf("fcm %d: %s", res.StatusCode, r.Error.Message)
+}
+
+// accessToken exchanges a signed assertion of the service account for an
+// OAuth2 access token, it is reused until it expires.
+func (p *fcmPusher) accessToken() (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.token != "" && time.Now().Before(p.tokenExpiresAt) {
+		return p.token, nil
+	}
+
+	now := time.Now()
+
+	assertion, err := signJWT(
+		p.key,
+		map[string]string{
+			"alg": jwtAlgRS256,
+			"typ": "JWT",
+		},
+		map[string]interface{}{
+			"aud":   p.tokenURL,
+			"exp":   now.Add(time.Hour).Unix(),
+			"iat":   now.Unix(),
+			"iss":   p.clientEmail,
+			"scope": fcmScope,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("assertion", assertion)
+	form.Set("grant_type", fcmGrantType)
+
+	res, err := p.client.Post(
+		p.tokenURL,
+		"application/x-www-form-urlencoded",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", wrapError(ErrUnavailable, "fcm token: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		if isRejected(res.StatusCode) {
+			return "", wrapError(ErrDeliveryFailure, "fcm token %d", res.StatusCode)
+		}
+
+		if isUnavailable(res.StatusCode) {
+			return "", wrapError(ErrUnavailable, "fcm token %d", res.StatusCode)
+		}
+
+		return "", fmt.Errorf("fcm token %d", res.StatusCode)
+	}
+
+	r := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&r)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = r.AccessToken
+	p.tokenExpiresAt = now.Add(
+		time.Duration(r.ExpiresIn)*time.Second - fcmTokenLeeway,
+	)
+
+	return p.token, nil
+}
@@ -0,0 +1,166 @@
+package push
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFCMPusherPush(t *testing.T) {
+	var (
+		key, raw  = testRSAKey(t)
+		exchanges = 0
+		message   = map[string]interface{}{}
+	)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+
+		if have, want := r.FormValue("grant_type"), fcmGrantType; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+
+		testVerifyRS256(t, &key.PublicKey, r.FormValue("assertion"))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access1",
+			"expires_in":   3600,
+		})
+	})
+
+	mux.HandleFunc("/v1/projects/project1/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if have, want := r.Header.Get("Authorization"), "Bearer access1"; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	p, err := FCMPusher(http.DefaultClient, FCMConfig{
+		ClientEmail: "push@project1.iam.gserviceaccount.com",
+		Host:        s.URL,
+		PrivateKey:  raw,
+		ProjectID:   "project1",
+		TokenURL:    s.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &Notification{
+		Alert: "Alice liked your Post.",
+		URN:   "example://tapglue/posts/123",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := p.Push("token1", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if have, want := exchanges, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	m := message["message"].(map[string]interface{})
+
+	if have, want := m["token"], "token1"; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := m["notification"].(map[string]interface{})["title"], n.Alert; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := m["data"].(map[string]interface{})["urn"], n.URN; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestFCMPusherPushError(t *testing.T) {
+	_, raw := testRSAKey(t)
+
+	cases := []struct {
+		code  int
+		body  string
+		check func(error) bool
+	}{
+		{http.StatusNotFound, `{"error": {"status": "NOT_FOUND", "details": [{"errorCode": "UNREGISTERED"}]}}`, IsInvalidTarget},
+		{http.StatusBadRequest, `{"error": {"status": "INVALID_ARGUMENT", "details": [{"errorCode": "UNREGISTERED"}]}}`, IsInvalidTarget},
+		{http.StatusBadRequest, `{"error": {"status": "INVALID_ARGUMENT"}}`, IsDeliveryFailure},
+		{http.StatusInternalServerError, `{"error": {"status": "INTERNAL"}}`, IsUnavailable},
+	}
+
+	for _, c := range cases {
+		mux := http.NewServeMux()
+
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"access_token": "access1", "expires_in": 3600}`))
+		})
+
+		mux.HandleFunc("/v1/projects/project1/messages:send", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.code)
+			_, _ = w.Write([]byte(c.body))
+		})
+
+		s := httptest.NewServer(mux)
+
+		p, err := FCMPusher(http.DefaultClient, FCMConfig{
+			Host:       s.URL,
+			PrivateKey: raw,
+			ProjectID:  "project1",
+			TokenURL:   s.URL + "/token",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Push("token1", &Notification{}); !c.check(err) {
+			t.Errorf("%d: unexpected error %v", c.code, err)
+		}
+
+		s.Close()
+	}
+}
+
+func testRSAKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, testPKCS8(t, key)
+}
+
+func testVerifyRS256(t *testing.T, key *rsa.PublicKey, token string) {
+	ps := strings.Split(token, ".")
+	if len(ps) != 3 {
+		t.Fatalf("malformed token '%s'", token)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(ps[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte(ps[0] + "." + ps[1]))
+
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	if err != nil {
+		t.Errorf("token signature invalid: %s", err)
+	}
+}
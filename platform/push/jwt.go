@@ -0,0 +1,122 @@
+package push
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+)
+
+const (
+	jwtAlgES256 = "ES256"
+	jwtAlgRS256 = "RS256"
+)
+
+// ParseECKey returns the ECDSA private key of a PEM encoded PKCS8 block as
+// issued by Apple for token based APNs authentication.
+func ParseECKey(raw []byte) (*ecdsa.PrivateKey, error) {
+	k, err := parsePKCS8(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := k.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, wrapError(ErrInvalidKey, "not an ECDSA key")
+	}
+
+	// ES256 is only defined for P-256.
+	if key.Curve != elliptic.P256() {
+		return nil, wrapError(ErrInvalidKey, "not a P-256 key")
+	}
+
+	return key, nil
+}
+
+// ParseRSAKey returns the RSA private key of a PEM encoded PKCS8 block as
+// found in Google service account credentials.
+func ParseRSAKey(raw []byte) (*rsa.PrivateKey, error) {
+	k, err := parsePKCS8(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, wrapError(ErrInvalidKey, "not an RSA key")
+	}
+
+	return key, nil
+}
+
+func parsePKCS8(raw []byte) (interface{}, error) {
+	b, _ := pem.Decode(raw)
+	if b == nil {
+		return nil, wrapError(ErrInvalidKey, "no PEM block found")
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(b.Bytes)
+	if err != nil {
+		return nil, wrapError(ErrInvalidKey, "%s", err)
+	}
+
+	return k, nil
+}
+
+// signJWT returns the compact serialisation of a JWT signed with the key,
+// which must either be an ECDSA P-256 or an RSA key.
+func signJWT(
+	key crypto.Signer,
+	header map[string]string,
+	claims map[string]interface{},
+) (string, error) {
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		enc    = base64.RawURLEncoding
+		input  = enc.EncodeToString(h) + "." + enc.EncodeToString(c)
+		digest = sha256.Sum256([]byte(input))
+		sig    []byte
+	)
+
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", wrapError(ErrInvalidKey, "not a P-256 key")
+		}
+
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+
+		// JWS expects the fixed size concatenation of r and s.
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", wrapError(ErrInvalidKey, "unsupported key type %T", key)
+	}
+
+	return input + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,27 @@
+package push
+
+import "net/http"
+
+// Notification is the platform agnostic content of a push message.
+type Notification struct {
+	Alert string
+	URN   string
+}
+
+// Pusher delivers a Notification to a target, which is the endpoint ARN for
+// SNS and the device token for direct delivery.
+type Pusher interface {
+	Push(target string, n *Notification) error
+}
+
+// isRejected reports if the status code signals a request which must not be
+// retried as is.
+func isRejected(code int) bool {
+	return code >= 400 && code < 500 && code != http.StatusTooManyRequests
+}
+
+// isUnavailable reports if the status code signals a temporary failure of the
+// service after which the request can be retried.
+func isUnavailable(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
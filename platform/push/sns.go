@@ -0,0 +1,86 @@
+package push
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// SNS platforms used as keys for the platform specific payloads.
+const (
+	SNSPlatformAPNS        = "APNS"
+	SNSPlatformAPNSSandbox = "APNS_SANDBOX"
+	SNSPlatformGCM         = "GCM"
+)
+
+const snsMessageStructure = "json"
+
+// SNSAPI bundles the SNS operations used for delivery.
+type SNSAPI interface {
+	Publish(*sns.PublishInput) (*sns.PublishOutput, error)
+}
+
+type gcmPayload struct {
+	Notification struct {
+		Data struct {
+			URN string `json:"urn"`
+		} `json:"data"`
+		Title string `json:"title"`
+	} `json:"notification"`
+}
+
+type snsPusher struct {
+	api      SNSAPI
+	platform string
+}
+
+// SNSPusher returns a Pusher which publishes to SNS platform endpoints of the
+// given SNS platform.
+func SNSPusher(api SNSAPI, platform string) Pusher {
+	return &snsPusher{
+		api:      api,
+		platform: platform,
+	}
+}
+
+func (p *snsPusher) Push(arn string, n *Notification) error {
+	var payload interface{} = apnsPayloadFrom(n)
+
+	if p.platform == SNSPlatformGCM {
+		f := gcmPayload{}
+		f.Notification.Data.URN = n.URN
+		f.Notification.Title = n.Alert
+
+		payload = f
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	// SNS expects the platform payloads as JSON encoded strings.
+	msg, err := json.Marshal(map[string]string{
+		p.platform: string(raw),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.api.Publish(&sns.PublishInput{
+		Message:          aws.String(string(msg)),
+		MessageStructure: aws.String(snsMessageStructure),
+		TargetArn:        aws.String(arn),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.RequestFailure); ok && isRejected(awsErr.StatusCode()) {
+			return wrapError(ErrDeliveryFailure, "sns: %s", awsErr.Message())
+		}
+
+		return err
+	}
+
+	return nil
+}
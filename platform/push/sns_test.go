@@ -0,0 +1,105 @@
+package push
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	awsSession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+const (
+	testSNSError = `<ErrorResponse xmlns="http://sns.amazonaws.com/doc/2010-03-31/">
+	<Error><Type>Sender</Type><Code>EndpointDisabled</Code><Message>Endpoint is disabled</Message></Error>
+	<RequestId>req1</RequestId>
+</ErrorResponse>`
+	testSNSPublish = `<PublishResponse xmlns="http://sns.amazonaws.com/doc/2010-03-31/">
+	<PublishResult><MessageId>msg1</MessageId></PublishResult>
+	<ResponseMetadata><RequestId>req1</RequestId></ResponseMetadata>
+</PublishResponse>`
+)
+
+func TestSNSPusherPush(t *testing.T) {
+	var (
+		arn  = "arn:aws:sns:eu-central-1:1:endpoint/GCM/test/1"
+		form = map[string]string{}
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+
+		_, _ = w.Write([]byte(testSNSPublish))
+	}))
+	defer s.Close()
+
+	n := &Notification{
+		Alert: `Alice "liked" your Post.`,
+		URN:   "example://tapglue/posts/123",
+	}
+
+	err := SNSPusher(testSNSAPI(s.URL), SNSPlatformGCM).Push(arn, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := form["TargetArn"], arn; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := form["MessageStructure"], snsMessageStructure; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	var (
+		msg     = map[string]string{}
+		payload = gcmPayload{}
+	)
+
+	if err := json.Unmarshal([]byte(form["Message"]), &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(msg[SNSPlatformGCM]), &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := payload.Notification.Title, n.Alert; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := payload.Notification.Data.URN, n.URN; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestSNSPusherPushError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(testSNSError))
+	}))
+	defer s.Close()
+
+	err := SNSPusher(testSNSAPI(s.URL), SNSPlatformAPNS).Push("arn", &Notification{})
+	if have, want := err, ErrDeliveryFailure; !IsDeliveryFailure(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testSNSAPI(endpoint string) SNSAPI {
+	return sns.New(awsSession.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Endpoint:    aws.String(endpoint),
+		MaxRetries:  aws.Int(0),
+		Region:      aws.String("eu-central-1"),
+	}))
+}
@@ -31,6 +31,13 @@ type API interface {
 	SendMessage(*sqs.SendMessageInput) (*sqs.SendMessageOutput, error)
 }
 
+// FanoutQueue returns the name of the queue which receives a copy of the
+// messages of the named queue for the timeline fan-out. Each consumer needs its
+// own queue, as SQS hands every message to one consumer only.
+func FanoutQueue(name string) string {
+	return "fanout-" + name
+}
+
 // ReceiveMessage given a queue url fetches the latest message with the common
 // attributes and timeouts.
 func ReceiveMessage(api API, queueURL string) (*sqs.ReceiveMessageOutput, error) {
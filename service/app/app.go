@@ -1,10 +1,17 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
+	"text/template"
 	"time"
 
+	"golang.org/x/text/language"
+
+	"github.com/tapglue/multiverse/platform/oidc"
+	"github.com/tapglue/multiverse/platform/push"
 	"github.com/tapglue/multiverse/platform/service"
+	"github.com/tapglue/multiverse/service/device"
 )
 
 const (
@@ -17,21 +24,102 @@ const (
 	limitStaging    = 100
 )
 
-// App represents an Org owned data container.
+// Access token lifetimes, MaxAccessTTL bounds how long a revoked session can
+// go unnoticed and how long revocations have to be kept.
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+	MaxAccessTTL      = time.Hour
+)
+
+// Backends to deliver push notifications with.
+const (
+	BackendAPNS = "apns"
+	BackendFCM  = "fcm"
+	BackendSNS  = "sns"
+)
+
+// Templates used for the notifications of the matching sims rules.
+const (
+	TemplateCommentPost     = "comment_post"
+	TemplateCommentPostOwn  = "comment_post_own"
+	TemplateCommentReply    = "comment_reply"
+	TemplateFollow          = "follow"
+	TemplateFriendConfirmed = "friend_confirmed"
+	TemplateFriendRequest   = "friend_request"
+	TemplateLikePost        = "like_post"
+	TemplateLikePostOwn     = "like_post_own"
+	TemplateMention         = "mention"
+	TemplatePostCreated     = "post_created"
+)
+
+// TemplateNames is the list of all supported templates.
+var TemplateNames = []string{
+	TemplateCommentPost,
+	TemplateCommentPostOwn,
+	TemplateCommentReply,
+	TemplateFollow,
+	TemplateFriendConfirmed,
+	TemplateFriendRequest,
+	TemplateLikePost,
+	TemplateLikePostOwn,
+	TemplateMention,
+	TemplatePostCreated,
+}
+
+// App represents an Org owned data container. Content reported at least
+// ReportThreshold times is hidden until reviewed, zero disables the threshold.
 type App struct {
-	BackendToken string    `json:"backend_token"`
-	Description  string    `json:"description"`
-	Enabled      bool      `json:"enabled"`
-	ID           uint64    `json:"-"`
-	InProduction bool      `json:"in_production"`
-	Name         string    `json:"name"`
-	OrgID        uint64    `json:"-"`
-	PublicID     string    `json:"id"`
-	PublicOrgID  string    `json:"account_id"`
-	Token        string    `json:"token"`
-	URL          string    `json:"url"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	BackendToken    string       `json:"backend_token"`
+	Connections     *Connections `json:"connections,omitempty"`
+	Description     string       `json:"description"`
+	Enabled         bool         `json:"enabled"`
+	ID              uint64       `json:"-"`
+	InProduction    bool         `json:"in_production"`
+	Name            string       `json:"name"`
+	OrgID           uint64       `json:"-"`
+	Providers       Providers    `json:"providers,omitempty"`
+	PublicID        string       `json:"id"`
+	PublicOrgID     string       `json:"account_id"`
+	Push            *Push        `json:"push,omitempty"`
+	ReportThreshold int          `json:"report_threshold,omitempty"`
+	Sessions        *Sessions    `json:"sessions,omitempty"`
+	Templates       Templates    `json:"templates,omitempty"`
+	Token           string       `json:"token"`
+	Tokens          *Tokens      `json:"tokens,omitempty"`
+	URL             string       `json:"url"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// ConnectionRules returns the connection configuration of the App, Apps
+// without one get the defaults.
+func (a *App) ConnectionRules() Connections {
+	if a.Connections == nil {
+		return Connections{}
+	}
+
+	return *a.Connections
+}
+
+// SessionRules returns the session configuration of the App, Apps without one
+// get the defaults.
+func (a *App) SessionRules() Sessions {
+	if a.Sessions == nil {
+		return Sessions{}
+	}
+
+	return *a.Sessions
+}
+
+// TokenRules returns the access token configuration of the App, Apps without
+// one authenticate with session tokens only.
+func (a *App) TokenRules() Tokens {
+	if a.Tokens == nil {
+		return Tokens{}
+	}
+
+	return *a.Tokens
 }
 
 // Limit returns the desired rate limit for an Application varied by production
@@ -50,13 +138,409 @@ func (a *App) Namespace() string {
 	return fmt.Sprintf(fmtNamespace, a.OrgID, a.ID)
 }
 
+// Validate performs semantic checks on the App.
 func (a *App) Validate() error {
-	return nil
+	if a.ReportThreshold < 0 {
+		return wrapError(ErrInvalidApp, "report threshold can't be negative")
+	}
+
+	if a.Connections != nil {
+		if err := a.Connections.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if a.Push != nil {
+		if err := a.Push.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := a.Providers.Validate(); err != nil {
+		return err
+	}
+
+	if a.Sessions != nil {
+		if err := a.Sessions.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if a.Tokens != nil {
+		if err := a.Tokens.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return a.Templates.Validate()
 }
 
 // List is an App collection.
 type List []*App
 
+// APNS holds the credentials for token based delivery through the Apple Push
+// Notification service.
+type APNS struct {
+	// Key is the PEM encoded signing key issued by Apple.
+	Key    string `json:"key"`
+	KeyID  string `json:"key_id"`
+	TeamID string `json:"team_id"`
+	// Topic is the bundle id of the app.
+	Topic string `json:"topic"`
+}
+
+// Validate performs semantic checks on the APNS credentials.
+func (a *APNS) Validate() error {
+	if a.KeyID == "" || a.TeamID == "" || a.Topic == "" {
+		return wrapError(ErrInvalidApp, "apns key_id, team_id and topic must be set")
+	}
+
+	if _, err := push.ParseECKey([]byte(a.Key)); err != nil {
+		return wrapError(ErrInvalidApp, "apns %s", err)
+	}
+
+	return nil
+}
+
+// Connections is the configuration of the connection state machine for an
+// App. With FollowApproval follows start out pending until the followed user
+// confirms them. Rerequest allows rejected connections to be requested again.
+// Pending requests older than RequestExpiry seconds are rejected, zero keeps
+// them pending forever.
+type Connections struct {
+	FollowApproval bool `json:"follow_approval"`
+	Rerequest      bool `json:"rerequest"`
+	RequestExpiry  int  `json:"request_expiry"`
+}
+
+// Expiry returns the duration after which pending requests are rejected.
+func (c Connections) Expiry() time.Duration {
+	return time.Duration(c.RequestExpiry) * time.Second
+}
+
+// Validate performs semantic checks on the Connections configuration.
+func (c *Connections) Validate() error {
+	if c.RequestExpiry < 0 {
+		return wrapError(ErrInvalidApp, "connections request_expiry can't be negative")
+	}
+
+	return nil
+}
+
+// FCM holds the service account credentials for delivery through Firebase
+// Cloud Messaging.
+type FCM struct {
+	ClientEmail string `json:"client_email"`
+	// PrivateKey is the PEM encoded key of the service account.
+	PrivateKey string `json:"private_key"`
+	ProjectID  string `json:"project_id"`
+}
+
+// Validate performs semantic checks on the FCM credentials.
+func (f *FCM) Validate() error {
+	if f.ClientEmail == "" || f.ProjectID == "" {
+		return wrapError(ErrInvalidApp, "fcm client_email and project_id must be set")
+	}
+
+	if _, err := push.ParseRSAKey([]byte(f.PrivateKey)); err != nil {
+		return wrapError(ErrInvalidApp, "fcm %s", err)
+	}
+
+	return nil
+}
+
+// Push is the configuration to deliver push notifications for an App.
+type Push struct {
+	APNS *APNS `json:"apns,omitempty"`
+	// ARNs maps device platforms to the SNS platform application to publish to.
+	ARNs map[device.Platform]string `json:"arns"`
+	// Backends maps device platforms to the backend used for delivery, SNS is
+	// used for platforms without an entry.
+	Backends map[device.Platform]string `json:"backends,omitempty"`
+	Enabled  bool                       `json:"enabled"`
+	FCM      *FCM                       `json:"fcm,omitempty"`
+	// Scheme is used to construct deep links from the URNs of notifications.
+	Scheme string `json:"scheme"`
+}
+
+// ARN returns the platform application for the given device platform.
+func (p *Push) ARN(platform device.Platform) (string, bool) {
+	arn, ok := p.ARNs[platform]
+
+	return arn, ok && arn != ""
+}
+
+// Backend returns the backend used to deliver to the given device platform.
+func (p *Push) Backend(platform device.Platform) string {
+	if b, ok := p.Backends[platform]; ok && b != "" {
+		return b
+	}
+
+	return BackendSNS
+}
+
+// Validate performs semantic checks on the Push configuration.
+func (p *Push) Validate() error {
+	for platform, arn := range p.ARNs {
+		if !isPlatform(platform) {
+			return wrapError(ErrInvalidApp, "push platform '%d' not supported", platform)
+		}
+
+		if arn == "" {
+			return wrapError(ErrInvalidApp, "push arn for platform '%d' missing", platform)
+		}
+	}
+
+	for platform, backend := range p.Backends {
+		if !isPlatform(platform) {
+			return wrapError(ErrInvalidApp, "push platform '%d' not supported", platform)
+		}
+
+		switch backend {
+		case BackendAPNS:
+			if platform == device.PlatformAndroid {
+				return wrapError(ErrInvalidApp, "push backend apns not supported for android")
+			}
+
+			if p.APNS == nil {
+				return wrapError(ErrInvalidApp, "push apns credentials missing")
+			}
+		case BackendFCM:
+			if platform != device.PlatformAndroid {
+				return wrapError(ErrInvalidApp, "push backend fcm only supported for android")
+			}
+
+			if p.FCM == nil {
+				return wrapError(ErrInvalidApp, "push fcm credentials missing")
+			}
+		case BackendSNS:
+		default:
+			return wrapError(ErrInvalidApp, "push backend '%s' not supported", backend)
+		}
+	}
+
+	if p.APNS != nil {
+		if err := p.APNS.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.FCM != nil {
+		if err := p.FCM.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Enabled && p.Scheme == "" {
+		return wrapError(ErrInvalidApp, "push scheme must be set")
+	}
+
+	return nil
+}
+
+// Provider is the configuration of an OpenID Connect identity provider. ID
+// tokens are accepted if issued by Issuer for one of the ClientIDs and signed
+// with a key published under JWKSURL.
+type Provider struct {
+	ClientIDs []string `json:"client_ids"`
+	Issuer    string   `json:"issuer"`
+	JWKSURL   string   `json:"jwks_url"`
+}
+
+// OIDC returns the Provider in the form used for token verification.
+func (p Provider) OIDC() oidc.Provider {
+	return oidc.Provider{
+		ClientIDs: p.ClientIDs,
+		Issuer:    p.Issuer,
+		JWKSURL:   p.JWKSURL,
+	}
+}
+
+// Providers maps social platforms to the identity providers users of an App can
+// log in with. The platforms are the keys of the social ids of users.
+type Providers map[string]Provider
+
+// Validate performs semantic checks on the Providers configuration.
+func (p Providers) Validate() error {
+	for platform, provider := range p {
+		if platform == "" {
+			return wrapError(ErrInvalidApp, "provider platform must be set")
+		}
+
+		if err := provider.OIDC().Validate(); err != nil {
+			return wrapError(ErrInvalidApp, "provider '%s' %s", platform, err)
+		}
+	}
+
+	return nil
+}
+
+// Sessions is the configuration of user session lifetimes for an App.
+// Sessions not seen for IdleTTL seconds or older than MaxTTL seconds expire,
+// zero disables the respective limit.
+type Sessions struct {
+	IdleTTL int `json:"idle_ttl"`
+	MaxTTL  int `json:"max_ttl"`
+}
+
+// Idle returns the duration of inactivity after which sessions expire.
+func (s Sessions) Idle() time.Duration {
+	return time.Duration(s.IdleTTL) * time.Second
+}
+
+// Max returns the duration after creation at which sessions expire.
+func (s Sessions) Max() time.Duration {
+	return time.Duration(s.MaxTTL) * time.Second
+}
+
+// Validate performs semantic checks on the Sessions configuration.
+func (s *Sessions) Validate() error {
+	if s.IdleTTL < 0 || s.MaxTTL < 0 {
+		return wrapError(ErrInvalidApp, "sessions idle_ttl and max_ttl can't be negative")
+	}
+
+	return nil
+}
+
+// Tokens is the configuration of signed access tokens for an App. If Enabled
+// users get an access token valid for AccessTTL seconds and a refresh token
+// valid for RefreshTTL seconds on login, zero falls back to the defaults.
+type Tokens struct {
+	AccessTTL  int  `json:"access_ttl"`
+	Enabled    bool `json:"enabled"`
+	RefreshTTL int  `json:"refresh_ttl"`
+}
+
+// Access returns the lifetime of access tokens.
+func (t Tokens) Access() time.Duration {
+	if t.AccessTTL == 0 {
+		return DefaultAccessTTL
+	}
+
+	return time.Duration(t.AccessTTL) * time.Second
+}
+
+// Refresh returns the lifetime of refresh tokens.
+func (t Tokens) Refresh() time.Duration {
+	if t.RefreshTTL == 0 {
+		return DefaultRefreshTTL
+	}
+
+	return time.Duration(t.RefreshTTL) * time.Second
+}
+
+// Validate performs semantic checks on the Tokens configuration.
+func (t *Tokens) Validate() error {
+	if t.AccessTTL < 0 || t.RefreshTTL < 0 {
+		return wrapError(ErrInvalidApp, "tokens access_ttl and refresh_ttl can't be negative")
+	}
+
+	if t.Access() > MaxAccessTTL {
+		return wrapError(ErrInvalidApp, "tokens access_ttl can't exceed %d", int(MaxAccessTTL/time.Second))
+	}
+
+	if t.Refresh() < t.Access() {
+		return wrapError(ErrInvalidApp, "tokens refresh_ttl can't be shorter than access_ttl")
+	}
+
+	return nil
+}
+
+// Templates maps template names to the template texts per language tag. The
+// texts are parsed with text/template.
+type Templates map[string]map[string]string
+
+// Template returns the text for the given template name and language.
+func (t Templates) Template(name, lang string) (string, bool) {
+	text, ok := t[name][lang]
+
+	return text, ok && text != ""
+}
+
+// Validate performs semantic checks on the Templates.
+func (t Templates) Validate() error {
+	for name, texts := range t {
+		if !isTemplateName(name) {
+			return wrapError(ErrInvalidApp, "template '%s' not supported", name)
+		}
+
+		for lang, text := range texts {
+			if _, err := language.Parse(lang); err != nil {
+				return wrapError(
+					ErrInvalidApp,
+					"template '%s' language invalid '%s'",
+					name,
+					lang,
+				)
+			}
+
+			if _, err := ExecuteTemplate(name, text, templateSample(name)); err != nil {
+				return wrapError(ErrInvalidApp, "template '%s' invalid: %s", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TemplateData is passed to the templates when they are executed. Post is only
+// present for templates of post related notifications.
+type TemplateData struct {
+	Origin *TemplateUser
+	Post   *TemplatePost
+	Target *TemplateUser
+}
+
+// TemplatePost is the view of a post exposed to templates.
+type TemplatePost struct {
+	contents map[string]string
+}
+
+// NewTemplatePost returns a TemplatePost for the given attachment contents
+// keyed by attachment name.
+func NewTemplatePost(contents map[string]string) *TemplatePost {
+	return &TemplatePost{contents: contents}
+}
+
+// Content returns the content of the attachment with the given name.
+func (p *TemplatePost) Content(name string) string {
+	return p.contents[name]
+}
+
+// TemplateUser is the view of a user exposed to templates.
+type TemplateUser struct {
+	Firstname string
+	Lastname  string
+	Username  string
+}
+
+// Name returns the firstname of the user if present or the username
+// otherwise.
+func (u *TemplateUser) Name() string {
+	if u.Firstname != "" {
+		return u.Firstname
+	}
+
+	return u.Username
+}
+
+// ExecuteTemplate parses the text as template with the given name and renders
+// it with data.
+func ExecuteTemplate(name, text string, data *TemplateData) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
 // QueryOptions are used to narrow down app queries.
 type QueryOptions struct {
 	Before        time.Time
@@ -80,3 +564,42 @@ type Service interface {
 
 // ServiceMiddleware is a chainable behaviour modifier for Service.
 type ServiceMiddleware func(Service) Service
+
+func isTemplateName(name string) bool {
+	for _, n := range TemplateNames {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// templateSample returns data in the shape a notification for the template
+// carries, so templates referring to absent fields fail validation.
+func templateSample(name string) *TemplateData {
+	d := &TemplateData{
+		Origin: &TemplateUser{
+			Firstname: "Alice",
+			Lastname:  "Doe",
+			Username:  "alice",
+		},
+		Target: &TemplateUser{
+			Firstname: "Bob",
+			Lastname:  "Doe",
+			Username:  "bob",
+		},
+	}
+
+	switch name {
+	case TemplateFollow, TemplateFriendConfirmed, TemplateFriendRequest:
+	default:
+		d.Post = NewTemplatePost(map[string]string{})
+	}
+
+	return d
+}
+
+func isPlatform(platform device.Platform) bool {
+	return platform >= device.PlatformIOSSandbox && platform <= device.PlatformAndroid
+}
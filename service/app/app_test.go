@@ -0,0 +1,246 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tapglue/multiverse/service/device"
+)
+
+func TestConnectionsValidate(t *testing.T) {
+	a := &App{
+		Connections: &Connections{
+			RequestExpiry: -1,
+		},
+	}
+
+	if have, want := a.Validate(), ErrInvalidApp; !IsInvalidApp(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	a.Connections.RequestExpiry = 3600
+
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if have, want := a.ConnectionRules().Expiry(), time.Hour; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestPushARN(t *testing.T) {
+	p := &Push{
+		ARNs: map[device.Platform]string{
+			device.PlatformAndroid: "arn:aws:sns:eu-central-1:1:app/GCM/test",
+		},
+	}
+
+	arn, ok := p.ARN(device.PlatformAndroid)
+	if !ok {
+		t.Fatal("want arn for android")
+	}
+
+	if have, want := arn, p.ARNs[device.PlatformAndroid]; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if _, ok := p.ARN(device.PlatformIOS); ok {
+		t.Error("want no arn for ios")
+	}
+}
+
+func TestPushValidate(t *testing.T) {
+	ps := []*Push{
+		{ARNs: map[device.Platform]string{4: "arn"}},                                // Unsupported Platform
+		{ARNs: map[device.Platform]string{device.PlatformAndroid: ""}},              // Missing ARN
+		{ARNs: map[device.Platform]string{}, Enabled: true, Scheme: ""},             // Missing Scheme
+		{Backends: map[device.Platform]string{device.PlatformIOS: "gcm"}},           // Unsupported Backend
+		{Backends: map[device.Platform]string{device.PlatformAndroid: BackendAPNS}}, // Unsupported Platform for Backend
+		{Backends: map[device.Platform]string{device.PlatformIOS: BackendFCM}},      // Unsupported Platform for Backend
+		{Backends: map[device.Platform]string{device.PlatformIOS: BackendAPNS}},     // Missing Credentials
+		{APNS: &APNS{KeyID: "key", TeamID: "team", Topic: "topic", Key: "key"}},     // Invalid Key
+		{FCM: &FCM{ClientEmail: "push@example.com", PrivateKey: "key"}},             // Missing ProjectID
+	}
+
+	for _, p := range ps {
+		if have, want := p.Validate(), ErrInvalidApp; !IsInvalidApp(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	a := &App{
+		Push: &Push{
+			ARNs: map[device.Platform]string{
+				device.PlatformIOSSandbox: "arn",
+			},
+			Enabled: true,
+			Scheme:  "test",
+		},
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPushBackend(t *testing.T) {
+	p := &Push{
+		Backends: map[device.Platform]string{
+			device.PlatformIOS: BackendAPNS,
+		},
+	}
+
+	if have, want := p.Backend(device.PlatformIOS), BackendAPNS; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := p.Backend(device.PlatformAndroid), BackendSNS; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestProvidersValidate(t *testing.T) {
+	a := &App{
+		Providers: Providers{
+			"google": {
+				Issuer:  "https://accounts.google.com",
+				JWKSURL: "https://www.googleapis.com/oauth2/v3/certs",
+			},
+		},
+	}
+
+	if have, want := a.Validate(), ErrInvalidApp; !IsInvalidApp(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	a.Providers["google"] = Provider{
+		ClientIDs: []string{"client"},
+		Issuer:    "https://accounts.google.com",
+		JWKSURL:   "https://www.googleapis.com/oauth2/v3/certs",
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSessionsValidate(t *testing.T) {
+	a := &App{
+		Sessions: &Sessions{
+			IdleTTL: -1,
+		},
+	}
+
+	if have, want := a.Validate(), ErrInvalidApp; !IsInvalidApp(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	a.Sessions = &Sessions{
+		IdleTTL: 3600,
+		MaxTTL:  86400,
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if have, want := a.SessionRules().Idle(), time.Hour; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := a.SessionRules().Max(), 24*time.Hour; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestTokensValidate(t *testing.T) {
+	cases := []*Tokens{
+		{AccessTTL: -1},
+		{AccessTTL: 7200},
+		{AccessTTL: 600, RefreshTTL: 60},
+	}
+
+	for _, c := range cases {
+		a := &App{Tokens: c}
+
+		if have, want := a.Validate(), ErrInvalidApp; !IsInvalidApp(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	a := &App{
+		Tokens: &Tokens{
+			Enabled: true,
+		},
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if have, want := a.TokenRules().Access(), DefaultAccessTTL; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := a.TokenRules().Refresh(), DefaultRefreshTTL; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestTemplatesTemplate(t *testing.T) {
+	ts := Templates{
+		TemplateFollow: map[string]string{
+			"de": "{{.Origin.Name}} folgt dir jetzt",
+			"en": "",
+		},
+	}
+
+	text, ok := ts.Template(TemplateFollow, "de")
+	if !ok {
+		t.Fatal("want template for de")
+	}
+
+	if have, want := text, ts[TemplateFollow]["de"]; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if _, ok := ts.Template(TemplateFollow, "en"); ok {
+		t.Error("want no template for empty text")
+	}
+
+	if _, ok := ts.Template(TemplateLikePost, "de"); ok {
+		t.Error("want no template for missing name")
+	}
+}
+
+func TestTemplatesValidate(t *testing.T) {
+	ts := []Templates{
+		{"unknown": {"en": "text"}},                         // Unsupported Template
+		{TemplateFollow: {"123456789": "text"}},             // Invalid Language
+		{TemplateFollow: {"en": "{{.Origin"}},               // Invalid Template
+		{TemplateFollow: {"en": "{{.Origin.Email}}"}},       // Unexposed Field
+		{TemplateFollow: {"en": "{{.Post.Content \"x\"}}"}}, // Missing Post
+	}
+
+	for _, tmpl := range ts {
+		if have, want := tmpl.Validate(), ErrInvalidApp; !IsInvalidApp(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	a := &App{
+		Templates: Templates{
+			TemplateFollow: {
+				"de": "{{.Origin.Name}} folgt dir jetzt",
+			},
+			TemplateLikePostOwn: {
+				"en": "{{.Origin.Name}} liked {{.Post.Content \"title\"}}",
+			},
+		},
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -9,7 +9,8 @@ const errFmt = "%s: %s"
 
 // Common errors for App services.
 var (
-	ErrNotFound = errors.New("app not found")
+	ErrInvalidApp = errors.New("invalid app")
+	ErrNotFound   = errors.New("app not found")
 )
 
 // Error wraps common App errors.
@@ -22,6 +23,11 @@ func (e Error) Error() string {
 	return e.msg
 }
 
+// IsInvalidApp indicates if err is ErrInvalidApp.
+func IsInvalidApp(err error) bool {
+	return unwrapError(err) == ErrInvalidApp
+}
+
 // IsNotFound indicates if err is ErrNotFound.
 func IsNotFound(err error) bool {
 	return unwrapError(err) == ErrNotFound
@@ -14,10 +14,13 @@ const (
 	StateRejected  State = "rejected"
 )
 
-// Supported types for connections.
+// Supported types for connections. Blocks and mutes are one-sided
+// restrictions the origin imposes on the target, they are always confirmed.
 const (
+	TypeBlock  Type = "block"
 	TypeFollow Type = "follow"
 	TypeFriend Type = "friend"
+	TypeMute   Type = "mute"
 )
 
 // Acker permantly removes the workload from the Source.
@@ -62,6 +65,10 @@ func (c Connection) Validate() error {
 	switch c.Type {
 	case TypeFollow, TypeFriend:
 		// valid
+	case TypeBlock, TypeMute:
+		if c.State != StateConfirmed {
+			return wrapError(ErrInvalidConnection, "%s must be confirmed", c.Type)
+		}
 	default:
 		return wrapError(ErrInvalidConnection, "invalid type")
 	}
@@ -130,6 +137,7 @@ type Service interface {
 
 	Count(namespace string, opts QueryOptions) (int, error)
 	Put(namespace string, connection *Connection) (*Connection, error)
+	PutBatch(namespace string, cons List) (List, error)
 	Query(namespace string, opts QueryOptions) (List, error)
 }
 
@@ -161,3 +169,56 @@ type SourceMiddleware func(Source) Source
 
 // Type of a user relation.
 type Type string
+
+// batchSize caps the connections looked up in one query, which keeps the
+// parameters of a query well below the limit of Postgres.
+const batchSize = 5000
+
+// queryStored returns the persisted counterparts of the given connections
+// keyed by their stringKey.
+func queryStored(s Service, ns string, cs List) (map[string]*Connection, error) {
+	stored := map[string]*Connection{}
+
+	for i := 0; i < len(cs); i += batchSize {
+		end := i + batchSize
+
+		if end > len(cs) {
+			end = len(cs)
+		}
+
+		var (
+			opts      = QueryOptions{}
+			seenFrom  = map[uint64]struct{}{}
+			seenTo    = map[uint64]struct{}{}
+			seenTypes = map[Type]struct{}{}
+		)
+
+		for _, con := range cs[i:end] {
+			if _, ok := seenFrom[con.FromID]; !ok {
+				opts.FromIDs = append(opts.FromIDs, con.FromID)
+				seenFrom[con.FromID] = struct{}{}
+			}
+
+			if _, ok := seenTo[con.ToID]; !ok {
+				opts.ToIDs = append(opts.ToIDs, con.ToID)
+				seenTo[con.ToID] = struct{}{}
+			}
+
+			if _, ok := seenTypes[con.Type]; !ok {
+				opts.Types = append(opts.Types, con.Type)
+				seenTypes[con.Type] = struct{}{}
+			}
+		}
+
+		es, err := s.Query(ns, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, con := range es {
+			stored[stringKey(con)] = con
+		}
+	}
+
+	return stored, nil
+}
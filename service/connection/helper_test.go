@@ -158,6 +158,98 @@ func testServicePut(t *testing.T, p prepareFunc) {
 	}
 }
 
+func testServicePutBatch(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_put_batch"
+		service   = p(t, namespace)
+		enabled   = true
+		fromID    = uint64(rand.Int63())
+		cs        = List{}
+	)
+
+	for i := 0; i < 3; i++ {
+		cs = append(cs, &Connection{
+			Enabled: true,
+			FromID:  fromID,
+			State:   StateConfirmed,
+			ToID:    uint64(rand.Int63()),
+			Type:    TypeFollow,
+		})
+	}
+
+	_, err := service.PutBatch(namespace, append(cs, &Connection{
+		FromID: fromID,
+	}))
+	if !IsInvalidConnection(err) {
+		t.Errorf("expected error: %s", ErrInvalidConnection)
+	}
+
+	count, err := service.Count(namespace, QueryOptions{
+		FromIDs: []uint64{fromID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = service.PutBatch(namespace, cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs[0].Enabled = false
+
+	_, err = service.PutBatch(namespace, cs[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err = service.Count(namespace, QueryOptions{
+		Enabled: &enabled,
+		FromIDs: []uint64{fromID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	// Large imports have to stay below the parameter limit of Postgres.
+	cs = List{}
+
+	for i := 0; i < 5*batchSize; i++ {
+		cs = append(cs, &Connection{
+			Enabled: true,
+			FromID:  fromID,
+			State:   StateConfirmed,
+			ToID:    uint64(rand.Int63()),
+			Type:    TypeFriend,
+		})
+	}
+
+	_, err = service.PutBatch(namespace, cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err = service.Count(namespace, QueryOptions{
+		FromIDs: []uint64{fromID},
+		Types:   []Type{TypeFriend},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, len(cs); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func testServicePutInvalid(t *testing.T, p prepareFunc) {
 	var (
 		namespace = "service_put_invalid"
@@ -196,6 +288,17 @@ func testServicePutInvalid(t *testing.T, p prepareFunc) {
 	if !IsInvalidConnection(err) {
 		t.Errorf("expected error: %s", ErrInvalidConnection)
 	}
+
+	// pending Block
+	_, err = service.Put(namespace, &Connection{
+		FromID: uint64(rand.Int63()),
+		ToID:   uint64(rand.Int63()),
+		State:  StatePending,
+		Type:   TypeBlock,
+	})
+	if !IsInvalidConnection(err) {
+		t.Errorf("expected error: %s", ErrInvalidConnection)
+	}
 }
 
 func testServiceQuery(t *testing.T, p prepareFunc) {
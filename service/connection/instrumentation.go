@@ -73,6 +73,17 @@ func (s *instrumentService) Put(
 	return s.next.Put(ns, input)
 }
 
+func (s *instrumentService) PutBatch(
+	ns string,
+	input List,
+) (output List, err error) {
+	defer func(begin time.Time) {
+		s.track("PutBatch", ns, begin, err)
+	}(time.Now())
+
+	return s.next.PutBatch(ns, input)
+}
+
 func (s *instrumentService) Query(
 	ns string,
 	opts QueryOptions,
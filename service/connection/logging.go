@@ -92,6 +92,28 @@ func (s *logService) Put(
 	return s.next.Put(ns, input)
 }
 
+func (s *logService) PutBatch(
+	ns string,
+	input List,
+) (output List, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"connection_len", len(input),
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "PutBatch",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.PutBatch(ns, input)
+}
+
 func (s *logService) Query(ns string, opts QueryOptions) (list List, err error) {
 	defer func(begin time.Time) {
 		ps := []interface{}{
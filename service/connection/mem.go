@@ -63,6 +63,28 @@ func (s *memService) Put(ns string, con *Connection) (*Connection, error) {
 	return con, nil
 }
 
+func (s *memService) PutBatch(ns string, cs List) (List, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	// Validate upfront so a faulty connection leaves the store untouched.
+	for _, con := range cs {
+		if err := con.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, con := range cs {
+		_, err := s.Put(ns, con)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cs, nil
+}
+
 func (s *memService) Query(ns string, opts QueryOptions) (List, error) {
 	if err := s.Setup(ns); err != nil {
 		return nil, err
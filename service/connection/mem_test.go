@@ -10,6 +10,10 @@ func TestMemPut(t *testing.T) {
 	testServicePut(t, prepareMem)
 }
 
+func TestMemPutBatch(t *testing.T) {
+	testServicePutBatch(t, prepareMem)
+}
+
 func TestMemPutInvalid(t *testing.T) {
 	testServicePutInvalid(t, prepareMem)
 }
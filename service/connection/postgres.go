@@ -178,13 +178,6 @@ func (s *pgService) Put(ns string, con *Connection) (*Connection, error) {
 		return nil, err
 	}
 
-	var (
-		now    = time.Now().UTC()
-		params = []interface{}{con.FromID, con.ToID, string(con.Type)}
-
-		query string
-	)
-
 	cs, err := s.Query(ns, QueryOptions{
 		FromIDs: []uint64{
 			con.FromID,
@@ -200,38 +193,70 @@ func (s *pgService) Put(ns string, con *Connection) (*Connection, error) {
 		return nil, err
 	}
 
+	var stored *Connection
+
 	if len(cs) > 0 {
-		query = wrapNamespace(pgUpdateConnection, ns)
+		stored = cs[0]
+	}
 
-		con.CreatedAt = cs[0].CreatedAt
-		con.UpdatedAt = now
-	} else {
-		params = []interface{}{}
-		query = wrapNamespace(pgInsertConnection, ns)
+	query, params, err := prepareUpsert(ns, con, stored, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
 
-		if con.CreatedAt.IsZero() {
-			con.CreatedAt = now
-		}
+	_, err = s.db.Exec(query, params...)
+	if err != nil {
+		return nil, err
+	}
 
-		if con.UpdatedAt.IsZero() {
-			con.UpdatedAt = now
-		}
+	return con, nil
+}
 
-		con.CreatedAt = con.CreatedAt.UTC()
-		con.UpdatedAt = con.UpdatedAt.UTC()
+func (s *pgService) PutBatch(ns string, cs List) (List, error) {
+	if len(cs) == 0 {
+		return cs, nil
 	}
 
-	data, err := json.Marshal(con)
+	for _, con := range cs {
+		if err := con.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := queryStored(s, ns, cs)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = s.db.Exec(query, append(params, data)...)
+	tx, err := s.db.Beginx()
 	if err != nil {
 		return nil, err
 	}
 
-	return con, nil
+	now := time.Now().UTC()
+
+	for _, con := range cs {
+		query, params, err := prepareUpsert(ns, con, stored[stringKey(con)], now)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		_, err = tx.Exec(query, params...)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		// Repeated connections in the batch update the first one.
+		stored[stringKey(con)] = con
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return cs, nil
 }
 
 func (s *pgService) Query(ns string, opts QueryOptions) (List, error) {
@@ -450,6 +475,45 @@ func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, erro
 	return query, params, nil
 }
 
+// prepareUpsert returns the statement to store the connection, updating the
+// stored one if present.
+func prepareUpsert(
+	ns string,
+	con, stored *Connection,
+	now time.Time,
+) (string, []interface{}, error) {
+	var (
+		params = []interface{}{}
+		query  = wrapNamespace(pgInsertConnection, ns)
+	)
+
+	if stored != nil {
+		params = []interface{}{con.FromID, con.ToID, string(con.Type)}
+		query = wrapNamespace(pgUpdateConnection, ns)
+
+		con.CreatedAt = stored.CreatedAt
+		con.UpdatedAt = now
+	} else {
+		if con.CreatedAt.IsZero() {
+			con.CreatedAt = now
+		}
+
+		if con.UpdatedAt.IsZero() {
+			con.UpdatedAt = now
+		}
+
+		con.CreatedAt = con.CreatedAt.UTC()
+		con.UpdatedAt = con.UpdatedAt.UTC()
+	}
+
+	data, err := json.Marshal(con)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query, append(params, data), nil
+}
+
 func wrapNamespace(query, namespace string) string {
 	return fmt.Sprintf(query, namespace)
 }
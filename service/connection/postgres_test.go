@@ -22,6 +22,10 @@ func TestPostgresPut(t *testing.T) {
 	testServicePut(t, preparePostgres)
 }
 
+func TestPostgresPutBatch(t *testing.T) {
+	testServicePutBatch(t, preparePostgres)
+}
+
 func TestPostgresPutInvalid(t *testing.T) {
 	testServicePutInvalid(t, preparePostgres)
 }
@@ -67,6 +67,32 @@ func (s *sourcingService) Put(
 	return s.service.Put(ns, input)
 }
 
+func (s *sourcingService) PutBatch(
+	ns string,
+	input List,
+) (new List, err error) {
+	olds := map[string]*Connection{}
+
+	defer func() {
+		if err == nil {
+			for _, con := range new {
+				_, _ = s.producer.Propagate(ns, olds[stringKey(con)], con)
+			}
+		}
+	}()
+
+	if len(input) == 0 {
+		return s.service.PutBatch(ns, input)
+	}
+
+	olds, err = queryStored(s.service, ns, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.service.PutBatch(ns, input)
+}
+
 func (s *sourcingService) Query(ns string, opts QueryOptions) (List, error) {
 	return s.service.Query(ns, opts)
 }
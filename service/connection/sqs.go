@@ -21,8 +21,18 @@ type sqsSource struct {
 
 // SQSSource returns an SQS backed Source implementation.
 func SQSSource(api platformSQS.API) (Source, error) {
+	return newSQSSource(api, queueName)
+}
+
+// FanoutSQSSource returns an SQS backed Source implementation on the copy of
+// the state changes dedicated to the timeline fan-out.
+func FanoutSQSSource(api platformSQS.API) (Source, error) {
+	return newSQSSource(api, platformSQS.FanoutQueue(queueName))
+}
+
+func newSQSSource(api platformSQS.API, name string) (Source, error) {
 	res, err := api.GetQueueUrl(&sqs.GetQueueUrlInput{
-		QueueName: aws.String(queueName),
+		QueueName: aws.String(name),
 	})
 	if err != nil {
 		return nil, err
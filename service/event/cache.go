@@ -65,6 +65,13 @@ func (s *cacheService) CreatedByDay(
 	return s.next.CreatedByDay(ns, start, end)
 }
 
+func (s *cacheService) ObjectCounts(
+	ns string,
+	opts QueryOptions,
+) (map[uint64]int, error) {
+	return s.next.ObjectCounts(ns, opts)
+}
+
 func (s *cacheService) Put(ns string, input *Event) (output *Event, err error) {
 	return s.next.Put(ns, input)
 }
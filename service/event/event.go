@@ -40,6 +40,9 @@ type Acker interface {
 // AggregateService for event interactions.
 type AggregateService interface {
 	ActiveUserIDs(string, Period) ([]uint64, error)
+	// ObjectCounts returns the number of events per object id. With a Limit
+	// only the objects with the most events are returned.
+	ObjectCounts(namespace string, opts QueryOptions) (map[uint64]int, error)
 }
 
 // Consumer observes state changes.
@@ -158,6 +158,67 @@ func testServiceCount(p prepareFunc, t *testing.T) {
 	}
 }
 
+func testServiceObjectCounts(p prepareFunc, t *testing.T) {
+	var (
+		namespace = "service_object_counts"
+		service   = p(namespace, t)
+		enabled   = true
+		likes     = map[uint64]int{
+			1: 3,
+			2: 1,
+			3: 2,
+		}
+	)
+
+	for id, n := range likes {
+		for i := 0; i < n; i++ {
+			_, err := service.Put(namespace, &Event{
+				Enabled:    enabled,
+				ObjectID:   id,
+				Type:       "tg_like",
+				UserID:     uint64(i + 1),
+				Visibility: VisibilityPublic,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	_, err := service.Put(namespace, &Event{
+		ObjectID:   2,
+		Type:       "tg_like",
+		UserID:     10,
+		Visibility: VisibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := service.ObjectCounts(namespace, QueryOptions{
+		Enabled: &enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := counts, likes; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	counts, err = service.ObjectCounts(namespace, QueryOptions{
+		Enabled: &enabled,
+		Limit:   2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := counts, map[uint64]int{1: 3, 3: 2}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func testEvent() *Event {
 	return &Event{
 		Enabled:    true,
@@ -73,6 +73,17 @@ func (s *instrumentService) CreatedByDay(
 	return s.next.CreatedByDay(ns, start, end)
 }
 
+func (s *instrumentService) ObjectCounts(
+	ns string,
+	opts QueryOptions,
+) (counts map[uint64]int, err error) {
+	defer func(begin time.Time) {
+		s.track("ObjectCounts", ns, begin, err)
+	}(time.Now())
+
+	return s.next.ObjectCounts(ns, opts)
+}
+
 func (s *instrumentService) Put(
 	ns string,
 	input *Event,
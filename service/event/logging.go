@@ -89,6 +89,29 @@ func (s *logService) CreatedByDay(
 	return s.next.CreatedByDay(ns, start, end)
 }
 
+func (s *logService) ObjectCounts(
+	ns string,
+	opts QueryOptions,
+) (counts map[uint64]int, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"datapoints", len(counts),
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "ObjectCounts",
+			"namespace", ns,
+			"opts", opts,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.ObjectCounts(ns, opts)
+}
+
 func (s *logService) Put(ns string, input *Event) (output *Event, err error) {
 	defer func(begin time.Time) {
 		ps := []interface{}{
@@ -3,6 +3,7 @@ package event
 import (
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/tapglue/multiverse/platform/flake"
@@ -71,6 +72,44 @@ func (s *memService) CreatedByDay(
 	return ts, nil
 }
 
+func (s *memService) ObjectCounts(
+	ns string,
+	opts QueryOptions,
+) (map[uint64]int, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	opts.Limit = 0
+
+	counts := map[uint64]int{}
+
+	for _, e := range filterList(s.events[ns], opts) {
+		counts[e.ObjectID]++
+	}
+
+	if limit <= 0 || len(counts) <= limit {
+		return counts, nil
+	}
+
+	ids := []uint64{}
+
+	for id := range counts {
+		ids = append(ids, id)
+	}
+
+	sort.Sort(&countedList{counts: counts, ids: ids})
+
+	top := map[uint64]int{}
+
+	for _, id := range ids[:limit] {
+		top[id] = counts[id]
+	}
+
+	return top, nil
+}
+
 func (s *memService) Put(ns string, event *Event) (*Event, error) {
 	if err := s.Setup(ns); err != nil {
 		return nil, err
@@ -147,6 +186,10 @@ func filterList(em Map, opts QueryOptions) List {
 	es := List{}
 
 	for id, event := range em {
+		if !opts.After.IsZero() && event.CreatedAt.UTC().Before(opts.After.UTC()) {
+			continue
+		}
+
 		if !opts.Before.IsZero() && event.CreatedAt.UTC().After(opts.Before.UTC()) {
 			continue
 		}
@@ -277,3 +320,31 @@ func inVisibilities(visibility Visibility, vs []Visibility) bool {
 
 	return keep
 }
+
+// countedList orders object ids by their event count, ids with equal counts
+// are ordered ascending.
+type countedList struct {
+	counts map[uint64]int
+	ids    []uint64
+}
+
+func (l *countedList) Len() int {
+	return len(l.ids)
+}
+
+func (l *countedList) Less(i, j int) bool {
+	var (
+		a = l.ids[i]
+		b = l.ids[j]
+	)
+
+	if l.counts[a] != l.counts[b] {
+		return l.counts[a] > l.counts[b]
+	}
+
+	return a < b
+}
+
+func (l *countedList) Swap(i, j int) {
+	l.ids[i], l.ids[j] = l.ids[j], l.ids[i]
+}
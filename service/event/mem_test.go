@@ -35,6 +35,10 @@ func TestMemCreatedByDay(t *testing.T) {
 	}
 }
 
+func TestMemObjectCounts(t *testing.T) {
+	testServiceObjectCounts(prepareMem, t)
+}
+
 func TestMemPut(t *testing.T) {
 	testServicePut(prepareMem, t)
 }
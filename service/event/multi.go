@@ -0,0 +1,31 @@
+package event
+
+type multiProducer struct {
+	producers []Producer
+}
+
+// MultiProducer returns a Producer which propagates every state change to all
+// given producers, to deliver a copy to each of their consumers.
+func MultiProducer(producers ...Producer) Producer {
+	return &multiProducer{producers: producers}
+}
+
+func (p *multiProducer) Propagate(
+	ns string,
+	old, new *Event,
+) (string, error) {
+	var id string
+
+	for _, producer := range p.producers {
+		pid, err := producer.Propagate(ns, old, new)
+		if err != nil {
+			return "", err
+		}
+
+		if id == "" {
+			id = pid
+		}
+	}
+
+	return id, nil
+}
@@ -11,6 +11,11 @@ import (
 	"github.com/tapglue/multiverse/platform/pg"
 )
 
+const (
+	orderNone ordering = iota
+	orderCreatedAt
+)
+
 const (
 	pgInsertEvent = `INSERT INTO %s.events(json_data) VALUES($1)`
 	pgUpdateEvent = `UPDATE %s.events SET json_data = $1
@@ -46,11 +51,18 @@ const (
         %s
     GROUP BY userid
     ORDER BY COUNT DESC`
+	pgObjectCounts = `SELECT (json_data->>'object_id')::BIGINT AS object_id, count(*) AS count
+		FROM %s.events
+		%s
+		GROUP BY object_id
+		ORDER BY count DESC, object_id ASC`
+
 	pgClauseByDay   = `(json_data ->> 'updated_at')::DATE > current_date - interval '1 day'`
 	pgClauseByWeek  = `(json_data ->> 'updated_at')::DATE > current_date - interval '1 week'`
 	pgClauseByMonth = `(json_data ->> 'updated_at')::DATE > current_date - interval '1 month'`
 
-	pgOrderCreatedAt = `ORDER BY (json_data->>'created_at') DESC`
+	pgOrderCreatedAt    = `ORDER BY (json_data->>'created_at') DESC`
+	pgOrderCreatedAtAsc = `ORDER BY (json_data->>'created_at') ASC`
 
 	pgCreatedByDay = `SELECT count(*), to_date(json_data->>'created_at', 'YYYY-MM-DD') as bucket
 		FROM %s.events
@@ -66,6 +78,8 @@ const (
 	pgDropTable = `DROP TABLE IF EXISTS %s.events`
 )
 
+type ordering int
+
 type pgService struct {
 	db *sqlx.DB
 }
@@ -122,7 +136,7 @@ func (s *pgService) ActiveUserIDs(
 }
 
 func (s *pgService) Count(ns string, opts QueryOptions) (int, error) {
-	where, params, err := convertOpts(opts)
+	where, params, err := convertOpts(opts, orderNone)
 	if err != nil {
 		return 0, err
 	}
@@ -174,6 +188,64 @@ func (s *pgService) CreatedByDay(
 	return ts, nil
 }
 
+func (s *pgService) ObjectCounts(
+	ns string,
+	opts QueryOptions,
+) (map[uint64]int, error) {
+	limit := opts.Limit
+	opts.Limit = 0
+
+	where, params, err := convertOpts(opts, orderNone)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(pgObjectCounts, ns, where)
+
+	if limit > 0 {
+		query = fmt.Sprintf("%s\nLIMIT %d", query, limit)
+	}
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			if err := s.Setup(ns); err != nil {
+				return nil, err
+			}
+
+			rows, err = s.db.Query(query, params...)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	counts := map[uint64]int{}
+
+	for rows.Next() {
+		var (
+			id    uint64
+			count int
+		)
+
+		err := rows.Scan(&id, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[id] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
 func (s *pgService) Put(ns string, event *Event) (*Event, error) {
 	var (
 		now   = time.Now().UTC()
@@ -243,7 +315,7 @@ func (s *pgService) Put(ns string, event *Event) (*Event, error) {
 }
 
 func (s *pgService) Query(ns string, opts QueryOptions) (List, error) {
-	where, params, err := convertOpts(opts)
+	where, params, err := convertOpts(opts, orderCreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -355,7 +427,7 @@ func (s *pgService) listEvents(
 	return es, nil
 }
 
-func convertOpts(opts QueryOptions) (string, []interface{}, error) {
+func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, error) {
 	var (
 		clauses = []string{}
 		params  = []interface{}{}
@@ -541,7 +613,11 @@ func convertOpts(opts QueryOptions) (string, []interface{}, error) {
 		query = sqlx.Rebind(sqlx.DOLLAR, pg.ClausesToWhere(clauses...))
 	}
 
-	if !opts.Before.IsZero() {
+	// Paging forward needs the events closest to the cursor first, otherwise the
+	// limit would cut out the ones directly after it.
+	if !opts.After.IsZero() && order == orderCreatedAt {
+		query = fmt.Sprintf("%s\n%s", query, pgOrderCreatedAtAsc)
+	} else if !opts.Before.IsZero() && order == orderCreatedAt {
 		query = fmt.Sprintf("%s\n%s", query, pgOrderCreatedAt)
 	}
 
@@ -112,6 +112,13 @@ func TestPostgresCount(t *testing.T) {
 	}, t)
 }
 
+func TestPostgresObjectCounts(t *testing.T) {
+	testServiceObjectCounts(func(ns string, t *testing.T) Service {
+		s, _ := preparePostgres(ns, t)
+		return s
+	}, t)
+}
+
 func TestPostgresPut(t *testing.T) {
 	testServicePut(func(ns string, t *testing.T) Service {
 		s, _ := preparePostgres(ns, t)
@@ -37,6 +37,13 @@ func (s *sourcingService) CreatedByDay(
 	return s.service.CreatedByDay(ns, start, end)
 }
 
+func (s *sourcingService) ObjectCounts(
+	ns string,
+	opts QueryOptions,
+) (map[uint64]int, error) {
+	return s.service.ObjectCounts(ns, opts)
+}
+
 func (s *sourcingService) Put(ns string, input *Event) (new *Event, err error) {
 	var old *Event
 
@@ -0,0 +1,270 @@
+package notification
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+type prepareFunc func(t *testing.T, namespace string) Service
+
+func testServiceCount(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_count"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+	)
+
+	for i := 0; i < 3; i++ {
+		n := testNotification(userID)
+		n.Read = i == 0
+
+		_, err := service.Put(namespace, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := service.Count(namespace, QueryOptions{
+		Read: &defaultUnread,
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceMarkRead(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_mark_read"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+		other     = testNotification(uint64(rand.Int63()))
+		ids       = []uint64{}
+	)
+
+	for i := 0; i < 3; i++ {
+		n, err := service.Put(namespace, testNotification(userID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, n.ID)
+	}
+
+	_, err := service.Put(namespace, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.MarkRead(namespace, QueryOptions{
+		IDs: ids[:1],
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unread := map[uint64]int{userID: 2, other.UserID: 1}
+
+	for id, want := range unread {
+		count, err := service.Count(namespace, QueryOptions{
+			Read: &defaultUnread,
+			UserIDs: []uint64{
+				id,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have := count; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	err = service.MarkRead(namespace, QueryOptions{
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := service.Count(namespace, QueryOptions{
+		Read: &defaultUnread,
+		UserIDs: []uint64{
+			userID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServicePut(t *testing.T, p prepareFunc) {
+	var (
+		namespace    = "service_put"
+		service      = p(t, namespace)
+		notification = testNotification(uint64(rand.Int63()))
+	)
+
+	created, err := service.Put(namespace, notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.ID == 0 {
+		t.Fatal("want ID to be set")
+	}
+
+	created.Read = true
+
+	updated, err := service.Put(namespace, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := updated.CreatedAt, created.CreatedAt; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ns, err := service.Query(namespace, QueryOptions{
+		IDs: []uint64{
+			created.ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ns), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := ns[0].Read, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	dup := testNotification(notification.UserID)
+	dup.OriginID = notification.OriginID
+
+	redelivered, err := service.Put(namespace, dup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := redelivered.ID, created.ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	count, err := service.Count(namespace, QueryOptions{
+		UserIDs: []uint64{
+			notification.UserID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := count, 1; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = service.Put(namespace, &Notification{})
+	if have, want := err, ErrInvalidNotification; !IsInvalidNotification(have) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceQuery(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_query"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+		start     = time.Now().UTC().Add(-time.Hour)
+		ns        = List{}
+	)
+
+	for i := 0; i < 5; i++ {
+		n := testNotification(userID)
+		n.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+
+		n, err := service.Put(namespace, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ns = append(ns, n)
+	}
+
+	_, err := service.Put(namespace, testNotification(uint64(rand.Int63())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[*QueryOptions][]uint64{
+		&QueryOptions{
+			UserIDs: []uint64{
+				userID,
+			},
+		}: []uint64{ns[4].ID, ns[3].ID, ns[2].ID, ns[1].ID, ns[0].ID},
+		&QueryOptions{
+			Before: ns[3].CreatedAt,
+			Limit:  2,
+			UserIDs: []uint64{
+				userID,
+			},
+		}: []uint64{ns[2].ID, ns[1].ID},
+		&QueryOptions{
+			After: ns[1].CreatedAt,
+			Limit: 2,
+			UserIDs: []uint64{
+				userID,
+			},
+		}: []uint64{ns[3].ID, ns[2].ID},
+	}
+
+	for opts, want := range cases {
+		list, err := service.Query(namespace, *opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have, want := len(list), len(want); have != want {
+			t.Fatalf("have %v, want %v", have, want)
+		}
+
+		for i, n := range list {
+			if have, want := n.ID, want[i]; have != want {
+				t.Errorf("have %v, want %v", have, want)
+			}
+		}
+	}
+}
+
+var defaultUnread = false
+
+func testNotification(userID uint64) *Notification {
+	return &Notification{
+		Message:  "Alice liked your Post.",
+		ObjectID: uint64(rand.Int63()),
+		OriginID: uint64(rand.Int63()),
+		Type:     "like_post_own",
+		URN:      "tapglue/posts/1",
+		UserID:   userID,
+	}
+}
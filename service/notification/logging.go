@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+type logService struct {
+	logger log.Logger
+	next   Service
+}
+
+// LogServiceMiddleware given a Logger wraps the next Service with logging capabilities.
+func LogServiceMiddleware(logger log.Logger, store string) ServiceMiddleware {
+	return func(next Service) Service {
+		logger = log.NewContext(logger).With(
+			"service", "notification",
+			"store", store,
+		)
+
+		return &logService{logger: logger, next: next}
+	}
+}
+
+func (s *logService) Count(ns string, opts QueryOptions) (count int, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"count", count,
+			"notification_opts", opts,
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Count",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Count(ns, opts)
+}
+
+func (s *logService) MarkRead(ns string, opts QueryOptions) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"notification_opts", opts,
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "MarkRead",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.MarkRead(ns, opts)
+}
+
+func (s *logService) Put(ns string, input *Notification) (output *Notification, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"notification_input", input,
+			"notification_output", output,
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Put",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Put(ns, input)
+}
+
+func (s *logService) Query(ns string, opts QueryOptions) (list List, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"notification_len", len(list),
+			"notification_opts", opts,
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Query",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Query(ns, opts)
+}
+
+func (s *logService) Setup(ns string) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Setup",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Setup(ns)
+}
+
+func (s *logService) Teardown(ns string) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Teardown",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Teardown(ns)
+}
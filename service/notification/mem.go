@@ -0,0 +1,191 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/tapglue/multiverse/platform/flake"
+)
+
+type memService struct {
+	notifications map[string]map[uint64]*Notification
+}
+
+// NewMemService returns a memory based Service implementation.
+func NewMemService() Service {
+	return &memService{
+		notifications: map[string]map[uint64]*Notification{},
+	}
+}
+
+func (s *memService) Count(ns string, opts QueryOptions) (int, error) {
+	if err := s.Setup(ns); err != nil {
+		return 0, err
+	}
+
+	opts.Limit = 0
+
+	return len(filterList(s.notifications[ns], opts)), nil
+}
+
+func (s *memService) MarkRead(ns string, opts QueryOptions) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	var (
+		bucket = s.notifications[ns]
+		now    = time.Now().UTC()
+	)
+
+	opts.Limit = 0
+
+	for _, n := range filterList(bucket, opts) {
+		if n.Read {
+			continue
+		}
+
+		n.Read = true
+		n.UpdatedAt = now
+
+		bucket[n.ID] = n
+	}
+
+	return nil
+}
+
+func (s *memService) Put(ns string, n *Notification) (*Notification, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		bucket = s.notifications[ns]
+		now    = time.Now().UTC()
+	)
+
+	if n.ID == 0 {
+		for _, old := range bucket {
+			if old.UserID == n.UserID &&
+				old.OriginID == n.OriginID &&
+				old.Type == n.Type &&
+				old.URN == n.URN {
+				return copy(old), nil
+			}
+		}
+
+		id, err := flake.NextID(flakeNamespace(ns))
+		if err != nil {
+			return nil, err
+		}
+
+		n.ID = id
+
+		if n.CreatedAt.IsZero() {
+			n.CreatedAt = now
+		}
+	} else {
+		old, ok := bucket[n.ID]
+		if !ok {
+			return nil, ErrNotFound
+		}
+
+		n.CreatedAt = old.CreatedAt
+	}
+
+	n.UpdatedAt = now
+
+	bucket[n.ID] = copy(n)
+
+	return copy(n), nil
+}
+
+func (s *memService) Query(ns string, opts QueryOptions) (List, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	return filterList(s.notifications[ns], opts), nil
+}
+
+func (s *memService) Setup(ns string) error {
+	if _, ok := s.notifications[ns]; !ok {
+		s.notifications[ns] = map[uint64]*Notification{}
+	}
+
+	return nil
+}
+
+func (s *memService) Teardown(ns string) error {
+	if _, ok := s.notifications[ns]; ok {
+		delete(s.notifications, ns)
+	}
+
+	return nil
+}
+
+func copy(n *Notification) *Notification {
+	old := *n
+	return &old
+}
+
+func filterList(bucket map[uint64]*Notification, opts QueryOptions) List {
+	ns := List{}
+
+	for _, n := range bucket {
+		if !opts.After.IsZero() && !n.CreatedAt.After(opts.After) {
+			continue
+		}
+
+		if !opts.Before.IsZero() && !n.CreatedAt.Before(opts.Before) {
+			continue
+		}
+
+		if !inIDs(n.ID, opts.IDs) {
+			continue
+		}
+
+		if opts.Read != nil && n.Read != *opts.Read {
+			continue
+		}
+
+		if !inIDs(n.UserID, opts.UserIDs) {
+			continue
+		}
+
+		ns = append(ns, copy(n))
+	}
+
+	sortList(ns)
+
+	if opts.Limit > 0 && len(ns) > opts.Limit {
+		// Paging forward keeps the notifications closest to the cursor.
+		if !opts.After.IsZero() {
+			return ns[len(ns)-opts.Limit:]
+		}
+
+		return ns[:opts.Limit]
+	}
+
+	return ns
+}
+
+func inIDs(id uint64, ids []uint64) bool {
+	if len(ids) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, i := range ids {
+		if id == i {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
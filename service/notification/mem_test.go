@@ -0,0 +1,29 @@
+package notification
+
+import "testing"
+
+func TestMemCount(t *testing.T) {
+	testServiceCount(t, prepareMem)
+}
+
+func TestMemMarkRead(t *testing.T) {
+	testServiceMarkRead(t, prepareMem)
+}
+
+func TestMemPut(t *testing.T) {
+	testServicePut(t, prepareMem)
+}
+
+func TestMemQuery(t *testing.T) {
+	testServiceQuery(t, prepareMem)
+}
+
+func prepareMem(t *testing.T, namespace string) Service {
+	s := NewMemService()
+
+	if err := s.Teardown(namespace); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/service"
+)
+
+// List is a collection of notifications.
+type List []*Notification
+
+func (l List) Len() int {
+	return len(l)
+}
+
+func (l List) Less(i, j int) bool {
+	return l[i].CreatedAt.After(l[j].CreatedAt)
+}
+
+func (l List) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// OriginIDs returns the ids of the users who caused the notifications.
+func (l List) OriginIDs() []uint64 {
+	var (
+		ids  = []uint64{}
+		seen = map[uint64]struct{}{}
+	)
+
+	for _, n := range l {
+		if _, ok := seen[n.OriginID]; ok {
+			continue
+		}
+
+		ids = append(ids, n.OriginID)
+		seen[n.OriginID] = struct{}{}
+	}
+
+	return ids
+}
+
+// Notification is the persisted record of a message addressed to a user.
+type Notification struct {
+	ID uint64
+	// Message is the text rendered in the default language.
+	Message   string
+	ObjectID  uint64
+	OriginID  uint64
+	Read      bool
+	Type      string
+	URN       string
+	UserID    uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate returns an error when a semantic check fails.
+func (n *Notification) Validate() error {
+	if n.OriginID == 0 {
+		return wrapError(ErrInvalidNotification, "OriginID must be set")
+	}
+
+	if n.Type == "" {
+		return wrapError(ErrInvalidNotification, "Type must be set")
+	}
+
+	if n.URN == "" {
+		return wrapError(ErrInvalidNotification, "URN must be set")
+	}
+
+	if n.UserID == 0 {
+		return wrapError(ErrInvalidNotification, "UserID must be set")
+	}
+
+	return nil
+}
+
+// QueryOptions is used to narrow-down notification queries.
+type QueryOptions struct {
+	After   time.Time
+	Before  time.Time
+	IDs     []uint64
+	Limit   int
+	Read    *bool
+	UserIDs []uint64
+}
+
+// Service for notification interactions.
+type Service interface {
+	service.Lifecycle
+
+	Count(namespace string, opts QueryOptions) (int, error)
+	// MarkRead flags all notifications matching the opts as read.
+	MarkRead(namespace string, opts QueryOptions) error
+	Put(namespace string, notification *Notification) (*Notification, error)
+	Query(namespace string, opts QueryOptions) (List, error)
+}
+
+// ServiceMiddleware is a chainable behaviour modifier for Service.
+type ServiceMiddleware func(Service) Service
+
+func flakeNamespace(ns string) string {
+	return fmt.Sprintf("%s_%s", ns, "notifications")
+}
+
+// sortList orders the notifications newest first.
+func sortList(l List) {
+	sort.Sort(l)
+}
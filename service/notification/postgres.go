@@ -0,0 +1,470 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/tapglue/multiverse/platform/flake"
+	"github.com/tapglue/multiverse/platform/pg"
+)
+
+const (
+	pgInsertNotification = `INSERT INTO
+		%s.notifications(id, message, object_id, origin_id, read, type, urn, user_id, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, origin_id, type, urn) DO UPDATE SET
+			urn = EXCLUDED.urn
+		RETURNING
+			id, message, object_id, read, created_at, updated_at`
+	pgUpdateNotification = `
+		UPDATE
+			%s.notifications
+		SET
+			read = $2,
+			updated_at = $3
+		WHERE
+			id = $1
+		RETURNING
+			created_at`
+	pgMarkRead = `
+		UPDATE
+			%s.notifications
+		SET
+			read = true,
+			updated_at = ?
+		WHERE
+			read = false
+			%s`
+
+	pgCountNotifications = `SELECT count(*) FROM %s.notifications %s`
+	pgListNotifications  = `
+		SELECT
+			id, message, object_id, origin_id, read, type, urn, user_id, created_at, updated_at
+		FROM
+			%s.notifications
+		%s`
+
+	pgClauseAfter   = `created_at > ?`
+	pgClauseBefore  = `created_at < ?`
+	pgClauseIDs     = `id IN (?)`
+	pgClauseRead    = `read = ?`
+	pgClauseUserIDs = `user_id IN (?)`
+
+	pgOrderCreatedAt    = `ORDER BY created_at DESC`
+	pgOrderCreatedAtAsc = `ORDER BY created_at ASC`
+
+	pgIndexID  = `CREATE INDEX %s ON %s.notifications (id)`
+	pgIndexKey = `
+		CREATE UNIQUE INDEX
+			%s
+		ON
+			%s.notifications (user_id, origin_id, type, urn)`
+	pgIndexUnread = `
+		CREATE INDEX
+			%s
+		ON
+			%s.notifications (user_id)
+		WHERE
+			read = false`
+	pgIndexUserIDCreatedAt = `
+		CREATE INDEX
+			%s
+		ON
+			%s.notifications (user_id, created_at)`
+
+	pgCreateSchema = `CREATE SCHEMA IF NOT EXISTS %s`
+	pgCreateTable  = `CREATE TABLE IF NOT EXISTS %s.notifications (
+		id BIGINT NOT NULL,
+		message TEXT NOT NULL,
+		object_id BIGINT NOT NULL,
+		origin_id BIGINT NOT NULL,
+		read BOOL DEFAULT false,
+		type TEXT NOT NULL,
+		urn TEXT NOT NULL,
+		user_id BIGINT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`
+	pgDropTable = `DROP TABLE IF EXISTS %s.notifications`
+)
+
+type pgService struct {
+	db *sqlx.DB
+}
+
+// PostgresService returns a Postgres based Service implementation.
+func PostgresService(db *sqlx.DB) Service {
+	return &pgService{
+		db: db,
+	}
+}
+
+func (s *pgService) Count(ns string, opts QueryOptions) (int, error) {
+	clauses, params, err := convertOpts(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := s.countNotifications(ns, clauses, params...)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return 0, err
+		}
+
+		count, err = s.countNotifications(ns, clauses, params...)
+	}
+
+	return count, err
+}
+
+func (s *pgService) MarkRead(ns string, opts QueryOptions) error {
+	opts.Read = nil
+
+	clauses, params, err := convertOpts(opts)
+	if err != nil {
+		return err
+	}
+
+	now, err := time.Parse(pg.TimeFormat, time.Now().UTC().Format(pg.TimeFormat))
+	if err != nil {
+		return err
+	}
+
+	c := ""
+
+	if len(clauses) > 0 {
+		c = fmt.Sprintf("AND %s", strings.Join(clauses, "\nAND "))
+	}
+
+	query := sqlx.Rebind(sqlx.DOLLAR, fmt.Sprintf(pgMarkRead, ns, c))
+	params = append([]interface{}{now}, params...)
+
+	_, err = s.db.Exec(query, params...)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return err
+		}
+
+		_, err = s.db.Exec(query, params...)
+	}
+
+	return err
+}
+
+func (s *pgService) Put(ns string, n *Notification) (*Notification, error) {
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+
+	now, err := time.Parse(pg.TimeFormat, time.Now().UTC().Format(pg.TimeFormat))
+	if err != nil {
+		return nil, err
+	}
+
+	n.UpdatedAt = now
+
+	if n.ID == 0 {
+		err = s.insert(ns, n)
+	} else {
+		err = s.update(ns, n)
+	}
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return nil, err
+		}
+
+		if n.ID == 0 {
+			err = s.insert(ns, n)
+		} else {
+			err = s.update(ns, n)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (s *pgService) Query(ns string, opts QueryOptions) (List, error) {
+	clauses, params, err := convertOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := s.listNotifications(ns, opts, clauses, params...)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return nil, err
+		}
+
+		l, err = s.listNotifications(ns, opts, clauses, params...)
+	}
+
+	return l, err
+}
+
+func (s *pgService) Setup(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgCreateSchema, ns),
+		fmt.Sprintf(pgCreateTable, ns),
+		pg.GuardIndex(ns, "notification_id", pgIndexID),
+		pg.GuardIndex(ns, "notification_key", pgIndexKey),
+		pg.GuardIndex(ns, "notification_unread", pgIndexUnread),
+		pg.GuardIndex(ns, "notification_user_id_created_at", pgIndexUserIDCreatedAt),
+	}
+
+	for _, q := range qs {
+		_, err := s.db.Exec(q)
+		if err != nil {
+			return fmt.Errorf("setup (%s): %s", q, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) Teardown(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgDropTable, ns),
+	}
+
+	for _, q := range qs {
+		_, err := s.db.Exec(q)
+		if err != nil {
+			return fmt.Errorf("teardown (%s): %s", q, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) countNotifications(
+	ns string,
+	clauses []string,
+	params ...interface{},
+) (int, error) {
+	query := sqlx.Rebind(
+		sqlx.DOLLAR,
+		fmt.Sprintf(pgCountNotifications, ns, where(clauses)),
+	)
+
+	count := 0
+
+	err := s.db.Get(&count, query, params...)
+
+	return count, err
+}
+
+func (s *pgService) insert(ns string, n *Notification) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = n.UpdatedAt
+	}
+
+	ts, err := time.Parse(pg.TimeFormat, n.CreatedAt.UTC().Format(pg.TimeFormat))
+	if err != nil {
+		return err
+	}
+
+	n.CreatedAt = ts
+
+	id, err := flake.NextID(flakeNamespace(ns))
+	if err != nil {
+		return err
+	}
+
+	// Redelivered state changes resolve to the same key, in which case the
+	// existing notification is returned instead of a duplicate.
+	err = s.db.QueryRow(
+		fmt.Sprintf(pgInsertNotification, ns),
+		id,
+		n.Message,
+		n.ObjectID,
+		n.OriginID,
+		n.Read,
+		n.Type,
+		n.URN,
+		n.UserID,
+		n.CreatedAt,
+		n.UpdatedAt,
+	).Scan(
+		&n.ID,
+		&n.Message,
+		&n.ObjectID,
+		&n.Read,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	n.CreatedAt = n.CreatedAt.UTC()
+	n.UpdatedAt = n.UpdatedAt.UTC()
+
+	return nil
+}
+
+func (s *pgService) listNotifications(
+	ns string,
+	opts QueryOptions,
+	clauses []string,
+	params ...interface{},
+) (List, error) {
+	order := pgOrderCreatedAt
+
+	// Paging forward needs the notifications closest to the cursor first,
+	// otherwise the limit would cut out the ones directly after it.
+	if !opts.After.IsZero() {
+		order = pgOrderCreatedAtAsc
+	}
+
+	qs := []string{
+		fmt.Sprintf(pgListNotifications, ns, where(clauses)),
+		order,
+	}
+
+	if opts.Limit > 0 {
+		qs = append(qs, fmt.Sprintf("LIMIT %d", opts.Limit))
+	}
+
+	query := sqlx.Rebind(sqlx.DOLLAR, strings.Join(qs, "\n"))
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l := List{}
+
+	for rows.Next() {
+		n := &Notification{}
+
+		err := rows.Scan(
+			&n.ID,
+			&n.Message,
+			&n.ObjectID,
+			&n.OriginID,
+			&n.Read,
+			&n.Type,
+			&n.URN,
+			&n.UserID,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		n.CreatedAt = n.CreatedAt.UTC()
+		n.UpdatedAt = n.UpdatedAt.UTC()
+
+		l = append(l, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortList(l)
+
+	return l, nil
+}
+
+// update only persists the read state, all other fields are immutable.
+func (s *pgService) update(ns string, n *Notification) error {
+	rows, err := s.db.Query(
+		fmt.Sprintf(pgUpdateNotification, ns),
+		n.ID,
+		n.Read,
+		n.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return ErrNotFound
+	}
+
+	if err := rows.Scan(&n.CreatedAt); err != nil {
+		return err
+	}
+
+	n.CreatedAt = n.CreatedAt.UTC()
+
+	return rows.Err()
+}
+
+func convertOpts(opts QueryOptions) ([]string, []interface{}, error) {
+	var (
+		clauses = []string{}
+		params  = []interface{}{}
+	)
+
+	if !opts.After.IsZero() {
+		clauses = append(clauses, pgClauseAfter)
+		params = append(params, opts.After.UTC().Format(pg.TimeFormat))
+	}
+
+	if !opts.Before.IsZero() {
+		clauses = append(clauses, pgClauseBefore)
+		params = append(params, opts.Before.UTC().Format(pg.TimeFormat))
+	}
+
+	if len(opts.IDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.IDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseIDs, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	if opts.Read != nil {
+		clauses = append(clauses, pgClauseRead)
+		params = append(params, *opts.Read)
+	}
+
+	if len(opts.UserIDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.UserIDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseUserIDs, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	return clauses, params, nil
+}
+
+func where(clauses []string) string {
+	if len(clauses) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("WHERE %s", strings.Join(clauses, "\nAND "))
+}
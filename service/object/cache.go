@@ -71,10 +71,24 @@ func (s *cacheService) Remove(ns string, id uint64) (err error) {
 	return s.next.Remove(ns, id)
 }
 
+func (s *cacheService) Search(
+	ns, lang, query string,
+	opts QueryOptions,
+) (List, error) {
+	return s.next.Search(ns, lang, query, opts)
+}
+
 func (s *cacheService) Setup(ns string) (err error) {
 	return s.next.Setup(ns)
 }
 
+func (s *cacheService) TagCounts(
+	ns, prefix string,
+	opts QueryOptions,
+) (ts TagCounts, err error) {
+	return s.next.TagCounts(ns, prefix, opts)
+}
+
 func (s *cacheService) Teardown(ns string) (err error) {
 	return s.next.Teardown(ns)
 }
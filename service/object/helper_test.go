@@ -1,6 +1,7 @@
 package object
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -81,6 +82,7 @@ func testCreateSet(objectID uint64, start time.Time) []*Object {
 		set = append(set, &Object{
 			OwnerID:    2,
 			ObjectID:   objectID,
+			ParentID:   objectID,
 			Type:       "comment",
 			Visibility: VisibilityGlobal,
 		})
@@ -282,14 +284,22 @@ func testServiceCount(t *testing.T, p prepareFunc) {
 func testServiceQuery(t *testing.T, p prepareFunc) {
 	var (
 		namespace  = "service_query"
-		service    = p(namespace, t)
-		testObject = *testArticle
-		owned      = true
-		notOwned   = false
-		start      = time.Now()
+		service   = p(namespace, t)
+		owned     = true
+		notOwned  = false
+		start     = time.Now()
+		moderated = &Object{
+			OwnerID: testArticle.OwnerID,
+			Private: &Private{
+				State:   StatePending,
+				Visible: true,
+			},
+			Type:       testArticle.Type,
+			Visibility: testArticle.Visibility,
+		}
 	)
 
-	article, err := service.Put(namespace, &testObject)
+	article, err := service.Put(namespace, moderated)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -308,10 +318,14 @@ func testServiceQuery(t *testing.T, p prepareFunc) {
 		&QueryOptions{Owned: &owned}:                                                                             20,
 		&QueryOptions{Owned: &owned, Types: []string{"tg_comment"}}:                                              20,
 		&QueryOptions{OwnerIDs: []uint64{1}}:                                                                     10,
+		&QueryOptions{ParentIDs: []uint64{article.ID}}:                                                          5,
+		&QueryOptions{OwnerIDs: []uint64{2}, ParentIDs: []uint64{0}}:                                            0,
 		&QueryOptions{Tags: []string{"one"}}:                                                                     3,
 		&QueryOptions{Tags: []string{"one", "two"}}:                                                              3,
 		&QueryOptions{Tags: []string{"one", "three"}}:                                                            3,
 		&QueryOptions{Visibilities: []Visibility{VisibilityPublic, VisibilityGlobal}}:                            11,
+		&QueryOptions{States: []State{StatePending}}:                                                             1,
+		&QueryOptions{States: []State{StateConfirmed, StateDeclined}}:                                            0,
 		&QueryOptions{ExternalIDs: []string{"external-input-123"}, Owned: &owned, Types: []string{"tg_comment"}}: 7,
 	}
 
@@ -326,3 +340,310 @@ func testServiceQuery(t *testing.T, p prepareFunc) {
 		}
 	}
 }
+
+func testServiceQueryGeo(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_query_geo"
+		service   = p(namespace, t)
+		os        = List{
+			{Latitude: 52.5219, Longitude: 13.4132, Location: "Alexanderplatz"},
+			{Latitude: 52.5163, Longitude: 13.3777, Location: "Brandenburger Tor"},
+			{Latitude: 53.5511, Longitude: 9.9937, Location: "Hamburg"},
+			{Latitude: -17.7134, Longitude: 179.9, Location: "Fiji"},
+			{Latitude: -17.7134, Longitude: -179.9, Location: "Fiji"},
+		}
+	)
+
+	for i, o := range os {
+		o.OwnerID = 123
+		o.Type = "checkin"
+		o.Visibility = VisibilityPublic
+
+		created, err := service.Put(namespace, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		os[i] = created
+	}
+
+	radius := &Radius{
+		Distance:  5000,
+		Latitude:  52.5200,
+		Longitude: 13.4050,
+	}
+
+	cases := map[*QueryOptions]int{
+		&QueryOptions{Radius: radius}: 2,
+		&QueryOptions{BoundingBox: &BoundingBox{
+			East:  15,
+			North: 55,
+			South: 47,
+			West:  5,
+		}}: 3,
+		&QueryOptions{BoundingBox: &BoundingBox{
+			East:  -179,
+			North: -15,
+			South: -20,
+			West:  179,
+		}}: 2,
+	}
+
+	for opts, want := range cases {
+		os, err := service.Query(namespace, *opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have := len(os); have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	rs, err := service.Query(namespace, QueryOptions{
+		Limit:         1,
+		Offset:        1,
+		OrderDistance: true,
+		Radius:        radius,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(rs), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := rs[0].ID, os[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceSearch(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_search"
+		service   = p(namespace, t)
+		os        = List{
+			{
+				Attachments: []Attachment{
+					NewTextAttachment("body", Contents{
+						"de": "Kuchen",
+						"en": "Cupcake cupcake chocolate",
+					}),
+				},
+				OwnerID:    123,
+				Type:       "post",
+				Visibility: VisibilityPublic,
+			},
+			{
+				Attachments: []Attachment{
+					NewTextAttachment("body", Contents{
+						"en": "Cupcake muffin",
+					}),
+				},
+				OwnerID:    123,
+				Type:       "post",
+				Visibility: VisibilityPublic,
+			},
+			{
+				Attachments: []Attachment{
+					NewURLAttachment("teaser", Contents{
+						"en": "http://cupcake.example",
+					}),
+				},
+				OwnerID:    123,
+				Type:       "post",
+				Visibility: VisibilityPublic,
+			},
+			{
+				Attachments: []Attachment{
+					NewTextAttachment("body", Contents{
+						"de": "Cupcake",
+					}),
+				},
+				OwnerID:    123,
+				Type:       "post",
+				Visibility: VisibilityPublic,
+			},
+			{
+				Attachments: []Attachment{
+					NewTextAttachment("body", Contents{
+						"en": "Cupcake",
+					}),
+				},
+				OwnerID:    123,
+				Type:       "post",
+				Visibility: VisibilityPrivate,
+			},
+		}
+		opts = QueryOptions{
+			Visibilities: []Visibility{
+				VisibilityPublic,
+			},
+		}
+	)
+
+	for i, o := range os {
+		created, err := service.Put(namespace, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		os[i] = created
+	}
+
+	rs, err := service.Search(namespace, "en", "cupcake", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(rs), 2; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := rs[0].ID, os[0].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := rs[1].ID, os[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	rs, err = service.Search(namespace, "en", "cupcake muffin", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(rs), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := rs[0].ID, os[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	rs, err = service.Search(namespace, "de", "kuchen", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(rs), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := rs[0].ID, os[0].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	opts.Limit = 1
+	opts.Offset = 1
+
+	rs, err = service.Search(namespace, "en", "cupcake", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(rs), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := rs[0].ID, os[1].ID; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceTagCounts(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_tag_counts"
+		service   = p(namespace, t)
+	)
+
+	for _, o := range testCreateSet(0, time.Now()) {
+		_, err := service.Put(namespace, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, o := range []*Object{testPost, testRecipe} {
+		object := *o
+
+		_, err := service.Put(namespace, &object)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts, err := service.TagCounts(namespace, "", QueryOptions{
+		Types: []string{"tagged"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := TagCounts{
+		{Count: 3, Tag: "one"},
+		{Count: 3, Tag: "three"},
+		{Count: 3, Tag: "two"},
+	}
+
+	if have := ts; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ts, err = service.TagCounts(namespace, "t", QueryOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = TagCounts{
+		{Count: 3, Tag: "three"},
+	}
+
+	if have := ts; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	_, err = service.Put(namespace, &Object{
+		OwnerID: 1,
+		Private: &Private{
+			State:   StatePending,
+			Visible: false,
+		},
+		Tags:       []string{"one"},
+		Type:       "tagged",
+		Visibility: VisibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hidden := false
+
+	ts, err = service.TagCounts(namespace, "one", QueryOptions{
+		Hidden: &hidden,
+		Types:  []string{"tagged"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = TagCounts{
+		{Count: 3, Tag: "one"},
+	}
+
+	if have := ts; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	os, err := service.Query(namespace, QueryOptions{
+		Tags: []string{"one", "guide"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(os), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
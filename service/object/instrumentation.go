@@ -82,6 +82,17 @@ func (s *instrumentService) Remove(ns string, id uint64) (err error) {
 	return s.next.Remove(ns, id)
 }
 
+func (s *instrumentService) Search(
+	ns, lang, query string,
+	opts QueryOptions,
+) (os List, err error) {
+	defer func(begin time.Time) {
+		s.track("Search", ns, begin, err)
+	}(time.Now())
+
+	return s.next.Search(ns, lang, query, opts)
+}
+
 func (s *instrumentService) Setup(ns string) (err error) {
 	defer func(begin time.Time) {
 		s.track("setup", ns, begin, err)
@@ -90,6 +101,17 @@ func (s *instrumentService) Setup(ns string) (err error) {
 	return s.next.Setup(ns)
 }
 
+func (s *instrumentService) TagCounts(
+	ns, prefix string,
+	opts QueryOptions,
+) (ts TagCounts, err error) {
+	defer func(begin time.Time) {
+		s.track("TagCounts", ns, begin, err)
+	}(time.Now())
+
+	return s.next.TagCounts(ns, prefix, opts)
+}
+
 func (s *instrumentService) Teardown(ns string) (err error) {
 	defer func(begin time.Time) {
 		s.track("teardown", ns, begin, err)
@@ -130,6 +130,31 @@ func (s *logService) Remove(ns string, id uint64) (err error) {
 	return s.next.Remove(ns, id)
 }
 
+func (s *logService) Search(
+	ns, lang, query string,
+	opts QueryOptions,
+) (os List, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"lang", lang,
+			"method", "Search",
+			"namespace", ns,
+			"opts", opts,
+			"query", query,
+			"size", len(os),
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Search(ns, lang, query, opts)
+}
+
 func (s *logService) Setup(ns string) (err error) {
 	defer func(begin time.Time) {
 		ps := []interface{}{
@@ -148,6 +173,30 @@ func (s *logService) Setup(ns string) (err error) {
 	return s.next.Setup(ns)
 }
 
+func (s *logService) TagCounts(
+	ns, prefix string,
+	opts QueryOptions,
+) (ts TagCounts, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "TagCounts",
+			"namespace", ns,
+			"opts", opts,
+			"prefix", prefix,
+			"tags", len(ts),
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.TagCounts(ns, prefix, opts)
+}
+
 func (s *logService) Teardown(ns string) (err error) {
 	defer func(begin time.Time) {
 		ps := []interface{}{
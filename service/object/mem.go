@@ -2,6 +2,8 @@ package object
 
 import (
 	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/tapglue/multiverse/platform/flake"
@@ -144,6 +146,61 @@ func (s *memService) Remove(namespace string, id uint64) error {
 	return nil
 }
 
+func (s *memService) Search(
+	ns, lang, query string,
+	opts QueryOptions,
+) (List, error) {
+	bucket, ok := s.objects[ns]
+	if !ok {
+		return nil, ErrNamespaceNotFound
+	}
+
+	var (
+		limit  = opts.Limit
+		now    = time.Now().UTC()
+		os     = List{}
+		scores = map[uint64]float64{}
+		terms  = searchWords(query)
+	)
+
+	if len(terms) == 0 {
+		return os, nil
+	}
+
+	offset := opts.Offset
+
+	opts.Limit = 0
+	opts.Offset = 0
+
+	for _, o := range filterMap(bucket, opts) {
+		relevance := searchRelevance(searchWords(searchText(o, lang)), terms)
+		if relevance == 0 {
+			continue
+		}
+
+		age := now.Sub(o.CreatedAt).Hours() / searchDecay.Hours()
+
+		scores[o.ID] = relevance / (1 + age)
+		os = append(os, o)
+	}
+
+	sort.Sort(&rankedList{objects: os, scores: scores})
+
+	if offset > 0 {
+		if offset >= len(os) {
+			return List{}, nil
+		}
+
+		os = os[offset:]
+	}
+
+	if limit > 0 && len(os) > limit {
+		os = os[:limit]
+	}
+
+	return os, nil
+}
+
 func (s *memService) Setup(namespace string) error {
 	if _, ok := s.objects[namespace]; !ok {
 		s.objects[namespace] = map[uint64]*Object{}
@@ -152,6 +209,43 @@ func (s *memService) Setup(namespace string) error {
 	return nil
 }
 
+func (s *memService) TagCounts(
+	ns, prefix string,
+	opts QueryOptions,
+) (TagCounts, error) {
+	bucket, ok := s.objects[ns]
+	if !ok {
+		return nil, ErrNamespaceNotFound
+	}
+
+	limit := opts.Limit
+	opts.Limit = 0
+
+	counts := map[string]int{}
+
+	for _, o := range filterMap(bucket, opts) {
+		for _, t := range o.Tags {
+			if strings.HasPrefix(t, prefix) {
+				counts[t]++
+			}
+		}
+	}
+
+	ts := TagCounts{}
+
+	for t, c := range counts {
+		ts = append(ts, &TagCount{Count: c, Tag: t})
+	}
+
+	sort.Sort(ts)
+
+	if limit > 0 && len(ts) > limit {
+		ts = ts[:limit]
+	}
+
+	return ts, nil
+}
+
 func (s *memService) Teardown(namespace string) error {
 	if _, ok := s.objects[namespace]; ok {
 		delete(s.objects, namespace)
@@ -165,6 +259,25 @@ func copy(o *Object) *Object {
 	return &old
 }
 
+func hasTags(tags, required []string) bool {
+	for _, r := range required {
+		keep := false
+
+		for _, t := range tags {
+			if t == r {
+				keep = true
+				break
+			}
+		}
+
+		if !keep {
+			return false
+		}
+	}
+
+	return true
+}
+
 func inIDs(id uint64, ids []uint64) bool {
 	if len(ids) == 0 {
 		return true
@@ -182,10 +295,38 @@ func inIDs(id uint64, ids []uint64) bool {
 	return keep
 }
 
+// distanceList orders objects by their distance to the centre of the radius.
+type distanceList struct {
+	objects List
+	radius  Radius
+}
+
+func (l *distanceList) Len() int {
+	return len(l.objects)
+}
+
+func (l *distanceList) Less(i, j int) bool {
+	var (
+		a = l.objects[i]
+		b = l.objects[j]
+	)
+
+	return l.radius.distance(a.Latitude, a.Longitude) <
+		l.radius.distance(b.Latitude, b.Longitude)
+}
+
+func (l *distanceList) Swap(i, j int) {
+	l.objects[i], l.objects[j] = l.objects[j], l.objects[i]
+}
+
 func filterMap(om Map, opts QueryOptions) List {
 	os := []*Object{}
 
 	for id, object := range om {
+		if !opts.After.IsZero() && object.CreatedAt.UTC().Before(opts.After.UTC()) {
+			continue
+		}
+
 		if !opts.Before.IsZero() && object.CreatedAt.UTC().After(opts.Before.UTC()) {
 			continue
 		}
@@ -194,6 +335,16 @@ func filterMap(om Map, opts QueryOptions) List {
 			continue
 		}
 
+		if opts.BoundingBox != nil &&
+			!opts.BoundingBox.contains(object.Latitude, object.Longitude) {
+			continue
+		}
+
+		if opts.Radius != nil &&
+			opts.Radius.distance(object.Latitude, object.Longitude) > opts.Radius.Distance {
+			continue
+		}
+
 		if opts.Owned != nil {
 			if object.Owned != *opts.Owned {
 				continue
@@ -208,6 +359,10 @@ func filterMap(om Map, opts QueryOptions) List {
 			continue
 		}
 
+		if !inIDs(id, opts.IDs) {
+			continue
+		}
+
 		if !inIDs(object.OwnerID, opts.OwnerIDs) {
 			continue
 		}
@@ -216,16 +371,24 @@ func filterMap(om Map, opts QueryOptions) List {
 			continue
 		}
 
-		if len(opts.Tags) > len(object.Tags) {
+		if !inIDs(object.ParentID, opts.ParentIDs) {
 			continue
 		}
 
-		for _, t := range opts.Tags {
-			if !inTypes(t, object.Tags) {
+		if opts.Hidden != nil && object.IsHidden() != *opts.Hidden {
+			continue
+		}
+
+		if len(opts.States) > 0 {
+			if object.Private == nil || !inStates(object.Private.State, opts.States) {
 				continue
 			}
 		}
 
+		if !hasTags(object.Tags, opts.Tags) {
+			continue
+		}
+
 		if !inTypes(object.Type, opts.Types) {
 			continue
 		}
@@ -241,6 +404,18 @@ func filterMap(om Map, opts QueryOptions) List {
 		return os
 	}
 
+	if opts.OrderDistance && opts.Radius != nil {
+		sort.Sort(&distanceList{objects: os, radius: *opts.Radius})
+	}
+
+	if opts.Offset > 0 {
+		if opts.Offset >= len(os) {
+			return []*Object{}
+		}
+
+		os = os[opts.Offset:]
+	}
+
 	if opts.Limit > 0 {
 		l := math.Min(float64(len(os)), float64(opts.Limit))
 
@@ -250,6 +425,58 @@ func filterMap(om Map, opts QueryOptions) List {
 	return os
 }
 
+// rankedList orders objects by their search score, objects with equal scores
+// are ordered by recency.
+type rankedList struct {
+	objects List
+	scores  map[uint64]float64
+}
+
+func (l *rankedList) Len() int {
+	return len(l.objects)
+}
+
+func (l *rankedList) Less(i, j int) bool {
+	var (
+		a = l.objects[i]
+		b = l.objects[j]
+	)
+
+	if l.scores[a.ID] != l.scores[b.ID] {
+		return l.scores[a.ID] > l.scores[b.ID]
+	}
+
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+func (l *rankedList) Swap(i, j int) {
+	l.objects[i], l.objects[j] = l.objects[j], l.objects[i]
+}
+
+// searchRelevance counts the words matching any of the terms as prefix, which
+// approximates the stemming of Postgres. All terms have to match, otherwise
+// the relevance is zero.
+func searchRelevance(words, terms []string) float64 {
+	count := 0
+
+	for _, t := range terms {
+		matched := false
+
+		for _, w := range words {
+			if strings.HasPrefix(w, t) {
+				count++
+				matched = true
+			}
+		}
+
+		if !matched {
+			return 0
+		}
+	}
+
+	return float64(count)
+}
+
 func inTypes(ty string, ts []string) bool {
 	if len(ts) == 0 {
 		return true
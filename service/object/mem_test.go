@@ -80,6 +80,10 @@ func TestMemServiceQuery(t *testing.T) {
 	testServiceQuery(t, prepareMem)
 }
 
+func TestMemServiceQueryGeo(t *testing.T) {
+	testServiceQueryGeo(t, prepareMem)
+}
+
 func TestMemServiceRemove(t *testing.T) {
 	var (
 		namespace = "service_remove"
@@ -119,6 +123,14 @@ func TestMemServiceRemove(t *testing.T) {
 	}
 }
 
+func TestMemServiceSearch(t *testing.T) {
+	testServiceSearch(t, prepareMem)
+}
+
+func TestMemServiceTagCounts(t *testing.T) {
+	testServiceTagCounts(t, prepareMem)
+}
+
 func prepareMem(namespace string, t *testing.T) Service {
 	s := NewMemService()
 
@@ -2,7 +2,10 @@ package object
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"time"
+	"unicode"
 
 	"golang.org/x/text/language"
 
@@ -20,6 +23,16 @@ const (
 // DefaultLanguage is used when no lang is provided for object content.
 const DefaultLanguage = "en"
 
+const (
+	// earthRadius is the mean radius of the earth in meters.
+	earthRadius = 6371008.8
+	// maxAttachments is the upper bound of attachments per Object.
+	maxAttachments = 5
+	// searchDecay is the age after which the relevance of a search result is
+	// halved.
+	searchDecay = 7 * 24 * time.Hour
+)
+
 // State variants available for Objects.
 const (
 	StatePending State = iota
@@ -40,6 +53,11 @@ type Acker interface {
 	Ack(id string) error
 }
 
+// AggregateService for object aggregations.
+type AggregateService interface {
+	TagCounts(namespace, prefix string, opts QueryOptions) (TagCounts, error)
+}
+
 // Attachment is typed media which belongs to an Object.
 type Attachment struct {
 	Contents Contents `json:"contents"`
@@ -102,6 +120,52 @@ func NewURLAttachment(name string, contents Contents) Attachment {
 	}
 }
 
+// BoundingBox narrows down objects to the ones located in the box. A box with
+// West greater than East spans the antimeridian.
+type BoundingBox struct {
+	East  float64
+	North float64
+	South float64
+	West  float64
+}
+
+// Validate returns an error if a BoundingBox constraint is not full-filled.
+func (b BoundingBox) Validate() error {
+	if err := validateLatitude(b.North); err != nil {
+		return err
+	}
+
+	if err := validateLatitude(b.South); err != nil {
+		return err
+	}
+
+	if err := validateLongitude(b.East); err != nil {
+		return err
+	}
+
+	if err := validateLongitude(b.West); err != nil {
+		return err
+	}
+
+	if b.South > b.North {
+		return wrapError(ErrInvalidObject, "south must not exceed north")
+	}
+
+	return nil
+}
+
+func (b BoundingBox) contains(lat, lon float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+
+	if b.West > b.East {
+		return lon >= b.West || lon <= b.East
+	}
+
+	return lon >= b.West && lon <= b.East
+}
+
 // Consumer observes state changes.
 type Consumer interface {
 	Consume() (*StateChange, error)
@@ -118,6 +182,17 @@ func (c Contents) Validate() error {
 // List is an Object collection.
 type List []*Object
 
+// IDs returns the ID of every Object.
+func (os List) IDs() []uint64 {
+	ids := []uint64{}
+
+	for _, o := range os {
+		ids = append(ids, o.ID)
+	}
+
+	return ids
+}
+
 // OwnerIDs returns all user ids of the associated object owners.
 func (os List) OwnerIDs() []uint64 {
 	ids := []uint64{}
@@ -143,9 +218,11 @@ type Object struct {
 	Latitude     float64       `json:"latitude"`
 	Location     string        `json:"location"`
 	Longitude    float64       `json:"longitude"`
+	Mentions     []uint64      `json:"mentions,omitempty"`
 	ObjectID     uint64        `json:"object_id"`
 	Owned        bool          `json:"owned"`
 	OwnerID      uint64        `json:"owner_id"`
+	ParentID     uint64        `json:"parent_id,omitempty"`
 	Private      *Private      `json:"private,omitempty"`
 	Restrictions *Restrictions `json:"restrictions,omitempty"`
 	Tags         []string      `json:"tags"`
@@ -154,9 +231,14 @@ type Object struct {
 	Visibility   Visibility    `json:"visibility"`
 }
 
+// IsHidden reports if the object was hidden by moderation.
+func (o *Object) IsHidden() bool {
+	return o.Private != nil && !o.Private.Visible
+}
+
 // Validate returns an error if a constraint on the Object is not full-filled.
 func (o *Object) Validate() error {
-	if len(o.Attachments) > 5 {
+	if len(o.Attachments) > maxAttachments {
 		return wrapError(ErrInvalidObject, "too many attachments")
 	}
 
@@ -166,6 +248,14 @@ func (o *Object) Validate() error {
 		}
 	}
 
+	if err := validateLatitude(o.Latitude); err != nil {
+		return err
+	}
+
+	if err := validateLongitude(o.Longitude); err != nil {
+		return err
+	}
+
 	if o.OwnerID == 0 {
 		return wrapError(ErrInvalidObject, "missing owner")
 	}
@@ -214,19 +304,72 @@ type Producer interface {
 	Propagate(namespace string, old, new *Object) (string, error)
 }
 
-// QueryOptions are passed to narrow down query for objects.
+// QueryOptions are passed to narrow down query for objects. Offset skips the
+// given number of results for orderings which can't be paged by time, like the
+// relevance of a Search or the distance of OrderDistance. OrderDistance is
+// only honoured in combination with a Radius. ParentIDs containing 0 match the
+// objects without a parent. Hidden narrows down to objects hidden by
+// moderation or, set to false, excludes them.
 type QueryOptions struct {
-	Before       time.Time
-	Deleted      bool
-	ExternalIDs  []string
-	ID           *uint64
-	Limit        int
-	ObjectIDs    []uint64
-	OwnerIDs     []uint64
-	Owned        *bool
-	Tags         []string
-	Types        []string
-	Visibilities []Visibility
+	After         time.Time
+	Before        time.Time
+	BoundingBox   *BoundingBox
+	Deleted       bool
+	ExternalIDs   []string
+	Hidden        *bool
+	ID            *uint64
+	IDs           []uint64
+	Limit         int
+	ObjectIDs     []uint64
+	Offset        int
+	OrderDistance bool
+	OwnerIDs      []uint64
+	Owned         *bool
+	ParentIDs     []uint64
+	Radius        *Radius
+	States        []State
+	Tags          []string
+	Types         []string
+	Visibilities  []Visibility
+}
+
+// Radius narrows down objects to the ones located within Distance meters of
+// the centre.
+type Radius struct {
+	Distance  float64
+	Latitude  float64
+	Longitude float64
+}
+
+// Validate returns an error if a Radius constraint is not full-filled.
+func (r Radius) Validate() error {
+	if err := validateLatitude(r.Latitude); err != nil {
+		return err
+	}
+
+	if err := validateLongitude(r.Longitude); err != nil {
+		return err
+	}
+
+	if r.Distance <= 0 {
+		return wrapError(ErrInvalidObject, "distance must be positive")
+	}
+
+	return nil
+}
+
+// distance returns the great-circle distance in meters from the centre.
+func (r Radius) distance(lat, lon float64) float64 {
+	var (
+		lat1 = r.Latitude * math.Pi / 180
+		lat2 = lat * math.Pi / 180
+		dLat = lat2 - lat1
+		dLon = (lon - r.Longitude) * math.Pi / 180
+		h    = math.Pow(math.Sin(dLat/2), 2) +
+			math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
 }
 
 // Restrictions is the composite to regulate common interactions on Posts.
@@ -236,8 +379,15 @@ type Restrictions struct {
 	Report  bool `json:"report"`
 }
 
+// SearchService for full-text search over the text attachments of objects.
+type SearchService interface {
+	Search(namespace, lang, query string, opts QueryOptions) (List, error)
+}
+
 // Service for object interactions.
 type Service interface {
+	AggregateService
+	SearchService
 	metrics.BucketByDay
 	service.Lifecycle
 
@@ -273,9 +423,60 @@ type SourceMiddleware func(Source) Source
 // State reflects the progress of an object through a review process.
 type State uint8
 
+// TagCount is the number of occurrences of a tag.
+type TagCount struct {
+	Count int
+	Tag   string
+}
+
+// TagCounts is a TagCount collection.
+type TagCounts []*TagCount
+
+func (ts TagCounts) Len() int {
+	return len(ts)
+}
+
+// Less orders by count and falls back to the tag for stable results.
+func (ts TagCounts) Less(i, j int) bool {
+	if ts[i].Count == ts[j].Count {
+		return ts[i].Tag < ts[j].Tag
+	}
+
+	return ts[i].Count > ts[j].Count
+}
+
+func (ts TagCounts) Swap(i, j int) {
+	ts[i], ts[j] = ts[j], ts[i]
+}
+
 // Visibility determines the visibility of Objects when consumed.
 type Visibility uint8
 
+// searchText returns the contents of all text attachments in the given
+// language.
+func searchText(o *Object, lang string) string {
+	ts := []string{}
+
+	for _, a := range o.Attachments {
+		if a.Type != AttachmentTypeText {
+			continue
+		}
+
+		if c, ok := a.Contents[lang]; ok {
+			ts = append(ts, c)
+		}
+	}
+
+	return strings.Join(ts, " ")
+}
+
+// searchWords splits the text into lower-cased words.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
 func flakeNamespace(ns string) string {
 	return fmt.Sprintf("%s_%s", ns, "objects")
 }
@@ -307,3 +508,19 @@ func inVisibilities(c Visibility, vs []Visibility) bool {
 
 	return false
 }
+
+func validateLatitude(lat float64) error {
+	if lat < -90 || lat > 90 {
+		return wrapError(ErrInvalidObject, "latitude out of range: %f", lat)
+	}
+
+	return nil
+}
+
+func validateLongitude(lon float64) error {
+	if lon < -180 || lon > 180 {
+		return wrapError(ErrInvalidObject, "longitude out of range: %f", lon)
+	}
+
+	return nil
+}
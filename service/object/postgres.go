@@ -1,8 +1,11 @@
 package object
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,17 +33,52 @@ const (
 	pgListObjects = `SELECT json_data FROM %s.objects
 		%s`
 
-	pgClauseBefore     = `(json_data->>'created_at') < ?`
-	pgClauseDeleted    = `(json_data->>'deleted')::BOOL = ?::BOOL`
-	pgClauseExternalID = `(json_data->>'external_id')::TEXT IN (?)`
-	pgClauseID         = `(json_data->>'id')::BIGINT = ?::BIGINT`
-	pgClauseObjectID   = `(json_data->>'object_id')::BIGINT IN (?)`
-	pgClauseOwnerID    = `(json_data->>'owner_id')::BIGINT IN (?)`
-	pgClauseOwned      = `(json_data->>'owned')::BOOL = ?::BOOL`
-	pgClauseTags       = `(json_data->'tags')::JSONB @> '[%s]'`
-	pgClauseType       = `(json_data->>'type')::TEXT IN (?)`
-	pgClauseVisibility = `(json_data->>'visibility')::INT IN (?)`
-	pgOrderCreatedAt   = `ORDER BY json_data->>'created_at' DESC`
+	pgClauseAfter       = `(json_data->>'created_at') > ?`
+	pgClauseBefore      = `(json_data->>'created_at') < ?`
+	pgClauseBoundingBox = `(json_data->>'latitude')::FLOAT8 BETWEEN ? AND ?
+		AND (json_data->>'longitude')::FLOAT8 BETWEEN ? AND ?`
+	pgClauseBoundingBoxWrap = `(json_data->>'latitude')::FLOAT8 BETWEEN ? AND ?
+		AND ((json_data->>'longitude')::FLOAT8 >= ? OR (json_data->>'longitude')::FLOAT8 <= ?)`
+	pgClauseDeleted     = `(json_data->>'deleted')::BOOL = ?::BOOL`
+	pgClauseExternalID  = `(json_data->>'external_id')::TEXT IN (?)`
+	pgClauseHidden      = `COALESCE(NOT (json_data->'private'->>'visible')::BOOL, false) = ?::BOOL`
+	pgClauseID          = `(json_data->>'id')::BIGINT = ?::BIGINT`
+	pgClauseIDs         = `(json_data->>'id')::BIGINT IN (?)`
+	pgClauseObjectID    = `(json_data->>'object_id')::BIGINT IN (?)`
+	pgClauseOwnerID     = `(json_data->>'owner_id')::BIGINT IN (?)`
+	pgClauseOwned       = `(json_data->>'owned')::BOOL = ?::BOOL`
+	pgClauseParentID    = `COALESCE((json_data->>'parent_id')::BIGINT, 0) IN (?)`
+	pgClauseRadius      = `ST_DWithin(` + pgLocation + `, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)`
+	pgClauseState       = `(json_data->'private'->>'state')::INT IN (?)`
+	pgClauseTags        = `(json_data->'tags')::JSONB @> '[%s]'`
+	pgClauseType        = `(json_data->>'type')::TEXT IN (?)`
+	pgClauseVisibility  = `(json_data->>'visibility')::INT IN (?)`
+	pgOrderCreatedAt    = `ORDER BY json_data->>'created_at' DESC`
+	pgOrderCreatedAtAsc = `ORDER BY json_data->>'created_at' ASC`
+	pgOrderDistance     = `ORDER BY ST_Distance(` + pgLocation + `, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography) ASC,
+		json_data->>'created_at' DESC`
+
+	// pgLocation is the position of an object, it has to match the index
+	// expression exactly for the index to be used.
+	pgLocation = `ST_SetSRID(ST_MakePoint((json_data->>'longitude')::FLOAT8, (json_data->>'latitude')::FLOAT8), 4326)::geography`
+
+	pgClauseSearch   = `AND %s @@ plainto_tsquery('%s', $%d)`
+	pgOrderSearch    = `ORDER BY ts_rank(%s, plainto_tsquery('%s', $%d)) / (1 + extract(epoch FROM now() - (json_data->>'created_at')::TIMESTAMP) / %d) DESC`
+	pgSearchDocument = `CASE WHEN json_data->'attachments'->%d->>'type' = 'text' THEN coalesce(json_data->'attachments'->%d->'contents'->>'%s', '') ELSE '' END`
+	pgSearchObjects  = `SELECT json_data FROM %s.objects
+		%s
+		%s
+		%s`
+
+	pgTagCounts = `SELECT tag, count(*) AS count
+		FROM (
+			SELECT jsonb_array_elements_text(json_data->'tags') AS tag
+			FROM %s.objects
+			%s
+		) AS tags
+		WHERE tag LIKE $%d
+		GROUP BY tag
+		ORDER BY count DESC, tag ASC`
 
 	pgCreatedByDay = `SELECT count(*), to_date(json_data->>'created_at', 'YYYY-MM-DD') as bucket
 		FROM %s.objects
@@ -49,8 +87,9 @@ const (
 		GROUP BY bucket
 		ORDER BY bucket`
 
-	pgCreateSchema = `CREATE SCHEMA IF NOT EXISTS %s`
-	pgCreateTable  = `CREATE TABLE IF NOT EXISTS %s.objects
+	pgCreateExtension = `CREATE EXTENSION IF NOT EXISTS postgis`
+	pgCreateSchema    = `CREATE SCHEMA IF NOT EXISTS %s`
+	pgCreateTable     = `CREATE TABLE IF NOT EXISTS %s.objects
 		(json_data JSONB NOT NULL)`
 
 	pgCreateIndexCreatedAt = `CREATE INDEX %s ON %s.objects
@@ -59,12 +98,20 @@ const (
 		USING btree (((json_data->>'external_id')::TEXT))`
 	pgCreateIndexID = `CREATE INDEX %s ON %s.objects
 		USING btree (((json_data->>'id')::BIGINT))`
+	pgCreateIndexLatitude = `CREATE INDEX %s ON %s.objects
+		USING btree (((json_data->>'latitude')::FLOAT8))`
+	pgCreateIndexLocation = `CREATE INDEX %s ON %s.objects
+		USING gist ((` + pgLocation + `))`
 	pgCreateIndexObjectID = `CREATE INDEX %s ON %s.objects
 		USING btree (((json_data->>'object_id')::BIGINT))`
 	pgCreateIndexOwnerID = `CREATE INDEX %s ON %s.objects
 		USING btree (((json_data->>'owner_id')::BIGINT))`
 	pgCreateIndexOwned = `CREATE INDEX %s ON %s.objects
 		USING btree (((json_data->>'owned')::BOOL))`
+	pgCreateIndexParentID = `CREATE INDEX %s ON %s.objects
+		USING btree ((COALESCE((json_data->>'parent_id')::BIGINT, 0)))`
+	pgCreateIndexSearch = `CREATE INDEX %%s ON %%s.objects
+		USING gin (%s)`
 	pgCreateIndexTags = `CREATE INDEX %s ON %s.objects
 		USING gin ((json_data->'tags'))`
 	pgCreateIndexType = `CREATE INDEX %s ON %s.objects
@@ -77,6 +124,32 @@ const (
 
 type ordering int
 
+// likeEscaper guards LIKE patterns against wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// pgSearchConfigs maps languages to the text search configuration used to
+// stem their contents, other languages use the simple configuration.
+var pgSearchConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// pgSearchLang guards the language which is part of the search expression.
+var pgSearchLang = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]+)*$`)
+
 type pgService struct {
 	db *sqlx.DB
 }
@@ -251,21 +324,116 @@ func (s *pgService) Remove(ns string, id uint64) error {
 	return pg.WrapError(err)
 }
 
+func (s *pgService) Search(
+	ns, lang, query string,
+	opts QueryOptions,
+) (List, error) {
+	if !pgSearchLang.MatchString(lang) {
+		return nil, wrapError(ErrInvalidObject, "unsupported language '%s'", lang)
+	}
+
+	var (
+		limit  = opts.Limit
+		offset = opts.Offset
+	)
+
+	opts.Limit = 0
+	opts.Offset = 0
+
+	where, params, err := convertOpts(opts, orderNone)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		config   = pgSearchConfig(lang)
+		document = pgSearchVector(lang)
+		page     = ""
+	)
+
+	params = append(params, query)
+
+	if limit > 0 {
+		page = fmt.Sprintf("LIMIT %d", limit)
+	}
+
+	if offset > 0 {
+		page = fmt.Sprintf("%s OFFSET %d", page, offset)
+	}
+
+	q := fmt.Sprintf(
+		pgSearchObjects,
+		ns,
+		where,
+		fmt.Sprintf(pgClauseSearch, document, config, len(params)),
+		fmt.Sprintf(
+			"%s\n%s",
+			fmt.Sprintf(
+				pgOrderSearch,
+				document,
+				config,
+				len(params),
+				int(searchDecay.Seconds()),
+			),
+			page,
+		),
+	)
+
+	rows, err := s.db.Query(q, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			if err := s.Setup(ns); err != nil {
+				return nil, err
+			}
+
+			rows, err = s.db.Query(q, params...)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	return scanObjects(rows)
+}
+
 func (s *pgService) Setup(ns string) error {
 	qs := []string{
+		pgCreateExtension,
 		wrapNamespace(pgCreateSchema, ns),
 		wrapNamespace(pgCreateTable, ns),
 		pg.GuardIndex(ns, "object_created_at", pgCreateIndexCreatedAt),
 		pg.GuardIndex(ns, "object_external_id", pgCreateIndexExternalID),
 		pg.GuardIndex(ns, "object_id", pgCreateIndexID),
+		pg.GuardIndex(ns, "object_latitude", pgCreateIndexLatitude),
+		pg.GuardIndex(ns, "object_location", pgCreateIndexLocation),
 		pg.GuardIndex(ns, "object_object_id", pgCreateIndexObjectID),
 		pg.GuardIndex(ns, "object_owned", pgCreateIndexOwned),
 		pg.GuardIndex(ns, "object_owned_id", pgCreateIndexOwnerID),
+		pg.GuardIndex(ns, "object_parent_id", pgCreateIndexParentID),
 		pg.GuardIndex(ns, "object_tags", pgCreateIndexTags),
 		pg.GuardIndex(ns, "object_type", pgCreateIndexType),
 		pg.GuardIndex(ns, "object_visibility", pgCreateIndexVisibility),
 	}
 
+	langs := []string{}
+
+	for lang := range pgSearchConfigs {
+		langs = append(langs, lang)
+	}
+
+	sort.Strings(langs)
+
+	for _, lang := range langs {
+		qs = append(qs, pg.GuardIndex(
+			ns,
+			fmt.Sprintf("object_search_%s", lang),
+			fmt.Sprintf(pgCreateIndexSearch, pgSearchVector(lang)),
+		))
+	}
+
 	for _, query := range qs {
 		_, err := s.db.Exec(query)
 		if err != nil {
@@ -276,6 +444,63 @@ func (s *pgService) Setup(ns string) error {
 	return nil
 }
 
+func (s *pgService) TagCounts(
+	ns, prefix string,
+	opts QueryOptions,
+) (TagCounts, error) {
+	limit := opts.Limit
+	opts.Limit = 0
+
+	where, params, err := convertOpts(opts, orderNone)
+	if err != nil {
+		return nil, err
+	}
+
+	params = append(params, fmt.Sprintf("%s%%", likeEscaper.Replace(prefix)))
+
+	query := fmt.Sprintf(pgTagCounts, ns, where, len(params))
+
+	if limit > 0 {
+		query = fmt.Sprintf("%s\nLIMIT %d", query, limit)
+	}
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			if err := s.Setup(ns); err != nil {
+				return nil, err
+			}
+
+			rows, err = s.db.Query(query, params...)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	ts := TagCounts{}
+
+	for rows.Next() {
+		t := &TagCount{}
+
+		err := rows.Scan(&t.Tag, &t.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		ts = append(ts, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
 func (s *pgService) Teardown(namespace string) error {
 	qs := []string{
 		fmt.Sprintf(pgDropTable, namespace),
@@ -335,6 +560,10 @@ func (s *pgService) listObjects(
 	}
 	defer rows.Close()
 
+	return scanObjects(rows)
+}
+
+func scanObjects(rows *sql.Rows) (List, error) {
 	os := List{}
 
 	for rows.Next() {
@@ -374,11 +603,27 @@ func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, erro
 		}
 	)
 
+	if !opts.After.IsZero() {
+		clauses = append(clauses, pgClauseAfter)
+		params = append(params, opts.After.UTC().Format(time.RFC3339Nano))
+	}
+
 	if !opts.Before.IsZero() {
 		clauses = append(clauses, pgClauseBefore)
 		params = append(params, opts.Before.UTC().Format(time.RFC3339Nano))
 	}
 
+	if b := opts.BoundingBox; b != nil {
+		clause := pgClauseBoundingBox
+
+		if b.West > b.East {
+			clause = pgClauseBoundingBoxWrap
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, b.South, b.North, b.West, b.East)
+	}
+
 	if len(opts.ExternalIDs) > 0 {
 		ps := []interface{}{}
 
@@ -395,11 +640,32 @@ func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, erro
 		params = append(params, ps...)
 	}
 
+	if opts.Hidden != nil {
+		params = append(params, *opts.Hidden)
+		clauses = append(clauses, pgClauseHidden)
+	}
+
 	if opts.ID != nil {
 		params = append(params, *opts.ID)
 		clauses = append(clauses, pgClauseID)
 	}
 
+	if len(opts.IDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.IDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseIDs, ps)
+		if err != nil {
+			return "", nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
 	if len(opts.OwnerIDs) > 0 {
 		ps := []interface{}{}
 
@@ -442,6 +708,43 @@ func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, erro
 		params = append(params, *opts.Owned)
 	}
 
+	if len(opts.ParentIDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.ParentIDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseParentID, ps)
+		if err != nil {
+			return "", nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	if r := opts.Radius; r != nil {
+		clauses = append(clauses, pgClauseRadius)
+		params = append(params, r.Longitude, r.Latitude, r.Distance)
+	}
+
+	if len(opts.States) > 0 {
+		ps := []interface{}{}
+
+		for _, s := range opts.States {
+			ps = append(ps, s)
+		}
+
+		clause, _, err := sqlx.In(pgClauseState, ps)
+		if err != nil {
+			return "", nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
 	if len(opts.Tags) > 0 {
 		ts := []string{}
 
@@ -491,7 +794,18 @@ func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, erro
 		query = sqlx.Rebind(sqlx.DOLLAR, pg.ClausesToWhere(clauses...))
 	}
 
-	if !opts.Before.IsZero() && order == orderCreatedAt {
+	// Paging forward needs the objects closest to the cursor first, otherwise
+	// the limit would cut out the ones directly after it.
+	if opts.OrderDistance && opts.Radius != nil && order == orderCreatedAt {
+		params = append(params, opts.Radius.Longitude, opts.Radius.Latitude)
+		query = fmt.Sprintf(
+			"%s\n%s",
+			query,
+			fmt.Sprintf(pgOrderDistance, len(params)-1, len(params)),
+		)
+	} else if !opts.After.IsZero() && order == orderCreatedAt {
+		query = fmt.Sprintf("%s\n%s", query, pgOrderCreatedAtAsc)
+	} else if !opts.Before.IsZero() && order == orderCreatedAt {
 		query = fmt.Sprintf("%s\n%s", query, pgOrderCreatedAt)
 	}
 
@@ -499,9 +813,39 @@ func convertOpts(opts QueryOptions, order ordering) (string, []interface{}, erro
 		query = fmt.Sprintf("%s\nLIMIT %d", query, opts.Limit)
 	}
 
+	if opts.Offset > 0 {
+		query = fmt.Sprintf("%s\nOFFSET %d", query, opts.Offset)
+	}
+
 	return query, params, nil
 }
 
+// pgSearchConfig returns the text search configuration for the language.
+func pgSearchConfig(lang string) string {
+	if config, ok := pgSearchConfigs[strings.ToLower(strings.SplitN(lang, "-", 2)[0])]; ok {
+		return config
+	}
+
+	return "simple"
+}
+
+// pgSearchVector returns the tsvector expression over the contents of the text
+// attachments in the given language. It has to match the index expression
+// exactly for the index to be used.
+func pgSearchVector(lang string) string {
+	ds := []string{}
+
+	for i := 0; i < maxAttachments; i++ {
+		ds = append(ds, fmt.Sprintf(pgSearchDocument, i, i, lang))
+	}
+
+	return fmt.Sprintf(
+		"to_tsvector('%s'::regconfig, %s)",
+		pgSearchConfig(lang),
+		strings.Join(ds, " || ' ' || "),
+	)
+}
+
 func wrapNamespace(query, namespace string) string {
 	return fmt.Sprintf(query, namespace)
 }
@@ -85,6 +85,10 @@ func TestPostgresServiceQuery(t *testing.T) {
 	testServiceQuery(t, preparePostgres)
 }
 
+func TestPostgresServiceQueryGeo(t *testing.T) {
+	testServiceQueryGeo(t, preparePostgres)
+}
+
 func TestPostgresServiceRemove(t *testing.T) {
 	var (
 		namespace = "service_remove"
@@ -119,6 +123,14 @@ func TestPostgresServiceRemove(t *testing.T) {
 	}
 }
 
+func TestPostgresServiceSearch(t *testing.T) {
+	testServiceSearch(t, preparePostgres)
+}
+
+func TestPostgresServiceTagCounts(t *testing.T) {
+	testServiceTagCounts(t, preparePostgres)
+}
+
 func preparePostgres(namespace string, t *testing.T) Service {
 	db, err := sqlx.Connect("postgres", pgURL)
 	if err != nil {
@@ -69,10 +69,24 @@ func (s *sourcingService) Remove(ns string, id uint64) error {
 	return s.service.Remove(ns, id)
 }
 
+func (s *sourcingService) Search(
+	ns, lang, query string,
+	opts QueryOptions,
+) (List, error) {
+	return s.service.Search(ns, lang, query, opts)
+}
+
 func (s *sourcingService) Setup(ns string) error {
 	return s.service.Setup(ns)
 }
 
+func (s *sourcingService) TagCounts(
+	ns, prefix string,
+	opts QueryOptions,
+) (TagCounts, error) {
+	return s.service.TagCounts(ns, prefix, opts)
+}
+
 func (s *sourcingService) Teardown(ns string) error {
 	return s.service.Teardown(ns)
 }
@@ -0,0 +1,48 @@
+package preference
+
+import (
+	"errors"
+	"fmt"
+)
+
+const errFmt = "%s: %s"
+
+// Common errors for Preference service implementations and validations.
+var (
+	ErrInvalidPreference = errors.New("invalid preference")
+)
+
+// Error wraps common Preference errors.
+type Error struct {
+	err error
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// IsInvalidPreference indicates if err is ErrInvalidPreference.
+func IsInvalidPreference(err error) bool {
+	return unwrapError(err) == ErrInvalidPreference
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.err
+	}
+
+	return err
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	return &Error{
+		err: err,
+		msg: fmt.Sprintf(
+			errFmt,
+			err,
+			fmt.Sprintf(format, args...),
+		),
+	}
+}
@@ -0,0 +1,113 @@
+package preference
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type prepareFunc func(t *testing.T, namespace string) Service
+
+func testServicePut(t *testing.T, p prepareFunc) {
+	var (
+		namespace  = "service_put"
+		service    = p(t, namespace)
+		preference = testPreference()
+	)
+
+	created, err := service.Put(namespace, preference)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created.Enabled = true
+
+	updated, err := service.Put(namespace, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := updated.CreatedAt, created.CreatedAt; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ps, err := service.Query(namespace, QueryOptions{
+		UserIDs: []uint64{
+			preference.UserID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ps), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := ps[0].Enabled, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceQuery(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_query"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+	)
+
+	for _, ty := range Types {
+		_, err := service.Put(namespace, &Preference{
+			Type:   ty,
+			UserID: userID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := service.Put(namespace, testPreference())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[*QueryOptions]int{
+		&QueryOptions{}: len(Types) + 1,
+		&QueryOptions{
+			Types: []Type{
+				TypeLike,
+			},
+		}: 2,
+		&QueryOptions{
+			UserIDs: []uint64{
+				userID,
+			},
+		}: len(Types),
+		&QueryOptions{
+			Types: []Type{
+				TypeFollow,
+			},
+			UserIDs: []uint64{
+				userID,
+			},
+		}: 1,
+	}
+
+	for opts, want := range cases {
+		ps, err := service.Query(namespace, *opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have := len(ps); have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
+
+func testPreference() *Preference {
+	return &Preference{
+		Enabled: false,
+		Type:    TypeLike,
+		UserID:  uint64(rand.Int63()),
+	}
+}
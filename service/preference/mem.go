@@ -0,0 +1,126 @@
+package preference
+
+import (
+	"fmt"
+	"time"
+)
+
+type memService struct {
+	preferences map[string]map[string]*Preference
+}
+
+// NewMemService returns a memory based Service implementation.
+func NewMemService() Service {
+	return &memService{
+		preferences: map[string]map[string]*Preference{},
+	}
+}
+
+func (s *memService) Put(ns string, p *Preference) (*Preference, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		bucket = s.preferences[ns]
+		key    = memKey(p.UserID, p.Type)
+		now    = time.Now().UTC()
+	)
+
+	if old, ok := bucket[key]; ok {
+		p.CreatedAt = old.CreatedAt
+	} else {
+		p.CreatedAt = now
+	}
+
+	p.UpdatedAt = now
+
+	bucket[key] = copy(p)
+
+	return copy(p), nil
+}
+
+func (s *memService) Query(ns string, opts QueryOptions) (List, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	ps := List{}
+
+	for _, p := range s.preferences[ns] {
+		if !inTypes(p.Type, opts.Types) {
+			continue
+		}
+
+		if !inIDs(p.UserID, opts.UserIDs) {
+			continue
+		}
+
+		ps = append(ps, copy(p))
+	}
+
+	return ps, nil
+}
+
+func (s *memService) Setup(ns string) error {
+	if _, ok := s.preferences[ns]; !ok {
+		s.preferences[ns] = map[string]*Preference{}
+	}
+
+	return nil
+}
+
+func (s *memService) Teardown(ns string) error {
+	if _, ok := s.preferences[ns]; ok {
+		delete(s.preferences, ns)
+	}
+
+	return nil
+}
+
+func copy(p *Preference) *Preference {
+	old := *p
+	return &old
+}
+
+func inIDs(id uint64, ids []uint64) bool {
+	if len(ids) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, i := range ids {
+		if id == i {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
+
+func inTypes(ty Type, ts []Type) bool {
+	if len(ts) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, t := range ts {
+		if ty == t {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
+
+func memKey(userID uint64, t Type) string {
+	return fmt.Sprintf("%d:%s", userID, t)
+}
@@ -0,0 +1,282 @@
+package preference
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/tapglue/multiverse/platform/pg"
+)
+
+const (
+	pgInsertPreference = `INSERT INTO
+		%s.preferences(enabled, type, user_id, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5)`
+	pgUpdatePreference = `
+		UPDATE
+			%s.preferences
+		SET
+			enabled = $1,
+			updated_at = $4
+		WHERE
+			type = $2
+			AND user_id = $3
+		RETURNING
+			created_at`
+
+	pgListPreferences = `
+		SELECT
+			enabled, type, user_id, created_at, updated_at
+		FROM
+			%s.preferences
+		%s`
+
+	pgClauseTypes   = `type IN (?)`
+	pgClauseUserIDs = `user_id IN (?)`
+
+	pgOrderCreatedAt = `ORDER BY created_at DESC`
+
+	pgIndexUserIDType = `
+		CREATE UNIQUE INDEX
+			%s
+		ON
+			%s.preferences (user_id, type)`
+
+	pgCreateSchema = `CREATE SCHEMA IF NOT EXISTS %s`
+	pgCreateTable  = `CREATE TABLE IF NOT EXISTS %s.preferences (
+		enabled BOOL DEFAULT true,
+		type TEXT NOT NULL,
+		user_id BIGINT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`
+	pgDropTable = `DROP TABLE IF EXISTS %s.preferences`
+)
+
+type pgService struct {
+	db *sqlx.DB
+}
+
+// PostgresService returns a Postgres based Service implementation.
+func PostgresService(db *sqlx.DB) Service {
+	return &pgService{
+		db: db,
+	}
+}
+
+func (s *pgService) Put(ns string, p *Preference) (*Preference, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	now, err := time.Parse(pg.TimeFormat, time.Now().UTC().Format(pg.TimeFormat))
+	if err != nil {
+		return nil, err
+	}
+
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	err = s.put(ns, p)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return nil, err
+		}
+
+		err = s.put(ns, p)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *pgService) Query(ns string, opts QueryOptions) (List, error) {
+	clauses, params, err := convertOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ps, err := s.listPreferences(ns, clauses, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			if err := s.Setup(ns); err != nil {
+				return nil, err
+			}
+		}
+
+		ps, err = s.listPreferences(ns, clauses, params...)
+	}
+
+	return ps, err
+}
+
+func (s *pgService) Setup(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgCreateSchema, ns),
+		fmt.Sprintf(pgCreateTable, ns),
+		pg.GuardIndex(ns, "preference_user_id_type", pgIndexUserIDType),
+	}
+
+	for _, q := range qs {
+		_, err := s.db.Exec(q)
+		if err != nil {
+			return fmt.Errorf("setup (%s): %s", q, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) Teardown(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgDropTable, ns),
+	}
+
+	for _, q := range qs {
+		_, err := s.db.Exec(q)
+		if err != nil {
+			return fmt.Errorf("teardown (%s): %s", q, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) listPreferences(
+	ns string,
+	clauses []string,
+	params ...interface{},
+) (List, error) {
+	c := strings.Join(clauses, "\nAND ")
+
+	if len(clauses) > 0 {
+		c = fmt.Sprintf("WHERE %s", c)
+	}
+
+	query := strings.Join([]string{
+		fmt.Sprintf(pgListPreferences, ns, c),
+		pgOrderCreatedAt,
+	}, "\n")
+
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps := List{}
+
+	for rows.Next() {
+		p := &Preference{}
+
+		err := rows.Scan(
+			&p.Enabled,
+			&p.Type,
+			&p.UserID,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		p.CreatedAt = p.CreatedAt.UTC()
+		p.UpdatedAt = p.UpdatedAt.UTC()
+
+		ps = append(ps, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// put updates the preference in place and only inserts it if there was none
+// stored for the user and type yet.
+func (s *pgService) put(ns string, p *Preference) error {
+	rows, err := s.db.Query(
+		fmt.Sprintf(pgUpdatePreference, ns),
+		p.Enabled,
+		string(p.Type),
+		p.UserID,
+		p.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&p.CreatedAt); err != nil {
+			return err
+		}
+
+		p.CreatedAt = p.CreatedAt.UTC()
+
+		return rows.Err()
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(
+		fmt.Sprintf(pgInsertPreference, ns),
+		p.Enabled,
+		string(p.Type),
+		p.UserID,
+		p.CreatedAt,
+		p.UpdatedAt,
+	)
+
+	return err
+}
+
+func convertOpts(opts QueryOptions) ([]string, []interface{}, error) {
+	var (
+		clauses = []string{}
+		params  = []interface{}{}
+	)
+
+	if len(opts.Types) > 0 {
+		ps := []interface{}{}
+
+		for _, t := range opts.Types {
+			ps = append(ps, string(t))
+		}
+
+		clause, _, err := sqlx.In(pgClauseTypes, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	if len(opts.UserIDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.UserIDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseUserIDs, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	return clauses, params, nil
+}
@@ -0,0 +1,98 @@
+package preference
+
+import (
+	"time"
+
+	"github.com/tapglue/multiverse/platform/service"
+)
+
+// Notification types users can opt out of.
+const (
+	TypeComment         Type = "comment"
+	TypeFollow          Type = "follow"
+	TypeFriendConfirmed Type = "friend_confirmed"
+	TypeFriendRequest   Type = "friend_request"
+	TypeLike            Type = "like"
+	TypeMention         Type = "mention"
+	TypePostCreated     Type = "post_created"
+	TypeReply           Type = "reply"
+)
+
+// Types is the list of all supported notification types.
+var Types = []Type{
+	TypeComment,
+	TypeFollow,
+	TypeFriendConfirmed,
+	TypeFriendRequest,
+	TypeLike,
+	TypeMention,
+	TypePostCreated,
+	TypeReply,
+}
+
+// List is a collection of preferences.
+type List []*Preference
+
+// Enabled reports if notifications of the given type are enabled for the user.
+// Types without a stored preference are enabled by default.
+func (l List) Enabled(userID uint64, t Type) bool {
+	for _, p := range l {
+		if p.UserID == userID && p.Type == t {
+			return p.Enabled
+		}
+	}
+
+	return true
+}
+
+// Preference controls if a user receives notifications of a type.
+type Preference struct {
+	Enabled   bool
+	Type      Type
+	UserID    uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate returns an error when a semantic check fails.
+func (p *Preference) Validate() error {
+	if !isSupported(p.Type) {
+		return wrapError(ErrInvalidPreference, "Type '%s' not supported", p.Type)
+	}
+
+	if p.UserID == 0 {
+		return wrapError(ErrInvalidPreference, "UserID must be set")
+	}
+
+	return nil
+}
+
+// QueryOptions is used to narrow-down preference queries.
+type QueryOptions struct {
+	Types   []Type
+	UserIDs []uint64
+}
+
+// Service for preference interactions.
+type Service interface {
+	service.Lifecycle
+
+	Put(namespace string, preference *Preference) (*Preference, error)
+	Query(namespace string, opts QueryOptions) (List, error)
+}
+
+// ServiceMiddleware is a chainable behaviour modifier for Service.
+type ServiceMiddleware func(Service) Service
+
+// Type of notification.
+type Type string
+
+func isSupported(t Type) bool {
+	for _, ty := range Types {
+		if t == ty {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package preference
+
+import "testing"
+
+func TestListEnabled(t *testing.T) {
+	var (
+		userID = uint64(123)
+		l      = List{
+			{
+				Enabled: false,
+				Type:    TypeLike,
+				UserID:  userID,
+			},
+			{
+				Enabled: true,
+				Type:    TypeFollow,
+				UserID:  userID,
+			},
+		}
+	)
+
+	cases := map[Type]bool{
+		TypeComment: true,
+		TypeFollow:  true,
+		TypeLike:    false,
+	}
+
+	for ty, want := range cases {
+		if have := l.Enabled(userID, ty); have != want {
+			t.Errorf("%s: have %v, want %v", ty, have, want)
+		}
+	}
+
+	if have, want := l.Enabled(userID+1, TypeLike), true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ps := List{
+		{},                 // Missing Type
+		{Type: "unknown"},  // Unsupported Type
+		{Type: TypeFollow}, // Missing UserID
+	}
+
+	for _, p := range ps {
+		if have, want := p.Validate(), ErrInvalidPreference; !IsInvalidPreference(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
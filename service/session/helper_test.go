@@ -4,12 +4,17 @@ import (
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tapglue/multiverse/platform/generate"
 )
 
 type prepareFunc func(t *testing.T, namespace string) Service
 
+type prepareRevocationsFunc func(t *testing.T) Revocations
+
+type prepareTrackerFunc func(t *testing.T) Tracker
+
 func testList() List {
 	ss := List{}
 
@@ -20,6 +25,86 @@ func testList() List {
 	return ss
 }
 
+func testRevocations(t *testing.T, p prepareRevocationsFunc) {
+	var (
+		revocations = p(t)
+		id          = generate.RandomString(16)
+	)
+
+	ok, err := revocations.Contains("revocations", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Errorf("have %v, want %v", ok, false)
+	}
+
+	if err := revocations.Add("revocations", id, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = revocations.Contains("revocations", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	ok, err = revocations.Contains("other", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceDisable(t *testing.T, p prepareFunc) {
+	var (
+		enabled   = true
+		namespace = "service_disable"
+		service   = p(t, namespace)
+	)
+
+	created, err := service.Put(namespace, testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.Disable(namespace, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := service.Query(namespace, QueryOptions{
+		Enabled: &enabled,
+		IDs: []string{
+			created.ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = service.Disable(namespace, created.ID)
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = service.Disable(namespace, "unknown")
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func testServicePut(t *testing.T, p prepareFunc) {
 	var (
 		enabled   = true
@@ -51,6 +136,55 @@ func testServicePut(t *testing.T, p prepareFunc) {
 	}
 }
 
+func testServicePutLastSeen(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_put_last_seen"
+		service   = p(t, namespace)
+	)
+
+	created, err := service.Put(namespace, testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		seen  = created.LastSeenAt.Add(time.Hour)
+		stale = created.LastSeenAt.Add(time.Minute)
+	)
+
+	err = service.PutLastSeen(namespace, map[string]time.Time{
+		created.ID: seen,
+		"unknown":  seen,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.PutLastSeen(namespace, map[string]time.Time{
+		created.ID: stale,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := service.Query(namespace, QueryOptions{
+		IDs: []string{
+			created.ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ss), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := ss[0].LastSeenAt, seen; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func testServiecQuery(t *testing.T, p prepareFunc) {
 	var (
 		enabled   = true
@@ -130,6 +264,50 @@ func testServiecQuery(t *testing.T, p prepareFunc) {
 	}
 }
 
+func testTracker(t *testing.T, p prepareTrackerFunc) {
+	var (
+		tracker = p(t)
+		first   = time.Now().Add(-time.Minute).UTC()
+		second  = time.Now().UTC()
+	)
+
+	prev, err := tracker.Touch("tracker", "session", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !prev.IsZero() {
+		t.Errorf("have %v, want zero time", prev)
+	}
+
+	prev, err = tracker.Touch("tracker", "session", second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := prev, first; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	seen, err := tracker.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := seen["tracker"]["session"], second; !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	seen, err = tracker.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(seen["tracker"]), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func testSession() *Session {
 	return &Session{
 		Enabled:  true,
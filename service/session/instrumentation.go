@@ -51,6 +51,25 @@ func (s *instrumentService) Put(
 	return s.next.Put(ns, input)
 }
 
+func (s *instrumentService) Disable(ns string, id string) (err error) {
+	defer func(begin time.Time) {
+		s.track("Disable", ns, begin, err)
+	}(time.Now())
+
+	return s.next.Disable(ns, id)
+}
+
+func (s *instrumentService) PutLastSeen(
+	ns string,
+	seen map[string]time.Time,
+) (err error) {
+	defer func(begin time.Time) {
+		s.track("PutLastSeen", ns, begin, err)
+	}(time.Now())
+
+	return s.next.PutLastSeen(ns, seen)
+}
+
 func (s *instrumentService) Query(
 	ns string,
 	opts QueryOptions,
@@ -46,6 +46,47 @@ func (s *logService) Put(
 	return s.next.Put(ns, input)
 }
 
+func (s *logService) Disable(ns string, id string) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Disable",
+			"namespace", ns,
+			"session_public_id", (&Session{ID: id}).PublicID(),
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Disable(ns, id)
+}
+
+func (s *logService) PutLastSeen(
+	ns string,
+	seen map[string]time.Time,
+) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "PutLastSeen",
+			"namespace", ns,
+			"seen_len", len(seen),
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.PutLastSeen(ns, seen)
+}
+
 func (s *logService) Query(ns string, opts QueryOptions) (list List, err error) {
 	defer func(begin time.Time) {
 		ps := []interface{}{
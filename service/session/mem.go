@@ -13,6 +13,21 @@ func NewMemService() Service {
 	}
 }
 
+func (s *memService) Disable(ns string, id string) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	session, ok := s.sessions[ns][id]
+	if !ok || !session.Enabled {
+		return ErrNotFound
+	}
+
+	session.Enabled = false
+
+	return nil
+}
+
 func (s *memService) Put(ns string, session *Session) (*Session, error) {
 	if err := s.Setup(ns); err != nil {
 		return nil, err
@@ -27,6 +42,7 @@ func (s *memService) Put(ns string, session *Session) (*Session, error) {
 	if session.ID == "" {
 		session.ID = generateID()
 		session.CreatedAt = time.Now().UTC()
+		session.LastSeenAt = session.CreatedAt
 	} else {
 		keep := false
 
@@ -34,6 +50,10 @@ func (s *memService) Put(ns string, session *Session) (*Session, error) {
 			if s.ID == session.ID {
 				keep = true
 				session.CreatedAt = s.CreatedAt
+
+				if session.LastSeenAt.Before(s.LastSeenAt) {
+					session.LastSeenAt = s.LastSeenAt
+				}
 			}
 		}
 
@@ -47,6 +67,25 @@ func (s *memService) Put(ns string, session *Session) (*Session, error) {
 	return copy(session), nil
 }
 
+func (s *memService) PutLastSeen(ns string, seen map[string]time.Time) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	bucket := s.sessions[ns]
+
+	for id, ts := range seen {
+		session, ok := bucket[id]
+		if !ok || !session.LastSeenAt.Before(ts) {
+			continue
+		}
+
+		session.LastSeenAt = ts.UTC()
+	}
+
+	return nil
+}
+
 func (s *memService) Query(ns string, opts QueryOptions) (List, error) {
 	if err := s.Setup(ns); err != nil {
 		return nil, err
@@ -2,14 +2,34 @@ package session
 
 import "testing"
 
+func TestMemDisable(t *testing.T) {
+	testServiceDisable(t, prepareMem)
+}
+
 func TestMemPut(t *testing.T) {
 	testServicePut(t, prepareMem)
 }
 
+func TestMemPutLastSeen(t *testing.T) {
+	testServicePutLastSeen(t, prepareMem)
+}
+
 func TestMemQuery(t *testing.T) {
 	testServiecQuery(t, prepareMem)
 }
 
+func TestMemRevocations(t *testing.T) {
+	testRevocations(t, func(t *testing.T) Revocations {
+		return NewMemRevocations()
+	})
+}
+
+func TestMemTracker(t *testing.T) {
+	testTracker(t, func(t *testing.T) Tracker {
+		return NewMemTracker()
+	})
+}
+
 func prepareMem(t *testing.T, ns string) Service {
 	s := NewMemService()
 
@@ -11,9 +11,17 @@ import (
 )
 
 const (
+	pgDisableSession = `
+		UPDATE
+			%s.sessions
+		SET
+			enabled = false
+		WHERE
+			session_id = $1 AND
+			enabled = true`
 	pgInsertSession = `INSERT INTO
-		%s.sessions(user_id, session_id, created_at, enabled, device_id)
-		VALUES($1, $2, $3, $4, $5)`
+		%s.sessions(user_id, session_id, created_at, enabled, device_id, last_seen_at)
+		VALUES($1, $2, $3, $4, $5, $6)`
 	pgUpdateSession = `
 		UPDATE
 			%s.sessions
@@ -22,6 +30,17 @@ const (
 		WHERE
 			user_id = $1 AND
 			session_id = $2`
+	pgUpdateLastSeen = `
+		UPDATE
+			%s.sessions AS s
+		SET
+			last_seen_at = v.last_seen_at
+		FROM
+			(VALUES %s) AS v(session_id, last_seen_at)
+		WHERE
+			s.session_id = v.session_id AND
+			(s.last_seen_at IS NULL OR s.last_seen_at < v.last_seen_at)`
+	pgValueLastSeen = `(?, ?::TIMESTAMP)`
 
 	pgClauseDeviceIDs = `device_id IN (?)`
 	pgClauseEnabled   = `enabled = ?`
@@ -32,7 +51,8 @@ const (
 
 	pgListSessions = `
 		SELECT
-			user_id, session_id, created_at, enabled, device_id
+			user_id, session_id, created_at, enabled, device_id,
+			COALESCE(last_seen_at, created_at)
 		FROM
 			%s.sessions
 		%s`
@@ -43,8 +63,11 @@ const (
 		session_id VARCHAR(40) NOT NULL,
 		created_at TIMESTAMP DEFAULT now() NOT NULL,
 		enabled BOOL DEFAULT TRUE NOT NULL,
-		device_id VARCHAR(255)
+		device_id VARCHAR(255),
+		last_seen_at TIMESTAMP DEFAULT now()
 	)`
+	pgAddLastSeen = `ALTER TABLE %s.sessions
+		ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP DEFAULT now()`
 	pgDropTable = `DROP TABLE IF EXISTS %s.sessions`
 
 	pgIndexDeviceIDUserID = `
@@ -79,6 +102,37 @@ func NewPostgresService(db *sqlx.DB) Service {
 	return &pgService{db: db}
 }
 
+func (s *pgService) Disable(ns string, id string) error {
+	query := fmt.Sprintf(pgDisableSession, ns)
+
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		if !pg.IsRelationNotFound(pg.WrapError(err)) {
+			return err
+		}
+
+		if err := s.Setup(ns); err != nil {
+			return err
+		}
+
+		res, err = s.db.Exec(query, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 func (s *pgService) Put(ns string, session *Session) (*Session, error) {
 	var (
 		params = []interface{}{
@@ -105,12 +159,14 @@ func (s *pgService) Put(ns string, session *Session) (*Session, error) {
 
 	if session.ID == "" {
 		session.ID = generateID()
+		session.LastSeenAt = session.CreatedAt
 		params = []interface{}{
 			session.UserID,
 			session.ID,
 			session.CreatedAt,
 			session.Enabled,
 			session.DeviceID,
+			session.LastSeenAt,
 		}
 		query = fmt.Sprintf(pgInsertSession, ns)
 	}
@@ -129,6 +185,36 @@ func (s *pgService) Put(ns string, session *Session) (*Session, error) {
 	return session, err
 }
 
+func (s *pgService) PutLastSeen(ns string, seen map[string]time.Time) error {
+	if len(seen) == 0 {
+		return nil
+	}
+
+	var (
+		params = []interface{}{}
+		values = []string{}
+	)
+
+	for id, ts := range seen {
+		params = append(params, id, ts.UTC())
+		values = append(values, pgValueLastSeen)
+	}
+
+	query := sqlx.Rebind(
+		sqlx.DOLLAR,
+		fmt.Sprintf(pgUpdateLastSeen, ns, strings.Join(values, ", ")),
+	)
+
+	_, err := s.db.Exec(query, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			return s.Setup(ns)
+		}
+	}
+
+	return err
+}
+
 func (s *pgService) Query(ns string, opts QueryOptions) (List, error) {
 	clauses, params, err := convertOpts(opts)
 	if err != nil {
@@ -142,6 +228,7 @@ func (s *pgService) Setup(ns string) error {
 	qs := []string{
 		fmt.Sprintf(pgCreateSchema, ns),
 		fmt.Sprintf(pgCreateTable, ns),
+		fmt.Sprintf(pgAddLastSeen, ns),
 		pg.GuardIndex(ns, "session_device_id_user_id", pgIndexDeviceIDUserID),
 		pg.GuardIndex(ns, "session_id", pgIndexID),
 		pg.GuardIndex(ns, "session_id_user_id", pgIndexIDUserID),
@@ -218,12 +305,14 @@ func (s *pgService) listSessions(
 			&s.CreatedAt,
 			&s.Enabled,
 			&s.DeviceID,
+			&s.LastSeenAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		s.CreatedAt = s.CreatedAt.UTC()
+		s.LastSeenAt = s.LastSeenAt.UTC()
 
 		ss = append(ss, s)
 	}
@@ -14,10 +14,18 @@ import (
 
 var pgTestURL string
 
+func TestPostgresDisable(t *testing.T) {
+	testServiceDisable(t, preparePostgres)
+}
+
 func TestPostgresPut(t *testing.T) {
 	testServicePut(t, preparePostgres)
 }
 
+func TestPostgresPutLastSeen(t *testing.T) {
+	testServicePutLastSeen(t, preparePostgres)
+}
+
 func TestPostgresQuery(t *testing.T) {
 	testServiecQuery(t, preparePostgres)
 }
@@ -0,0 +1,193 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const (
+	redisKeyPrefix        = "session.seen"
+	redisKeyPrefixRevoked = "session.revoked"
+	redisSeparator        = "."
+
+	redisArgumentEX = "EX"
+
+	redisCommandDEL      = "DEL"
+	redisCommandEXEC     = "EXEC"
+	redisCommandEXISTS   = "EXISTS"
+	redisCommandHGET     = "HGET"
+	redisCommandHGETALL  = "HGETALL"
+	redisCommandHSET     = "HSET"
+	redisCommandMULTI    = "MULTI"
+	redisCommandSADD     = "SADD"
+	redisCommandSET      = "SET"
+	redisCommandSMEMBERS = "SMEMBERS"
+	redisCommandSREM     = "SREM"
+)
+
+type redisTracker struct {
+	pool *redis.Pool
+}
+
+// NewRedisTracker returns a Redis backed Tracker implementation. The times of
+// every namespace are kept in a hash keyed by session id, the namespaces with
+// pending times are kept in a set.
+func NewRedisTracker(pool *redis.Pool) Tracker {
+	return &redisTracker{
+		pool: pool,
+	}
+}
+
+func (t *redisTracker) Flush() (map[string]map[string]time.Time, error) {
+	con := t.pool.Get()
+	defer con.Close()
+
+	nss, err := redis.Strings(con.Do(redisCommandSMEMBERS, redisKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("tracker flush failed: %s", err)
+	}
+
+	seen := map[string]map[string]time.Time{}
+
+	for _, ns := range nss {
+		if err := con.Send(redisCommandMULTI); err != nil {
+			return nil, fmt.Errorf("tracker flush failed: %s", err)
+		}
+
+		if err := con.Send(redisCommandHGETALL, redisSeenKey(ns)); err != nil {
+			return nil, fmt.Errorf("tracker flush failed: %s", err)
+		}
+
+		if err := con.Send(redisCommandDEL, redisSeenKey(ns)); err != nil {
+			return nil, fmt.Errorf("tracker flush failed: %s", err)
+		}
+
+		if err := con.Send(redisCommandSREM, redisKeyPrefix, ns); err != nil {
+			return nil, fmt.Errorf("tracker flush failed: %s", err)
+		}
+
+		rs, err := redis.Values(con.Do(redisCommandEXEC))
+		if err != nil {
+			return nil, fmt.Errorf("tracker flush failed: %s", err)
+		}
+
+		vs, err := redis.StringMap(rs[0], nil)
+		if err != nil {
+			return nil, fmt.Errorf("tracker flush failed: %s", err)
+		}
+
+		seen[ns] = map[string]time.Time{}
+
+		for id, v := range vs {
+			at, err := parseSeen(v)
+			if err != nil {
+				return nil, err
+			}
+
+			seen[ns][id] = at
+		}
+	}
+
+	return seen, nil
+}
+
+func (t *redisTracker) Touch(ns, id string, at time.Time) (time.Time, error) {
+	con := t.pool.Get()
+	defer con.Close()
+
+	if err := con.Send(redisCommandMULTI); err != nil {
+		return time.Time{}, fmt.Errorf("tracker touch failed: %s", err)
+	}
+
+	if err := con.Send(redisCommandHGET, redisSeenKey(ns), id); err != nil {
+		return time.Time{}, fmt.Errorf("tracker touch failed: %s", err)
+	}
+
+	err := con.Send(redisCommandHSET, redisSeenKey(ns), id, at.UnixNano())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("tracker touch failed: %s", err)
+	}
+
+	if err := con.Send(redisCommandSADD, redisKeyPrefix, ns); err != nil {
+		return time.Time{}, fmt.Errorf("tracker touch failed: %s", err)
+	}
+
+	rs, err := redis.Values(con.Do(redisCommandEXEC))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("tracker touch failed: %s", err)
+	}
+
+	if rs[0] == nil {
+		return time.Time{}, nil
+	}
+
+	v, err := redis.String(rs[0], nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("tracker touch failed: %s", err)
+	}
+
+	return parseSeen(v)
+}
+
+type redisRevocations struct {
+	pool *redis.Pool
+}
+
+// NewRedisRevocations returns a Redis backed Revocations implementation. Every
+// revoked session is kept under its own key which expires with the ttl.
+func NewRedisRevocations(pool *redis.Pool) Revocations {
+	return &redisRevocations{
+		pool: pool,
+	}
+}
+
+func (r *redisRevocations) Add(ns, id string, ttl time.Duration) error {
+	con := r.pool.Get()
+	defer con.Close()
+
+	secs := int64(ttl / time.Second)
+
+	if secs < 1 {
+		secs = 1
+	}
+
+	_, err := con.Do(redisCommandSET, redisRevokedKey(ns, id), 1, redisArgumentEX, secs)
+	if err != nil {
+		return fmt.Errorf("revocation add failed: %s", err)
+	}
+
+	return nil
+}
+
+func (r *redisRevocations) Contains(ns, id string) (bool, error) {
+	con := r.pool.Get()
+	defer con.Close()
+
+	ok, err := redis.Bool(con.Do(redisCommandEXISTS, redisRevokedKey(ns, id)))
+	if err != nil {
+		return false, fmt.Errorf("revocation lookup failed: %s", err)
+	}
+
+	return ok, nil
+}
+
+func parseSeen(v string) (time.Time, error) {
+	ns, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid seen time '%s': %s", v, err)
+	}
+
+	return time.Unix(0, ns).UTC(), nil
+}
+
+func redisSeenKey(ns string) string {
+	return strings.Join([]string{redisKeyPrefix, ns}, redisSeparator)
+}
+
+func redisRevokedKey(ns, id string) string {
+	return strings.Join([]string{redisKeyPrefixRevoked, ns, id}, redisSeparator)
+}
@@ -0,0 +1,35 @@
+// +build integration
+
+package session
+
+import (
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+func TestRedisRevocations(t *testing.T) {
+	testRevocations(t, func(t *testing.T) Revocations {
+		return NewRedisRevocations(redis.NewPool(func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:6379")
+		}, 10))
+	})
+}
+
+func TestRedisTracker(t *testing.T) {
+	testTracker(t, prepareRedisTracker)
+}
+
+func prepareRedisTracker(t *testing.T) Tracker {
+	pool := redis.NewPool(func() (redis.Conn, error) {
+		return redis.Dial("tcp", "127.0.0.1:6379")
+	}, 10)
+
+	tracker := NewRedisTracker(pool)
+
+	if _, err := tracker.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	return tracker
+}
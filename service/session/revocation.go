@@ -0,0 +1,123 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Revocations lists sessions which were disabled while access tokens issued
+// for them might still be valid. Entries are only kept for the given ttl,
+// which should match the longest access token lifetime.
+type Revocations interface {
+	// Add marks the session with the public id as revoked for ttl.
+	Add(namespace, publicID string, ttl time.Duration) error
+
+	// Contains reports if the session with the public id is revoked.
+	Contains(namespace, publicID string) (bool, error)
+}
+
+type memRevocations struct {
+	sync.Mutex
+
+	now     func() time.Time
+	revoked map[string]map[string]time.Time
+}
+
+// NewMemRevocations returns a memory based Revocations implementation.
+func NewMemRevocations() Revocations {
+	return &memRevocations{
+		now:     time.Now,
+		revoked: map[string]map[string]time.Time{},
+	}
+}
+
+func (r *memRevocations) Add(ns, id string, ttl time.Duration) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.revoked[ns]; !ok {
+		r.revoked[ns] = map[string]time.Time{}
+	}
+
+	r.revoked[ns][id] = r.now().Add(ttl)
+
+	return nil
+}
+
+func (r *memRevocations) Contains(ns, id string) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	until, ok := r.revoked[ns][id]
+	if !ok {
+		return false, nil
+	}
+
+	if !r.now().Before(until) {
+		delete(r.revoked[ns], id)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+type revokeService struct {
+	next        Service
+	revocations Revocations
+	ttl         time.Duration
+}
+
+// RevokeServiceMiddleware adds every session stored as disabled or disabled
+// through Disable to the revocations for ttl, so access tokens issued for it
+// are rejected before they expire.
+func RevokeServiceMiddleware(
+	revocations Revocations,
+	ttl time.Duration,
+) ServiceMiddleware {
+	return func(next Service) Service {
+		return &revokeService{
+			next:        next,
+			revocations: revocations,
+			ttl:         ttl,
+		}
+	}
+}
+
+func (s *revokeService) Disable(ns string, id string) error {
+	if err := s.next.Disable(ns, id); err != nil {
+		return err
+	}
+
+	return s.revocations.Add(ns, (&Session{ID: id}).PublicID(), s.ttl)
+}
+
+func (s *revokeService) Put(ns string, input *Session) (*Session, error) {
+	output, err := s.next.Put(ns, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if !output.Enabled {
+		if err := s.revocations.Add(ns, output.PublicID(), s.ttl); err != nil {
+			return nil, err
+		}
+	}
+
+	return output, nil
+}
+
+func (s *revokeService) PutLastSeen(ns string, seen map[string]time.Time) error {
+	return s.next.PutLastSeen(ns, seen)
+}
+
+func (s *revokeService) Query(ns string, opts QueryOptions) (List, error) {
+	return s.next.Query(ns, opts)
+}
+
+func (s *revokeService) Setup(ns string) error {
+	return s.next.Setup(ns)
+}
+
+func (s *revokeService) Teardown(ns string) error {
+	return s.next.Teardown(ns)
+}
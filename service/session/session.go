@@ -1,7 +1,9 @@
 package session
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"math/rand"
 	"time"
 
@@ -30,20 +32,51 @@ type QueryOptions struct {
 type Service interface {
 	service.Lifecycle
 
+	// Disable flips the session from enabled to disabled and returns
+	// ErrNotFound if it is unknown or was disabled already, so only one of
+	// concurrent callers succeeds.
+	Disable(namespace string, id string) error
 	Put(namespace string, session *Session) (*Session, error)
+	PutLastSeen(namespace string, seen map[string]time.Time) error
 	Query(namespace string, opts QueryOptions) (List, error)
 }
 
 // ServiceMiddleware is a chainable behaviour modifier for Service.
 type ServiceMiddleware func(Service) Service
 
-// Session attaches a session id to a user id.
+// Session attaches a session id to a user id. LastSeenAt is the time of the
+// latest request made with the session.
 type Session struct {
-	CreatedAt time.Time
-	DeviceID  string
-	Enabled   bool
-	ID        string
-	UserID    uint64
+	CreatedAt  time.Time
+	DeviceID   string
+	Enabled    bool
+	ID         string
+	LastSeenAt time.Time
+	UserID     uint64
+}
+
+// Expired reports if the session wasn't seen for longer than idle or was
+// created more than max ago, a zero duration disables the respective check.
+func (s *Session) Expired(now time.Time, idle, max time.Duration) bool {
+	if max > 0 && !now.Before(s.CreatedAt.Add(max)) {
+		return true
+	}
+
+	seen := s.LastSeenAt
+
+	if seen.Before(s.CreatedAt) {
+		seen = s.CreatedAt
+	}
+
+	return idle > 0 && !now.Before(seen.Add(idle))
+}
+
+// PublicID identifies the session without revealing its id, which is the
+// secret token of the user.
+func (s *Session) PublicID() string {
+	sum := sha256.Sum256([]byte(s.ID))
+
+	return hex.EncodeToString(sum[:16])
 }
 
 // Validate performs semantic checks on the Session.
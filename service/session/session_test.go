@@ -1,6 +1,9 @@
 package session
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestValidate(t *testing.T) {
 	ss := List{
@@ -14,3 +17,88 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestExpired(t *testing.T) {
+	var (
+		now = time.Now()
+		s   = &Session{
+			CreatedAt:  now.Add(-3 * time.Hour),
+			LastSeenAt: now.Add(-time.Hour),
+		}
+		cases = []struct {
+			idle    time.Duration
+			max     time.Duration
+			expired bool
+		}{
+			{0, 0, false},
+			{2 * time.Hour, 0, false},
+			{time.Hour, 0, true},
+			{0, 4 * time.Hour, false},
+			{0, 3 * time.Hour, true},
+			{2 * time.Hour, 4 * time.Hour, false},
+		}
+	)
+
+	for _, c := range cases {
+		if have, want := s.Expired(now, c.idle, c.max), c.expired; have != want {
+			t.Errorf("idle %v max %v: have %v, want %v", c.idle, c.max, have, want)
+		}
+	}
+}
+
+func TestRevokeServiceMiddleware(t *testing.T) {
+	var (
+		namespace   = "service_revoke"
+		revocations = NewMemRevocations()
+		sessions    = RevokeServiceMiddleware(revocations, time.Minute)(NewMemService())
+	)
+
+	s, err := sessions.Put(namespace, testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := revocations.Contains(namespace, s.PublicID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	s.Enabled = false
+
+	s, err = sessions.Put(namespace, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = revocations.Contains(namespace, s.PublicID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	s, err = sessions.Put(namespace, testSession())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sessions.Disable(namespace, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = revocations.Contains(namespace, s.PublicID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
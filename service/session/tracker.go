@@ -0,0 +1,59 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker buffers the last time sessions were seen, so they can be persisted
+// in batches instead of writing on every request.
+type Tracker interface {
+	// Flush returns all recorded times by namespace and session id and clears
+	// them.
+	Flush() (map[string]map[string]time.Time, error)
+
+	// Touch records that the session was seen at the given time and returns the
+	// time recorded before, which is zero if there was none since the last
+	// Flush.
+	Touch(namespace, id string, at time.Time) (time.Time, error)
+}
+
+type memTracker struct {
+	sync.Mutex
+
+	seen map[string]map[string]time.Time
+}
+
+// NewMemTracker returns a memory based Tracker implementation.
+func NewMemTracker() Tracker {
+	return &memTracker{
+		seen: map[string]map[string]time.Time{},
+	}
+}
+
+func (t *memTracker) Flush() (map[string]map[string]time.Time, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	seen := t.seen
+	t.seen = map[string]map[string]time.Time{}
+
+	return seen, nil
+}
+
+func (t *memTracker) Touch(ns, id string, at time.Time) (time.Time, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.seen[ns]; !ok {
+		t.seen[ns] = map[string]time.Time{}
+	}
+
+	prev := t.seen[ns][id]
+
+	if at.After(prev) {
+		t.seen[ns][id] = at
+	}
+
+	return prev, nil
+}
@@ -0,0 +1,206 @@
+package timeline
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+type prepareFunc func(t *testing.T, namespace string) Service
+
+func testServiceBackfilled(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_backfilled"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+	)
+
+	ok, err := service.Backfilled(namespace, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := service.PutBackfilled(namespace, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ok, err = service.Backfilled(namespace, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := ok, true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServicePut(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_put"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+		es        = testList(userID)
+	)
+
+	err := service.Put(namespace, userID, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Putting the same entries again must not duplicate them.
+	err = service.Put(namespace, userID, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := service.Query(namespace, userID, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), len(es); have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := list[0].Key(), es[len(es)-1].Key(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = service.Put(namespace, userID, List{{Type: TypePost}})
+	if have, want := IsInvalidEntry(err), true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceQuery(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_query"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+		es        = testList(userID)
+	)
+
+	list, err := service.Query(namespace, userID, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = service.Put(namespace, userID, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[*QueryOptions]int{
+		{Limit: 2}:                          2,
+		{After: es[3].CreatedAt}:            2,
+		{Before: es[3].CreatedAt}:           3,
+		{OwnerIDs: []uint64{es[0].OwnerID}}: 1,
+		{Types: []Type{TypePost}}:           3,
+		{Types: []Type{TypeFollow}}:         1,
+	}
+
+	for opts, want := range cases {
+		list, err := service.Query(namespace, userID, *opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have := len(list); have != want {
+			t.Errorf("%#v: have %v, want %v", opts, have, want)
+		}
+	}
+
+	list, err = service.Query(namespace, userID, QueryOptions{
+		After: es[1].CreatedAt,
+		Limit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), 2; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := list[1].Key(), es[2].Key(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceRemove(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_remove"
+		service   = p(t, namespace)
+		userID    = uint64(rand.Int63())
+		es        = testList(userID)
+	)
+
+	err := service.Put(namespace, userID, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.Remove(namespace, userID, es[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := service.Query(namespace, userID, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), len(es)-2; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+// testList returns entries sorted by creation time ascending.
+func testList(userID uint64) List {
+	var (
+		now = time.Now().UTC().Truncate(time.Millisecond)
+		es  = List{}
+	)
+
+	for i := 0; i < 3; i++ {
+		es = append(es, &Entry{
+			ItemID:  uint64(rand.Int63()),
+			OwnerID: uint64(rand.Int63()),
+			Type:    TypePost,
+		})
+	}
+
+	es = append(es, &Entry{
+		ItemID:  uint64(rand.Int63()),
+		OwnerID: uint64(rand.Int63()),
+		Type:    TypeEvent,
+	})
+
+	es = append(es, &Entry{
+		OwnerID:  uint64(rand.Int63()),
+		TargetID: userID,
+		Type:     TypeFollow,
+	})
+
+	es = append(es, &Entry{
+		OwnerID:  uint64(rand.Int63()),
+		TargetID: uint64(rand.Int63()),
+		Type:     TypeFriend,
+	})
+
+	for i, e := range es {
+		e.CreatedAt = now.Add(time.Duration(i-len(es)) * time.Minute)
+	}
+
+	return es
+}
@@ -0,0 +1,168 @@
+package timeline
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+type logService struct {
+	logger log.Logger
+	next   Service
+}
+
+// LogServiceMiddleware given a Logger wraps the next Service with logging
+// capabilities.
+func LogServiceMiddleware(logger log.Logger, store string) ServiceMiddleware {
+	return func(next Service) Service {
+		logger = log.NewContext(logger).With(
+			"service", "timeline",
+			"store", store,
+		)
+
+		return &logService{logger: logger, next: next}
+	}
+}
+
+func (s *logService) Backfilled(
+	ns string,
+	userID uint64,
+) (ok bool, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"backfilled", ok,
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Backfilled",
+			"namespace", ns,
+			"user_id", userID,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Backfilled(ns, userID)
+}
+
+func (s *logService) Put(ns string, userID uint64, es List) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"entry_len", len(es),
+			"method", "Put",
+			"namespace", ns,
+			"user_id", userID,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Put(ns, userID, es)
+}
+
+func (s *logService) PutBackfilled(ns string, userID uint64) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "PutBackfilled",
+			"namespace", ns,
+			"user_id", userID,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.PutBackfilled(ns, userID)
+}
+
+func (s *logService) Query(
+	ns string,
+	userID uint64,
+	opts QueryOptions,
+) (list List, err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"entry_len", len(list),
+			"entry_opts", opts,
+			"method", "Query",
+			"namespace", ns,
+			"user_id", userID,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Query(ns, userID, opts)
+}
+
+func (s *logService) Remove(ns string, userID uint64, es List) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"entry_len", len(es),
+			"method", "Remove",
+			"namespace", ns,
+			"user_id", userID,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Remove(ns, userID, es)
+}
+
+func (s *logService) Setup(ns string) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Setup",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Setup(ns)
+}
+
+func (s *logService) Teardown(ns string) (err error) {
+	defer func(begin time.Time) {
+		ps := []interface{}{
+			"duration_ns", time.Since(begin).Nanoseconds(),
+			"method", "Teardown",
+			"namespace", ns,
+		}
+
+		if err != nil {
+			ps = append(ps, "err", err)
+		}
+
+		_ = s.logger.Log(ps...)
+	}(time.Now())
+
+	return s.next.Teardown(ns)
+}
@@ -0,0 +1,138 @@
+package timeline
+
+import "sort"
+
+type memService struct {
+	backfilled map[string]map[uint64]struct{}
+	timelines  map[string]map[uint64]map[string]*Entry
+}
+
+// NewMemService returns a memory backed implementation of Service.
+func NewMemService() Service {
+	return &memService{
+		backfilled: map[string]map[uint64]struct{}{},
+		timelines:  map[string]map[uint64]map[string]*Entry{},
+	}
+}
+
+func (s *memService) Backfilled(ns string, userID uint64) (bool, error) {
+	if err := s.Setup(ns); err != nil {
+		return false, err
+	}
+
+	_, ok := s.backfilled[ns][userID]
+
+	return ok, nil
+}
+
+func (s *memService) Put(ns string, userID uint64, es List) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	for _, e := range es {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	bucket, ok := s.timelines[ns][userID]
+	if !ok {
+		bucket = map[string]*Entry{}
+		s.timelines[ns][userID] = bucket
+	}
+
+	for _, e := range es {
+		bucket[e.Key()] = copy(e)
+	}
+
+	if len(bucket) > MaxEntries {
+		l := listBucket(bucket)
+
+		for _, e := range l[MaxEntries:] {
+			delete(bucket, e.Key())
+		}
+	}
+
+	return nil
+}
+
+func (s *memService) PutBackfilled(ns string, userID uint64) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	s.backfilled[ns][userID] = struct{}{}
+
+	return nil
+}
+
+func (s *memService) Query(
+	ns string,
+	userID uint64,
+	opts QueryOptions,
+) (List, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	bucket, ok := s.timelines[ns][userID]
+	if !ok {
+		return List{}, nil
+	}
+
+	return filterList(listBucket(bucket), opts), nil
+}
+
+func (s *memService) Remove(ns string, userID uint64, es List) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	bucket, ok := s.timelines[ns][userID]
+	if !ok {
+		return nil
+	}
+
+	for _, e := range es {
+		delete(bucket, e.Key())
+	}
+
+	return nil
+}
+
+func (s *memService) Setup(ns string) error {
+	if _, ok := s.backfilled[ns]; !ok {
+		s.backfilled[ns] = map[uint64]struct{}{}
+	}
+
+	if _, ok := s.timelines[ns]; !ok {
+		s.timelines[ns] = map[uint64]map[string]*Entry{}
+	}
+
+	return nil
+}
+
+func (s *memService) Teardown(ns string) error {
+	delete(s.backfilled, ns)
+	delete(s.timelines, ns)
+
+	return nil
+}
+
+func copy(e *Entry) *Entry {
+	old := *e
+	return &old
+}
+
+func listBucket(bucket map[string]*Entry) List {
+	es := List{}
+
+	for _, e := range bucket {
+		es = append(es, copy(e))
+	}
+
+	sort.Sort(es)
+
+	return es
+}
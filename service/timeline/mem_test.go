@@ -0,0 +1,29 @@
+package timeline
+
+import "testing"
+
+func TestMemBackfilled(t *testing.T) {
+	testServiceBackfilled(t, prepareMem)
+}
+
+func TestMemPut(t *testing.T) {
+	testServicePut(t, prepareMem)
+}
+
+func TestMemQuery(t *testing.T) {
+	testServiceQuery(t, prepareMem)
+}
+
+func TestMemRemove(t *testing.T) {
+	testServiceRemove(t, prepareMem)
+}
+
+func prepareMem(t *testing.T, ns string) Service {
+	s := NewMemService()
+
+	if err := s.Teardown(ns); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
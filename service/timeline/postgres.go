@@ -0,0 +1,372 @@
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/tapglue/multiverse/platform/pg"
+)
+
+const (
+	pgInsertEntry = `INSERT INTO
+		%s.timelines(user_id, entry_key, owner_id, type, created_at)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM %s.timelines WHERE user_id = $1 AND entry_key = $2
+		)`
+	pgDeleteEntry = `DELETE FROM %s.timelines
+		WHERE user_id = $1 AND entry_key = $2`
+	pgEvictEntries = `DELETE FROM %s.timelines
+		WHERE user_id = $1 AND created_at < (
+			SELECT created_at FROM %s.timelines
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			OFFSET $2
+			LIMIT 1
+		)`
+
+	pgInsertBackfilled = `INSERT INTO
+		%s.timeline_backfills(user_id)
+		VALUES($1)
+		ON CONFLICT (user_id) DO NOTHING`
+	pgSelectBackfilled = `SELECT EXISTS (
+		SELECT 1 FROM %s.timeline_backfills WHERE user_id = $1
+	)`
+
+	pgListEntries = `
+		SELECT
+			entry_key, created_at
+		FROM
+			%s.timelines
+		%s`
+
+	pgClauseAfter    = `created_at > ?`
+	pgClauseBefore   = `created_at < ?`
+	pgClauseOwnerIDs = `owner_id IN (?)`
+	pgClauseTypes    = `type IN (?)`
+	pgClauseUserID   = `user_id = ?`
+
+	pgOrderCreatedAt    = `ORDER BY created_at DESC`
+	pgOrderCreatedAtAsc = `ORDER BY created_at ASC`
+
+	pgCreateSchema = `CREATE SCHEMA IF NOT EXISTS %s`
+	pgCreateTable  = `CREATE TABLE IF NOT EXISTS %s.timelines (
+		user_id BIGINT NOT NULL,
+		entry_key VARCHAR(255) NOT NULL,
+		owner_id BIGINT NOT NULL,
+		type VARCHAR(16) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`
+	pgCreateTableBackfills = `CREATE TABLE IF NOT EXISTS %s.timeline_backfills (
+		user_id BIGINT PRIMARY KEY
+	)`
+	pgDropTable          = `DROP TABLE IF EXISTS %s.timelines`
+	pgDropTableBackfills = `DROP TABLE IF EXISTS %s.timeline_backfills`
+
+	pgIndexUserIDCreatedAt = `
+		CREATE INDEX
+			%s
+		ON
+			%s.timelines (user_id, created_at DESC)`
+	pgIndexUserIDKey = `
+		CREATE UNIQUE INDEX
+			%s
+		ON
+			%s.timelines (user_id, entry_key)`
+)
+
+type pgService struct {
+	db *sqlx.DB
+}
+
+// NewPostgresService returns a Postgres based Service implementation.
+func NewPostgresService(db *sqlx.DB) Service {
+	return &pgService{db: db}
+}
+
+func (s *pgService) Backfilled(ns string, userID uint64) (bool, error) {
+	var (
+		ok    bool
+		query = fmt.Sprintf(pgSelectBackfilled, ns)
+	)
+
+	err := s.db.Get(&ok, query, userID)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return false, err
+		}
+
+		err = s.db.Get(&ok, query, userID)
+	}
+
+	return ok, err
+}
+
+func (s *pgService) Put(ns string, userID uint64, es List) error {
+	for _, e := range es {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	err := s.put(ns, userID, es)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return err
+		}
+
+		err = s.put(ns, userID, es)
+	}
+
+	return err
+}
+
+func (s *pgService) PutBackfilled(ns string, userID uint64) error {
+	query := fmt.Sprintf(pgInsertBackfilled, ns)
+
+	_, err := s.db.Exec(query, userID)
+	if err != nil && pg.IsRelationNotFound(pg.WrapError(err)) {
+		if err := s.Setup(ns); err != nil {
+			return err
+		}
+
+		_, err = s.db.Exec(query, userID)
+	}
+
+	return err
+}
+
+func (s *pgService) Query(
+	ns string,
+	userID uint64,
+	opts QueryOptions,
+) (List, error) {
+	where, params, err := convertOpts(userID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.listEntries(ns, where, params...)
+}
+
+func (s *pgService) Remove(ns string, userID uint64, es List) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(pgDeleteEntry, ns)
+
+	for _, e := range es {
+		_, err := tx.Exec(query, userID, e.Key())
+		if err != nil {
+			_ = tx.Rollback()
+
+			if pg.IsRelationNotFound(pg.WrapError(err)) {
+				return s.Setup(ns)
+			}
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *pgService) Setup(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgCreateSchema, ns),
+		fmt.Sprintf(pgCreateTable, ns),
+		fmt.Sprintf(pgCreateTableBackfills, ns),
+		pg.GuardIndex(ns, "timeline_user_id_created_at", pgIndexUserIDCreatedAt),
+		pg.GuardIndex(ns, "timeline_user_id_key", pgIndexUserIDKey),
+	}
+
+	for _, query := range qs {
+		_, err := s.db.Exec(query)
+		if err != nil {
+			return fmt.Errorf("query (%s): %s", query, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) Teardown(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgDropTable, ns),
+		fmt.Sprintf(pgDropTableBackfills, ns),
+	}
+
+	for _, query := range qs {
+		_, err := s.db.Exec(query)
+		if err != nil {
+			return fmt.Errorf("query (%s): %s", query, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) listEntries(
+	ns, where string,
+	params ...interface{},
+) (List, error) {
+	query := fmt.Sprintf(pgListEntries, ns, where)
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			if err := s.Setup(ns); err != nil {
+				return nil, err
+			}
+
+			rows, err = s.db.Query(query, params...)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	es := List{}
+
+	for rows.Next() {
+		var (
+			createdAt time.Time
+			key       string
+		)
+
+		err := rows.Scan(&key, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := parseKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		e.CreatedAt = createdAt.UTC()
+
+		es = append(es, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(es)
+
+	return es, nil
+}
+
+func (s *pgService) put(ns string, userID uint64, es List) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(pgInsertEntry, ns, ns)
+
+	for _, e := range es {
+		_, err := tx.Exec(
+			query,
+			userID,
+			e.Key(),
+			e.OwnerID,
+			string(e.Type),
+			e.CreatedAt.UTC(),
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(pgEvictEntries, ns, ns), userID, MaxEntries-1)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func convertOpts(
+	userID uint64,
+	opts QueryOptions,
+) (string, []interface{}, error) {
+	var (
+		clauses = []string{
+			pgClauseUserID,
+		}
+		params = []interface{}{
+			userID,
+		}
+	)
+
+	if !opts.After.IsZero() {
+		clauses = append(clauses, pgClauseAfter)
+		params = append(params, opts.After.UTC())
+	}
+
+	if !opts.Before.IsZero() {
+		clauses = append(clauses, pgClauseBefore)
+		params = append(params, opts.Before.UTC())
+	}
+
+	if len(opts.OwnerIDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.OwnerIDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseOwnerIDs, ps)
+		if err != nil {
+			return "", nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	if len(opts.Types) > 0 {
+		ps := []interface{}{}
+
+		for _, t := range opts.Types {
+			ps = append(ps, string(t))
+		}
+
+		clause, _, err := sqlx.In(pgClauseTypes, ps)
+		if err != nil {
+			return "", nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	query := sqlx.Rebind(sqlx.DOLLAR, pg.ClausesToWhere(clauses...))
+
+	// Paging forward needs the entries closest to the cursor first, otherwise
+	// the limit would cut out the ones directly after it.
+	if !opts.After.IsZero() {
+		query = strings.Join([]string{query, pgOrderCreatedAtAsc}, "\n")
+	} else {
+		query = strings.Join([]string{query, pgOrderCreatedAt}, "\n")
+	}
+
+	if opts.Limit > 0 {
+		query = fmt.Sprintf("%s\nLIMIT %d", query, opts.Limit)
+	}
+
+	return query, params, nil
+}
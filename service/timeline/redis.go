@@ -0,0 +1,238 @@
+package timeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const (
+	redisKeyBackfilled = "backfilled"
+	redisKeyPrefix     = "timeline"
+	redisSeparator     = "."
+
+	redisCommandDEL              = "DEL"
+	redisCommandKEYS             = "KEYS"
+	redisCommandSADD             = "SADD"
+	redisCommandSISMEMBER        = "SISMEMBER"
+	redisCommandZADD             = "ZADD"
+	redisCommandZREM             = "ZREM"
+	redisCommandZREMRANGEBYRANK  = "ZREMRANGEBYRANK"
+	redisCommandZREVRANGEBYSCORE = "ZREVRANGEBYSCORE"
+	redisWithScores              = "WITHSCORES"
+)
+
+type redisService struct {
+	pool *redis.Pool
+}
+
+// NewRedisService returns a Redis backed implementation of Service. Every
+// timeline is kept in a sorted set scored by the creation time of its entries.
+func NewRedisService(pool *redis.Pool) Service {
+	return &redisService{
+		pool: pool,
+	}
+}
+
+func (s *redisService) Backfilled(ns string, userID uint64) (bool, error) {
+	con := s.pool.Get()
+	defer con.Close()
+
+	ok, err := redis.Bool(con.Do(redisCommandSISMEMBER, redisBackfilledKey(ns), userID))
+	if err != nil {
+		return false, fmt.Errorf("timeline backfilled failed: %s", err)
+	}
+
+	return ok, nil
+}
+
+func (s *redisService) Put(ns string, userID uint64, es List) error {
+	if len(es) == 0 {
+		return nil
+	}
+
+	args := []interface{}{
+		redisTimelineKey(ns, userID),
+	}
+
+	for _, e := range es {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+
+		args = append(args, toScore(e.CreatedAt), e.Key())
+	}
+
+	con := s.pool.Get()
+	defer con.Close()
+
+	_, err := con.Do(redisCommandZADD, args...)
+	if err != nil {
+		return fmt.Errorf("timeline put failed: %s", err)
+	}
+
+	_, err = con.Do(
+		redisCommandZREMRANGEBYRANK,
+		redisTimelineKey(ns, userID),
+		0,
+		-(MaxEntries + 1),
+	)
+	if err != nil {
+		return fmt.Errorf("timeline evict failed: %s", err)
+	}
+
+	return nil
+}
+
+func (s *redisService) PutBackfilled(ns string, userID uint64) error {
+	con := s.pool.Get()
+	defer con.Close()
+
+	_, err := con.Do(redisCommandSADD, redisBackfilledKey(ns), userID)
+	if err != nil {
+		return fmt.Errorf("timeline put backfilled failed: %s", err)
+	}
+
+	return nil
+}
+
+func (s *redisService) Query(
+	ns string,
+	userID uint64,
+	opts QueryOptions,
+) (List, error) {
+	var (
+		max = "+inf"
+		min = "-inf"
+	)
+
+	if !opts.After.IsZero() {
+		min = fmt.Sprintf("(%d", toScore(opts.After))
+	}
+
+	if !opts.Before.IsZero() {
+		max = fmt.Sprintf("(%d", toScore(opts.Before))
+	}
+
+	con := s.pool.Get()
+	defer con.Close()
+
+	vs, err := redis.Strings(con.Do(
+		redisCommandZREVRANGEBYSCORE,
+		redisTimelineKey(ns, userID),
+		max,
+		min,
+		redisWithScores,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("timeline query failed: %s", err)
+	}
+
+	es := List{}
+
+	for i := 0; i+1 < len(vs); i += 2 {
+		e, err := parseKey(vs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var score int64
+
+		_, err = fmt.Sscan(vs[i+1], &score)
+		if err != nil {
+			return nil, err
+		}
+
+		e.CreatedAt = fromScore(score)
+
+		es = append(es, e)
+	}
+
+	return filterList(es, opts), nil
+}
+
+func (s *redisService) Remove(ns string, userID uint64, es List) error {
+	if len(es) == 0 {
+		return nil
+	}
+
+	args := []interface{}{
+		redisTimelineKey(ns, userID),
+	}
+
+	for _, e := range es {
+		args = append(args, e.Key())
+	}
+
+	con := s.pool.Get()
+	defer con.Close()
+
+	_, err := con.Do(redisCommandZREM, args...)
+	if err != nil {
+		return fmt.Errorf("timeline remove failed: %s", err)
+	}
+
+	return nil
+}
+
+func (s *redisService) Setup(ns string) error {
+	return nil
+}
+
+func (s *redisService) Teardown(ns string) error {
+	con := s.pool.Get()
+	defer con.Close()
+
+	keys, err := redis.Strings(con.Do(
+		redisCommandKEYS,
+		strings.Join([]string{redisKeyPrefix, ns, "*"}, redisSeparator),
+	))
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := []interface{}{}
+
+	for _, k := range keys {
+		args = append(args, k)
+	}
+
+	_, err = con.Do(redisCommandDEL, args...)
+
+	return err
+}
+
+// redisBackfilledKey is the set of users whose timeline is backfilled, it
+// can't clash with timeline keys as those end in a numeric id.
+func redisBackfilledKey(ns string) string {
+	return strings.Join([]string{
+		redisKeyPrefix,
+		ns,
+		redisKeyBackfilled,
+	}, redisSeparator)
+}
+
+func redisTimelineKey(ns string, userID uint64) string {
+	return strings.Join([]string{
+		redisKeyPrefix,
+		ns,
+		fmt.Sprintf("%d", userID),
+	}, redisSeparator)
+}
+
+// fromScore reverses toScore.
+func fromScore(score int64) time.Time {
+	return time.Unix(0, score*int64(time.Microsecond)).UTC()
+}
+
+// toScore converts t into microseconds as sorted set scores are doubles and
+// can't represent nanoseconds since the epoch without loss.
+func toScore(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
@@ -0,0 +1,39 @@
+// +build integration
+
+package timeline
+
+import (
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+func TestRedisBackfilled(t *testing.T) {
+	testServiceBackfilled(t, prepareRedis)
+}
+
+func TestRedisPut(t *testing.T) {
+	testServicePut(t, prepareRedis)
+}
+
+func TestRedisQuery(t *testing.T) {
+	testServiceQuery(t, prepareRedis)
+}
+
+func TestRedisRemove(t *testing.T) {
+	testServiceRemove(t, prepareRedis)
+}
+
+func prepareRedis(t *testing.T, namespace string) Service {
+	pool := redis.NewPool(func() (redis.Conn, error) {
+		return redis.Dial("tcp", "127.0.0.1:6379")
+	}, 10)
+
+	s := NewRedisService(pool)
+
+	if err := s.Teardown(namespace); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
@@ -0,0 +1,232 @@
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/service"
+)
+
+// Supported types for timeline entries.
+const (
+	TypeEvent  Type = "event"
+	TypeFollow Type = "follow"
+	TypeFriend Type = "friend"
+	TypePost   Type = "post"
+)
+
+// MaxEntries is the upper bound of entries kept per timeline, older entries
+// are evicted once it is reached.
+const MaxEntries = 1000
+
+const keySeparator = ":"
+
+// Entry references an item materialised in the timeline of a user. Events and
+// posts are referenced by their id, connections by the two users involved.
+type Entry struct {
+	CreatedAt time.Time
+	ItemID    uint64
+	OwnerID   uint64
+	TargetID  uint64
+	Type      Type
+}
+
+// Key returns the identifier of the Entry which is unique per timeline.
+func (e *Entry) Key() string {
+	id := e.ItemID
+
+	if e.Type == TypeFollow || e.Type == TypeFriend {
+		id = e.TargetID
+	}
+
+	return strings.Join([]string{
+		string(e.Type),
+		strconv.FormatUint(e.OwnerID, 10),
+		strconv.FormatUint(id, 10),
+	}, keySeparator)
+}
+
+// Validate performs semantic checks on the Entry.
+func (e *Entry) Validate() error {
+	if e.OwnerID == 0 {
+		return wrapError(ErrInvalidEntry, "missing owner")
+	}
+
+	switch e.Type {
+	case TypeEvent, TypePost:
+		if e.ItemID == 0 {
+			return wrapError(ErrInvalidEntry, "missing item id")
+		}
+	case TypeFollow, TypeFriend:
+		if e.TargetID == 0 {
+			return wrapError(ErrInvalidEntry, "missing target")
+		}
+	default:
+		return wrapError(ErrInvalidEntry, "unsupported type '%s'", e.Type)
+	}
+
+	if e.CreatedAt.IsZero() {
+		return wrapError(ErrInvalidEntry, "missing creation time")
+	}
+
+	return nil
+}
+
+// List is a collection of entries.
+type List []*Entry
+
+// ItemIDs returns the item ids of all entries of the given type.
+func (l List) ItemIDs(t Type) []uint64 {
+	ids := []uint64{}
+
+	for _, e := range l {
+		if e.Type == t {
+			ids = append(ids, e.ItemID)
+		}
+	}
+
+	return ids
+}
+
+func (l List) Len() int {
+	return len(l)
+}
+
+func (l List) Less(i, j int) bool {
+	return l[i].CreatedAt.After(l[j].CreatedAt)
+}
+
+func (l List) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// QueryOptions are used to narrow down timeline queries.
+type QueryOptions struct {
+	After    time.Time
+	Before   time.Time
+	Limit    int
+	OwnerIDs []uint64
+	Types    []Type
+}
+
+// Service for timeline interactions. Timelines are only read once they are
+// marked as backfilled, as the fan-out maintains them from then on.
+type Service interface {
+	service.Lifecycle
+
+	Backfilled(namespace string, userID uint64) (bool, error)
+	Put(namespace string, userID uint64, entries List) error
+	PutBackfilled(namespace string, userID uint64) error
+	Query(namespace string, userID uint64, opts QueryOptions) (List, error)
+	Remove(namespace string, userID uint64, entries List) error
+}
+
+// ServiceMiddleware is a chainable behaviour modifier for Service.
+type ServiceMiddleware func(Service) Service
+
+// Type of a timeline entry.
+type Type string
+
+func parseKey(key string) (*Entry, error) {
+	ps := strings.Split(key, keySeparator)
+
+	if len(ps) != 3 {
+		return nil, fmt.Errorf("malformed key '%s'", key)
+	}
+
+	ownerID, err := strconv.ParseUint(ps[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseUint(ps[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Entry{
+		OwnerID: ownerID,
+		Type:    Type(ps[0]),
+	}
+
+	if e.Type == TypeFollow || e.Type == TypeFriend {
+		e.TargetID = id
+	} else {
+		e.ItemID = id
+	}
+
+	return e, nil
+}
+
+// filterList applies the query options shared by all implementations to the
+// given list, which is expected to be sorted by creation time descending.
+func filterList(es List, opts QueryOptions) List {
+	fs := List{}
+
+	for _, e := range es {
+		if !opts.After.IsZero() && !e.CreatedAt.After(opts.After) {
+			continue
+		}
+
+		if !opts.Before.IsZero() && !e.CreatedAt.Before(opts.Before) {
+			continue
+		}
+
+		if !inIDs(e.OwnerID, opts.OwnerIDs) {
+			continue
+		}
+
+		if !inTypes(e.Type, opts.Types) {
+			continue
+		}
+
+		fs = append(fs, e)
+	}
+
+	if opts.Limit <= 0 || len(fs) <= opts.Limit {
+		return fs
+	}
+
+	// Paging forward keeps the entries closest to the cursor.
+	if !opts.After.IsZero() {
+		return fs[len(fs)-opts.Limit:]
+	}
+
+	return fs[:opts.Limit]
+}
+
+func inIDs(id uint64, ids []uint64) bool {
+	if len(ids) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, i := range ids {
+		if id == i {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
+
+func inTypes(t Type, ts []Type) bool {
+	if len(ts) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, ty := range ts {
+		if t == ty {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
@@ -0,0 +1,49 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+)
+
+const errFmt = "%s: %s"
+
+// Common errors for Token service implementations and validations.
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrNotFound     = errors.New("token not found")
+)
+
+// Error wraps common Token errors.
+type Error struct {
+	err error
+	msg string
+}
+
+func (e Error) Error() string {
+	return e.msg
+}
+
+// IsInvalidToken indicates if err is ErrInvalidToken.
+func IsInvalidToken(err error) bool {
+	return unwrapError(err) == ErrInvalidToken
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		return e.err
+	}
+
+	return err
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	return &Error{
+		err: err,
+		msg: fmt.Sprintf(
+			errFmt,
+			err,
+			fmt.Sprintf(format, args...),
+		),
+	}
+}
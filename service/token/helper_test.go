@@ -0,0 +1,182 @@
+package token
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type prepareFunc func(t *testing.T, namespace string) Service
+
+func testList() List {
+	ts := List{}
+
+	for i := 0; i < 6; i++ {
+		ts = append(ts, testToken(TypeVerification))
+	}
+
+	return ts
+}
+
+func testServiceDisable(t *testing.T, p prepareFunc) {
+	var (
+		enabled   = true
+		namespace = "service_disable"
+		service   = p(t, namespace)
+	)
+
+	created, err := service.Put(namespace, testToken(TypeVerification))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.Disable(namespace, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := service.Query(namespace, QueryOptions{
+		Enabled: &enabled,
+		IDs: []string{
+			created.ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = service.Disable(namespace, created.ID)
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	err = service.Disable(namespace, "unknown")
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServicePut(t *testing.T, p prepareFunc) {
+	var (
+		enabled   = true
+		namespace = "service_put"
+		service   = p(t, namespace)
+		token     = testToken(TypePasswordReset)
+	)
+
+	created, err := service.Put(namespace, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := service.Query(namespace, QueryOptions{
+		Enabled: &enabled,
+		IDs: []string{
+			created.ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := list[0], created; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	created.Enabled = false
+
+	_, err = service.Put(namespace, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = service.Query(namespace, QueryOptions{
+		Enabled: &enabled,
+		IDs: []string{
+			created.ID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(list), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	unknown := testToken(TypePasswordReset)
+	unknown.ID = "unknown"
+
+	_, err = service.Put(namespace, unknown)
+	if have, want := err, ErrNotFound; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func testServiceQuery(t *testing.T, p prepareFunc) {
+	var (
+		enabled   = true
+		namespace = "service_query"
+		service   = p(t, namespace)
+	)
+
+	ts, err := service.Query(namespace, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(ts), 0; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	created, err := service.Put(namespace, testToken(TypePasswordReset))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range testList() {
+		_, err := service.Put(namespace, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[*QueryOptions]int{
+		&QueryOptions{Enabled: &enabled}:                                                    7,
+		&QueryOptions{IDs: []string{created.ID}}:                                            1,
+		&QueryOptions{Types: []string{TypePasswordReset}}:                                   1,
+		&QueryOptions{Types: []string{TypeVerification}}:                                    6,
+		&QueryOptions{UserIDs: []uint64{created.UserID}}:                                    1,
+		&QueryOptions{UserIDs: []uint64{created.UserID}, Types: []string{TypeVerification}}: 0,
+	}
+
+	for opts, want := range cases {
+		ts, err := service.Query(namespace, *opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have := len(ts); have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
+
+func testToken(ty string) *Token {
+	return &Token{
+		Email:     "alice@example.com",
+		Enabled:   true,
+		ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond),
+		Type:      ty,
+		UserID:    uint64(rand.Int63()),
+	}
+}
@@ -0,0 +1,151 @@
+package token
+
+import "time"
+
+type memService struct {
+	tokens map[string]map[string]*Token
+}
+
+// NewMemService returns a memory based Service implementation.
+func NewMemService() Service {
+	return &memService{
+		tokens: map[string]map[string]*Token{},
+	}
+}
+
+func (s *memService) Disable(ns string, id string) error {
+	if err := s.Setup(ns); err != nil {
+		return err
+	}
+
+	t, ok := s.tokens[ns][id]
+	if !ok || !t.Enabled {
+		return ErrNotFound
+	}
+
+	t.Enabled = false
+
+	return nil
+}
+
+func (s *memService) Put(ns string, token *Token) (*Token, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	if err := token.Validate(); err != nil {
+		return nil, err
+	}
+
+	bucket := s.tokens[ns]
+
+	if token.ID == "" {
+		id, err := generateID()
+		if err != nil {
+			return nil, err
+		}
+
+		token.ID = id
+		token.CreatedAt = time.Now().UTC()
+	} else {
+		old, ok := bucket[token.ID]
+		if !ok {
+			return nil, ErrNotFound
+		}
+
+		token.CreatedAt = old.CreatedAt
+	}
+
+	bucket[token.ID] = copy(token)
+
+	return copy(token), nil
+}
+
+func (s *memService) Query(ns string, opts QueryOptions) (List, error) {
+	if err := s.Setup(ns); err != nil {
+		return nil, err
+	}
+
+	return filterMap(s.tokens[ns], opts), nil
+}
+
+func (s *memService) Setup(ns string) error {
+	if _, ok := s.tokens[ns]; !ok {
+		s.tokens[ns] = map[string]*Token{}
+	}
+
+	return nil
+}
+
+func (s *memService) Teardown(ns string) error {
+	if _, ok := s.tokens[ns]; ok {
+		delete(s.tokens, ns)
+	}
+
+	return nil
+}
+
+func copy(t *Token) *Token {
+	old := *t
+	return &old
+}
+
+func filterMap(tm map[string]*Token, opts QueryOptions) List {
+	ts := List{}
+
+	for id, t := range tm {
+		if opts.Enabled != nil && t.Enabled != *opts.Enabled {
+			continue
+		}
+
+		if !inTypes(id, opts.IDs) {
+			continue
+		}
+
+		if !inTypes(t.Type, opts.Types) {
+			continue
+		}
+
+		if !inIDs(t.UserID, opts.UserIDs) {
+			continue
+		}
+
+		ts = append(ts, copy(t))
+	}
+
+	return ts
+}
+
+func inIDs(id uint64, ids []uint64) bool {
+	if len(ids) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, i := range ids {
+		if id == i {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
+
+func inTypes(ty string, ts []string) bool {
+	if len(ts) == 0 {
+		return true
+	}
+
+	keep := false
+
+	for _, t := range ts {
+		if ty == t {
+			keep = true
+			break
+		}
+	}
+
+	return keep
+}
@@ -0,0 +1,25 @@
+package token
+
+import "testing"
+
+func TestMemDisable(t *testing.T) {
+	testServiceDisable(t, prepareMem)
+}
+
+func TestMemPut(t *testing.T) {
+	testServicePut(t, prepareMem)
+}
+
+func TestMemQuery(t *testing.T) {
+	testServiceQuery(t, prepareMem)
+}
+
+func prepareMem(t *testing.T, ns string) Service {
+	s := NewMemService()
+
+	if err := s.Teardown(ns); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
@@ -0,0 +1,349 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/tapglue/multiverse/platform/pg"
+)
+
+const (
+	pgDisableToken = `
+		UPDATE
+			%s.tokens
+		SET
+			enabled = false
+		WHERE
+			token_id = $1
+			AND enabled = true`
+	pgInsertToken = `INSERT INTO
+		%s.tokens(token_id, user_id, type, email, enabled, expires_at, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`
+	pgUpdateToken = `
+		UPDATE
+			%s.tokens
+		SET
+			enabled = $2
+		WHERE
+			token_id = $1`
+
+	pgClauseEnabled = `enabled = ?`
+	pgClauseIDs     = `token_id IN (?)`
+	pgClauseTypes   = `type IN (?)`
+	pgClauseUserIDs = `user_id IN (?)`
+
+	pgOrderCreatedAt = `ORDER BY created_at DESC`
+
+	pgListTokens = `
+		SELECT
+			token_id, user_id, type, email, enabled, expires_at, created_at
+		FROM
+			%s.tokens
+		%s`
+
+	pgCreateSchema = `CREATE SCHEMA IF NOT EXISTS %s`
+	pgCreateTable  = `CREATE TABLE IF NOT EXISTS %s.tokens (
+		token_id VARCHAR(64) PRIMARY KEY,
+		user_id BIGINT NOT NULL,
+		type VARCHAR(32) NOT NULL,
+		email TEXT DEFAULT '' NOT NULL,
+		enabled BOOL DEFAULT TRUE NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT now() NOT NULL
+	)`
+	pgDropTable = `DROP TABLE IF EXISTS %s.tokens`
+
+	pgIndexUserIDType = `
+		CREATE INDEX
+			%s
+		ON
+			%s.tokens (user_id, type)
+		WHERE
+			enabled = true`
+)
+
+type pgService struct {
+	db *sqlx.DB
+}
+
+// NewPostgresService returns a Postgres based Service implementation.
+func NewPostgresService(db *sqlx.DB) Service {
+	return &pgService{db: db}
+}
+
+func (s *pgService) Disable(ns string, id string) error {
+	query := fmt.Sprintf(pgDisableToken, ns)
+
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		if !pg.IsRelationNotFound(pg.WrapError(err)) {
+			return err
+		}
+
+		if err := s.Setup(ns); err != nil {
+			return err
+		}
+
+		res, err = s.db.Exec(query, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *pgService) Put(ns string, token *Token) (*Token, error) {
+	var (
+		params = []interface{}{
+			token.ID,
+			token.Enabled,
+		}
+		query = fmt.Sprintf(pgUpdateToken, ns)
+	)
+
+	if err := token.Validate(); err != nil {
+		return nil, err
+	}
+
+	token.ExpiresAt = token.ExpiresAt.UTC().Truncate(time.Microsecond)
+
+	if token.ID == "" {
+		id, err := generateID()
+		if err != nil {
+			return nil, err
+		}
+
+		ts, err := time.Parse(pg.TimeFormat, time.Now().Format(pg.TimeFormat))
+		if err != nil {
+			return nil, err
+		}
+
+		token.ID = id
+		token.CreatedAt = ts.UTC()
+
+		params = []interface{}{
+			token.ID,
+			token.UserID,
+			token.Type,
+			token.Email,
+			token.Enabled,
+			token.ExpiresAt,
+			token.CreatedAt,
+		}
+		query = fmt.Sprintf(pgInsertToken, ns)
+	}
+
+	res, err := s.db.Exec(query, params...)
+	if err != nil {
+		if !pg.IsRelationNotFound(pg.WrapError(err)) {
+			return nil, err
+		}
+
+		if err := s.Setup(ns); err != nil {
+			return nil, err
+		}
+
+		res, err = s.db.Exec(query, params...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	return token, nil
+}
+
+func (s *pgService) Query(ns string, opts QueryOptions) (List, error) {
+	clauses, params, err := convertOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.listTokens(ns, clauses, params...)
+}
+
+func (s *pgService) Setup(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgCreateSchema, ns),
+		fmt.Sprintf(pgCreateTable, ns),
+		pg.GuardIndex(ns, "token_user_id_type", pgIndexUserIDType),
+	}
+
+	for _, query := range qs {
+		_, err := s.db.Exec(query)
+		if err != nil {
+			return fmt.Errorf("query (%s): %s", query, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) Teardown(ns string) error {
+	qs := []string{
+		fmt.Sprintf(pgDropTable, ns),
+	}
+
+	for _, query := range qs {
+		_, err := s.db.Exec(query)
+		if err != nil {
+			return fmt.Errorf("query (%s): %s", query, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgService) listTokens(
+	ns string,
+	clauses []string,
+	params ...interface{},
+) (List, error) {
+	c := strings.Join(clauses, "\nAND ")
+
+	if len(clauses) > 0 {
+		c = fmt.Sprintf("WHERE %s", c)
+	}
+
+	query := strings.Join([]string{
+		fmt.Sprintf(pgListTokens, ns, c),
+		pgOrderCreatedAt,
+	}, "\n")
+
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	rows, err := s.db.Query(query, params...)
+	if err != nil {
+		if pg.IsRelationNotFound(pg.WrapError(err)) {
+			if err := s.Setup(ns); err != nil {
+				return nil, err
+			}
+
+			rows, err = s.db.Query(query, params...)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	ts := List{}
+
+	for rows.Next() {
+		t := &Token{}
+
+		err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Type,
+			&t.Email,
+			&t.Enabled,
+			&t.ExpiresAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		t.CreatedAt = t.CreatedAt.UTC()
+		t.ExpiresAt = t.ExpiresAt.UTC()
+
+		ts = append(ts, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func convertOpts(opts QueryOptions) ([]string, []interface{}, error) {
+	var (
+		clauses = []string{}
+		params  = []interface{}{}
+	)
+
+	if opts.Enabled != nil {
+		clause, _, err := sqlx.In(pgClauseEnabled, []interface{}{*opts.Enabled})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, *opts.Enabled)
+	}
+
+	if len(opts.IDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.IDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseIDs, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	if len(opts.Types) > 0 {
+		ps := []interface{}{}
+
+		for _, t := range opts.Types {
+			ps = append(ps, t)
+		}
+
+		clause, _, err := sqlx.In(pgClauseTypes, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	if len(opts.UserIDs) > 0 {
+		ps := []interface{}{}
+
+		for _, id := range opts.UserIDs {
+			ps = append(ps, id)
+		}
+
+		clause, _, err := sqlx.In(pgClauseUserIDs, ps)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		clauses = append(clauses, clause)
+		params = append(params, ps...)
+	}
+
+	return clauses, params, nil
+}
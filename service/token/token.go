@@ -0,0 +1,88 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/tapglue/multiverse/platform/service"
+)
+
+// Supported token types.
+const (
+	TypePasswordReset = "password_reset"
+	TypeVerification  = "email_verification"
+)
+
+const idLen = 32
+
+// List is a collection of tokens.
+type List []*Token
+
+// QueryOptions is used to narrow-down token queries.
+type QueryOptions struct {
+	Enabled *bool
+	IDs     []string
+	Types   []string
+	UserIDs []uint64
+}
+
+// Service for token interactions.
+type Service interface {
+	service.Lifecycle
+
+	Disable(namespace string, id string) error
+	Put(namespace string, token *Token) (*Token, error)
+	Query(namespace string, opts QueryOptions) (List, error)
+}
+
+// ServiceMiddleware is a chainable behaviour modifier for Service.
+type ServiceMiddleware func(Service) Service
+
+// Token is a single-use secret handed out to a user to prove access to their
+// email address. Tokens are used up by disabling them. Email holds the address
+// the token was sent to.
+type Token struct {
+	CreatedAt time.Time
+	Email     string
+	Enabled   bool
+	ExpiresAt time.Time
+	ID        string
+	Type      string
+	UserID    uint64
+}
+
+// Expired reports if the token can't be used anymore at the given time.
+func (t *Token) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// Validate performs semantic checks on the Token.
+func (t *Token) Validate() error {
+	if t.ExpiresAt.IsZero() {
+		return wrapError(ErrInvalidToken, "ExpiresAt must be set")
+	}
+
+	if t.Type != TypePasswordReset && t.Type != TypeVerification {
+		return wrapError(ErrInvalidToken, "Type '%s' not supported", t.Type)
+	}
+
+	if t.UserID == 0 {
+		return wrapError(ErrInvalidToken, "UserID must be set")
+	}
+
+	return nil
+}
+
+// generateID returns a url safe secret, as tokens are usually passed around
+// in links.
+func generateID() (string, error) {
+	b := make([]byte, idLen)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
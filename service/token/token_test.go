@@ -0,0 +1,40 @@
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpired(t *testing.T) {
+	var (
+		now   = time.Now()
+		token = &Token{ExpiresAt: now}
+	)
+
+	if have, want := token.Expired(now.Add(-time.Second)), false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	if have, want := token.Expired(now), true; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var (
+		expires = time.Now().Add(time.Hour)
+		ts      = List{
+			{},
+			{ExpiresAt: expires, UserID: 123},
+			{ExpiresAt: expires, Type: "session", UserID: 123},
+			{ExpiresAt: expires, Type: TypePasswordReset},
+			{Type: TypeVerification, UserID: 123},
+		}
+	)
+
+	for _, token := range ts {
+		if have, want := token.Validate(), ErrInvalidToken; !IsInvalidToken(have) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+}
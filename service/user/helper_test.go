@@ -509,6 +509,71 @@ func testServiceSearch(t *testing.T, p prepareFunc) {
 	}
 }
 
+func testServiceSearchRanked(t *testing.T, p prepareFunc) {
+	var (
+		namespace = "service_search_ranked"
+		service   = p(t, namespace)
+		term      = "zqx"
+		order     = []string{"zqx_linden", "bowers", "marvin"}
+	)
+
+	for _, names := range [][3]string{
+		{"Mazqx", "Bowers", "marvin"},
+		{"Zqxina", "Bowers", "bowers"},
+		{"Carl", "Linden", "zqx_linden"},
+		{"Dora", "Miller", "dmiller"},
+	} {
+		u := testUser()
+		u.Firstname = names[0]
+		u.Lastname = names[1]
+		u.Username = names[2]
+
+		_, err := service.Put(namespace, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := QueryOptions{
+		Enabled:    &defaultEnabled,
+		Emails:     []string{term},
+		Firstnames: []string{term},
+		Lastnames:  []string{term},
+		Usernames:  []string{term},
+	}
+
+	us, err := service.Search(namespace, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(us), len(order); have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	for i, u := range us {
+		if have, want := u.Username, order[i]; have != want {
+			t.Errorf("have %v, want %v", have, want)
+		}
+	}
+
+	opts.Limit = 1
+	opts.Offset = 1
+
+	us, err = service.Search(namespace, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(us), 1; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+
+	if have, want := us[0].Username, order[1]; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
+
 func testUser() *User {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
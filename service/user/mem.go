@@ -1,6 +1,7 @@
 package user
 
 import (
+	"sort"
 	"strings"
 	"time"
 
@@ -8,6 +9,12 @@ import (
 	"github.com/tapglue/multiverse/platform/metrics"
 )
 
+// Weights of matches in the different fields for the search relevance.
+const (
+	weightName     = 0.4
+	weightUsername = 1.0
+)
+
 type memService struct {
 	users map[string]Map
 }
@@ -150,6 +157,18 @@ func (s *memService) Search(ns string, opts QueryOptions) (List, error) {
 	us := filterMap(s.users[ns], opts)
 	us = searchUsers(us, sOpts)
 
+	if opts.Offset > 0 {
+		if opts.Offset >= len(us) {
+			return List{}, nil
+		}
+
+		us = us[opts.Offset:]
+	}
+
+	if opts.Limit > 0 && len(us) > opts.Limit {
+		us = us[:opts.Limit]
+	}
+
 	return us, nil
 }
 
@@ -169,22 +188,6 @@ func (s *memService) Teardown(ns string) error {
 	return nil
 }
 
-func contains(s string, ts ...string) bool {
-	if len(ts) == 0 {
-		return true
-	}
-
-	keep := false
-
-	for _, t := range ts {
-		if keep = strings.Contains(s, t); keep {
-			break
-		}
-	}
-
-	return keep
-}
-
 func copy(u *User) *User {
 	old := *u
 	return &old
@@ -278,19 +281,122 @@ func inTypes(ty string, ts []string) bool {
 	return keep
 }
 
+// rankedList orders users by their search score, users with equal scores are
+// ordered by username.
+type rankedList struct {
+	scores map[uint64]float64
+	users  List
+}
+
+func (l *rankedList) Len() int {
+	return len(l.users)
+}
+
+func (l *rankedList) Less(i, j int) bool {
+	var (
+		a = l.users[i]
+		b = l.users[j]
+	)
+
+	if l.scores[a.ID] != l.scores[b.ID] {
+		return l.scores[a.ID] > l.scores[b.ID]
+	}
+
+	if a.Username != b.Username {
+		return a.Username < b.Username
+	}
+
+	return a.ID < b.ID
+}
+
+func (l *rankedList) Swap(i, j int) {
+	l.users[i], l.users[j] = l.users[j], l.users[i]
+}
+
+// searchUsers returns the users matching any of the search terms ordered by
+// relevance. Prefix matches of words in the username weigh more than the ones
+// in first and last name, which mirrors the weights of the Postgres search
+// vector.
 func searchUsers(is List, opts QueryOptions) List {
-	us := List{}
+	var (
+		terms  = searchTerms(opts)
+		scores = map[uint64]float64{}
+		us     = List{}
+
+		searching = len(terms) > 0 || len(opts.Emails) > 0
+	)
 
 	for _, u := range is {
-		if !contains(u.Email, opts.Emails...) ||
-			!contains(u.Firstname, opts.Firstnames...) ||
-			!contains(u.Lastname, opts.Lastnames...) ||
-			!contains(u.Username, opts.Usernames...) {
+		score := searchScore(u, terms)
+
+		if searching && score == 0 &&
+			!containsFold(u.Email, opts.Emails...) &&
+			!containsFold(u.Firstname, opts.Firstnames...) &&
+			!containsFold(u.Lastname, opts.Lastnames...) &&
+			!containsFold(u.Username, opts.Usernames...) {
 			continue
 		}
 
+		scores[u.ID] = score
 		us = append(us, u)
 	}
 
+	sort.Sort(&rankedList{scores: scores, users: us})
+
 	return us
 }
+
+// searchScore sums the weights of the words of the terms matching a word of
+// the user as prefix, terms which don't match with all their words are ignored.
+func searchScore(u *User, terms []string) float64 {
+	var (
+		names     = append(searchWords(u.Firstname), searchWords(u.Lastname)...)
+		usernames = searchWords(u.Username)
+		score     = 0.0
+	)
+
+	for _, t := range terms {
+		var (
+			matched = true
+			s       = 0.0
+			ws      = searchWords(t)
+		)
+
+		for _, w := range ws {
+			switch {
+			case hasPrefix(usernames, w):
+				s += weightUsername
+			case hasPrefix(names, w):
+				s += weightName
+			default:
+				matched = false
+			}
+		}
+
+		if matched {
+			score += s
+		}
+	}
+
+	return score
+}
+
+func containsFold(s string, ts ...string) bool {
+	for _, t := range ts {
+		if strings.Contains(strings.ToLower(s), strings.ToLower(t)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasPrefix(ws []string, prefix string) bool {
+	for _, w := range ws {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -22,6 +22,10 @@ func TestMemSearch(t *testing.T) {
 	testServiceSearch(t, prepareMem)
 }
 
+func TestMemSearchRanked(t *testing.T) {
+	testServiceSearchRanked(t, prepareMem)
+}
+
 func prepareMem(t *testing.T, ns string) Service {
 	s := NewMemService()
 
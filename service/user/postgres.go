@@ -15,6 +15,8 @@ import (
 
 const limitDefault = 200
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 const (
 	pgInsertUser     = `INSERT INTO %s.users(json_data) VALUES($1)`
 	pgUpdateLastRead = `
@@ -42,15 +44,18 @@ const (
 	pgClauseSocialIDs = `(json_data->'social_ids'->>'%s')::TEXT IN (?)`
 	pgClauseUsernames = `(json_data->>'user_name')::TEXT IN (?)`
 
-	pgClauseSearchEmail     = `(json_data->>'email')::TEXT ILIKE '%%%s%%'`
-	pgClauseSearchFirstname = `(json_data->>'first_name')::TEXT ILIKE '%%%s%%'`
-	pgClauseSearchLastname  = `(json_data->>'last_name')::TEXT ILIKE '%%%s%%'`
-	pgClauseSearchUsername  = `(json_data->>'user_name')::TEXT ILIKE '%%%s%%'`
+	pgClauseSearchEmail     = `(json_data->>'email')::TEXT ILIKE ?`
+	pgClauseSearchFirstname = `(json_data->>'first_name')::TEXT ILIKE ?`
+	pgClauseSearchLastname  = `(json_data->>'last_name')::TEXT ILIKE ?`
+	pgClauseSearchUsername  = `(json_data->>'user_name')::TEXT ILIKE ?`
+	pgClauseSearchVector    = `search @@ to_tsquery('simple', ?)`
 
 	pgOrderCreatedAt = `json_data->>'created_at' DESC`
 	pgOrderFirstname = `json_data->>'first_name' ASC`
 	pgOrderLastname  = `json_data->>'first_naem' ASC`
 	pgOrderUsername  = `json_data->>'user_name' ASC`
+	pgOrderRank      = `ts_rank(search, to_tsquery('simple', ?)) DESC`
+	pgOrderID        = `(json_data->>'id')::BIGINT ASC`
 
 	pgCountUsers = `SELECT count(json_data) FROM %s.users
 		%s`
@@ -64,13 +69,24 @@ const (
 		GROUP BY bucket
 		ORDER BY bucket`
 
-	pgCreateSchema = `CREATE SCHEMA IF NOT EXISTS %s`
-	pgCreateTable  = `CREATE TABLE IF NOT EXISTS %s.users (
+	pgCreateExtension = `CREATE EXTENSION IF NOT EXISTS pg_trgm`
+	pgCreateSchema    = `CREATE SCHEMA IF NOT EXISTS %s`
+	pgCreateTable     = `CREATE TABLE IF NOT EXISTS %s.users (
 		json_data JSONB NOT NULL,
 		last_read TIMESTAMP DEFAULT '0001-01-01 00:00:00 UTC' NOT NULL
 	)`
 	pgDropTable = `DROP TABLE IF EXISTS %s.users`
 
+	pgAddSearch = `
+		ALTER TABLE
+			%s.users
+		ADD COLUMN IF NOT EXISTS
+			search TSVECTOR GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', coalesce(json_data->>'user_name', '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(json_data->>'first_name', '')), 'B') ||
+				setweight(to_tsvector('simple', coalesce(json_data->>'last_name', '')), 'B')
+			) STORED`
+
 	pgIndexEmail = `
 		CREATE INDEX
 			%s
@@ -85,18 +101,22 @@ const (
 			%s.users(((json_data->>'id')::BIGINT))
 		WHERE
 			(json_data->>'enabled')::BOOL = true`
-	pgIndexSearch = `
+	pgIndexSearchTrigram = `
+		CREATE INDEX
+			%%s
+		ON
+			%%s.users
+		USING
+			gin (((json_data->>'%s')::TEXT) gin_trgm_ops)
+		WHERE
+			(json_data->>'enabled')::BOOL = true`
+	pgIndexSearchVector = `
 		CREATE INDEX
 			%s
 		ON
 			%s.users
 		USING
-			gin (
-				((json_data->>'email')::TEXT) gin_trgm_ops,
-				((json_data->>'first_name')::TEXT) gin_trgm_ops,
-				((json_data->>'last_name')::TEXT) gin_trgm_ops,
-				((json_data->>'user_name')::TEXT) gin_trgm_ops
-			)
+			gin (search)
 		WHERE
 			(json_data->>'enabled')::BOOL = true`
 	pgIndexUsername = `
@@ -284,11 +304,17 @@ func (s *pgService) Search(ns string, opts QueryOptions) (List, error) {
 
 func (s *pgService) Setup(ns string) error {
 	qs := []string{
+		pgCreateExtension,
 		wrapNamespace(pgCreateSchema, ns),
 		wrapNamespace(pgCreateTable, ns),
+		wrapNamespace(pgAddSearch, ns),
 		pg.GuardIndex(ns, "user_email", pgIndexEmail),
 		pg.GuardIndex(ns, "user_id", pgIndexID),
-		pg.GuardIndex(ns, "user_search", pgIndexSearch),
+		pg.GuardIndex(ns, "user_search_email", fmt.Sprintf(pgIndexSearchTrigram, "email")),
+		pg.GuardIndex(ns, "user_search_first_name", fmt.Sprintf(pgIndexSearchTrigram, "first_name")),
+		pg.GuardIndex(ns, "user_search_last_name", fmt.Sprintf(pgIndexSearchTrigram, "last_name")),
+		pg.GuardIndex(ns, "user_search_user_name", fmt.Sprintf(pgIndexSearchTrigram, "user_name")),
+		pg.GuardIndex(ns, "user_search_vector", pgIndexSearchVector),
 		pg.GuardIndex(ns, "user_username", pgIndexUsername),
 	}
 
@@ -612,56 +638,100 @@ func convertSearchOpts(opts QueryOptions) (string, []interface{}, error) {
 
 	var (
 		sClauses = []string{}
+		sParams  = []interface{}{}
 	)
 
 	for _, t := range opts.Emails {
-		t = strings.Replace(t, "'", "''", -1)
-		sClauses = append(sClauses, fmt.Sprintf(pgClauseSearchEmail, t))
+		sClauses = append(sClauses, pgClauseSearchEmail)
+		sParams = append(sParams, likeTerm(t))
 	}
 
 	for _, t := range opts.Firstnames {
-		t = strings.Replace(t, "'", "''", -1)
-		sClauses = append(sClauses, fmt.Sprintf(pgClauseSearchFirstname, t))
+		sClauses = append(sClauses, pgClauseSearchFirstname)
+		sParams = append(sParams, likeTerm(t))
 	}
 
 	for _, t := range opts.Lastnames {
-		t = strings.Replace(t, "'", "''", -1)
-		sClauses = append(sClauses, fmt.Sprintf(pgClauseSearchLastname, t))
+		sClauses = append(sClauses, pgClauseSearchLastname)
+		sParams = append(sParams, likeTerm(t))
 	}
 
 	for _, t := range opts.Usernames {
-		t = strings.Replace(t, "'", "''", -1)
-		sClauses = append(sClauses, fmt.Sprintf(pgClauseSearchUsername, t))
+		sClauses = append(sClauses, pgClauseSearchUsername)
+		sParams = append(sParams, likeTerm(t))
+	}
+
+	// Every branch of the disjunction is backed by an index, the search vector
+	// by its GIN index and the ILIKE clauses by a trigram index per column, so
+	// the planner can combine them in a bitmap scan.
+	tsQuery := prefixQuery(searchTerms(opts)...)
+
+	if tsQuery != "" {
+		sClauses = append(sClauses, pgClauseSearchVector)
+		sParams = append(sParams, tsQuery)
 	}
 
 	if len(sClauses) > 0 {
 		sClause := fmt.Sprintf("(%s)", strings.Join(sClauses, "\nOR "))
 		clauses = append(clauses, sClause)
+		params = append(params, sParams...)
 	}
 
 	query := ""
 
 	if len(clauses) > 0 {
-		query = sqlx.Rebind(sqlx.DOLLAR, pg.ClausesToWhere(clauses...))
+		query = pg.ClausesToWhere(clauses...)
 	}
 
-	if opts.Before > 0 {
-		query = fmt.Sprintf(
-			"%s\nORDER BY %s\n",
-			query,
-			strings.Join([]string{
-				pgOrderUsername,
-				pgOrderFirstname,
-				pgOrderLastname,
-			}, ",\n"),
-		)
+	// Prefix matches on the search vector rank highest, remaining matches are
+	// ordered alphabetically.
+	order := []string{
+		pgOrderUsername,
+		pgOrderID,
 	}
 
+	if tsQuery != "" {
+		order = append([]string{pgOrderRank}, order...)
+		params = append(params, tsQuery)
+	}
+
+	query = fmt.Sprintf("%s\nORDER BY %s\n", query, strings.Join(order, ",\n"))
+
 	if opts.Limit > 0 {
 		query = fmt.Sprintf("%s\nLIMIT %d", query, opts.Limit)
 	}
 
-	return query, params, nil
+	if opts.Offset > 0 {
+		query = fmt.Sprintf("%s\nOFFSET %d", query, opts.Offset)
+	}
+
+	return sqlx.Rebind(sqlx.DOLLAR, query), params, nil
+}
+
+// likeTerm returns the pattern to match the term anywhere in a column, the
+// wildcards of ILIKE are escaped.
+func likeTerm(term string) string {
+	return fmt.Sprintf("%%%s%%", likeEscaper.Replace(term))
+}
+
+// prefixQuery returns a tsquery matching any of the terms, where a term matches
+// if all of its words are found as prefixes.
+func prefixQuery(terms ...string) string {
+	qs := []string{}
+
+	for _, t := range terms {
+		ws := []string{}
+
+		for _, w := range searchWords(t) {
+			ws = append(ws, fmt.Sprintf("%s:*", w))
+		}
+
+		if len(ws) > 0 {
+			qs = append(qs, fmt.Sprintf("(%s)", strings.Join(ws, " & ")))
+		}
+	}
+
+	return strings.Join(qs, " | ")
 }
 
 func wrapNamespace(query, namespace string) string {
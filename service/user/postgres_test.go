@@ -38,6 +38,10 @@ func TestPostgresSearch(t *testing.T) {
 	testServiceSearch(t, preparePostgres)
 }
 
+func TestPostgresSearchRanked(t *testing.T) {
+	testServiceSearchRanked(t, preparePostgres)
+}
+
 func preparePostgres(t *testing.T, namespace string) Service {
 	db, err := sqlx.Connect("postgres", pgTestURL)
 	if err != nil {
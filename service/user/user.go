@@ -2,7 +2,9 @@ package user
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/asaskevich/govalidator"
 
@@ -61,6 +63,17 @@ func (m Map) Merge(x Map) Map {
 	return m
 }
 
+// ToList transforms the Map to a List.
+func (m Map) ToList() List {
+	us := List{}
+
+	for _, u := range m {
+		us = append(us, u)
+	}
+
+	return us
+}
+
 // Metadata is a bucket to provide additional user information.
 type Metadata map[string]interface{}
 
@@ -70,7 +83,8 @@ type Private struct {
 	Verified bool   `json:"verified"`
 }
 
-// QueryOptions is used to narrow-down user queries.
+// QueryOptions is used to narrow-down user queries. Offset skips the given
+// number of results of a Search, which are ordered by relevance.
 type QueryOptions struct {
 	Before     uint64
 	CustomIDs  []string
@@ -81,6 +95,7 @@ type QueryOptions struct {
 	IDs        []uint64
 	Lastnames  []string
 	Limit      int
+	Offset     int
 	SocialIDs  map[string][]string
 	Usernames  []string
 }
@@ -100,9 +115,12 @@ type Service interface {
 // ServiceMiddleware is a chainable behaviour modifier for Service.
 type ServiceMiddleware func(Service) Service
 
-// User is the representation of a customer of an app.
+// User is the representation of a customer of an app. Follows of a user with
+// IsPrivate set need the approval of the user.
 type User struct {
 	About          string            `json:"about"`
+	AccessExpiry   time.Time         `json:"-"`
+	AccessToken    string            `json:"-"`
 	CustomID       string            `json:"custom_id,omitempty"`
 	Deleted        bool              `json:"deleted"`
 	Enabled        bool              `json:"enabled"`
@@ -116,11 +134,15 @@ type User struct {
 	IsFollower     bool              `json:"-"`
 	IsFollowing    bool              `json:"-"`
 	IsFriend       bool              `json:"-"`
+	IsPrivate      bool              `json:"is_private"`
+	IsRequested    bool              `json:"-"`
 	Lastname       string            `json:"last_name"`
 	LastRead       time.Time         `json:"-"`
 	Metadata       Metadata          `json:"metadata"`
+	MutualCount    int               `json:"-"`
 	Password       string            `json:"password"`
 	Private        *Private          `json:"private,omitempty"`
+	RefreshToken   string            `json:"-"`
 	SessionToken   string            `json:"-"`
 	SocialIDs      map[string]string `json:"social_ids,omitempty"`
 	URL            string            `json:"url,omitempty"`
@@ -224,3 +246,33 @@ func MapFromIDs(s Service, ns string, ids ...uint64) (Map, error) {
 func flakeNamespace(ns string) string {
 	return fmt.Sprintf("%s_%s", ns, "users")
 }
+
+// searchTerms returns the distinct terms matched against the search vector of
+// username, first name and last name.
+func searchTerms(opts QueryOptions) []string {
+	var (
+		seen  = map[string]struct{}{}
+		terms = []string{}
+	)
+
+	for _, ts := range [][]string{opts.Usernames, opts.Firstnames, opts.Lastnames} {
+		for _, t := range ts {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+
+			seen[t] = struct{}{}
+			terms = append(terms, t)
+		}
+	}
+
+	return terms
+}
+
+// searchWords splits the term into lower-cased words. Characters other than
+// letters and digits separate words as they carry meaning in a tsquery.
+func searchWords(term string) []string {
+	return strings.FieldsFunc(strings.ToLower(term), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
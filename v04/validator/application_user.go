@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/tapglue/multiverse/errors"
+	hash "github.com/tapglue/multiverse/platform/password"
 	"github.com/tapglue/multiverse/utils"
 	"github.com/tapglue/multiverse/v04/core"
 	"github.com/tapglue/multiverse/v04/entity"
@@ -126,6 +127,20 @@ func UpdateUser(datastore core.ApplicationUser, accountID, applicationID int64,
 
 // ApplicationUserCredentialsValid checks is a certain user has the right credentials
 func ApplicationUserCredentialsValid(password string, user *entity.ApplicationUser) (errs []errors.Error) {
+	// Passwords set or upgraded through the current API use the versioned format.
+	if strings.HasPrefix(user.Password, "$") {
+		valid, _, err := hash.DefaultHasher.Verify(password, user.Password)
+		if err != nil {
+			return []errors.Error{errmsg.ErrAuthGeneric.UpdateInternalMessage(err.Error()).SetCurrentLocation()}
+		}
+
+		if !valid {
+			return []errors.Error{errmsg.ErrAuthGeneric.UpdateInternalMessage("password mismatch").SetCurrentLocation()}
+		}
+
+		return nil
+	}
+
 	pass, err := utils.Base64Decode(user.Password)
 	if err != nil {
 		return []errors.Error{errmsg.ErrAuthGeneric.UpdateInternalMessage(err.Error()).SetCurrentLocation()}